@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Fields AccessLogWriter reads the request from, the names HTTP middleware
+// should log them under
+const (
+	AccessRemoteAddrField = "remote_addr" // Client address, host or host:port
+	AccessUserField       = "user"        // Authenticated user
+	AccessMethodField     = "method"      // Request method
+	AccessURIField        = "uri"         // Request URI, path and query
+	AccessProtoField      = "proto"       // Protocol, such as HTTP/1.1
+	AccessStatusField     = "status"      // Response status code
+	AccessBytesField      = "bytes"       // Size of the response body
+	AccessRefererField    = "referer"     // Referer header
+	AccessUserAgentField  = "user_agent"  // User-Agent header
+)
+
+// accessLogTime is the time layout of the Common Log Format
+const accessLogTime = "02/Jan/2006:15:04:05 -0700"
+
+// AccessLogWriter writes request entries to Out as lines of the NCSA Common
+// Log Format, or the Combined Log Format of Apache, for analyzers such as
+// awstats and goaccess
+//
+//	access := &logger.AccessLogWriter{Out: accessFile, Combined: true}
+//	logger.InitLogger(logger.Config{Sinks: []logger.SinkConfig{
+//		{Writer: os.Stderr},
+//		{Writer: access},
+//	}})
+//
+//	logger.GetLogger("http").Info().Str("remote_addr", "10.0.0.7").Str("method", "GET").
+//		Str("uri", "/index.html").Str("proto", "HTTP/1.1").
+//		Int("status", 200).Int("bytes", 2326).Msg("request")
+//
+// gives
+//
+//	10.0.0.7 - - [14/Oct/2026:13:55:36 +0000] "GET /index.html HTTP/1.1" 200 2326
+//
+// The request is read from the Access*Field fields; entries without a
+// method or status are not requests and are dropped, so application
+// entries can go to the same logger. Missing values are written as "-",
+// and the port of remote_addr is left out. The format names "common" and
+// "combined" use an AccessLogWriter with the defaults.
+type AccessLogWriter struct {
+	Out      io.Writer // Destination, required
+	Combined bool      // Add the referer and user agent, as Apache's combined format
+
+	// TimeFormat is the layout of the entries' time field, Config's default
+	// if empty. Entries without a time are stamped with the current time.
+	TimeFormat string
+}
+
+// commonLogWriter is the "common" output format
+func commonLogWriter(w io.Writer, cfg Config) io.Writer {
+	return &AccessLogWriter{Out: w, TimeFormat: cfg.TimeFormat}
+}
+
+// combinedLogWriter is the "combined" output format
+func combinedLogWriter(w io.Writer, cfg Config) io.Writer {
+	return &AccessLogWriter{Out: w, Combined: true, TimeFormat: cfg.TimeFormat}
+}
+
+// Write implements io.Writer for entries without a known level
+func (a *AccessLogWriter) Write(p []byte) (int, error) {
+	return a.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (a *AccessLogWriter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	line := a.append(nil, p)
+	if line == nil {
+		return len(p), nil
+	}
+	if lw, ok := a.Out.(zerolog.LevelWriter); ok {
+		if _, err := lw.WriteLevel(lvl, line); err != nil {
+			return 0, err
+		}
+	} else if _, err := a.Out.Write(line); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// append appends entry, a zerolog JSON entry, as an access log line to
+// dst, or returns nil if the entry is not a request
+func (a *AccessLogWriter) append(dst []byte, entry []byte) []byte {
+	_, fields := decodeEntry(bytes.TrimRight(entry, "\n"))
+	method, status := accessField(fields, AccessMethodField), accessField(fields, AccessStatusField)
+	if method == "" || status == "" {
+		return nil
+	}
+
+	timeFormat := a.TimeFormat
+	if timeFormat == "" {
+		timeFormat = defaultConfig.TimeFormat
+	}
+	ts, ok := entryTime(fields[zerolog.TimestampFieldName], timeFormat)
+	if !ok {
+		ts = time.Now()
+	}
+
+	host := accessField(fields, AccessRemoteAddrField)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	request := method + " " + orDash(accessField(fields, AccessURIField))
+	if proto := accessField(fields, AccessProtoField); proto != "" {
+		request += " " + proto
+	}
+	size := accessField(fields, AccessBytesField)
+	if size == "0" {
+		size = "" // %b writes "-" for empty bodies
+	}
+
+	dst = append(dst, orDash(host)...)
+	dst = append(dst, " - "...)
+	dst = append(dst, orDash(accessField(fields, AccessUserField))...)
+	dst = append(dst, " ["...)
+	dst = ts.AppendFormat(dst, accessLogTime)
+	dst = append(dst, "] "...)
+	dst = appendAccessQuoted(dst, request)
+	dst = append(dst, ' ')
+	dst = append(dst, status...)
+	dst = append(dst, ' ')
+	dst = append(dst, orDash(size)...)
+	if a.Combined {
+		dst = append(dst, ' ')
+		dst = appendAccessQuoted(dst, orDash(accessField(fields, AccessRefererField)))
+		dst = append(dst, ' ')
+		dst = appendAccessQuoted(dst, orDash(accessField(fields, AccessUserAgentField)))
+	}
+	return append(dst, '\n')
+}
+
+// accessField returns the named field as text, "" if missing or null
+func accessField(fields map[string]interface{}, name string) string {
+	v, ok := fields[name]
+	if !ok || v == nil {
+		return ""
+	}
+	return fieldString(v)
+}
+
+// orDash returns s, or "-" for empty values as the log format writes them
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// appendAccessQuoted appends s in double quotes, escaping quotes,
+// backslashes and control characters as Apache does
+func appendAccessQuoted(dst []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"' || c == '\\':
+			dst = append(dst, '\\', c)
+		case c < 0x20 || c == 0x7f:
+			dst = append(dst, '\\', 'x', hex[c>>4], hex[c&0xf])
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, '"')
+}
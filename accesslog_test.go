@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestAccessLogWriter(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		combined bool
+		entry    string
+		want     string
+	}{
+		{
+			"common",
+			false,
+			`{"level":"info","time":"2026-10-15T01:02:03Z","remote_addr":"10.0.0.7:51234","user":"alice","method":"GET","uri":"/index.html","proto":"HTTP/1.1","status":200,"bytes":2326,"message":"request"}`,
+			`10.0.0.7 - alice [15/Oct/2026:01:02:03 +0000] "GET /index.html HTTP/1.1" 200 2326`,
+		},
+		{
+			"combined",
+			true,
+			`{"time":"2026-10-15T01:02:03Z","remote_addr":"::1","method":"POST","uri":"/a\"b","status":201,"bytes":0,"referer":null,"user_agent":"curl/8.0\u0007"}`,
+			`::1 - - [15/Oct/2026:01:02:03 +0000] "POST /a\"b" 201 - "-" "curl/8.0\x07"`,
+		},
+		{
+			"missing values",
+			true,
+			`{"time":"2026-10-15T01:02:03Z","method":"GET","status":404}`,
+			`- - - [15/Oct/2026:01:02:03 +0000] "GET -" 404 - "-" "-"`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			a := &AccessLogWriter{Out: &buf, Combined: tt.combined}
+			if n, err := a.Write([]byte(tt.entry + "\n")); err != nil || n != len(tt.entry)+1 {
+				t.Fatalf("Write = %d, %v", n, err)
+			}
+			if got := buf.String(); got != tt.want+"\n" {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccessLogWriterDropsNonRequests(t *testing.T) {
+	var buf bytes.Buffer
+	a := &AccessLogWriter{Out: &buf}
+	for _, entry := range []string{
+		`{"level":"info","message":"started"}`,
+		`{"method":"GET","message":"no status"}`,
+		`{"status":200,"message":"no method"}`,
+	} {
+		if n, err := a.Write([]byte(entry)); err != nil || n != len(entry) {
+			t.Errorf("Write(%s) = %d, %v", entry, n, err)
+		}
+	}
+	if buf.Len() != 0 {
+		t.Errorf("wrote %q", buf.String())
+	}
+}
+
+func TestAccessLogWriterTime(t *testing.T) {
+	var buf bytes.Buffer
+	a := &AccessLogWriter{Out: &buf, TimeFormat: zerolog.TimeFormatUnixMs}
+	a.Write([]byte(`{"time":1792026123000,"method":"GET","status":200}`))
+	if want := time.UnixMilli(1792026123000).Format(accessLogTime); !strings.Contains(buf.String(), "["+want+"]") {
+		t.Errorf("got %q, want time %s", buf.String(), want)
+	}
+
+	// Entries without a time are stamped when written
+	buf.Reset()
+	before := time.Now().Truncate(time.Second)
+	a.Write([]byte(`{"method":"GET","status":200}`))
+	start := strings.IndexByte(buf.String(), '[')
+	end := strings.IndexByte(buf.String(), ']')
+	ts, err := time.Parse(accessLogTime, buf.String()[start+1:end])
+	if err != nil || ts.Before(before) || ts.After(time.Now()) {
+		t.Errorf("stamped %q, %v", buf.String(), err)
+	}
+}
+
+func TestAccessLogFormats(t *testing.T) {
+	for format, want := range map[string]string{
+		"common":   `"GET / HTTP/2.0" 204 -` + "\n",
+		"combined": `"GET / HTTP/2.0" 204 - "https://example.com/" "-"` + "\n",
+	} {
+		var buf bytes.Buffer
+		l, err := New(Config{Output: &buf, Format: format})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Info("started")
+		l.Info("request", "remote_addr", "10.0.0.7", "method", "GET", "uri", "/", "proto", "HTTP/2.0", "status", 204, "referer", "https://example.com/")
+		if got := buf.String(); !strings.HasPrefix(got, "10.0.0.7 - - [") || !strings.HasSuffix(got, want) {
+			t.Errorf("%s: got %q, want suffix %q", format, got, want)
+		}
+	}
+}
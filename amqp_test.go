@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// fakeRabbit is an AMQP 0-9-1 broker going through the handshake with a
+// frame-max of 64 bytes and confirming every message, or answering the
+// publishes as reply says, and recording the bytes it received
+type fakeRabbit struct {
+	ln    net.Listener
+	reply string // "ack", "nack" or "close"
+	mu    sync.Mutex
+	raw   bytes.Buffer
+}
+
+func newFakeRabbit(t *testing.T, reply string) *fakeRabbit {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRabbit{ln: ln, reply: reply}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRabbit) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(io.TeeReader(conn, lockedWriter{&f.mu, &f.raw}))
+	if _, err := io.ReadFull(r, make([]byte, 8)); err != nil {
+		return
+	}
+	// connection.start: version 0-9, no server properties, PLAIN, en_US
+	conn.Write(amqpTestFrame(1, 0, "\x00\x0a\x00\x0a\x00\x09\x00\x00\x00\x00\x00\x00\x00\x05PLAIN\x00\x00\x00\x05en_US"))
+	var tag uint64
+	for {
+		kind, channel, payload, err := readAMQPTestFrame(r)
+		if err != nil {
+			return
+		}
+		if kind == amqpFrameHeader {
+			tag++
+			switch f.reply {
+			case "ack", "nack":
+				method := "\x00\x50"
+				if f.reply == "nack" {
+					method = "\x00\x78"
+				}
+				conn.Write(amqpTestFrame(1, 1, "\x00\x3c"+method+string(binary.BigEndian.AppendUint64(nil, tag))+"\x00"))
+			case "close":
+				conn.Write(amqpTestFrame(1, 1, "\x00\x14\x00\x28\x01\x94\x17NOT_FOUND - no exchange\x00\x3c\x00\x28"))
+			}
+			continue
+		}
+		if kind != amqpFrameMethod {
+			continue
+		}
+		switch method := payload[:4]; method {
+		case "\x00\x0a\x00\x0b": // connection.start-ok
+			conn.Write(amqpTestFrame(1, 0, "\x00\x0a\x00\x1e\x07\xff\x00\x00\x00\x40\x00\x3c"))
+		case "\x00\x0a\x00\x28": // connection.open
+			conn.Write(amqpTestFrame(1, 0, "\x00\x0a\x00\x29\x00"))
+		case "\x00\x14\x00\x0a": // channel.open
+			conn.Write(amqpTestFrame(1, channel, "\x00\x14\x00\x0b\x00\x00\x00\x00"))
+		case "\x00\x55\x00\x0a": // confirm.select
+			conn.Write(amqpTestFrame(1, channel, "\x00\x55\x00\x0b"))
+		case "\x00\x0a\x00\x32": // connection.close
+			conn.Write(amqpTestFrame(1, 0, "\x00\x0a\x00\x33"))
+			return
+		}
+	}
+}
+
+// received returns the bytes sent to the broker so far
+func (f *fakeRabbit) received() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]byte(nil), f.raw.Bytes()...)
+}
+
+// amqpTestFrame frames payload, written out by hand rather than with
+// amqpFrame so the tests do not trust the code they check
+func amqpTestFrame(kind byte, channel uint16, payload string) []byte {
+	frame := []byte{kind, byte(channel >> 8), byte(channel)}
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload)))
+	return append(append(frame, payload...), 0xce)
+}
+
+func readAMQPTestFrame(r *bufio.Reader) (byte, uint16, string, error) {
+	var head [7]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, 0, "", err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(head[3:])+1)
+	_, err := io.ReadFull(r, payload)
+	return head[0], binary.BigEndian.Uint16(head[1:]), string(payload[:len(payload)-1]), err
+}
+
+func TestAMQPSinkFrames(t *testing.T) {
+	f := newFakeRabbit(t, "ack")
+	s := &AMQPSink{URL: "amqp://app:secret@" + f.ln.Addr().String() + "/logs", Exchange: "logs", RoutingKey: "{component}.{level}"}
+	entry := `{"component":"db","message":"` + strings.Repeat("x", 40) + `"}`
+	at := time.Unix(1792026123, 0)
+	if err := s.send([]batchEntry{{level: zerolog.WarnLevel, time: at, data: []byte(entry)}}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	var want bytes.Buffer
+	want.WriteString("AMQP\x00\x00\x09\x01")
+	// connection.start-ok: client properties, PLAIN with the URL's user, locale
+	want.Write(amqpTestFrame(1, 0, "\x00\x0a\x00\x0b"+
+		"\x00\x00\x00\x24\x07productS\x00\x00\x00\x17github.com/minya/logger"+
+		"\x05PLAIN\x00\x00\x00\x0b\x00app\x00secret\x05en_US"))
+	// connection.tune-ok: one channel, the broker's frame-max, no heartbeats
+	want.Write(amqpTestFrame(1, 0, "\x00\x0a\x00\x1f\x00\x01\x00\x00\x00\x40\x00\x00"))
+	// connection.open of vhost logs, channel.open and confirm.select
+	want.Write(amqpTestFrame(1, 0, "\x00\x0a\x00\x28\x04logs\x00\x00"))
+	want.Write(amqpTestFrame(1, 1, "\x00\x14\x00\x0a\x00"))
+	want.Write(amqpTestFrame(1, 1, "\x00\x55\x00\x0a\x00"))
+	// basic.publish to exchange logs with routing key db.warn
+	want.Write(amqpTestFrame(1, 1, "\x00\x3c\x00\x28\x00\x00\x04logs\x07db.warn\x00"))
+	// content header: body size, content-type, persistent, timestamp
+	want.Write(amqpTestFrame(2, 1, "\x00\x3c\x00\x00"+string(binary.BigEndian.AppendUint64(nil, uint64(len(entry))))+
+		"\x90\x40\x10application/json\x02"+string(binary.BigEndian.AppendUint64(nil, 1792026123))))
+	// body split at frame-max less the 8 bytes of frame overhead
+	want.Write(amqpTestFrame(3, 1, entry[:56]))
+	want.Write(amqpTestFrame(3, 1, entry[56:]))
+	// connection.close with 200 bye
+	want.Write(amqpTestFrame(1, 0, "\x00\x0a\x00\x32\x00\xc8\x03bye\x00\x00\x00\x00"))
+
+	if got := f.received(); !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("sent\n%q\nwant\n%q", got, want.Bytes())
+	}
+}
+
+func TestAMQPSinkRejected(t *testing.T) {
+	batch := []batchEntry{{level: zerolog.InfoLevel, time: time.Now(), data: []byte("{}")}}
+
+	f := newFakeRabbit(t, "nack")
+	s := &AMQPSink{URL: "amqp://" + f.ln.Addr().String()}
+	err := s.send(batch)
+	if err == nil || !strings.Contains(err.Error(), "rejected 1 of 1") || errors.As(err, new(permanentError)) {
+		t.Errorf("nack: got %v, want a retryable rejection", err)
+	}
+
+	f = newFakeRabbit(t, "close")
+	s = &AMQPSink{URL: "amqp://" + f.ln.Addr().String(), Exchange: "missing"}
+	err = s.send(batch)
+	var aerr amqpError
+	if !errors.As(err, &aerr) || aerr.code != 404 || !errors.As(err, new(permanentError)) {
+		t.Errorf("missing exchange: got %v, want a permanent 404", err)
+	}
+	// The close is acknowledged before the sink gives up
+	closeOk := amqpTestFrame(1, 1, "\x00\x14\x00\x29")
+	for deadline := time.Now().Add(5 * time.Second); !bytes.HasSuffix(f.received(), closeOk); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no channel.close-ok")
+		}
+	}
+}
@@ -0,0 +1,581 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// AsyncWriter hands entries to a background goroutine that writes them to
+// Out, so log calls return without waiting for a slow disk or network
+//
+//	w := &logger.AsyncWriter{Out: file, Capacity: 4096}
+//	defer w.Close()
+//	logger.InitLogger(logger.Config{Output: w})
+//
+// Entries are copied into a ring buffer of Capacity entries, which log
+// calls claim without taking a lock, so goroutines logging at the same
+// time do not queue up behind each other. The goroutine writes them in
+// order: as soon as it can when FlushInterval is zero, otherwise every
+// FlushInterval or once the buffer is half full, so a burst of entries is
+// written in one go.
+//
+// When the buffer is full, log calls wait for room. With DropWhenFull they
+// discard entries instead, the least severe first: debug and trace entries
+// once the buffer is three quarters full, info entries at seven eighths
+// and the rest only when it is full. Dropped entries are counted in Stats
+// and DroppedEntries and reported as a warning written to Out.
+//
+// Flush waits until everything logged before it is written and Close
+// flushes and stops the goroutine; entries written after Close go to Out
+// directly. Out is not closed.
+//
+// Config.Async and SinkConfig.Async wrap outputs in AsyncWriters that
+// Flush and Close reach.
+type AsyncWriter struct {
+	Out           io.Writer     // Destination, required
+	Capacity      int           // Entries buffered, rounded up to a power of two, 1024 if zero
+	FlushInterval time.Duration // Longest an entry waits to be written, no wait if zero
+	OnError       func(error)   // Called when writing to Out fails, stderr if nil
+
+	// DropWhenFull discards entries rather than blocking log calls when
+	// the buffer is full, for latency-critical paths
+	DropWhenFull bool
+
+	// DropReportInterval is how often the number of entries dropped since
+	// the last report is written to Out, a minute if zero
+	DropReportInterval time.Duration
+
+	setup sync.Once
+	slots []asyncEntry
+	mask  uint64
+
+	// tail is the next position log calls claim, with asyncClosed set once
+	// the writer is closed, and head the next one the goroutine writes;
+	// both only grow. The padding keeps them on cache lines of their own,
+	// as they are written by different cores.
+	_    [64]byte
+	tail atomic.Uint64
+	_    [56]byte
+	head atomic.Uint64
+	_    [56]byte
+
+	written  atomic.Uint64
+	dropped  atomic.Uint64
+	sleeping atomic.Bool  // the goroutine waits for a wake-up
+	waiters  atomic.Int64 // log calls waiting for room
+
+	// mu and its conditions are taken only by log calls waiting for room
+	// and by Flush, never on the fast path
+	mu      sync.Mutex
+	notFull *sync.Cond // signalled when the goroutine frees slots
+	drained *sync.Cond // signalled when the goroutine has written entries
+
+	// outMu serializes the writes log calls make to Out themselves once
+	// the writer is closed
+	outMu sync.Mutex
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// asyncClosed is the bit of an AsyncWriter's tail set by Close, so no
+// log call can claim a position once the writer is closed
+const asyncClosed = 1 << 63
+
+// asyncEntry is one slot of an AsyncWriter's ring buffer. Its seq is one
+// more than the position of the last entry published in it, so the entry
+// at pos is ready once seq is pos+1; a slot is free to claim again once
+// head has passed it.
+type asyncEntry struct {
+	seq   atomic.Uint64
+	level zerolog.Level
+	data  []byte
+}
+
+// AsyncStats is a snapshot of an AsyncWriter's counters
+type AsyncStats struct {
+	Queued  int    // Entries waiting to be written
+	Written uint64 // Entries passed on to Out
+	Dropped uint64 // Entries discarded because the buffer was full
+}
+
+// AsyncConfig makes writing asynchronous, see AsyncWriter
+type AsyncConfig struct {
+	Capacity           int           // Entries buffered before log calls wait, 1024 if zero
+	FlushInterval      time.Duration // Longest an entry waits to be written, no wait if zero
+	DropWhenFull       bool          // Discard the least severe entries instead of waiting
+	DropReportInterval time.Duration // How often drops are reported, a minute if zero
+}
+
+// asyncDropped counts the entries dropped by every AsyncWriter
+var asyncDropped atomic.Uint64
+
+// DroppedEntries returns how many entries AsyncWriters with DropWhenFull
+// have discarded since the program started
+func DroppedEntries() uint64 {
+	return asyncDropped.Load()
+}
+
+// validate rejects a negative capacity or flush interval
+func (ac *AsyncConfig) validate() error {
+	switch {
+	case ac == nil:
+		return nil
+	case ac.Capacity < 0:
+		return fmt.Errorf("logger: async capacity %d is negative", ac.Capacity)
+	case ac.FlushInterval < 0:
+		return fmt.Errorf("logger: async flush interval %s is negative", ac.FlushInterval)
+	case ac.DropReportInterval < 0:
+		return fmt.Errorf("logger: async drop report interval %s is negative", ac.DropReportInterval)
+	}
+	return nil
+}
+
+// bufferedWriter is a writer made for a configuration that holds entries
+// back, an AsyncWriter or a BatchWriter, which Flush and Close must reach
+type bufferedWriter interface {
+	io.Writer
+	Flush() error
+	Close() error
+}
+
+// buffered are the bufferedWriters made for configurations, in the order
+// they were made
+var buffered = struct {
+	sync.Mutex
+	list []bufferedWriter
+}{}
+
+// recordBuffered adds w to the writers Flush and Close act on
+func recordBuffered(w bufferedWriter) {
+	buffered.Lock()
+	buffered.list = append(buffered.list, w)
+	buffered.Unlock()
+}
+
+// recordBufferedLast adds w to the writers Flush and Close act on, after
+// all others, for a destination such as a FileSink that the others may
+// still be writing to
+func recordBufferedLast(w bufferedWriter) {
+	buffered.Lock()
+	buffered.list = append([]bufferedWriter{w}, buffered.list...)
+	buffered.Unlock()
+}
+
+// forgetBuffered removes a closed writer from them
+func forgetBuffered(w bufferedWriter) {
+	buffered.Lock()
+	defer buffered.Unlock()
+	for i, b := range buffered.list {
+		if b == w {
+			buffered.list = append(buffered.list[:i], buffered.list[i+1:]...)
+			return
+		}
+	}
+}
+
+// newAsyncWriter wraps w in an AsyncWriter configured by ac and records it
+// for Flush and Close
+func newAsyncWriter(w io.Writer, ac AsyncConfig) *AsyncWriter {
+	a := &AsyncWriter{
+		Out:                w,
+		Capacity:           ac.Capacity,
+		FlushInterval:      ac.FlushInterval,
+		DropWhenFull:       ac.DropWhenFull,
+		DropReportInterval: ac.DropReportInterval,
+	}
+	recordBuffered(a)
+	return a
+}
+
+// Flush waits until the entries logged so far through the AsyncWriters,
+// BatchWriters and buffered FileSinks of every configuration, global or
+// not, are written
+func Flush() {
+	for _, w := range bufferedWriters() {
+		w.Flush()
+	}
+}
+
+// Close flushes and stops the AsyncWriters, BatchWriters and buffered
+// FileSinks of every configuration. Call it before the program exits so
+// no buffered entries are lost; entries logged afterwards are written
+// synchronously.
+func Close() {
+	for _, w := range bufferedWriters() {
+		w.Close()
+	}
+}
+
+// exitCloser is the writer zerolog is given. zerolog closes it before a
+// fatal entry exits the process, so closing it flushes the whole chain of
+// writers and closes every buffered writer, wherever it sits behind
+// routers, rate limiters and dedup writers that do not pass Close on.
+type exitCloser struct {
+	out io.Writer
+}
+
+// Write implements io.Writer
+func (w exitCloser) Write(p []byte) (int, error) {
+	return w.out.Write(p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (w exitCloser) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if lw, ok := w.out.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(lvl, p)
+	}
+	return w.out.Write(p)
+}
+
+// Close writes what the writers hold back and closes the buffered ones
+func (w exitCloser) Close() error {
+	if f, ok := w.out.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	Close()
+	return nil
+}
+
+// bufferedWriters returns the recorded writers newest first, so a writer
+// is flushed before the ones it writes to
+func bufferedWriters() []bufferedWriter {
+	buffered.Lock()
+	defer buffered.Unlock()
+	list := make([]bufferedWriter, len(buffered.list))
+	for i, w := range buffered.list {
+		list[len(list)-1-i] = w
+	}
+	return list
+}
+
+// closeBuffered closes the writers of a replaced configuration, given in
+// the order they were made
+func closeBuffered(list []bufferedWriter) {
+	for i := len(list) - 1; i >= 0; i-- {
+		list[i].Close()
+	}
+}
+
+// start allocates the buffer and launches the goroutine once
+func (a *AsyncWriter) start() {
+	a.setup.Do(func() {
+		capacity := a.Capacity
+		if capacity <= 0 {
+			capacity = 1024
+		}
+		capacity = 1 << bits.Len(uint(capacity-1))
+		a.slots = make([]asyncEntry, capacity)
+		a.mask = uint64(capacity - 1)
+		a.notFull = sync.NewCond(&a.mu)
+		a.drained = sync.NewCond(&a.mu)
+		a.wake = make(chan struct{}, 1)
+		a.done = make(chan struct{})
+		a.stopped = make(chan struct{})
+		go a.run()
+	})
+}
+
+// Write implements io.Writer for entries without a known level
+func (a *AsyncWriter) Write(p []byte) (int, error) {
+	return a.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (a *AsyncWriter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	a.start()
+	if !a.enqueue(lvl, p) {
+		return a.writeClosed(lvl, p)
+	}
+	return len(p), nil
+}
+
+// enqueue publishes p in the buffer, or drops it with DropWhenFull. It
+// returns false once the writer is closed.
+func (a *AsyncWriter) enqueue(lvl zerolog.Level, p []byte) bool {
+	size := uint64(len(a.slots))
+	for {
+		// head is read first so the room computed is never overstated
+		head := a.head.Load()
+		pos := a.tail.Load()
+		if pos&asyncClosed != 0 {
+			return false
+		}
+		if a.DropWhenFull && a.shed(lvl, pos-head) {
+			a.drop()
+			return true
+		}
+		if pos-head >= size {
+			if !a.waitForRoom() {
+				return false
+			}
+			continue
+		}
+		// Setting asyncClosed makes this fail, so an entry is only
+		// published while the goroutine is bound to write it
+		if !a.tail.CompareAndSwap(pos, pos+1) {
+			continue
+		}
+		slot := &a.slots[pos&a.mask]
+		slot.level, slot.data = lvl, append(slot.data[:0], p...)
+		slot.seq.Store(pos + 1)
+		if a.FlushInterval <= 0 || pos+1-a.head.Load() >= size/2 {
+			a.signal()
+		}
+		return true
+	}
+}
+
+// isClosed reports whether Close was called
+func (a *AsyncWriter) isClosed() bool {
+	return a.tail.Load()&asyncClosed != 0
+}
+
+// writeClosed writes an entry logged after Close to Out, once the
+// goroutine has written the buffered entries, so entries keep their order
+// and Out is never written from two goroutines at once
+func (a *AsyncWriter) writeClosed(lvl zerolog.Level, p []byte) (int, error) {
+	<-a.stopped
+	a.outMu.Lock()
+	defer a.outMu.Unlock()
+	return a.writeOut(lvl, p)
+}
+
+// shed reports whether an entry at lvl is dropped with used slots taken
+func (a *AsyncWriter) shed(lvl zerolog.Level, used uint64) bool {
+	size := uint64(len(a.slots))
+	switch lvl = baseLevel(lvl); {
+	case lvl <= zerolog.DebugLevel:
+		return used >= size-size/4
+	case lvl == zerolog.InfoLevel:
+		return used >= size-size/8
+	}
+	return used >= size
+}
+
+// waitForRoom blocks until the goroutine frees a slot, or returns false
+// once the writer is closed
+func (a *AsyncWriter) waitForRoom() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.waiters.Add(1)
+	defer a.waiters.Add(-1)
+	for !a.isClosed() {
+		head := a.head.Load()
+		if a.tail.Load()-head < uint64(len(a.slots)) {
+			return true
+		}
+		a.notFull.Wait()
+	}
+	return false
+}
+
+// wakeWaiters wakes the log calls waiting for room, if there are any
+func (a *AsyncWriter) wakeWaiters() {
+	if a.waiters.Load() > 0 {
+		a.mu.Lock()
+		a.notFull.Broadcast()
+		a.mu.Unlock()
+	}
+}
+
+// drop counts a discarded entry
+func (a *AsyncWriter) drop() {
+	a.dropped.Add(1)
+	asyncDropped.Add(1)
+}
+
+// Stats returns the writer's current counters
+func (a *AsyncWriter) Stats() AsyncStats {
+	a.start()
+	head := a.head.Load()
+	return AsyncStats{
+		Queued:  int(a.tail.Load()&^asyncClosed - head),
+		Written: a.written.Load(),
+		Dropped: a.dropped.Load(),
+	}
+}
+
+// Flush waits until the entries written before it are passed on to Out,
+// then flushes Out if it has a Flush method
+func (a *AsyncWriter) Flush() error {
+	a.start()
+	target := a.tail.Load() &^ asyncClosed
+	a.kick()
+
+	a.mu.Lock()
+	for a.head.Load() < target {
+		a.drained.Wait()
+	}
+	a.mu.Unlock()
+	if f, ok := a.Out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close writes the buffered entries and stops the goroutine; later
+// entries are written to Out directly. Out is left open.
+func (a *AsyncWriter) Close() error {
+	a.start()
+	if a.tail.Or(asyncClosed)&asyncClosed != 0 {
+		<-a.stopped
+		return nil
+	}
+	a.mu.Lock()
+	a.notFull.Broadcast()
+	a.mu.Unlock()
+	close(a.done)
+	<-a.stopped
+
+	forgetBuffered(a)
+	if f, ok := a.Out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// signal wakes the goroutine if it is waiting for entries
+func (a *AsyncWriter) signal() {
+	if a.sleeping.Load() && a.sleeping.CompareAndSwap(true, false) {
+		a.kick()
+	}
+}
+
+// kick wakes the goroutine unless it is already due to run
+func (a *AsyncWriter) kick() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run writes buffered entries until the writer is closed
+func (a *AsyncWriter) run() {
+	defer close(a.stopped)
+	var tick, reportTick <-chan time.Time
+	if a.FlushInterval > 0 {
+		ticker := time.NewTicker(a.FlushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	if a.DropWhenFull {
+		interval := a.DropReportInterval
+		if interval <= 0 {
+			interval = time.Minute
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		reportTick = ticker.C
+	}
+	var reported uint64
+	for {
+		// Announce the wait first, then look again, so an entry published
+		// in between is not left waiting for the next wake-up
+		a.sleeping.Store(true)
+		if a.ready() {
+			a.sleeping.Store(false)
+			a.drain()
+			continue
+		}
+		select {
+		case <-a.wake:
+		case <-tick:
+		case <-reportTick:
+			reported = a.reportDropped(reported)
+		case <-a.done:
+			a.sleeping.Store(false)
+			// Log calls that claimed a position before Close may still be
+			// copying their entries
+			for end := a.tail.Load() &^ asyncClosed; a.drain() < end; {
+				runtime.Gosched()
+			}
+			a.reportDropped(reported)
+			return
+		}
+		a.sleeping.Store(false)
+		a.drain()
+	}
+}
+
+// reportDropped writes a warning to Out if entries were dropped since
+// reported of them were last reported, and returns the new total
+func (a *AsyncWriter) reportDropped(reported uint64) uint64 {
+	total := a.dropped.Load()
+	if total == reported {
+		return total
+	}
+	var buf bytes.Buffer
+	zl := zerolog.New(&buf)
+	zl.Warn().Timestamp().Uint64("dropped", total-reported).Msg("logger: async buffer full, entries dropped")
+	if _, err := a.writeOut(zerolog.WarnLevel, buf.Bytes()); err != nil {
+		a.report(err)
+	}
+	return total
+}
+
+// ready reports whether the entry at head is published
+func (a *AsyncWriter) ready() bool {
+	pos := a.head.Load()
+	return a.slots[pos&a.mask].seq.Load() == pos+1
+}
+
+// drain writes the published entries in order and returns the new head.
+// Slots are freed by moving head past them once a run of entries is
+// written, and every eighth of the buffer in a long run so waiting log
+// calls get in.
+func (a *AsyncWriter) drain() uint64 {
+	size := uint64(len(a.slots))
+	for {
+		head := a.head.Load()
+		pos := head
+		for ; a.slots[pos&a.mask].seq.Load() == pos+1; pos++ {
+			slot := &a.slots[pos&a.mask]
+			if _, err := a.writeOut(slot.level, slot.data); err != nil {
+				a.report(err)
+			}
+			if (pos+1)%(size/8+1) == 0 {
+				a.head.Store(pos + 1)
+				a.wakeWaiters()
+			}
+		}
+		if pos == head {
+			return head
+		}
+		a.head.Store(pos)
+		a.written.Add(pos - head)
+		a.mu.Lock()
+		a.notFull.Broadcast()
+		a.drained.Broadcast()
+		a.mu.Unlock()
+	}
+}
+
+// writeOut passes one entry on to Out, keeping its level
+func (a *AsyncWriter) writeOut(lvl zerolog.Level, p []byte) (int, error) {
+	if lw, ok := a.Out.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(lvl, p)
+	}
+	return a.Out.Write(p)
+}
+
+// report passes a failed write to OnError, or prints it to stderr since
+// it cannot be returned from Write
+func (a *AsyncWriter) report(err error) {
+	err = fmt.Errorf("logger: async: %w", err)
+	if a.OnError != nil {
+		a.OnError(err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
@@ -0,0 +1,453 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// mutexAsyncWriter is the mutex-guarded queue AsyncWriter used before
+// its lock-free ring, less DropWhenFull and FlushInterval, kept as the
+// baseline of the benchmarks
+type mutexAsyncWriter struct {
+	out     io.Writer
+	mu      sync.Mutex
+	notFull *sync.Cond
+	drained *sync.Cond
+	slots   []asyncEntry
+	head    int
+	count   int
+	writing int
+	queued  uint64
+	written uint64
+	closed  bool
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newMutexAsyncWriter(out io.Writer, capacity int) *mutexAsyncWriter {
+	m := &mutexAsyncWriter{
+		out:     out,
+		slots:   make([]asyncEntry, capacity),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	m.notFull = sync.NewCond(&m.mu)
+	m.drained = sync.NewCond(&m.mu)
+	go m.run()
+	return m
+}
+
+func (m *mutexAsyncWriter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	m.mu.Lock()
+	for m.count == len(m.slots) && !m.closed {
+		m.notFull.Wait()
+	}
+	if m.closed {
+		m.mu.Unlock()
+		return m.out.Write(p)
+	}
+	slot := &m.slots[(m.head+m.count)%len(m.slots)]
+	slot.level, slot.data = lvl, append(slot.data[:0], p...)
+	m.count++
+	m.queued++
+	m.mu.Unlock()
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+func (m *mutexAsyncWriter) run() {
+	defer close(m.stopped)
+	for {
+		select {
+		case <-m.wake:
+		case <-m.done:
+			m.drain()
+			return
+		}
+		m.drain()
+	}
+}
+
+func (m *mutexAsyncWriter) drain() {
+	for {
+		m.mu.Lock()
+		head, n := m.head, m.count
+		m.writing = n
+		m.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		for i := 0; i < n; i++ {
+			slot := &m.slots[(head+i)%len(m.slots)]
+			if lw, ok := m.out.(zerolog.LevelWriter); ok {
+				lw.WriteLevel(slot.level, slot.data)
+			} else {
+				m.out.Write(slot.data)
+			}
+		}
+		m.mu.Lock()
+		m.head = (head + n) % len(m.slots)
+		m.count -= n
+		m.writing = 0
+		m.written += uint64(n)
+		m.notFull.Broadcast()
+		m.drained.Broadcast()
+		m.mu.Unlock()
+	}
+}
+
+func (m *mutexAsyncWriter) Close() {
+	m.mu.Lock()
+	m.closed = true
+	m.notFull.Broadcast()
+	m.mu.Unlock()
+	close(m.done)
+	<-m.stopped
+}
+
+// benchEntry is a typical JSON entry
+var benchEntry = []byte(`{"level":"info","component":"api","request_id":"4bf92f3577b34da6","time":"2026-10-15T01:41:52Z","message":"request served"}` + "\n")
+
+// BenchmarkAsyncWriter compares the lock-free ring with the mutex queue it
+// replaced, with more goroutines logging at once than CPUs; run with
+// -cpu 1,4,16 to see the mutex queue slow down as callers contend for it.
+func BenchmarkAsyncWriter(b *testing.B) {
+	b.Run("ring", func(b *testing.B) {
+		w := &AsyncWriter{Out: io.Discard, Capacity: 4096}
+		defer w.Close()
+		b.ReportAllocs()
+		b.SetBytes(int64(len(benchEntry)))
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				w.WriteLevel(zerolog.InfoLevel, benchEntry)
+			}
+		})
+	})
+	b.Run("mutex", func(b *testing.B) {
+		w := newMutexAsyncWriter(io.Discard, 4096)
+		defer w.Close()
+		b.ReportAllocs()
+		b.SetBytes(int64(len(benchEntry)))
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				w.WriteLevel(zerolog.InfoLevel, benchEntry)
+			}
+		})
+	})
+}
+
+// serialWriter fails the test if it is written from two goroutines at
+// once, and holds its first write until gate is closed
+type serialWriter struct {
+	t    *testing.T
+	gate chan struct{}
+	busy sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (w *serialWriter) Write(p []byte) (int, error) {
+	if !w.busy.TryLock() {
+		w.t.Error("concurrent write to Out")
+		return len(p), nil
+	}
+	defer w.busy.Unlock()
+	<-w.gate
+	return w.buf.Write(p)
+}
+
+func TestAsyncWriterCloseSerializesWaiters(t *testing.T) {
+	out := &serialWriter{t: t, gate: make(chan struct{})}
+	w := &AsyncWriter{Out: out, Capacity: 2}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fmt.Fprintf(w, "entry %d\n", i)
+		}()
+	}
+	for w.waiters.Load() == 0 {
+		runtime.Gosched()
+	}
+	closed := make(chan struct{})
+	go func() {
+		w.Close()
+		close(closed)
+	}()
+	for !w.isClosed() {
+		runtime.Gosched()
+	}
+	close(out.gate)
+	<-closed
+	wg.Wait()
+	if got := bytes.Count(out.buf.Bytes(), []byte("\n")); got != 16 {
+		t.Errorf("wrote %d entries, want 16", got)
+	}
+}
+
+// entryRecorder records the entries written to it with their levels, and
+// counts calls to Flush
+type entryRecorder struct {
+	mu      sync.Mutex
+	levels  []zerolog.Level
+	lines   []string
+	flushes int
+	err     error
+}
+
+func (r *entryRecorder) Write(p []byte) (int, error) {
+	return r.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (r *entryRecorder) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.levels = append(r.levels, lvl)
+	r.lines = append(r.lines, string(p))
+	return len(p), r.err
+}
+
+func (r *entryRecorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushes++
+	return nil
+}
+
+func (r *entryRecorder) written() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.lines...)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	out := &entryRecorder{}
+	w := &AsyncWriter{Out: out, Capacity: 5}
+	var want []string
+	for i := 0; i < 100; i++ {
+		line := fmt.Sprintf("entry %d\n", i)
+		want = append(want, line)
+		lvl := zerolog.InfoLevel
+		if i%2 == 1 {
+			lvl = zerolog.ErrorLevel
+		}
+		if n, err := w.WriteLevel(lvl, []byte(line)); err != nil || n != len(line) {
+			t.Fatalf("WriteLevel = %d, %v", n, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.written(); strings.Join(got, "") != strings.Join(want, "") {
+		t.Errorf("wrote %q", got)
+	}
+	for i, lvl := range out.levels {
+		if want := []zerolog.Level{zerolog.InfoLevel, zerolog.ErrorLevel}[i%2]; lvl != want {
+			t.Errorf("entry %d written at %s, want %s", i, lvl, want)
+		}
+	}
+	if s := w.Stats(); s.Queued != 0 || s.Written != 100 || s.Dropped != 0 {
+		t.Errorf("Stats() = %+v", s)
+	}
+	if out.flushes != 1 {
+		t.Errorf("Out flushed %d times, want once", out.flushes)
+	}
+
+	// Entries written after Close go to Out directly
+	w.Close()
+	w.Write([]byte("late\n"))
+	if got := out.written(); got[len(got)-1] != "late\n" {
+		t.Errorf("last entry %q, want the one written after Close", got[len(got)-1])
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestAsyncWriterFlushInterval(t *testing.T) {
+	out := &entryRecorder{}
+	w := &AsyncWriter{Out: out, FlushInterval: 10 * time.Millisecond}
+	defer w.Close()
+	w.Write([]byte("entry\n"))
+	// Written by the ticker, without a Flush
+	deadline := time.Now().Add(5 * time.Second)
+	for len(out.written()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("entry not written within 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAsyncWriterOnError(t *testing.T) {
+	var errs []error
+	w := &AsyncWriter{Out: &entryRecorder{err: errors.New("disk full")}, OnError: func(err error) { errs = append(errs, err) }}
+	w.Write([]byte("entry\n"))
+	w.Close()
+	if len(errs) != 1 || errs[0].Error() != "logger: async: disk full" {
+		t.Errorf("reported %v", errs)
+	}
+}
+
+func TestConfigAsync(t *testing.T) {
+	out := &entryRecorder{}
+	l, err := New(Config{Output: out, Async: &AsyncConfig{Capacity: 16, FlushInterval: time.Hour}, NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+	l.Info("queued")
+	Flush()
+	if got := out.written(); len(got) != 1 || got[0] != `{"level":"info","message":"queued"}`+"\n" {
+		t.Errorf("wrote %q after Flush", got)
+	}
+
+	for _, ac := range []AsyncConfig{{Capacity: -1}, {FlushInterval: -1}, {DropReportInterval: -1}} {
+		if _, err := New(Config{Output: io.Discard, Async: &ac}); err == nil || !strings.Contains(err.Error(), "negative") {
+			t.Errorf("New with Async %+v: %v", ac, err)
+		}
+	}
+}
+
+// stallWriter holds its first write until gate is closed, announcing it on
+// started
+type stallWriter struct {
+	entryRecorder
+	once    sync.Once
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (w *stallWriter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.started)
+		<-w.gate
+	})
+	return w.entryRecorder.WriteLevel(lvl, p)
+}
+
+func (w *stallWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func TestAsyncWriterDropWhenFull(t *testing.T) {
+	out := &stallWriter{started: make(chan struct{}), gate: make(chan struct{})}
+	w := &AsyncWriter{Out: out, Capacity: 8, DropWhenFull: true}
+	before := DroppedEntries()
+	w.WriteLevel(zerolog.InfoLevel, []byte("first\n"))
+	<-out.started
+
+	// With 8 slots, debug entries are dropped once 6 are taken, info
+	// entries at 7 and the rest at 8; no call blocks
+	for _, e := range []struct {
+		lvl  zerolog.Level
+		line string
+	}{
+		{zerolog.InfoLevel, "info 1\n"},
+		{zerolog.InfoLevel, "info 2\n"},
+		{zerolog.InfoLevel, "info 3\n"},
+		{zerolog.InfoLevel, "info 4\n"},
+		{zerolog.InfoLevel, "info 5\n"},
+		{zerolog.DebugLevel, "dropped debug\n"},
+		{zerolog.InfoLevel, "info 6\n"},
+		{zerolog.InfoLevel, "dropped info\n"},
+		{zerolog.TraceLevel, "dropped trace\n"},
+		{zerolog.WarnLevel, "warn\n"},
+		{zerolog.ErrorLevel, "dropped error\n"},
+	} {
+		w.WriteLevel(e.lvl, []byte(e.line))
+	}
+	if s := w.Stats(); s.Dropped != 4 || s.Queued != 8 {
+		t.Errorf("Stats() = %+v, want 4 dropped and 8 queued", s)
+	}
+	if got := DroppedEntries() - before; got != 4 {
+		t.Errorf("DroppedEntries() grew by %d, want 4", got)
+	}
+
+	close(out.gate)
+	w.Close()
+	got := out.written()
+	want := "first\ninfo 1\ninfo 2\ninfo 3\ninfo 4\ninfo 5\ninfo 6\nwarn\n"
+	if len(got) != 9 || strings.Join(got[:8], "") != want {
+		t.Fatalf("wrote %q", got)
+	}
+	// The drops are reported when the writer closes
+	if report := got[8]; !strings.Contains(report, `"level":"warn"`) || !strings.Contains(report, `"dropped":4`) || out.levels[8] != zerolog.WarnLevel {
+		t.Errorf("report %q at %s", report, out.levels[8])
+	}
+}
+
+func TestAsyncWriterDropReportInterval(t *testing.T) {
+	out := &stallWriter{started: make(chan struct{}), gate: make(chan struct{})}
+	w := &AsyncWriter{Out: out, Capacity: 2, DropWhenFull: true, DropReportInterval: 10 * time.Millisecond}
+	defer w.Close()
+	w.Write([]byte("first\n"))
+	<-out.started
+	w.Write([]byte("second\n"))
+	w.Write([]byte("dropped\n"))
+	close(out.gate)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(strings.Join(out.written(), ""), `"dropped":1`) {
+		if time.Now().After(deadline) {
+			t.Fatalf("no report within 5s, wrote %q", out.written())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAsyncWriterConcurrent(t *testing.T) {
+	out := &entryRecorder{}
+	w := &AsyncWriter{Out: out, Capacity: 5}
+	const writers, entries = 8, 200
+	var wg sync.WaitGroup
+	for g := 0; g < writers; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < entries; i++ {
+				fmt.Fprintf(w, "%d %d\n", g, i)
+			}
+		}()
+	}
+	wg.Wait()
+	w.Close()
+	if len(w.slots) != 8 {
+		t.Errorf("%d slots, want the capacity rounded up to 8", len(w.slots))
+	}
+
+	// Every entry is written once, each goroutine's in the order logged
+	next := make([]int, writers)
+	for _, line := range out.written() {
+		var g, i int
+		if _, err := fmt.Sscanf(line, "%d %d\n", &g, &i); err != nil {
+			t.Fatalf("entry %q: %v", line, err)
+		}
+		if i != next[g] {
+			t.Fatalf("goroutine %d: entry %d written after %d", g, i, next[g]-1)
+		}
+		next[g]++
+	}
+	for g, n := range next {
+		if n != entries {
+			t.Errorf("goroutine %d: %d entries written, want %d", g, n, entries)
+		}
+	}
+	if s := w.Stats(); s.Written != writers*entries || s.Queued != 0 {
+		t.Errorf("Stats() = %+v", s)
+	}
+}
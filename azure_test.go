@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestAzureColumn(t *testing.T) {
+	for name, want := range map[string]string{
+		"message":       "message",
+		"http.status":   "http_status",
+		"request-id":    "request_id",
+		"_internal":     "f__internal",
+		"2xx":           "f_2xx",
+		"":              "f_",
+		"Größe":         "Gr__e",
+		"user_Agent_v2": "user_Agent_v2",
+	} {
+		if got := azureColumn(name); got != want {
+			t.Errorf("azureColumn(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestAzureSink(t *testing.T) {
+	key := []byte("workspace key")
+	var mu sync.Mutex
+	var records []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		date := r.Header.Get("x-ms-date")
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte("POST\n" + strconv.Itoa(len(body)) + "\napplication/json\nx-ms-date:" + date + "\n/api/logs"))
+		if want := "SharedKey ws:" + base64.StdEncoding.EncodeToString(mac.Sum(nil)); r.Header.Get("Authorization") != want {
+			t.Errorf("Authorization %q, want %q", r.Header.Get("Authorization"), want)
+		}
+		if r.Header.Get("Log-Type") != "AppLogs" || r.Header.Get("time-generated-field") != "TimeGenerated" {
+			t.Errorf("headers %v", r.Header)
+		}
+		var batch []map[string]interface{}
+		if err := json.Unmarshal(body, &batch); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		records = append(records, batch...)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s := &AzureSink{
+		WorkspaceID: "ws",
+		SharedKey:   base64.StdEncoding.EncodeToString(key),
+		FieldMap:    map[string]string{"message": "Message"},
+		Endpoint:    srv.URL + "/api/logs?api-version=2016-04-01",
+	}
+	s.WriteLevel(zerolog.WarnLevel, []byte(`{"time":"2026-10-15T01:02:03Z","http.status":503,"message":"slow upstream"}`+"\n"))
+	s.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 1 {
+		t.Fatalf("%d records, want 1", len(records))
+	}
+	got, _ := json.Marshal(records[0])
+	if want := `{"Message":"slow upstream","TimeGenerated":"2026-10-15T01:02:03Z","http_status":503,"level":"warn"}`; string(got) != want {
+		t.Errorf("record\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAzureSinkInvalidKey(t *testing.T) {
+	var errs []error
+	s := &AzureSink{WorkspaceID: "ws", SharedKey: "not base64!", OnError: func(err error) { errs = append(errs, err) }}
+	s.Write([]byte(`{"message":"lost"}` + "\n"))
+	s.Close()
+	if len(errs) != 1 {
+		t.Errorf("reported %v, want one error", errs)
+	}
+}
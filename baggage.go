@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// BaggageFunc returns the value of the baggage member key on ctx, if
+// there is one
+type BaggageFunc func(ctx context.Context, key string) (string, bool)
+
+// baggageConfig is what SetBaggage set
+type baggageConfig struct {
+	f    BaggageFunc
+	keys []string
+}
+
+// baggageConf is the configuration set with SetBaggage
+var baggageConf atomic.Pointer[baggageConfig]
+
+// SetBaggage makes entries logged with a context get the baggage members
+// named in keys as fields of the same name, so metadata propagated across
+// services such as a tenant or a feature flag shows up in every service's
+// logs. Only the listed members are copied; baggage is set by callers and
+// may hold anything. Like SetSpanContextFunc it takes OpenTelemetry's
+// lookup rather than depending on it:
+//
+//	logger.SetBaggage(func(ctx context.Context, key string) (string, bool) {
+//		m := baggage.FromContext(ctx).Member(key)
+//		return m.Value(), m.Key() != ""
+//	}, "tenant", "feature_flag")
+//
+// Members missing from the context are left out. A nil f or no keys turn
+// this off again.
+func SetBaggage(f BaggageFunc, keys ...string) {
+	if f == nil || len(keys) == 0 {
+		baggageConf.Store(nil)
+		return
+	}
+	baggageConf.Store(&baggageConfig{f: f, keys: append([]string(nil), keys...)})
+}
+
+// addBaggage adds the allowed baggage members on ctx to e
+func addBaggage(e *zerolog.Event, ctx context.Context) {
+	bc := baggageConf.Load()
+	if bc == nil {
+		return
+	}
+	for _, key := range bc.keys {
+		if v, ok := bc.f(ctx, key); ok {
+			e.Str(key, v)
+		}
+	}
+}
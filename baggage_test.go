@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// baggageKey is the context key of the test baggage
+type baggageKey struct{}
+
+func TestSetBaggage(t *testing.T) {
+	lookup := func(ctx context.Context, key string) (string, bool) {
+		members, _ := ctx.Value(baggageKey{}).(map[string]string)
+		v, ok := members[key]
+		return v, ok
+	}
+	SetBaggage(lookup, "tenant", "feature_flag")
+	t.Cleanup(func() { SetBaggage(nil) })
+
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), baggageKey{}, map[string]string{
+		"tenant":   "acme",
+		"password": "hunter2",
+	})
+	l.InfoCtx(ctx, "allowed")
+	l.InfoCtx(context.Background(), "no baggage")
+	zl := l.Zerolog()
+	zl.Warn().Ctx(ctx).Msg("zerolog")
+	want := `{"level":"info","tenant":"acme","message":"allowed"}` + "\n" +
+		`{"level":"info","message":"no baggage"}` + "\n" +
+		`{"level":"warn","tenant":"acme","message":"zerolog"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// No keys turn the fields off
+	SetBaggage(lookup)
+	buf.Reset()
+	l.InfoCtx(ctx, "off")
+	if got := buf.String(); got != `{"level":"info","message":"off"}`+"\n" {
+		t.Errorf("got %s", got)
+	}
+}
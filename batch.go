@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// BatchWriter gathers entries and passes them on to Out in one Write, so
+// a busy service makes one syscall per batch rather than one per entry
+//
+//	w := &logger.BatchWriter{Out: file, MaxBytes: 256 << 10, MaxDelay: 50 * time.Millisecond}
+//	defer w.Close()
+//
+// A batch is written once it reaches MaxBytes or MaxDelay after its first
+// entry, whichever comes first. Entries are never split across writes.
+// Flush writes the pending batch and Close does so too, after which
+// entries go to Out directly. Out is not closed.
+//
+// Batches are written without a level, so Out cannot route entries by
+// level. Config.Batch and SinkConfig.Batch wrap outputs in BatchWriters
+// that Flush and Close reach, splitting a LevelRouter so each side is
+// batched on its own.
+type BatchWriter struct {
+	Out      io.Writer     // Destination, required
+	MaxBytes int           // Size a batch is written at, 64 KB if zero
+	MaxDelay time.Duration // Longest an entry waits to be written, 100ms if zero
+	OnError  func(error)   // Called when a timed write fails, stderr if nil
+
+	mu     sync.Mutex
+	buf    []byte
+	timer  *time.Timer
+	closed bool
+}
+
+// BatchConfig gathers entries into larger writes, see BatchWriter
+type BatchConfig struct {
+	MaxBytes int           // Size a batch is written at, 64 KB if zero
+	MaxDelay time.Duration // Longest an entry waits to be written, 100ms if zero
+}
+
+// validate rejects a negative size or delay
+func (bc *BatchConfig) validate() error {
+	switch {
+	case bc == nil:
+		return nil
+	case bc.MaxBytes < 0:
+		return fmt.Errorf("logger: batch size %d is negative", bc.MaxBytes)
+	case bc.MaxDelay < 0:
+		return fmt.Errorf("logger: batch delay %s is negative", bc.MaxDelay)
+	}
+	return nil
+}
+
+// batchWriter wraps w in a BatchWriter configured by bc, or one for each
+// side of a LevelRouter, and adds them to bufs. A nil bc leaves w as it is.
+func batchWriter(w io.Writer, bc *BatchConfig, bufs *[]bufferedWriter) io.Writer {
+	if bc == nil || w == nil {
+		return w
+	}
+	if r, ok := w.(*LevelRouter); ok {
+		return &LevelRouter{High: batchWriter(r.High, bc, bufs), Low: batchWriter(r.Low, bc, bufs), Threshold: r.Threshold}
+	}
+	b := &BatchWriter{Out: w, MaxBytes: bc.MaxBytes, MaxDelay: bc.MaxDelay}
+	recordBuffered(b)
+	*bufs = append(*bufs, b)
+	return b
+}
+
+// Write adds p to the pending batch, writing the batch if it is full
+func (b *BatchWriter) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return b.Out.Write(p)
+	}
+	limit := b.MaxBytes
+	if limit <= 0 {
+		limit = 64 << 10
+	}
+	if len(b.buf) > 0 && len(b.buf)+len(p) > limit {
+		if err := b.flushLocked(); err != nil {
+			return 0, err
+		}
+	}
+	b.buf = append(b.buf, p...)
+	if len(b.buf) >= limit {
+		if err := b.flushLocked(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if b.timer == nil {
+		delay := b.MaxDelay
+		if delay <= 0 {
+			delay = 100 * time.Millisecond
+		}
+		b.timer = time.AfterFunc(delay, b.timedFlush)
+	}
+	return len(p), nil
+}
+
+// Flush writes the pending batch, then flushes Out if it has a Flush
+// method
+func (b *BatchWriter) Flush() error {
+	b.mu.Lock()
+	err := b.flushLocked()
+	b.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if f, ok := b.Out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close writes the pending batch; later entries are written to Out
+// directly. Out is left open.
+func (b *BatchWriter) Close() error {
+	b.mu.Lock()
+	err := b.flushLocked()
+	b.closed = true
+	b.mu.Unlock()
+	forgetBuffered(b)
+	return err
+}
+
+// timedFlush writes the batch once its first entry waited MaxDelay
+func (b *BatchWriter) timedFlush() {
+	b.mu.Lock()
+	err := b.flushLocked()
+	b.mu.Unlock()
+	if err != nil {
+		b.report(err)
+	}
+}
+
+// flushLocked writes the pending batch. Callers must hold b.mu.
+func (b *BatchWriter) flushLocked() error {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.buf) == 0 {
+		return nil
+	}
+	_, err := b.Out.Write(b.buf)
+	b.buf = b.buf[:0]
+	return err
+}
+
+// report passes a failed timed write to OnError, or prints it to stderr
+// since it cannot be returned from Write
+func (b *BatchWriter) report(err error) {
+	err = fmt.Errorf("logger: batch: %w", err)
+	if b.OnError != nil {
+		b.OnError(err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchWriter(t *testing.T) {
+	out := &entryRecorder{}
+	b := &BatchWriter{Out: out, MaxBytes: 10, MaxDelay: time.Hour}
+	for _, entry := range []string{"aaaa\n", "bbbb\n", "cc\n", "dddddddd\n"} {
+		if n, err := b.Write([]byte(entry)); err != nil || n != len(entry) {
+			t.Fatalf("Write(%q) = %d, %v", entry, n, err)
+		}
+	}
+	// A full batch is written at once, and an entry that would overflow
+	// the batch starts the next one
+	if got, want := out.written(), []string{"aaaa\nbbbb\n", "cc\n"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+	// Entries larger than a batch are written whole
+	b.Write([]byte(strings.Repeat("e", 20) + "\n"))
+	b.Flush()
+	if got, want := out.written()[2:], []string{"dddddddd\n", strings.Repeat("e", 20) + "\n"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+	if out.flushes != 1 {
+		t.Errorf("Out flushed %d times, want once", out.flushes)
+	}
+
+	// Close writes the pending batch; later entries go to Out directly
+	b.Write([]byte("f\n"))
+	b.Close()
+	b.Write([]byte("g\n"))
+	if got, want := out.written()[4:], []string{"f\n", "g\n"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("wrote %q, want %q", got, want)
+	}
+}
+
+func TestBatchWriterMaxDelay(t *testing.T) {
+	out := &entryRecorder{}
+	b := &BatchWriter{Out: out, MaxDelay: 10 * time.Millisecond}
+	defer b.Close()
+	b.Write([]byte("a\n"))
+	b.Write([]byte("b\n"))
+	deadline := time.Now().Add(5 * time.Second)
+	for len(out.written()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("batch not written within 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := out.written(); len(got) != 1 || got[0] != "a\nb\n" {
+		t.Errorf("wrote %q", got)
+	}
+}
+
+func TestBatchWriterOnError(t *testing.T) {
+	errs := make(chan error, 1)
+	b := &BatchWriter{Out: &entryRecorder{err: errors.New("disk full")}, MaxDelay: time.Millisecond, OnError: func(err error) { errs <- err }}
+	b.Write([]byte("a\n"))
+	select {
+	case err := <-errs:
+		if err.Error() != "logger: batch: disk full" {
+			t.Errorf("reported %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed write error not reported within 5s")
+	}
+
+	// Errors of writes made by Write are returned
+	b = &BatchWriter{Out: &entryRecorder{err: errors.New("disk full")}, MaxBytes: 1}
+	if _, err := b.Write([]byte("a\n")); err == nil {
+		t.Error("Write of a full batch: no error")
+	}
+}
+
+func TestConfigBatch(t *testing.T) {
+	var high, low bytes.Buffer
+	l, err := New(Config{Output: NewLevelRouter(&high, &low), Batch: &BatchConfig{MaxDelay: time.Hour}, NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+	l.Info("low")
+	l.Error(nil, "high")
+	if high.Len() != 0 || low.Len() != 0 {
+		t.Errorf("written before Flush: %q, %q", high.String(), low.String())
+	}
+	// Each side of the router is batched on its own
+	Flush()
+	if got := strings.Join(messages(t, &high), ","); got != "high" {
+		t.Errorf("high got %q", got)
+	}
+	if got := strings.Join(messages(t, &low), ","); got != "low" {
+		t.Errorf("low got %q", got)
+	}
+
+	for _, bc := range []BatchConfig{{MaxBytes: -1}, {MaxDelay: -1}} {
+		if _, err := New(Config{Output: io.Discard, Batch: &bc}); err == nil || !strings.Contains(err.Error(), "negative") {
+			t.Errorf("New with Batch %+v: %v", bc, err)
+		}
+	}
+}
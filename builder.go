@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Builder assembles a logger configuration step by step
+//
+//	log, err := logger.NewBuilder().
+//		Level("debug").
+//		Pretty().
+//		Output(f).
+//		WithFields(map[string]interface{}{"service": "billing"}).
+//		Build()
+//
+// Build returns an independent zerolog logger, New an independent Logger and
+// Init installs the configuration as the global logger.
+type Builder struct {
+	cfg Config
+}
+
+// NewBuilder returns a Builder starting from the default configuration
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Level sets the log level name
+func (b *Builder) Level(level string) *Builder {
+	b.cfg.Level = level
+	return b
+}
+
+// Pretty enables pretty (human-readable) output
+func (b *Builder) Pretty() *Builder {
+	b.cfg.Pretty = true
+	return b
+}
+
+// Format sets the output format, see Config.Format
+func (b *Builder) Format(name string) *Builder {
+	b.cfg.Format = name
+	return b
+}
+
+// Theme sets the colors of pretty output
+func (b *Builder) Theme(t Theme) *Builder {
+	b.cfg.Theme = &t
+	return b
+}
+
+// Schema stamps every entry with the schema name and version
+func (b *Builder) Schema(name, version string) *Builder {
+	b.cfg.Schema, b.cfg.SchemaVersion = name, version
+	return b
+}
+
+// Output sets the writer log entries are written to
+func (b *Builder) Output(w io.Writer) *Builder {
+	b.cfg.Output = w
+	return b
+}
+
+// Sink adds a destination that gets entries at minLevel and above in the
+// given format, replacing Output and Pretty
+func (b *Builder) Sink(w io.Writer, minLevel, format string) *Builder {
+	b.cfg.Sinks = append(b.cfg.Sinks, SinkConfig{Writer: w, MinLevel: minLevel, Format: format})
+	return b
+}
+
+// Async writes entries from a background goroutine, see AsyncWriter
+func (b *Builder) Async(capacity int, flushInterval time.Duration) *Builder {
+	b.cfg.Async = &AsyncConfig{Capacity: capacity, FlushInterval: flushInterval}
+	return b
+}
+
+// Caller includes caller information in log entries
+func (b *Builder) Caller() *Builder {
+	b.cfg.WithCaller = true
+	return b
+}
+
+// CallerSkip skips n extra stack frames when reporting the caller
+func (b *Builder) CallerSkip(n int) *Builder {
+	b.cfg.CallerSkipFrames = n
+	return b
+}
+
+// TimeFormat sets the timestamp format
+func (b *Builder) TimeFormat(format string) *Builder {
+	b.cfg.TimeFormat = format
+	return b
+}
+
+// Location sets the time zone timestamps are written in
+func (b *Builder) Location(loc *time.Location) *Builder {
+	b.cfg.Location = loc
+	return b
+}
+
+// NoTimestamp leaves the timestamp out of entries
+func (b *Builder) NoTimestamp() *Builder {
+	b.cfg.NoTimestamp = true
+	return b
+}
+
+// Disabled makes the logger a no-op
+func (b *Builder) Disabled() *Builder {
+	b.cfg.Disabled = true
+	return b
+}
+
+// Scoped leaves zerolog's global settings alone when the configuration is
+// installed with Init
+func (b *Builder) Scoped() *Builder {
+	b.cfg.Scoped = true
+	return b
+}
+
+// WithFields adds static fields to every entry, merging with fields added
+// by earlier calls
+func (b *Builder) WithFields(fields map[string]interface{}) *Builder {
+	if b.cfg.Fields == nil {
+		b.cfg.Fields = make(map[string]interface{}, len(fields))
+	}
+	for k, v := range fields {
+		b.cfg.Fields[k] = v
+	}
+	return b
+}
+
+// Config returns the configuration assembled so far
+func (b *Builder) Config() Config {
+	return b.cfg
+}
+
+// Build validates the configuration and returns an independent zerolog
+// logger that does not touch the global logger
+func (b *Builder) Build() (zerolog.Logger, error) {
+	if err := b.cfg.Validate(); err != nil {
+		return zerolog.Nop(), err
+	}
+	cfg := withDefaults(b.cfg)
+	level, _ := cfg.level() // checked by Validate
+	lowerGlobalLevel(level)
+	logger, _ := newLogger(cfg, nil)
+	logger = logger.Level(level)
+	if cfg.WithCaller {
+		logger = logger.With().CallerWithSkipFrameCount(zerolog.CallerSkipFrameCount + cfg.CallerSkipFrames).Logger()
+	}
+	return logger, nil
+}
+
+// New validates the configuration and returns an independent Logger
+func (b *Builder) New() (*Logger, error) {
+	return New(b.cfg)
+}
+
+// Init validates the configuration and installs it as the global logger,
+// following the same first-call-wins rule as InitLogger
+func (b *Builder) Init() error {
+	return InitLoggerE(b.cfg)
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBuilderBuild(t *testing.T) {
+	var buf bytes.Buffer
+	zl, err := NewBuilder().
+		Level("warn").
+		Output(&buf).
+		NoTimestamp().
+		Caller().
+		WithFields(map[string]interface{}{"service": "billing", "env": "dev"}).
+		WithFields(map[string]interface{}{"env": "prod"}).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	zl.Info().Msg("dropped")
+	zl.Warn().Msg("kept")
+
+	var e map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	if e["message"] != "kept" || e["service"] != "billing" || e["env"] != "prod" {
+		t.Errorf("got %v", e)
+	}
+	if _, ok := e["time"]; ok {
+		t.Error("timestamp written despite NoTimestamp")
+	}
+	if caller, _ := e["caller"].(string); !strings.Contains(caller, "builder_test.go") {
+		t.Errorf("caller %q, want builder_test.go", caller)
+	}
+}
+
+func TestBuilderBuildInvalid(t *testing.T) {
+	if _, err := NewBuilder().Level("loud").Build(); err == nil {
+		t.Error("no error for an invalid level")
+	}
+}
+
+func TestBuilderNew(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := NewBuilder().Level("info").Output(&buf).New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("hello")
+	if got := strings.Join(messages(t, &buf), ","); got != "hello" {
+		t.Errorf("got %q, want hello", got)
+	}
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/rs/zerolog"
+)
+
+// cborWriter is the "cbor" output format: each entry becomes one CBOR map
+// (RFC 8949) with the entry's fields, written one after another as a CBOR
+// sequence (RFC 8742) that any CBOR library can read. Unlike zerolog's
+// binary_log build tag it needs no rebuild and leaves the other outputs
+// in their own formats.
+func cborWriter(w io.Writer, _ Config) io.Writer {
+	return &encodingWriter{w: w, encode: func(dst []byte, _ zerolog.Level, entry []byte) []byte {
+		_, fields := decodeEntry(bytes.TrimRight(entry, "\n"))
+		return appendCBOR(dst, fields)
+	}}
+}
+
+// appendCBOR appends v, a decoded JSON value, to dst. Map keys are sorted
+// so equal entries encode the same way.
+func appendCBOR(dst []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, 0xf6)
+	case bool:
+		if v {
+			return append(dst, 0xf5)
+		}
+		return append(dst, 0xf4)
+	case string:
+		return append(cborHead(dst, 3, uint64(len(v))), v...)
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			if i < 0 {
+				return cborHead(dst, 1, uint64(-(i + 1)))
+			}
+			return cborHead(dst, 0, uint64(i))
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return cborHead(dst, 0, u)
+		}
+		if f, err := v.Float64(); err == nil {
+			return binary.BigEndian.AppendUint64(append(dst, 0xfb), math.Float64bits(f))
+		}
+		return appendCBOR(dst, string(v))
+	case []interface{}:
+		dst = cborHead(dst, 4, uint64(len(v)))
+		for _, item := range v {
+			dst = appendCBOR(dst, item)
+		}
+		return dst
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		dst = cborHead(dst, 5, uint64(len(keys)))
+		for _, k := range keys {
+			dst = appendCBOR(appendCBOR(dst, k), v[k])
+		}
+		return dst
+	}
+	return appendCBOR(dst, fmt.Sprint(v))
+}
+
+// cborHead appends the head of a data item of the given major type with
+// its argument in the shortest form
+func cborHead(dst []byte, major byte, arg uint64) []byte {
+	major <<= 5
+	switch {
+	case arg < 24:
+		return append(dst, major|byte(arg))
+	case arg <= math.MaxUint8:
+		return append(dst, major|24, byte(arg))
+	case arg <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, major|25), uint16(arg))
+	case arg <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(dst, major|26), uint32(arg))
+	}
+	return binary.BigEndian.AppendUint64(append(dst, major|27), arg)
+}
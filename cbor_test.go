@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+func TestAppendCBOR(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{nil, "f6"},
+		{true, "f5"},
+		{false, "f4"},
+		{"", "60"},
+		{"a", "6161"},
+		{json.Number("0"), "00"},
+		{json.Number("23"), "17"},
+		{json.Number("24"), "1818"},
+		{json.Number("256"), "190100"},
+		{json.Number("65536"), "1a00010000"},
+		{json.Number("4294967296"), "1b0000000100000000"},
+		{json.Number("18446744073709551615"), "1bffffffffffffffff"},
+		{json.Number("-1"), "20"},
+		{json.Number("-25"), "3818"},
+		{json.Number("1.5"), "fb3ff8000000000000"},
+		{json.Number("1e400"), "65" + hex.EncodeToString([]byte("1e400"))},
+		{[]interface{}{json.Number("1"), "a"}, "82016161"},
+		{map[string]interface{}{"b": json.Number("2"), "a": json.Number("1")}, "a2616101616202"},
+		{7, "6137"},
+	} {
+		if got := hex.EncodeToString(appendCBOR(nil, tt.v)); got != tt.want {
+			t.Errorf("%v encoded as %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestCBORFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Sinks: []SinkConfig{{Writer: &buf, Format: "cbor"}}, NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("hi", "n", 1)
+	l.Warn("slow")
+	// A CBOR sequence of one map per entry, keys sorted
+	want := "a3" + "656c6576656c" + "64696e666f" + "676d657373616765" + "626869" + "616e" + "01" +
+		"a2" + "656c6576656c" + "647761726e" + "676d657373616765" + "64736c6f77"
+	if got := hex.EncodeToString(buf.Bytes()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
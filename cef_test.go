@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestCEFWriter(t *testing.T) {
+	host, _ := os.Hostname()
+	var buf bytes.Buffer
+	c := &CEFWriter{Out: &buf, Vendor: "Acme", Product: "bill|ing", Version: "2.1"}
+	c.WriteLevel(zerolog.WarnLevel, []byte(`{"level":"warn","time":"2026-10-15T01:02:03Z","event_id":"login_failed","suser":"alice","src":"10.0.0.7","component":"auth","error":"bad\npassword","http.path":"/login?a=b","attempts":[1,2],"message":"login failed\nagain"}`+"\n"))
+	want := `CEF:0|Acme|bill\|ing|2.1|login_failed|login failed again|6|rt=1792026123000 dvchost=` + host + ` attempts=[1,2] deviceFacility=auth http_path=/login?a\=b reason=bad\npassword src=10.0.0.7 suser=alice` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Without an event ID the level is the signature; the program names
+	// the device by default
+	buf.Reset()
+	c = &CEFWriter{Out: &buf}
+	c.Write([]byte(`{"level":"error","message":"boom"}` + "\n"))
+	program := filepath.Base(os.Args[0])
+	if prefix := "CEF:0|" + program + "|" + program + "|0|error|boom|8|"; !strings.HasPrefix(buf.String(), prefix) {
+		t.Errorf("got %q, want prefix %q", buf.String(), prefix)
+	}
+}
+
+func TestCEFSeverity(t *testing.T) {
+	for lvl, want := range map[zerolog.Level]int{
+		zerolog.TraceLevel: 0,
+		zerolog.DebugLevel: 1,
+		zerolog.InfoLevel:  3,
+		zerolog.WarnLevel:  6,
+		zerolog.ErrorLevel: 8,
+		zerolog.FatalLevel: 10,
+		zerolog.PanicLevel: 10,
+		zerolog.NoLevel:    3,
+	} {
+		if got := cefSeverity(lvl); got != want {
+			t.Errorf("cefSeverity(%s) = %d, want %d", lvl, got, want)
+		}
+	}
+}
+
+func TestCEFFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, Format: "cef", NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("served", "act", "allowed")
+	if got := buf.String(); !strings.HasPrefix(got, "CEF:0|") || !strings.Contains(got, "|info|served|3|") || !strings.HasSuffix(got, " act=allowed\n") {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// levelKey is the context key of a level set with ContextWithLevel
+type levelKey struct{}
+
+// loggerKey is the context key of a logger set with WithContext
+type loggerKey struct{}
+
+// fieldsKey is the context key of the fields added with AppendCtxFields
+type fieldsKey struct{}
+
+// WithContext returns a copy of ctx carrying l, so code further down the
+// call stack logs with l's fields through FromContext and the package's
+// Ctx functions without passing the logger around
+//
+//	ctx = logger.Get("api").WithField("request_id", id).WithContext(ctx)
+//	...
+//	logger.InfoCtx(ctx, "order placed") // has component and request_id
+//
+// zerolog.Ctx(ctx) returns l's zerolog logger as well.
+func (l *Logger) WithContext(ctx context.Context) context.Context {
+	ctx = l.state.Load().zl.WithContext(ctx)
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// WithContext returns a copy of ctx carrying the global logger
+func WithContext(ctx context.Context) context.Context {
+	return std.WithContext(ctx)
+}
+
+// FromContext returns the logger set on ctx with WithContext, or the
+// global logger if there is none
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(loggerKey{}).(*Logger); ok && l != nil {
+			return l
+		}
+	}
+	return std
+}
+
+// ContextWithLevel returns a copy of ctx that makes the Ctx log functions
+// use level instead of the logger's own, so a single request can be
+// logged at debug while the rest of the process stays at info
+//
+//	if r.Header.Get("X-Debug") == "1" {
+//		r = r.WithContext(logger.ContextWithLevel(r.Context(), zerolog.DebugLevel))
+//	}
+//
+// The level also applies to zerolog events of this package's loggers that
+// carry ctx through Event.Ctx.
+func ContextWithLevel(ctx context.Context, level zerolog.Level) context.Context {
+	lowerGlobalLevel(level)
+	return context.WithValue(ctx, levelKey{}, level)
+}
+
+// LevelFromContext returns the level set on ctx with ContextWithLevel
+func LevelFromContext(ctx context.Context) (zerolog.Level, bool) {
+	if ctx == nil {
+		return zerolog.NoLevel, false
+	}
+	lvl, ok := ctx.Value(levelKey{}).(zerolog.Level)
+	return lvl, ok
+}
+
+// AppendCtxFields returns a copy of ctx carrying fields on top of those
+// ctx already carries, so each layer of a call chain adds what it knows
+// and every entry logged further down has all of it
+//
+//	ctx = logger.AppendCtxFields(ctx, logger.Str("user_id", user.ID)) // auth
+//	ctx = logger.AppendCtxFields(ctx, logger.Int64("order_id", id))   // handler
+//	ctx = logger.AppendCtxFields(ctx, logger.Str("sql", query))       // repository
+//	logger.InfoCtx(ctx, "order saved") // has user_id, order_id and sql
+//
+// Fields are written in the order they were added by InfoCtx and the
+// other Ctx functions and by zerolog events of this package's loggers
+// that carry ctx through Event.Ctx. ctx itself is left as it is, so
+// sibling calls do not see each other's fields.
+func AppendCtxFields(ctx context.Context, fields ...Field) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	prev := ctxFields(ctx)
+	all := make([]Field, 0, len(prev)+len(fields))
+	all = append(append(all, prev...), fields...)
+	return context.WithValue(ctx, fieldsKey{}, all)
+}
+
+// ctxFields returns the fields added to ctx with AppendCtxFields
+func ctxFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	return fields
+}
+
+// DeadlineRemainingField is the key of the milliseconds left until the
+// context's deadline, added with Config.DeadlineRemaining. It is negative
+// once the deadline has passed.
+const DeadlineRemainingField = "deadline_remaining_ms"
+
+// ctxHook adds the values carried by an event's context: its request ID,
+// the fields added with AppendCtxFields, those of the registered
+// ContextHooks, the allowed baggage members, the trace context of its
+// span and, with deadline, the time left until its deadline
+type ctxHook struct {
+	deadline bool
+}
+
+// Run implements zerolog.Hook
+func (h ctxHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if !e.Enabled() {
+		return
+	}
+	ctx := e.GetCtx()
+	if id, ok := RequestIDFromContext(ctx); ok {
+		e.Str(RequestIDField, id)
+	}
+	for _, f := range ctxFields(ctx) {
+		f.apply(e)
+	}
+	addHookFields(e, ctx)
+	addBaggage(e, ctx)
+	addSpanContext(e, ctx)
+	if h.deadline {
+		if dl, ok := ctx.Deadline(); ok {
+			e.Int64(DeadlineRemainingField, time.Until(dl).Milliseconds())
+		}
+	}
+}
+
+// enabledCtx is like enabled but lets a level on ctx take precedence
+func (st *loggerState) enabledCtx(ctx context.Context, lvl zerolog.Level) bool {
+	if ctxLvl, ok := LevelFromContext(ctx); ok {
+		return lvl >= ctxLvl
+	}
+	return st.enabled(lvl)
+}
+
+// InfoCtx logs an info message through the logger on ctx, honoring a level
+// set on ctx
+func InfoCtx(ctx context.Context, msg string, args ...interface{}) {
+	if st := FromContext(ctx).state.Load(); st.enabledCtx(ctx, zerolog.InfoLevel) {
+		processArgs(addCallerInfo(st.zl.Info().Ctx(ctx), st), msg, args...)
+	}
+}
+
+// WarnCtx logs a warning message through the logger on ctx, honoring a level
+// set on ctx
+func WarnCtx(ctx context.Context, msg string, args ...interface{}) {
+	if st := FromContext(ctx).state.Load(); st.enabledCtx(ctx, zerolog.WarnLevel) {
+		processArgs(addCallerInfo(st.zl.Warn().Ctx(ctx), st), msg, args...)
+	}
+}
+
+// ErrorCtx logs an error message through the logger on ctx, honoring a level
+// set on ctx
+func ErrorCtx(ctx context.Context, err error, msg string, args ...interface{}) {
+	if st := FromContext(ctx).state.Load(); st.enabledCtx(ctx, zerolog.ErrorLevel) {
+		processArgs(addCallerInfo(st.zl.Error().Err(err).Ctx(ctx), st), msg, args...)
+	}
+}
+
+// InfoCtx logs an info message, honoring a level set on ctx
+func (l *Logger) InfoCtx(ctx context.Context, msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabledCtx(ctx, zerolog.InfoLevel) {
+		processArgs(addCallerInfo(st.zl.Info().Ctx(ctx), st), msg, args...)
+	}
+}
+
+// WarnCtx logs a warning message, honoring a level set on ctx
+func (l *Logger) WarnCtx(ctx context.Context, msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabledCtx(ctx, zerolog.WarnLevel) {
+		processArgs(addCallerInfo(st.zl.Warn().Ctx(ctx), st), msg, args...)
+	}
+}
+
+// ErrorCtx logs an error message, honoring a level set on ctx
+func (l *Logger) ErrorCtx(ctx context.Context, err error, msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabledCtx(ctx, zerolog.ErrorLevel) {
+		processArgs(addCallerInfo(st.zl.Error().Err(err).Ctx(ctx), st), msg, args...)
+	}
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestContextWithLevel(t *testing.T) {
+	cfg, c := TestConfig()
+	cfg.Level = "warn"
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verbose := ContextWithLevel(context.Background(), zerolog.InfoLevel)
+	quiet := ContextWithLevel(context.Background(), zerolog.ErrorLevel)
+
+	l.Info("dropped")
+	l.InfoCtx(verbose, "verbose request")
+	l.WarnCtx(quiet, "dropped")
+	l.ErrorCtx(quiet, nil, "quiet request")
+	zl := l.Zerolog()
+	zl.Info().Ctx(verbose).Msg("zerolog event")
+
+	if got := strings.Join(captured(c), ","); got != "verbose request,quiet request,zerolog event" {
+		t.Errorf("got %q", got)
+	}
+	if lvl, ok := LevelFromContext(quiet); !ok || lvl != zerolog.ErrorLevel {
+		t.Errorf("LevelFromContext = %v, %v", lvl, ok)
+	}
+	if _, ok := LevelFromContext(context.Background()); ok {
+		t.Error("level found on a context without one")
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	cfg, c := TestConfig()
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := l.Component("api").WithField("request_id", "r1").WithContext(context.Background())
+	InfoCtx(ctx, "info")
+	WarnCtx(ctx, "warn")
+	ErrorCtx(ctx, nil, "error")
+	FromContext(ctx).Info("from context")
+	zerolog.Ctx(ctx).Info().Msg("zerolog")
+
+	entries := c.Entries()
+	if len(entries) != 5 {
+		t.Fatalf("got %d entries, want 5 through the logger on ctx", len(entries))
+	}
+	for _, e := range entries {
+		if e["component"] != "api" || e["request_id"] != "r1" {
+			t.Errorf("entry %v lacks the fields of the logger on ctx", e)
+		}
+	}
+
+	if FromContext(context.Background()) != std {
+		t.Error("FromContext without a logger did not return the global one")
+	}
+	if FromContext(WithContext(context.Background())) != std {
+		t.Error("WithContext did not carry the global logger")
+	}
+}
+
+func TestAppendCtxFields(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := context.Background()
+	if AppendCtxFields(base) != base {
+		t.Error("AppendCtxFields without fields made a new context")
+	}
+	auth := AppendCtxFields(base, Str("user_id", "u1"))
+	handler := AppendCtxFields(auth, Int64("order_id", 7))
+	sibling := AppendCtxFields(auth, Str("sibling", "x"))
+	repo := AppendCtxFields(handler, Str("sql", "SELECT 1"), Int("rows", 1))
+
+	l.InfoCtx(repo, "saved")
+	zl := l.Zerolog()
+	zl.Info().Ctx(sibling).Msg("zerolog")
+	l.InfoCtx(base, "plain")
+
+	want := `{"level":"info","user_id":"u1","order_id":7,"sql":"SELECT 1","rows":1,"message":"saved"}` + "\n" +
+		`{"level":"info","user_id":"u1","sibling":"x","message":"zerolog"}` + "\n" +
+		`{"level":"info","message":"plain"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDeadlineRemaining(t *testing.T) {
+	if !NewConfig(WithDeadlineRemaining()).DeadlineRemaining {
+		t.Error("WithDeadlineRemaining did not set DeadlineRemaining")
+	}
+	cfg, c := TestConfig()
+	cfg.DeadlineRemaining = true
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	past, cancelPast := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelPast()
+	l.InfoCtx(ctx, "pending")
+	l.InfoCtx(past, "late")
+	l.InfoCtx(context.Background(), "no deadline")
+
+	entries := c.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if ms, _ := entries[0][DeadlineRemainingField].(float64); ms <= 55000 || ms > 60000 {
+		t.Errorf("%s = %v, want about a minute", DeadlineRemainingField, entries[0][DeadlineRemainingField])
+	}
+	if ms, _ := entries[1][DeadlineRemainingField].(float64); ms > -1000 {
+		t.Errorf("%s = %v past the deadline, want at most -1000", DeadlineRemainingField, entries[1][DeadlineRemainingField])
+	}
+	if _, ok := entries[2][DeadlineRemainingField]; ok {
+		t.Error("deadline added to an entry without one")
+	}
+
+	// Off by default
+	cfg, c = TestConfig()
+	if l, err = New(cfg); err != nil {
+		t.Fatal(err)
+	}
+	l.InfoCtx(ctx, "pending")
+	if _, ok := c.Entries()[0][DeadlineRemainingField]; ok {
+		t.Error("deadline added without DeadlineRemaining")
+	}
+}
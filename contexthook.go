@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// ContextHook pulls fields out of the context of entries logged with
+// one, so values an application keeps on its contexts, such as a session,
+// a locale or a shard, reach every entry without each call adding them
+//
+//	type sessionHook struct{}
+//
+//	func (sessionHook) Fields(ctx context.Context) []logger.Field {
+//		s, ok := session.FromContext(ctx)
+//		if !ok {
+//			return nil
+//		}
+//		return []logger.Field{logger.Str("session", s.ID), logger.Str("locale", s.Locale)}
+//	}
+//
+//	logger.RegisterContextHook(sessionHook{})
+//
+// Fields runs for every entry logged through InfoCtx and the other Ctx
+// functions, and for zerolog events of this package's loggers that carry
+// a context through Event.Ctx, so it should be quick and must be safe for
+// concurrent use.
+type ContextHook interface {
+	Fields(ctx context.Context) []Field
+}
+
+// ContextHookFunc adapts a function to a ContextHook
+type ContextHookFunc func(ctx context.Context) []Field
+
+// Fields implements ContextHook
+func (f ContextHookFunc) Fields(ctx context.Context) []Field {
+	return f(ctx)
+}
+
+// contextHooks holds the registered hooks, replaced as a whole under
+// contextHooksMu so loggers read it without locking
+var (
+	contextHooksMu sync.Mutex
+	contextHooks   atomic.Pointer[[]ContextHook]
+)
+
+// RegisterContextHook adds h to the hooks every logger runs on the
+// context of its entries, after the ones registered before it. Hooks are
+// meant to be registered once at startup and cannot be removed.
+func RegisterContextHook(h ContextHook) {
+	contextHooksMu.Lock()
+	defer contextHooksMu.Unlock()
+	var current []ContextHook
+	if p := contextHooks.Load(); p != nil {
+		current = *p
+	}
+	next := make([]ContextHook, 0, len(current)+1)
+	next = append(append(next, current...), h)
+	contextHooks.Store(&next)
+}
+
+// addHookFields adds the fields the registered hooks pull out of ctx to e
+func addHookFields(e *zerolog.Event, ctx context.Context) {
+	p := contextHooks.Load()
+	if p == nil {
+		return
+	}
+	for _, h := range *p {
+		for _, f := range h.Fields(ctx) {
+			f.apply(e)
+		}
+	}
+}
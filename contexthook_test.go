@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// hookCallsKey marks the contexts whose ContextHook calls are counted, so
+// the hook registered by the test leaves other tests' entries alone
+type hookCallsKey struct{}
+
+// sessionKey is the context key of the session the tests' hooks read
+type sessionKey struct{}
+
+type sessionHook struct{}
+
+func (sessionHook) Fields(ctx context.Context) []Field {
+	id, ok := ctx.Value(sessionKey{}).(string)
+	if !ok {
+		return nil
+	}
+	return []Field{Str("session", id), Str("locale", "de")}
+}
+
+// sessionHooks registers the tests' hooks once, as hooks cannot be
+// removed and -count runs a test more than once
+var sessionHooks sync.Once
+
+func TestRegisterContextHook(t *testing.T) {
+	sessionHooks.Do(func() {
+		RegisterContextHook(sessionHook{})
+		RegisterContextHook(ContextHookFunc(func(ctx context.Context) []Field {
+			if ctx.Value(sessionKey{}) == nil {
+				return nil
+			}
+			return []Field{Int("shard", 3)}
+		}))
+	})
+
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), sessionKey{}, "s1")
+	ctx = AppendCtxFields(ctx, Str("user_id", "u1"))
+	l.InfoCtx(ctx, "hooked")
+	zl := l.Zerolog()
+	zl.Warn().Ctx(ctx).Msg("zerolog")
+	l.InfoCtx(context.Background(), "no session")
+
+	// Hooks run in the order they were registered, after the fields added
+	// with AppendCtxFields
+	want := `{"level":"info","user_id":"u1","session":"s1","locale":"de","shard":3,"message":"hooked"}` + "\n" +
+		`{"level":"warn","user_id":"u1","session":"s1","locale":"de","shard":3,"message":"zerolog"}` + "\n" +
+		`{"level":"info","message":"no session"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestContextHookSkipsDiscardedEntries(t *testing.T) {
+	var calls atomic.Int32
+	RegisterContextHook(ContextHookFunc(func(ctx context.Context) []Field {
+		if ctx.Value(hookCallsKey{}) == nil {
+			return nil
+		}
+		calls.Add(1)
+		return []Field{Str("tenant", "acme")}
+	}))
+
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, Level: "warn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), hookCallsKey{}, true)
+	zl := l.Zerolog()
+	zl.Info().Ctx(ctx).Msg("discarded")
+	if n := calls.Load(); n != 0 {
+		t.Errorf("hook called %d times for a discarded entry", n)
+	}
+	zl.Warn().Ctx(ctx).Msg("kept")
+	if n := calls.Load(); n != 1 {
+		t.Errorf("hook called %d times for a kept entry, want 1", n)
+	}
+	if !strings.Contains(buf.String(), `"tenant":"acme"`) {
+		t.Errorf("hook fields missing: %s", buf.String())
+	}
+}
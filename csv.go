@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// DefaultCSVColumns are the columns of a CSVWriter without Columns
+var DefaultCSVColumns = []string{"time", "level", "component", "message", "error"}
+
+// CSVWriter writes entries to Out as CSV rows with a fixed set of columns,
+// for loading logs straight into a spreadsheet or a database such as
+// DuckDB
+//
+//	w := &logger.CSVWriter{Out: f, Columns: []string{"time", "level", "message", "user_id", "duration"}, Header: true}
+//	logger.InitLogger(logger.Config{Output: w})
+//
+//	SELECT level, avg(duration) FROM read_csv('app.csv') GROUP BY level;
+//
+// A column is named after the field it holds; time, level, message,
+// caller and error are the standard fields under whatever names
+// FieldNames gives them, and the column fields holds the entry's fields
+// no other column took as a JSON object. Missing fields leave their cell
+// empty and nested values are written as JSON. Rows follow RFC 4180,
+// quoting cells as needed, with a newline after each. The format name
+// "csv" uses a CSVWriter with Config.CSVColumns and no header.
+type CSVWriter struct {
+	Out     io.Writer // Destination, required
+	Columns []string  // Columns of each row, DefaultCSVColumns if empty
+	Header  bool      // Write a row of the column names before the first entry
+
+	mu      sync.Mutex
+	started bool
+}
+
+// csvWriter is the "csv" output format
+func csvWriter(w io.Writer, cfg Config) io.Writer {
+	return &CSVWriter{Out: w, Columns: cfg.CSVColumns}
+}
+
+// Write implements io.Writer for entries without a known level
+func (c *CSVWriter) Write(p []byte) (int, error) {
+	return c.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (c *CSVWriter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	columns := c.Columns
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+	line := appendCSVRow(nil, lvl, p, columns)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Header && !c.started {
+		line = append(appendCSVRecord(nil, columns), line...)
+	}
+	if lw, ok := c.Out.(zerolog.LevelWriter); ok {
+		if _, err := lw.WriteLevel(lvl, line); err != nil {
+			return 0, err
+		}
+	} else if _, err := c.Out.Write(line); err != nil {
+		return 0, err
+	}
+	c.started = true
+	return len(p), nil
+}
+
+// csvStandardColumns maps the column names of the standard fields to the
+// field names entries currently use
+func csvStandardColumns() map[string]string {
+	return map[string]string{
+		"time":    zerolog.TimestampFieldName,
+		"level":   zerolog.LevelFieldName,
+		"message": zerolog.MessageFieldName,
+		"caller":  zerolog.CallerFieldName,
+		"error":   zerolog.ErrorFieldName,
+	}
+}
+
+// appendCSVRow appends entry, a zerolog JSON entry, as a CSV row of the
+// given columns to dst
+func appendCSVRow(dst []byte, lvl zerolog.Level, entry []byte, columns []string) []byte {
+	_, fields := decodeEntry(bytes.TrimRight(entry, "\n"))
+	if lvl = entryLevel(lvl, fields); lvl != zerolog.NoLevel {
+		fields[zerolog.LevelFieldName] = LevelString(lvl)
+	}
+
+	standard := csvStandardColumns()
+	cells := make([]string, len(columns))
+	rest := -1
+	for i, col := range columns {
+		if col == "fields" {
+			rest = i
+			continue
+		}
+		key := col
+		if name, ok := standard[col]; ok {
+			key = name
+		}
+		if v, ok := fields[key]; ok {
+			if v != nil {
+				cells[i] = fieldString(v)
+			}
+			delete(fields, key)
+		}
+	}
+	if rest >= 0 && len(fields) > 0 {
+		var obj jsonObject
+		obj.addSorted(fields)
+		cells[rest] = string(bytes.TrimSuffix(obj.line(nil), []byte("\n")))
+	}
+	return appendCSVRecord(dst, cells)
+}
+
+// appendCSVRecord appends cells as one CSV line to dst, quoting the cells
+// that hold commas, quotes, line breaks or surrounding spaces
+func appendCSVRecord(dst []byte, cells []string) []byte {
+	for i, cell := range cells {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		if !strings.ContainsAny(cell, ",\"\r\n") && strings.TrimSpace(cell) == cell {
+			dst = append(dst, cell...)
+			continue
+		}
+		dst = append(dst, '"')
+		dst = append(dst, strings.ReplaceAll(cell, `"`, `""`)...)
+		dst = append(dst, '"')
+	}
+	return append(dst, '\n')
+}
@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	c := &CSVWriter{Out: &buf, Columns: []string{"time", "level", "message", "user_id", "fields", "duration"}, Header: true}
+	c.WriteLevel(zerolog.WarnLevel, []byte(`{"level":"warn","time":"2026-10-15T01:02:03Z","user_id":7,"message":"slow, \"very\"\nslow","tags":["a","b"],"http":{"status":503},"duration":null}`+"\n"))
+	c.Write([]byte(`{"level":"info","message":" padded "}` + "\n"))
+	want := "time,level,message,user_id,fields,duration\n" +
+		`2026-10-15T01:02:03Z,warn,"slow, ""very""` + "\n" + `slow",7,"{""http"":{""status"":503},""tags"":[""a"",""b""]}",` + "\n" +
+		`,info," padded ",,,` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// The rows are read back as written
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"2026-10-15T01:02:03Z", "warn", "slow, \"very\"\nslow", "7", `{"http":{"status":503},"tags":["a","b"]}`, ""}; !reflect.DeepEqual(rows[1], want) {
+		t.Errorf("row %q, want %q", rows[1], want)
+	}
+}
+
+func TestCSVWriterDefaultColumns(t *testing.T) {
+	var buf bytes.Buffer
+	c := &CSVWriter{Out: &buf}
+	c.WriteLevel(zerolog.ErrorLevel, []byte(`{"time":"2026-10-15T01:02:03Z","component":"billing","error":"declined","order":7,"message":"charge failed"}`))
+	if got, want := buf.String(), "2026-10-15T01:02:03Z,error,billing,charge failed,declined\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestCSVFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, Format: "csv", CSVColumns: []string{"level", "message", "fields"}, NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("served", "status", 200)
+	l.Warn("slow")
+	if got, want := buf.String(), "info,served,\"{\"\"status\"\":200}\"\nwarn,slow,\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
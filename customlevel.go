@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// customLevelStart is the zerolog level value of the first registered
+// custom level, well clear of the levels zerolog defines
+const customLevelStart zerolog.Level = 20
+
+// customLevel is a registered level and the standard level it ranks as
+type customLevel struct {
+	name string
+	base zerolog.Level
+}
+
+var (
+	customMu sync.Mutex // serializes RegisterLevel
+
+	// customLevels maps registered zerolog level values to their
+	// definition. The map is replaced on registration, never modified.
+	customLevels atomic.Pointer[map[zerolog.Level]customLevel]
+)
+
+// RegisterLevel adds a named level, such as "audit" or "security", that is
+// filtered like base but written with its own name in the level field
+//
+//	var Audit = logger.MustRegisterLevel("audit", zerolog.InfoLevel)
+//
+//	logger.Log(Audit, "ledger exported", "user", user)
+//
+// The returned level can also be passed to zerolog's WithLevel. Entries at
+// a custom level never exit or panic, even with a fatal or panic base.
+// Registering the first level installs a zerolog.LevelFieldMarshalFunc
+// that names custom levels and defers to the previous function otherwise.
+func RegisterLevel(name string, base zerolog.Level) (zerolog.Level, error) {
+	name = strings.ToLower(name)
+	if name == "" {
+		return zerolog.NoLevel, fmt.Errorf("logger: custom level name is empty")
+	}
+	if base < zerolog.TraceLevel || base > zerolog.PanicLevel {
+		return zerolog.NoLevel, fmt.Errorf("logger: custom level %q: base level %d is not a standard level", name, base)
+	}
+	if _, ok := Levels[name]; ok {
+		return zerolog.NoLevel, fmt.Errorf("logger: custom level %q clashes with a standard level", name)
+	}
+
+	customMu.Lock()
+	defer customMu.Unlock()
+
+	current := customLevelMap()
+	for _, c := range current {
+		if c.name == name {
+			return zerolog.NoLevel, fmt.Errorf("logger: custom level %q is already registered", name)
+		}
+	}
+	lvl := customLevelStart + zerolog.Level(len(current))
+	if lvl < customLevelStart {
+		return zerolog.NoLevel, fmt.Errorf("logger: too many custom levels")
+	}
+
+	next := make(map[zerolog.Level]customLevel, len(current)+1)
+	for k, v := range current {
+		next[k] = v
+	}
+	next[lvl] = customLevel{name: name, base: base}
+	if len(current) == 0 {
+		prev := zerolog.LevelFieldMarshalFunc
+		zerolog.LevelFieldMarshalFunc = func(l zerolog.Level) string {
+			if c, ok := customLevelMap()[l]; ok {
+				return c.name
+			}
+			return prev(l)
+		}
+	}
+	customLevels.Store(&next)
+	return lvl, nil
+}
+
+// MustRegisterLevel is like RegisterLevel but panics on error, for
+// registering levels in package variables
+func MustRegisterLevel(name string, base zerolog.Level) zerolog.Level {
+	lvl, err := RegisterLevel(name, base)
+	if err != nil {
+		panic(err)
+	}
+	return lvl
+}
+
+// customLevelMap returns the registered custom levels
+func customLevelMap() map[zerolog.Level]customLevel {
+	if m := customLevels.Load(); m != nil {
+		return *m
+	}
+	return nil
+}
+
+// baseLevel returns the standard level lvl is filtered as
+func baseLevel(lvl zerolog.Level) zerolog.Level {
+	if lvl < customLevelStart {
+		return lvl
+	}
+	if c, ok := customLevelMap()[lvl]; ok {
+		return c.base
+	}
+	return lvl
+}
+
+// Log logs a message at a level registered with RegisterLevel or at a
+// standard level, without exiting or panicking for fatal and panic
+func Log(level zerolog.Level, msg string, args ...interface{}) {
+	if st := std.state.Load(); st.enabled(baseLevel(level)) {
+		processArgs(addCallerInfo(st.zl.WithLevel(level), st), msg, args...)
+	}
+}
+
+// Log logs a message at level, like the package-level Log
+func (l *Logger) Log(level zerolog.Level, msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(baseLevel(level)) {
+		processArgs(addCallerInfo(st.zl.WithLevel(level), st), msg, args...)
+	}
+}
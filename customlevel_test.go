@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	testAudit    = MustRegisterLevel("test-audit", zerolog.InfoLevel)
+	testSecurity = MustRegisterLevel("test-security", zerolog.ErrorLevel)
+)
+
+func TestCustomLevels(t *testing.T) {
+	var all, errs bytes.Buffer
+	l, err := New(Config{
+		Level: "warn",
+		Sinks: []SinkConfig{{Writer: &all}, {Writer: &errs, MinLevel: "error"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Log(testAudit, "audit")       // filtered as info
+	l.Log(testSecurity, "security") // filtered as error
+	zl := l.Zerolog()
+	zl.WithLevel(testSecurity).Msg("zerolog")
+
+	if got := strings.Count(all.String(), `"level":"test-security"`); got != 2 {
+		t.Errorf("got %s, want two entries named test-security", all.String())
+	}
+	if got := strings.Join(messages(t, &all), ","); got != "security,zerolog" {
+		t.Errorf("got %q, want the audit entry filtered as info", got)
+	}
+	if got := strings.Join(messages(t, &errs), ","); got != "security,zerolog" {
+		t.Errorf("error sink got %q, want the security entries", got)
+	}
+	if got := LevelString(testAudit); got != "test-audit" {
+		t.Errorf("LevelString = %q", got)
+	}
+}
+
+func TestRegisterLevelErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		base zerolog.Level
+	}{
+		{"", zerolog.InfoLevel},
+		{"warn", zerolog.WarnLevel},
+		{"Test-Audit", zerolog.InfoLevel},
+		{"test-bad-base", zerolog.NoLevel},
+	} {
+		if _, err := RegisterLevel(tt.name, tt.base); err == nil {
+			t.Errorf("RegisterLevel(%q, %v): no error", tt.name, tt.base)
+		}
+	}
+}
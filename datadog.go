@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DatadogSink ships entries to Datadog's logs intake API without an agent,
+// in gzipped batches from a background goroutine
+//
+//	sink := &logger.DatadogSink{
+//		APIKey:  os.Getenv("DD_API_KEY"),
+//		Service: "api",
+//		Tags:    map[string]string{"env": "prod", "version": version},
+//	}
+//	defer sink.Close()
+//
+// Each entry is sent with its fields as attributes, its level as status,
+// and the configured ddsource, ddtags, hostname and service. Site selects
+// the Datadog region.
+type DatadogSink struct {
+	APIKey        string            // API key, required
+	Site          string            // Datadog site, datadoghq.com if empty
+	Service       string            // service of every entry
+	Source        string            // ddsource, "go" if empty
+	Tags          map[string]string // ddtags, such as env and version
+	Hostname      string            // hostname, the host's name if empty
+	Client        *http.Client      // HTTP client, http.DefaultClient if nil
+	Endpoint      string            // Intake URL, derived from Site if empty
+	BatchSize     int               // Entries per request, 100 if zero, at most 1000
+	FlushInterval time.Duration     // Longest wait before sending, 5s if zero
+	QueueWait     time.Duration     // Longest a log call waits for room in a full queue, none if zero
+	OnError       func(error)       // Called when entries are lost, stderr if nil
+
+	setup sync.Once
+	batch entryBatcher
+}
+
+// Write implements io.Writer for entries without a known level
+func (s *DatadogSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *DatadogSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if err := s.batcher().add(lvl, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush sends the queued entries and waits until they are delivered
+func (s *DatadogSink) Flush() error {
+	s.batcher().flush()
+	return nil
+}
+
+// Close sends the queued entries and stops the background goroutine
+func (s *DatadogSink) Close() error {
+	s.batcher().close()
+	return nil
+}
+
+// batcher returns the sink's batcher, configured from its fields on
+// first use
+func (s *DatadogSink) batcher() *entryBatcher {
+	s.setup.Do(func() {
+		s.batch = entryBatcher{send: s.send, size: min(s.BatchSize, 1000), interval: s.FlushInterval, wait: s.QueueWait, onError: s.OnError}
+	})
+	return &s.batch
+}
+
+// send posts one gzipped batch to the intake
+func (s *DatadogSink) send(batch []batchEntry) error {
+	if s.APIKey == "" {
+		return permanentError{fmt.Errorf("logger: datadog: APIKey is required")}
+	}
+	host := s.Hostname
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	source := s.Source
+	if source == "" {
+		source = "go"
+	}
+	tags := make([]string, 0, len(s.Tags))
+	for k, v := range s.Tags {
+		tags = append(tags, k+":"+v)
+	}
+	sort.Strings(tags)
+
+	logs := make([]map[string]interface{}, len(batch))
+	for i, e := range batch {
+		_, attrs := decodeEntry(e.data)
+		if e.level != zerolog.NoLevel {
+			attrs["status"] = LevelString(e.level)
+		} else if lvl, ok := attrs[zerolog.LevelFieldName]; ok {
+			attrs["status"] = lvl
+		}
+		if _, ok := attrs[zerolog.TimestampFieldName]; !ok {
+			attrs["timestamp"] = e.time.UnixMilli()
+		}
+		attrs["ddsource"] = source
+		attrs["hostname"] = host
+		if len(tags) > 0 {
+			attrs["ddtags"] = strings.Join(tags, ",")
+		}
+		if s.Service != "" {
+			attrs["service"] = s.Service
+		}
+		logs[i] = attrs
+	}
+
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	if err := json.NewEncoder(gz).Encode(logs); err != nil {
+		return permanentError{err}
+	}
+	if err := gz.Close(); err != nil {
+		return permanentError{err}
+	}
+
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		site := s.Site
+		if site == "" {
+			site = "datadoghq.com"
+		}
+		endpoint = "https://http-intake.logs." + site + "/api/v2/logs"
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, endpoint, &body)
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("DD-API-KEY", s.APIKey)
+	if err := doHTTP(s.Client, req); err != nil {
+		return fmt.Errorf("logger: datadog: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestDatadogSink(t *testing.T) {
+	var mu sync.Mutex
+	var logs []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "dd-key" || r.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("headers %v", r.Header)
+		}
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var batch []map[string]interface{}
+		if err := json.NewDecoder(gz).Decode(&batch); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		logs = append(logs, batch...)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s := &DatadogSink{
+		APIKey:   "dd-key",
+		Service:  "api",
+		Tags:     map[string]string{"version": "1.2", "env": "prod"},
+		Hostname: "web-1",
+		Endpoint: srv.URL,
+	}
+	s.WriteLevel(zerolog.ErrorLevel, []byte(`{"time":"2026-10-15T01:02:03Z","message":"charge failed"}`+"\n"))
+	s.Write([]byte(`{"level":"warn","message":"slow"}` + "\n"))
+	s.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(logs) != 2 {
+		t.Fatalf("%d logs, want 2", len(logs))
+	}
+	got, _ := json.Marshal(logs[0])
+	if want := `{"ddsource":"go","ddtags":"env:prod,version:1.2","hostname":"web-1","message":"charge failed","service":"api","status":"error","time":"2026-10-15T01:02:03Z"}`; string(got) != want {
+		t.Errorf("log\n%s\nwant\n%s", got, want)
+	}
+	// Without a level from the logger the entry's is the status, and
+	// entries without a time get the one they were logged at
+	if logs[1]["status"] != "warn" || logs[1]["timestamp"] == nil {
+		t.Errorf("second log %v", logs[1])
+	}
+}
+
+func TestDatadogSinkRequiresAPIKey(t *testing.T) {
+	var errs []error
+	s := &DatadogSink{OnError: func(err error) { errs = append(errs, err) }}
+	s.Write([]byte(`{"message":"lost"}` + "\n"))
+	s.Close()
+	if len(errs) != 1 {
+		t.Errorf("reported %v, want one error", errs)
+	}
+}
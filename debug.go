@@ -0,0 +1,102 @@
+//go:build !logger_nodbg
+
+package logger
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+)
+
+// The debug and trace functions live here so the logger_nodbg build tag
+// can swap them for the empty stubs of debug_nodbg.go.
+
+// debugCompiled reports whether the debug and trace functions log at all
+const debugCompiled = true
+
+// Debug logs a debug message
+func Debug(msg string, args ...interface{}) {
+	if st := std.state.Load(); st.enabled(zerolog.DebugLevel) {
+		processArgs(addCallerInfo(st.zl.Debug(), st), msg, args...)
+	}
+}
+
+// Debug logs a debug message
+func (l *Logger) Debug(msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.DebugLevel) {
+		processArgs(addCallerInfo(st.zl.Debug(), st), msg, args...)
+	}
+}
+
+// DebugCtx logs a debug message through the logger on ctx, honoring a
+// level set on ctx
+func DebugCtx(ctx context.Context, msg string, args ...interface{}) {
+	if st := FromContext(ctx).state.Load(); st.enabledCtx(ctx, zerolog.DebugLevel) {
+		processArgs(addCallerInfo(st.zl.Debug().Ctx(ctx), st), msg, args...)
+	}
+}
+
+// DebugCtx logs a debug message, honoring a level set on ctx
+func (l *Logger) DebugCtx(ctx context.Context, msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabledCtx(ctx, zerolog.DebugLevel) {
+		processArgs(addCallerInfo(st.zl.Debug().Ctx(ctx), st), msg, args...)
+	}
+}
+
+// DebugFields logs a debug message with typed fields
+func DebugFields(msg string, fields ...Field) {
+	if st := std.state.Load(); st.enabled(zerolog.DebugLevel) {
+		writeFields(addCallerInfo(st.zl.Debug(), st), msg, fields)
+	}
+}
+
+// DebugFields logs a debug message with typed fields
+func (l *Logger) DebugFields(msg string, fields ...Field) {
+	if st := l.state.Load(); st.enabled(zerolog.DebugLevel) {
+		writeFields(addCallerInfo(st.zl.Debug(), st), msg, fields)
+	}
+}
+
+// DebugSampled logs a debug message on the first of every n calls from the
+// same line, for hot loops that would otherwise flood the log
+func DebugSampled(n uint32, msg string, args ...interface{}) {
+	if st := std.state.Load(); st.enabled(zerolog.DebugLevel) && sampledHere(n) {
+		processArgs(addCallerInfo(st.zl.Debug(), st), msg, args...)
+	}
+}
+
+// DebugSampled logs a debug message on the first of every n calls from the
+// same line
+func (l *Logger) DebugSampled(n uint32, msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.DebugLevel) && sampledHere(n) {
+		processArgs(addCallerInfo(st.zl.Debug(), st), msg, args...)
+	}
+}
+
+// Debugf logs a debug message formatted with fmt.Sprintf
+func Debugf(format string, args ...interface{}) {
+	if st := std.state.Load(); st.enabled(zerolog.DebugLevel) {
+		addCallerInfo(st.zl.Debug(), st).Msgf(format, args...)
+	}
+}
+
+// Debugf logs a debug message formatted with fmt.Sprintf
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.DebugLevel) {
+		addCallerInfo(st.zl.Debug(), st).Msgf(format, args...)
+	}
+}
+
+// Trace logs a trace message
+func Trace(msg string, args ...interface{}) {
+	if st := std.state.Load(); st.enabled(zerolog.TraceLevel) {
+		processArgs(addCallerInfo(st.zl.Trace(), st), msg, args...)
+	}
+}
+
+// Trace logs a trace message
+func (l *Logger) Trace(msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.TraceLevel) {
+		processArgs(addCallerInfo(st.zl.Trace(), st), msg, args...)
+	}
+}
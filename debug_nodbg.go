@@ -0,0 +1,50 @@
+//go:build logger_nodbg
+
+package logger
+
+import "context"
+
+// Built with the logger_nodbg tag, the debug and trace functions are empty
+// so the compiler inlines them away, together with their arguments when
+// those have no side effects. Their levels can still be set but nothing
+// is logged at them through these functions; zerolog events from Zerolog
+// or GetLogger are not affected.
+
+// debugCompiled reports whether the debug and trace functions log at all
+const debugCompiled = false
+
+// Debug does nothing in logger_nodbg builds
+func Debug(msg string, args ...interface{}) {}
+
+// Debug does nothing in logger_nodbg builds
+func (l *Logger) Debug(msg string, args ...interface{}) {}
+
+// DebugCtx does nothing in logger_nodbg builds
+func DebugCtx(ctx context.Context, msg string, args ...interface{}) {}
+
+// DebugCtx does nothing in logger_nodbg builds
+func (l *Logger) DebugCtx(ctx context.Context, msg string, args ...interface{}) {}
+
+// DebugFields does nothing in logger_nodbg builds
+func DebugFields(msg string, fields ...Field) {}
+
+// DebugFields does nothing in logger_nodbg builds
+func (l *Logger) DebugFields(msg string, fields ...Field) {}
+
+// DebugSampled does nothing in logger_nodbg builds
+func DebugSampled(n uint32, msg string, args ...interface{}) {}
+
+// DebugSampled does nothing in logger_nodbg builds
+func (l *Logger) DebugSampled(n uint32, msg string, args ...interface{}) {}
+
+// Debugf does nothing in logger_nodbg builds
+func Debugf(format string, args ...interface{}) {}
+
+// Debugf does nothing in logger_nodbg builds
+func (l *Logger) Debugf(format string, args ...interface{}) {}
+
+// Trace does nothing in logger_nodbg builds
+func Trace(msg string, args ...interface{}) {}
+
+// Trace does nothing in logger_nodbg builds
+func (l *Logger) Trace(msg string, args ...interface{}) {}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestDebugFunctions passes with and without the logger_nodbg tag:
+//
+//	go test -tags logger_nodbg -run TestDebugFunctions
+func TestDebugFunctions(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, Level: "trace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	l.Debug("debug")
+	l.DebugCtx(ctx, "debugctx")
+	l.DebugFields("debugfields", Int("n", 1))
+	l.DebugSampled(1, "debugsampled")
+	l.Debugf("debug%s", "f")
+	l.Trace("trace")
+	zl := l.Zerolog()
+	zl.Debug().Msg("zerolog")
+
+	want := "debug,debugctx,debugfields,debugsampled,debugf,trace,zerolog"
+	if !debugCompiled {
+		// Zerolog events are not compiled out
+		want = "zerolog"
+	}
+	if got := strings.Join(messages(t, &buf), ","); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if l.DebugEnabled() != debugCompiled || l.TraceEnabled() != debugCompiled {
+		t.Errorf("DebugEnabled %v, TraceEnabled %v in a build with debugCompiled %v", l.DebugEnabled(), l.TraceEnabled(), debugCompiled)
+	}
+
+	var c Capture
+	useGlobal(t, Config{Output: &c, Level: "trace"})
+	Debug("debug")
+	DebugCtx(ctx, "debugctx")
+	DebugFields("debugfields")
+	DebugSampled(1, "debugsampled")
+	Debugf("debug%s", "f")
+	Trace("trace")
+	want = "debug,debugctx,debugfields,debugsampled,debugf,trace"
+	if !debugCompiled {
+		want = ""
+	}
+	if got := strings.Join(captured(&c), ","); got != want {
+		t.Errorf("global got %q, want %q", got, want)
+	}
+}
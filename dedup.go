@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// RepeatCountField is the key of the number of collapsed repeats on the
+// entry a DedupWriter writes at the end of a run
+const RepeatCountField = "repeat_count"
+
+// DedupWriter collapses runs of consecutive entries with the same level
+// and message, like syslog's "last message repeated N times", so a tight
+// error loop produces two entries rather than thousands
+//
+//	w := &logger.DedupWriter{Out: os.Stderr, Window: 5 * time.Second}
+//
+// The first entry of a run is written right away. Repeats within Window
+// of it are held back; when the run ends, because a different entry
+// arrives or Window is over, the last repeat is written with the number
+// of held back entries in repeat_count.
+//
+// Config.DedupWindow puts one in front of the output.
+type DedupWriter struct {
+	Out    io.Writer     // Destination, required
+	Window time.Duration // Longest a run is collapsed for, a second if zero
+
+	mu        sync.Mutex
+	level     zerolog.Level // of the current run
+	msg       string        // of the current run
+	start     time.Time     // when the current run began, zero if none
+	repeats   int           // entries held back in the current run
+	held      []byte        // last held back entry
+	heldLevel zerolog.Level
+	timer     *time.Timer // ends the run once Window is over
+	run       uint64      // counts runs so a late timer leaves a newer one alone
+}
+
+// Write implements io.Writer for entries without a known level
+func (w *DedupWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (w *DedupWriter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	msg, _ := decodeEntry(p)
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	within := !w.start.IsZero() && now.Sub(w.start) < w.window()
+	if within && lvl == w.level && msg == w.msg {
+		w.repeats++
+		w.held, w.heldLevel = append(w.held[:0], p...), lvl
+		if w.timer == nil {
+			run := w.run
+			w.timer = time.AfterFunc(w.window()-now.Sub(w.start), func() { w.expire(run) })
+		}
+		return len(p), nil
+	}
+	w.endRun()
+	w.level, w.msg, w.start = lvl, msg, now
+	w.run++
+	return w.writeOut(lvl, p)
+}
+
+// Flush writes the held back repeats of the current run, then flushes Out
+// if it has a Flush method
+func (w *DedupWriter) Flush() error {
+	w.mu.Lock()
+	w.endRun()
+	w.mu.Unlock()
+	if f, ok := w.Out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// window returns Window or its default
+func (w *DedupWriter) window() time.Duration {
+	if w.Window <= 0 {
+		return time.Second
+	}
+	return w.Window
+}
+
+// expire ends the run once its window is over
+func (w *DedupWriter) expire(run uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if run != w.run {
+		return
+	}
+	w.timer = nil
+	w.endRun()
+	w.start = time.Time{}
+}
+
+// endRun writes the last held back entry with the repeat count, if any.
+// Callers must hold w.mu.
+func (w *DedupWriter) endRun() {
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.repeats == 0 {
+		return
+	}
+	w.writeOut(w.heldLevel, withRepeatCount(w.held, w.repeats))
+	w.repeats = 0
+}
+
+// withRepeatCount adds the repeat count as the last field of a JSON entry
+func withRepeatCount(entry []byte, n int) []byte {
+	end := bytes.LastIndexByte(entry, '}')
+	if end < 0 {
+		return entry
+	}
+	out := make([]byte, 0, len(entry)+len(RepeatCountField)+16)
+	head := bytes.TrimRight(entry[:end], " \t\r\n")
+	out = append(out, head...)
+	if len(head) > 0 && head[len(head)-1] != '{' {
+		out = append(out, ',')
+	}
+	out = append(out, '"')
+	out = append(out, RepeatCountField...)
+	out = append(out, '"', ':')
+	out = strconv.AppendInt(out, int64(n), 10)
+	return append(out, entry[end:]...)
+}
+
+// writeOut passes one entry on to Out, keeping its level
+func (w *DedupWriter) writeOut(lvl zerolog.Level, p []byte) (int, error) {
+	if lw, ok := w.Out.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(lvl, p)
+	}
+	return w.Out.Write(p)
+}
@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestDedupWriter(t *testing.T) {
+	out := &entryRecorder{}
+	w := &DedupWriter{Out: out, Window: time.Hour}
+	for i := 0; i < 4; i++ {
+		w.WriteLevel(zerolog.ErrorLevel, []byte(`{"level":"error","attempt":`+strconv.Itoa(i+1)+`,"message":"retry"}`+"\n"))
+	}
+	// The same message at another level starts a run of its own
+	w.WriteLevel(zerolog.WarnLevel, []byte(`{"level":"warn","message":"retry"}`+"\n"))
+	w.WriteLevel(zerolog.WarnLevel, []byte(`{"level":"warn","message":"done"}`+"\n"))
+	want := []string{
+		`{"level":"error","attempt":1,"message":"retry"}` + "\n",
+		`{"level":"error","attempt":4,"message":"retry","repeat_count":3}` + "\n",
+		`{"level":"warn","message":"retry"}` + "\n",
+		`{"level":"warn","message":"done"}` + "\n",
+	}
+	if got := out.written(); strings.Join(got, "") != strings.Join(want, "") {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+	if out.levels[1] != zerolog.ErrorLevel {
+		t.Errorf("repeat written at %s, want error", out.levels[1])
+	}
+
+	// Flush writes the repeats held back so far
+	w.WriteLevel(zerolog.WarnLevel, []byte(`{"level":"warn","message":"done"}`+"\n"))
+	w.Flush()
+	if got := out.written(); len(got) != 5 || got[4] != `{"level":"warn","message":"done","repeat_count":1}`+"\n" {
+		t.Errorf("after Flush got %q", got)
+	}
+}
+
+func TestDedupWriterWindowEnds(t *testing.T) {
+	out := &entryRecorder{}
+	w := &DedupWriter{Out: out, Window: 20 * time.Millisecond}
+	for i := 0; i < 3; i++ {
+		w.Write([]byte(`{"message":"retry"}` + "\n"))
+	}
+
+	// The run ends with its window, without another entry
+	deadline := time.Now().Add(5 * time.Second)
+	for len(out.written()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("run not ended within 5s, wrote %q", out.written())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := out.written()[1]; got != `{"message":"retry","repeat_count":2}`+"\n" {
+		t.Errorf("got %q", got)
+	}
+
+	// The next entry starts a new run and is written at once
+	w.Write([]byte(`{"message":"retry"}` + "\n"))
+	if got := len(out.written()); got != 3 {
+		t.Errorf("wrote %d entries, want the new run's first", got)
+	}
+}
+
+func TestWithRepeatCount(t *testing.T) {
+	for entry, want := range map[string]string{
+		`{}`:             `{"repeat_count":2}`,
+		`{"a":1}` + "\n": `{"a":1,"repeat_count":2}` + "\n",
+		`{"a":1 }`:       `{"a":1,"repeat_count":2}`,
+		`not json`:       `not json`,
+	} {
+		if got := string(withRepeatCount([]byte(entry), 2)); got != want {
+			t.Errorf("withRepeatCount(%q) = %q, want %q", entry, got, want)
+		}
+	}
+}
+
+func TestConfigDedupWindow(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(NewConfig(WithOutput(&buf), WithDedup(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		l.Error(nil, "retry")
+	}
+	l.Info("done")
+	if got := strings.Join(messages(t, &buf), ","); got != "retry,retry,done" || !strings.Contains(buf.String(), `"repeat_count":2}`) {
+		t.Errorf("got %s", buf.String())
+	}
+}
@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// ecsVersion is the version of the Elastic Common Schema entries follow
+const ecsVersion = "8.11.0"
+
+// ecsWriter wraps w so entries are written in the Elastic Common Schema,
+// as Filebeat and Elastic Agent ingest them without an ingest pipeline:
+//
+//	{"@timestamp":"2024-05-01T12:00:00.000Z","log.level":"error","message":"charge failed",
+//		"ecs.version":"8.11.0","log.logger":"billing","error.message":"card declined",
+//		"service.name":"billing","trace.id":"4bf92f3577b34da6a3ce929d0e0e4736","amount":42}
+//
+// The standard fields are renamed to their ECS names: the component to
+// log.logger, the caller to log.origin.file.name and log.origin.file.line,
+// the error to error.message and its stack to error.stack_trace. The
+// service, trace_id and span_id fields become service.name, trace.id and
+// span.id. Other fields are kept as they are, after the ECS ones.
+func ecsWriter(w io.Writer, cfg Config) io.Writer {
+	return &encodingWriter{w: w, encode: func(dst []byte, lvl zerolog.Level, entry []byte) []byte {
+		return appendECS(dst, lvl, entry, cfg.TimeFormat)
+	}}
+}
+
+// appendECS appends entry, a zerolog JSON entry, in ECS form to dst
+func appendECS(dst []byte, lvl zerolog.Level, entry []byte, timeFormat string) []byte {
+	msg, fields := decodeEntry(entry)
+	var o jsonObject
+
+	// ECS wants a timestamp even when the logger leaves it out
+	t, ok := entryTime(fields[zerolog.TimestampFieldName], timeFormat)
+	if !ok {
+		t = time.Now()
+	} else {
+		delete(fields, zerolog.TimestampFieldName)
+	}
+	o.add("@timestamp", t.UTC().Format("2006-01-02T15:04:05.000Z"))
+	if lvl = entryLevel(lvl, fields); lvl != zerolog.NoLevel {
+		o.add("log.level", LevelString(lvl))
+	}
+	delete(fields, zerolog.LevelFieldName)
+	o.add("message", msg)
+	delete(fields, zerolog.MessageFieldName)
+	o.add("ecs.version", ecsVersion)
+
+	if v, ok := fields["component"]; ok {
+		o.add("log.logger", v)
+		delete(fields, "component")
+	}
+	if caller, ok := fields[zerolog.CallerFieldName].(string); ok {
+		file, line := caller, ""
+		if i := strings.LastIndexByte(caller, ':'); i > 0 {
+			file, line = caller[:i], caller[i+1:]
+		}
+		o.add("log.origin.file.name", file)
+		if n, err := strconv.Atoi(line); err == nil {
+			o.add("log.origin.file.line", n)
+		}
+		delete(fields, zerolog.CallerFieldName)
+	}
+	if v, ok := fields[zerolog.ErrorFieldName]; ok {
+		o.add("error.message", fieldString(v))
+		delete(fields, zerolog.ErrorFieldName)
+	}
+	if v, ok := fields[zerolog.ErrorStackFieldName]; ok {
+		o.add("error.stack_trace", stackText(v))
+		delete(fields, zerolog.ErrorStackFieldName)
+	}
+	for from, to := range map[string]string{"service": "service.name", "trace_id": "trace.id", "span_id": "span.id"} {
+		if v, ok := fields[from]; ok {
+			if _, taken := fields[to]; !taken {
+				fields[to] = v
+			}
+			delete(fields, from)
+		}
+	}
+	for _, key := range []string{"service.name", "trace.id", "span.id"} {
+		if v, ok := fields[key]; ok {
+			o.add(key, v)
+			delete(fields, key)
+		}
+	}
+	o.addSorted(fields)
+	return o.line(dst)
+}
+
+// stackText renders a stack field as text. zerolog's stack marshalers
+// produce arrays of frames with func, source and line, which are written
+// the way Go prints them; anything else is kept as JSON.
+func stackText(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var b strings.Builder
+		for _, frame := range v {
+			f, ok := frame.(map[string]interface{})
+			if !ok {
+				return string(appendJSON(nil, v))
+			}
+			fmt.Fprintf(&b, "%s\n\t%s:%s\n", fieldString(f["func"]), fieldString(f["source"]), fieldString(f["line"]))
+		}
+		return b.String()
+	}
+	return string(appendJSON(nil, v))
+}
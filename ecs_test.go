@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestAppendECS(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		lvl   zerolog.Level
+		entry string
+		want  string
+	}{
+		{
+			"renamed fields",
+			zerolog.ErrorLevel,
+			`{"level":"error","time":"2026-10-15T01:02:03.5Z","component":"billing","caller":"billing/charge.go:42","error":"card declined","service":"billing","trace_id":"4bf92f35","span_id":"00f067aa","amount":42,"message":"charge failed"}`,
+			`{"@timestamp":"2026-10-15T01:02:03.500Z","log.level":"error","message":"charge failed","ecs.version":"8.11.0","log.logger":"billing","log.origin.file.name":"billing/charge.go","log.origin.file.line":42,"error.message":"card declined","service.name":"billing","trace.id":"4bf92f35","span.id":"00f067aa","amount":42}`,
+		},
+		{
+			"level from the entry and a stack",
+			zerolog.NoLevel,
+			`{"level":"warn","time":"2026-10-15T01:02:03Z","stack":[{"func":"charge","source":"charge.go","line":"42"}],"service.name":"api","service":"ignored","message":"retrying"}`,
+			`{"@timestamp":"2026-10-15T01:02:03.000Z","log.level":"warn","message":"retrying","ecs.version":"8.11.0","error.stack_trace":"charge\n\tcharge.go:42\n","service.name":"api"}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(appendECS(nil, tt.lvl, []byte(tt.entry), "")); got != tt.want+"\n" {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestECSFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, Format: "ecs", NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Component("api").Info("served", "status", 200)
+	var e map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("%q: %v", buf.String(), err)
+	}
+	// A timestamp is added even when the logger leaves it out
+	if e["log.level"] != "info" || e["log.logger"] != "api" || e["status"] != 200.0 || e["@timestamp"] == nil {
+		t.Errorf("entry %v", e)
+	}
+}
+
+func TestStackText(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{"goroutine 1 [running]", "goroutine 1 [running]"},
+		{[]interface{}{map[string]interface{}{"func": "a", "source": "a.go", "line": "1"}, map[string]interface{}{"func": "b", "source": "b.go", "line": "2"}}, "a\n\ta.go:1\nb\n\tb.go:2\n"},
+		{[]interface{}{"not a frame"}, `["not a frame"]`},
+		{map[string]interface{}{"depth": json.Number("3")}, `{"depth":3}`},
+	} {
+		if got := stackText(tt.v); got != tt.want {
+			t.Errorf("stackText(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// ElasticsearchSink indexes entries through the _bulk API, in batches from
+// a background goroutine
+//
+//	dlq, _ := os.OpenFile("rejected.ndjson", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+//	sink := &logger.ElasticsearchSink{URL: "http://es:9200", Index: "logs-api-%Y.%m.%d", DeadLetter: dlq}
+//	defer sink.Close()
+//
+// Index may contain %Y, %m, %d and %H, expanded in UTC with each entry's
+// time, giving one index per day or hour for index templates and ILM to
+// act on. When the cluster pushes back, with 429 for the whole request or
+// for single documents, the rejected part is sent again with backoff.
+// Documents rejected for other reasons, such as a mapping conflict, and
+// those still pushed back after the retries are written to DeadLetter as
+// one JSON line each with the error, instead of being lost silently.
+type ElasticsearchSink struct {
+	URL           string        // Cluster URL, such as http://es:9200, required
+	Index         string        // Index name template, "logs-%Y.%m.%d" if empty
+	Username      string        // Basic auth user
+	Password      string        // Basic auth password
+	APIKey        string        // Base64 API key, sent instead of basic auth when set
+	DeadLetter    io.Writer     // Receives rejected documents, reported through OnError if nil
+	Client        *http.Client  // HTTP client, http.DefaultClient if nil
+	BatchSize     int           // Documents per bulk request, 100 if zero
+	FlushInterval time.Duration // Longest wait before sending, 5s if zero
+	QueueWait     time.Duration // Longest a log call waits for room in a full queue, none if zero
+	OnError       func(error)   // Called when entries are lost, stderr if nil
+
+	setup sync.Once
+	batch entryBatcher
+}
+
+// esBulkResponse is the part of a _bulk response the sink reads
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+// Write implements io.Writer for entries without a known level
+func (s *ElasticsearchSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *ElasticsearchSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if err := s.batcher().add(lvl, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush indexes the queued entries and waits until they are delivered
+func (s *ElasticsearchSink) Flush() error {
+	s.batcher().flush()
+	return nil
+}
+
+// Close indexes the queued entries and stops the background goroutine
+func (s *ElasticsearchSink) Close() error {
+	s.batcher().close()
+	return nil
+}
+
+// batcher returns the sink's batcher, configured from its fields on
+// first use
+func (s *ElasticsearchSink) batcher() *entryBatcher {
+	s.setup.Do(func() {
+		s.batch = entryBatcher{send: s.send, size: s.BatchSize, interval: s.FlushInterval, wait: s.QueueWait, onError: s.OnError}
+	})
+	return &s.batch
+}
+
+// send indexes one batch, sending documents the cluster pushed back again
+// until they are accepted or the retries run out
+func (s *ElasticsearchSink) send(batch []batchEntry) error {
+	if s.URL == "" {
+		return permanentError{fmt.Errorf("logger: elasticsearch: URL is required")}
+	}
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		retry, err := s.bulk(batch)
+		if err != nil {
+			return fmt.Errorf("logger: elasticsearch: %w", err)
+		}
+		if len(retry) == 0 {
+			return nil
+		}
+		if attempt == batchRetries {
+			for _, e := range retry {
+				s.deadLetter(e, 429, json.RawMessage(`"still rejected after retries"`))
+			}
+			return nil
+		}
+		batch = retry
+		select {
+		case <-time.After(backoff):
+		case <-s.batch.done:
+		}
+		backoff *= 2
+	}
+}
+
+// bulk sends one _bulk request and returns the documents to send again.
+// Rejected documents go to the dead letter writer.
+func (s *ElasticsearchSink) bulk(batch []batchEntry) ([]batchEntry, error) {
+	index := s.Index
+	if index == "" {
+		index = "logs-%Y.%m.%d"
+	}
+	var body bytes.Buffer
+	for _, e := range batch {
+		action, _ := json.Marshal(map[string]map[string]string{"create": {"_index": expandTemplate(index, e.time.UTC())}})
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(esDocument(e))
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, strings.TrimRight(s.URL, "/")+"/_bulk", &body)
+	if err != nil {
+		return nil, permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+s.APIKey)
+	} else if s.Username != "" || s.Password != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return batch, nil
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+		if resp.StatusCode >= 500 {
+			return nil, err
+		}
+		return nil, permanentError{err}
+	}
+
+	var result esBulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil, nil
+	}
+	var retry []batchEntry
+	for i, item := range result.Items {
+		if i >= len(batch) {
+			break
+		}
+		for _, r := range item {
+			switch {
+			case r.Status < 300:
+			case r.Status == http.StatusTooManyRequests:
+				retry = append(retry, batch[i])
+			default:
+				s.deadLetter(batch[i], r.Status, r.Error)
+			}
+		}
+	}
+	return retry, nil
+}
+
+// esDocument returns the document of an entry: the entry itself, with an
+// @timestamp added when it has no time field so data streams accept it
+func esDocument(e batchEntry) []byte {
+	_, fields := decodeEntry(e.data)
+	if _, ok := fields[zerolog.TimestampFieldName]; ok && json.Valid(e.data) {
+		return e.data
+	}
+	fields["@timestamp"] = e.time.UTC().Format(time.RFC3339Nano)
+	doc, _ := json.Marshal(fields)
+	return doc
+}
+
+// deadLetter records a rejected document
+func (s *ElasticsearchSink) deadLetter(e batchEntry, status int, reason json.RawMessage) {
+	if len(reason) == 0 {
+		reason = json.RawMessage("null")
+	}
+	if s.DeadLetter == nil {
+		s.batch.report(fmt.Errorf("logger: elasticsearch: document rejected with status %d: %s", status, reason))
+		return
+	}
+	line, _ := json.Marshal(struct {
+		Status   int             `json:"status"`
+		Error    json.RawMessage `json:"error"`
+		Document json.RawMessage `json:"document"`
+	}{status, reason, esDocument(e)})
+	if _, err := s.DeadLetter.Write(append(line, '\n')); err != nil {
+		s.batch.report(fmt.Errorf("logger: elasticsearch: dead letter: %w", err))
+	}
+}
@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestElasticsearchSinkBulk(t *testing.T) {
+	var mu sync.Mutex
+	var indexed []string
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" || r.Header.Get("Authorization") != "ApiKey a2V5" {
+			t.Errorf("bulk to %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		var items []string
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var action map[string]map[string]string
+			json.Unmarshal(sc.Bytes(), &action)
+			sc.Scan()
+			doc := sc.Text()
+			switch {
+			case strings.Contains(doc, "conflict"):
+				items = append(items, `{"create":{"status":400,"error":{"type":"mapper_parsing_exception"}}}`)
+			case strings.Contains(doc, "busy") && requests == 1:
+				items = append(items, `{"create":{"status":429}}`)
+			default:
+				indexed = append(indexed, action["create"]["_index"]+" "+doc)
+				items = append(items, `{"create":{"status":201}}`)
+			}
+		}
+		w.Write([]byte(`{"errors":true,"items":[` + strings.Join(items, ",") + `]}`))
+	}))
+	defer srv.Close()
+
+	var dlq bytes.Buffer
+	s := &ElasticsearchSink{URL: srv.URL, Index: "logs-api-%Y", APIKey: "a2V5", DeadLetter: &dlq}
+	s.Write([]byte(`{"time":"2026-10-15T01:02:03Z","message":"ok"}` + "\n"))
+	s.Write([]byte(`{"time":"2026-10-15T01:02:03Z","message":"conflict"}` + "\n"))
+	s.Write([]byte(`{"message":"busy"}` + "\n"))
+	s.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 2 {
+		t.Errorf("%d bulk requests, want the rejected document sent again", requests)
+	}
+	index := "logs-api-" + strconv.Itoa(time.Now().UTC().Year())
+	if len(indexed) != 2 || indexed[0] != index+` {"time":"2026-10-15T01:02:03Z","message":"ok"}` || !strings.HasPrefix(indexed[1], index+` {"@timestamp":"`) {
+		t.Errorf("indexed %q", indexed)
+	}
+	if got := dlq.String(); got != `{"status":400,"error":{"type":"mapper_parsing_exception"},"document":{"time":"2026-10-15T01:02:03Z","message":"conflict"}}`+"\n" {
+		t.Errorf("dead letter got %q", got)
+	}
+}
+
+func TestEsDocument(t *testing.T) {
+	at := time.Date(2026, 10, 15, 1, 2, 3, 0, time.UTC)
+	for _, tt := range []struct{ data, want string }{
+		{`{"time":1792026123,"message":"kept"}`, `{"time":1792026123,"message":"kept"}`},
+		{`{"message":"no time"}`, `{"@timestamp":"2026-10-15T01:02:03Z","message":"no time"}`},
+		{`not json`, `{"@timestamp":"2026-10-15T01:02:03Z","message":"not json"}`},
+	} {
+		if got := esDocument(batchEntry{time: at, data: []byte(tt.data)}); !bytes.Equal(got, []byte(tt.want)) {
+			t.Errorf("esDocument(%s) = %s, want %s", tt.data, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables read by InitFromEnv
+const (
+	EnvLevel       = "LOGGER_LEVEL"        // Log level name
+	EnvPretty      = "LOGGER_PRETTY"       // Boolean, enables pretty output
+	EnvFormat      = "LOGGER_FORMAT"       // Output format, see Config.Format
+	EnvOutput      = "LOGGER_OUTPUT"       // stderr, stdout, split or a file path
+	EnvCaller      = "LOGGER_CALLER"       // Boolean, includes caller information
+	EnvTimeFormat  = "LOGGER_TIME_FORMAT"  // Timestamp format
+	EnvFields      = "LOGGER_FIELDS"       // Static fields as key=value pairs separated by commas
+	EnvTimezone    = "LOGGER_TIMEZONE"     // Time zone name such as UTC or Europe/Berlin
+	EnvNoTimestamp = "LOGGER_NO_TIMESTAMP" // Boolean, leaves timestamps out
+	EnvDisabled    = "LOGGER_DISABLED"     // Boolean, turns logging off
+)
+
+// EnvLevelOverride is the variable that replaces Config.Level when
+// Config.EnvLevelOverride is set, whichever way the logger is initialized
+const EnvLevelOverride = "LOG_LEVEL"
+
+// InitFromEnv initializes the global logger from environment variables
+// The options are applied first and any LOGGER_* variable that is set
+// takes precedence over them, so code supplies defaults and the
+// environment has the final word:
+//
+//	defaults < opts < LOGGER_* environment variables
+//
+// LOGGER_FORMAT is applied after LOGGER_PRETTY when both are set.
+// Like InitLogger, only the first initialization takes effect.
+func InitFromEnv(opts ...Option) error {
+	cfg := NewConfig(opts...)
+	if err := applyEnv(&cfg); err != nil {
+		return err
+	}
+	return InitLoggerE(cfg)
+}
+
+// withEnvLevel returns cfg with its level taken from LOG_LEVEL if the
+// override is enabled and the variable is set
+func (cfg Config) withEnvLevel() Config {
+	if !cfg.EnvLevelOverride {
+		return cfg
+	}
+	if v := os.Getenv(EnvLevelOverride); v != "" {
+		cfg.Level = v
+	}
+	return cfg
+}
+
+// applyEnv overrides cfg with the LOGGER_* variables present in the environment
+func applyEnv(cfg *Config) error {
+	if v, ok := os.LookupEnv(EnvLevel); ok {
+		cfg.Level = v
+	}
+	if v, ok := os.LookupEnv(EnvPretty); ok {
+		pretty, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("logger: invalid %s %q: %w", EnvPretty, v, err)
+		}
+		cfg.Pretty, cfg.Format = pretty, ""
+	}
+	if v, ok := os.LookupEnv(EnvFormat); ok {
+		format, err := parseFormat(v)
+		if err != nil {
+			return fmt.Errorf("logger: invalid %s: %w", EnvFormat, err)
+		}
+		cfg.Format = format
+	}
+	if v, ok := os.LookupEnv(EnvOutput); ok {
+		w, err := openOutput(v)
+		if err != nil {
+			return err
+		}
+		cfg.Output = w
+	}
+	if v, ok := os.LookupEnv(EnvCaller); ok {
+		caller, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("logger: invalid %s %q: %w", EnvCaller, v, err)
+		}
+		cfg.WithCaller = caller
+	}
+	if v, ok := os.LookupEnv(EnvTimeFormat); ok {
+		cfg.TimeFormat = v
+	}
+	if v, ok := os.LookupEnv(EnvNoTimestamp); ok {
+		noTimestamp, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("logger: invalid %s %q: %w", EnvNoTimestamp, v, err)
+		}
+		cfg.NoTimestamp = noTimestamp
+	}
+	if v, ok := os.LookupEnv(EnvDisabled); ok {
+		disabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("logger: invalid %s %q: %w", EnvDisabled, v, err)
+		}
+		cfg.Disabled = disabled
+	}
+	if v, ok := os.LookupEnv(EnvTimezone); ok {
+		loc, err := parseLocation(v)
+		if err != nil {
+			return fmt.Errorf("logger: invalid %s: %w", EnvTimezone, err)
+		}
+		cfg.Location = loc
+	}
+	if v, ok := os.LookupEnv(EnvFields); ok {
+		fields, err := parseFields(v)
+		if err != nil {
+			return fmt.Errorf("logger: invalid %s: %w", EnvFields, err)
+		}
+		WithDefaultFields(fields)(cfg)
+	}
+	return nil
+}
+
+// parseFields parses "key=value,key=value" into a field map
+func parseFields(s string) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("malformed field %q: want key=value", pair)
+		}
+		fields[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return fields, nil
+}
+
+// openOutput resolves an output name to a writer
+// "stderr" and "stdout" map to the standard streams, anything else is
+// treated as a file path opened for appending.
+func openOutput(name string) (io.Writer, error) {
+	switch strings.ToLower(name) {
+	case "", "stderr":
+		return os.Stderr, nil
+	case "stdout":
+		return os.Stdout, nil
+	case "split":
+		return NewStdStreams(), nil
+	}
+	return openFile(name)
+}
+
+// openFile opens a log file for appending, creating it if needed
+func openFile(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("logger: open output: %w", err)
+	}
+	return f, nil
+}
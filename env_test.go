@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	t.Setenv(EnvLevel, "warn")
+	t.Setenv(EnvPretty, "true")
+	t.Setenv(EnvFormat, "logfmt")
+	t.Setenv(EnvOutput, path)
+	t.Setenv(EnvCaller, "1")
+	t.Setenv(EnvTimezone, "UTC")
+	t.Setenv(EnvNoTimestamp, "false")
+	t.Setenv(EnvFields, "service=api, env = prod,")
+
+	cfg := NewConfig(WithLevel("debug"), WithDefaultFields(map[string]interface{}{"env": "dev", "region": "eu"}))
+	if err := applyEnv(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := cfg.Output.(*os.File); !ok || f.Name() != path {
+		t.Errorf("Output = %v, want %s", cfg.Output, path)
+	} else {
+		f.Close()
+	}
+	if cfg.Level != "warn" || !cfg.Pretty || cfg.Format != "logfmt" || !cfg.WithCaller || cfg.Location != time.UTC {
+		t.Errorf("got level %q pretty %v format %q caller %v location %v", cfg.Level, cfg.Pretty, cfg.Format, cfg.WithCaller, cfg.Location)
+	}
+	want := map[string]interface{}{"service": "api", "env": "prod", "region": "eu"}
+	if len(cfg.Fields) != len(want) {
+		t.Errorf("Fields = %v, want %v", cfg.Fields, want)
+	}
+	for k, v := range want {
+		if cfg.Fields[k] != v {
+			t.Errorf("Fields[%s] = %v, want %v", k, cfg.Fields[k], v)
+		}
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	for _, tt := range []struct{ name, value string }{
+		{EnvPretty, "maybe"},
+		{EnvCaller, "yes please"},
+		{EnvNoTimestamp, "2"},
+		{EnvDisabled, "off-ish"},
+		{EnvFormat, "yaml"},
+		{EnvTimezone, "Mars/Olympus"},
+		{EnvFields, "novalue"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			var cfg Config
+			if err := applyEnv(&cfg); err == nil {
+				t.Errorf("%s=%q: no error", tt.name, tt.value)
+			}
+		})
+	}
+}
+
+func TestEnvLevelOverride(t *testing.T) {
+	t.Setenv(EnvLevelOverride, "error")
+	useGlobal(t, Config{Output: io.Discard, Level: "debug"})
+	if got := GetLevel(); got != "debug" {
+		t.Errorf("level %s without the override, want debug", got)
+	}
+	Reconfigure(Config{Output: io.Discard, Level: "debug", EnvLevelOverride: true})
+	if got := GetLevel(); got != "error" {
+		t.Errorf("level %s with the override, want error from %s", got, EnvLevelOverride)
+	}
+	l, err := New(NewConfig(WithOutput(io.Discard), WithEnvLevelOverride()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := l.GetLevel(); got != "error" {
+		t.Errorf("New: level %s, want error", got)
+	}
+
+	t.Setenv(EnvLevelOverride, "loud")
+	err = Config{Level: "debug", EnvLevelOverride: true}.Validate()
+	if err == nil || !strings.Contains(err.Error(), EnvLevelOverride) {
+		t.Errorf("got %v, want an error naming %s", err, EnvLevelOverride)
+	}
+}
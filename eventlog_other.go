@@ -0,0 +1,29 @@
+//go:build !windows
+
+package logger
+
+import "errors"
+
+// EventLogSink writes entries to the Windows Event Log. On other
+// platforms it cannot be created; NewEventLogSink always fails.
+type EventLogSink struct {
+	EventID uint32 // ID of every event, 1 if zero
+}
+
+// errNoEventLog is returned by EventLogSink outside Windows
+var errNoEventLog = errors.New("logger: the Windows Event Log is only available on Windows")
+
+// NewEventLogSink fails on platforms other than Windows
+func NewEventLogSink(source string) (*EventLogSink, error) {
+	return nil, errNoEventLog
+}
+
+// Write implements io.Writer
+func (s *EventLogSink) Write(p []byte) (int, error) {
+	return 0, errNoEventLog
+}
+
+// Close implements io.Closer
+func (s *EventLogSink) Close() error {
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEventLogSinkOutsideWindows(t *testing.T) {
+	if _, err := NewEventLogSink("billing"); !errors.Is(err, errNoEventLog) {
+		t.Errorf("NewEventLogSink: got %v, want errNoEventLog", err)
+	}
+	s := &EventLogSink{}
+	if _, err := s.Write([]byte("{}\n")); !errors.Is(err, errNoEventLog) {
+		t.Errorf("Write: got %v, want errNoEventLog", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// EventLogSink writes entries to the Windows Event Log under an event
+// source, as Information, Warning or Error events
+//
+//	sink, err := logger.NewEventLogSink("billing")
+//	if err != nil {
+//		return err
+//	}
+//	defer sink.Close()
+//	logger.InitLogger(logger.Config{Output: sink})
+//
+// Trace, debug and info entries become Information events, warn entries
+// Warning events and error, fatal and panic entries Error events. The
+// event data is the JSON entry, so Event Viewer and collectors keep every
+// field.
+type EventLogSink struct {
+	EventID uint32 // ID of every event, 1 if zero
+
+	mu  sync.Mutex
+	log *eventlog.Log
+}
+
+// NewEventLogSink registers source with the Event Log if it is not
+// registered yet and opens it. Registering needs administrator rights,
+// so installers usually do it once and services only open the source.
+func NewEventLogSink(source string) (*EventLogSink, error) {
+	err := eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info)
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		return nil, fmt.Errorf("logger: register event source %s: %w", source, err)
+	}
+	l, err := eventlog.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("logger: open event source %s: %w", source, err)
+	}
+	return &EventLogSink{log: l}, nil
+}
+
+// Write implements io.Writer for entries without a known level
+func (s *EventLogSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *EventLogSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return 0, fmt.Errorf("logger: event log sink is closed")
+	}
+
+	eid := s.EventID
+	if eid == 0 {
+		eid = 1
+	}
+	msg := string(bytes.TrimRight(p, "\n"))
+	var err error
+	switch lvl = baseLevel(lvl); {
+	case lvl >= zerolog.ErrorLevel && lvl < zerolog.NoLevel:
+		err = s.log.Error(eid, msg)
+	case lvl == zerolog.WarnLevel:
+		err = s.log.Warning(eid, msg)
+	default:
+		err = s.log.Info(eid, msg)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("logger: event log: %w", err)
+	}
+	return len(p), nil
+}
+
+// Close closes the event source
+func (s *EventLogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return nil
+	}
+	err := s.log.Close()
+	s.log = nil
+	return err
+}
@@ -0,0 +1,18 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestEventLogSinkClosed(t *testing.T) {
+	s := &EventLogSink{}
+	if _, err := s.WriteLevel(zerolog.ErrorLevel, []byte("{}\n")); err == nil || !strings.Contains(err.Error(), "closed") {
+		t.Errorf("got %v, want an error for a closed sink", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
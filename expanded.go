@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// expandedFieldsKey is where the expanded format keeps an entry's fields
+// between taking them off the first line and writing them below it
+const expandedFieldsKey = "\x00expanded"
+
+// expandedIndent is the indent of the lines below the first
+const expandedIndent = "    "
+
+// Colors of the JSON values in expanded output
+const (
+	expandedStringColor  = "32"
+	expandedNumberColor  = "33"
+	expandedLiteralColor = "35"
+)
+
+// expandedWriter is the "expanded" output format: pretty output with the
+// time, level, caller and message on the first line and each field on a
+// line of its own below it, objects and arrays as indented, highlighted
+// JSON and the stack one frame per line
+func expandedWriter(w io.Writer, cfg Config) io.Writer {
+	if r, ok := w.(*LevelRouter); ok {
+		return &LevelRouter{High: expandedWriter(r.High, cfg), Low: expandedWriter(r.Low, cfg), Threshold: r.Threshold}
+	}
+	cw := consoleWriter(w, cfg).(zerolog.ConsoleWriter)
+	noColor := cw.NoColor || os.Getenv("NO_COLOR") != ""
+	keyColor, errColor := "36", "1;31"
+	if cfg.Theme != nil {
+		keyColor, errColor = cfg.Theme.FieldName, cfg.Theme.ErrorValue
+	}
+	paint := func(s, color string) string {
+		if noColor || color == "" {
+			return s
+		}
+		return "\x1b[" + color + "m" + s + "\x1b[0m"
+	}
+
+	cw.FieldsExclude = append(cw.FieldsExclude, expandedFieldsKey)
+	cw.FormatPrepare = func(evt map[string]interface{}) error {
+		fields := make(map[string]interface{}, len(evt))
+		for k, v := range evt {
+			switch k {
+			case zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.CallerFieldName, zerolog.MessageFieldName:
+				continue
+			}
+			fields[k] = v
+			delete(evt, k)
+		}
+		evt[expandedFieldsKey] = fields
+		return nil
+	}
+	cw.FormatExtra = func(evt map[string]interface{}, buf *bytes.Buffer) error {
+		fields, _ := evt[expandedFieldsKey].(map[string]interface{})
+		for _, k := range expandedOrder(fields, cfg.PrettyFieldOrder) {
+			v := fields[k]
+			buf.WriteString("\n" + expandedIndent)
+			if k == zerolog.ErrorStackFieldName {
+				buf.WriteString(paint(k+":", keyColor))
+				for _, line := range strings.Split(strings.TrimRight(stackText(v), "\n"), "\n") {
+					buf.WriteString("\n" + expandedIndent + "  " + strings.ReplaceAll(line, "\t", "  "))
+				}
+				continue
+			}
+			buf.WriteString(paint(k+":", keyColor) + " ")
+			switch v := v.(type) {
+			case string:
+				s := strings.ReplaceAll(v, "\n", "\n"+expandedIndent+"  ")
+				if k == zerolog.ErrorFieldName {
+					s = paint(s, errColor)
+				}
+				buf.WriteString(s)
+			default:
+				data, err := json.MarshalIndent(v, expandedIndent, "  ")
+				if err != nil {
+					fmt.Fprint(buf, v)
+					continue
+				}
+				appendHighlightedJSON(buf, data, keyColor, paint)
+			}
+		}
+		return nil
+	}
+	return cw
+}
+
+// expandedOrder returns the keys of fields in the order the expanded
+// format writes them: the error first, then the fields of order as
+// listed, the stack last and the others sorted in between
+func expandedOrder(fields map[string]interface{}, order []string) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	rank := func(k string) int {
+		switch k {
+		case zerolog.ErrorFieldName:
+			return -1
+		case zerolog.ErrorStackFieldName:
+			return len(order) + 1
+		}
+		for i, name := range order {
+			if name == k {
+				return i
+			}
+		}
+		return len(order)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if ri, rj := rank(keys[i]), rank(keys[j]); ri != rj {
+			return ri < rj
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// appendHighlightedJSON writes data, valid JSON, to buf with its keys,
+// strings, numbers and literals painted in their colors
+func appendHighlightedJSON(buf *bytes.Buffer, data []byte, keyColor string, paint func(s, color string) string) {
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c == '"':
+			j := i + 1
+			for j < len(data) && data[j] != '"' {
+				if data[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j++
+			color := expandedStringColor
+			if k := bytes.IndexFunc(data[j:], func(r rune) bool { return r != ' ' && r != '\n' }); k >= 0 && data[j+k] == ':' {
+				color = keyColor
+			}
+			buf.WriteString(paint(string(data[i:j]), color))
+			i = j
+		case c == '-' || c >= '0' && c <= '9':
+			j := i + 1
+			for j < len(data) && strings.IndexByte("0123456789.eE+-", data[j]) >= 0 {
+				j++
+			}
+			buf.WriteString(paint(string(data[i:j]), expandedNumberColor))
+			i = j
+		case c == 't' || c == 'f' || c == 'n':
+			j := i + 1
+			for j < len(data) && data[j] >= 'a' && data[j] <= 'z' {
+				j++
+			}
+			buf.WriteString(paint(string(data[i:j]), expandedLiteralColor))
+			i = j
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+}
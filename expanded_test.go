@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandedFormat(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, Format: "expanded", NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Error(errors.New("card\ndeclined"), "charge failed", "order", 7, "http", map[string]interface{}{"status": 503, "retry": true}, "note", "two\nlines",
+		"stack", []interface{}{map[string]interface{}{"func": "charge", "source": "billing.go", "line": "42"}})
+	want := "ERR charge failed\n" +
+		"    error: card\n" +
+		"      declined\n" +
+		"    http: {\n" +
+		"      \"retry\": true,\n" +
+		"      \"status\": 503\n" +
+		"    }\n" +
+		"    note: two\n" +
+		"      lines\n" +
+		"    order: 7\n" +
+		"    stack:\n" +
+		"      charge\n" +
+		"        billing.go:42\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestAppendHighlightedJSON(t *testing.T) {
+	paint := func(s, color string) string { return "<" + color + ">" + s + "</>" }
+	var buf bytes.Buffer
+	appendHighlightedJSON(&buf, []byte(`{"a\"b": ["x", -1.5e3, true, null]}`), "36", paint)
+	want := `{<36>"a\"b"</>: [<32>"x"</>, <33>-1.5e3</>, <35>true</>, <35>null</>]}`
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExpandedFormatColors(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	theme := DefaultTheme()
+	theme.FieldName, theme.ErrorValue = "34", "35"
+	var buf bytes.Buffer
+	l, _ := New(Config{Output: &buf, Format: "expanded", NoTimestamp: true, Theme: &theme})
+	l.Error(errors.New("declined"), "charge failed", "tags", []string{"a"})
+	want := "\n    \x1b[34merror:\x1b[0m \x1b[35mdeclined\x1b[0m" +
+		"\n    \x1b[34mtags:\x1b[0m [\n      \x1b[32m\"a\"\x1b[0m\n    ]\n"
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("got\n%q\nwant suffix\n%q", got, want)
+	}
+}
+
+func TestPrettyFieldOrder(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	order := []string{"request_id", "duration", "missing"}
+	var pretty, expanded bytes.Buffer
+	for format, buf := range map[string]*bytes.Buffer{"pretty": &pretty, "expanded": &expanded} {
+		l, err := New(Config{Output: buf, Format: format, NoTimestamp: true, PrettyFieldOrder: order})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Error(errors.New("declined"), "charge failed", "amount", 42, "duration", 12, "request_id", "r1", "zone", "eu")
+	}
+	if got, want := pretty.String(), "ERR charge failed error=declined request_id=r1 duration=12 amount=42 zone=eu\n"; got != want {
+		t.Errorf("pretty got\n%q\nwant\n%q", got, want)
+	}
+	want := "ERR charge failed\n    error: declined\n    request_id: r1\n    duration: 12\n    amount: 42\n    zone: eu\n"
+	if got := expanded.String(); got != want {
+		t.Errorf("expanded got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestExpandedOrder(t *testing.T) {
+	fields := map[string]interface{}{"b": 1, "a": 1, "stack": 1, "error": 1, "id": 1, "took": 1}
+	got := expandedOrder(fields, []string{"took", "id"})
+	if want := []string{"error", "took", "id", "a", "b", "stack"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expandedOrder = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"math"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Field is a typed key/value pair for the log functions, written with
+// zerolog's typed methods instead of fmt and reflection
+//
+//	logger.Info("request served", logger.Str("path", r.URL.Path),
+//		logger.Int("status", status), logger.Dur("took", time.Since(start)))
+//
+// Fields can be mixed with plain key/value pairs in the same call. Passed
+// that way each Field is boxed in an interface, which allocates; the
+// Fields variants such as InfoFields take them as they are and log
+// without allocating.
+type Field struct {
+	Key  string
+	kind fieldKind
+	num  int64
+	str  string
+	t    time.Time
+	val  interface{}
+}
+
+// fieldKind selects the zerolog method a Field is written with
+type fieldKind uint8
+
+const (
+	fieldAny fieldKind = iota
+	fieldStr
+	fieldInt
+	fieldUint
+	fieldFloat
+	fieldBool
+	fieldDur
+	fieldTime
+	fieldErr
+)
+
+// Str returns a string field
+func Str(key, value string) Field {
+	return Field{Key: key, kind: fieldStr, str: value}
+}
+
+// Int returns an int field
+func Int(key string, value int) Field {
+	return Field{Key: key, kind: fieldInt, num: int64(value)}
+}
+
+// Int64 returns an int64 field
+func Int64(key string, value int64) Field {
+	return Field{Key: key, kind: fieldInt, num: value}
+}
+
+// Uint64 returns a uint64 field
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, kind: fieldUint, num: int64(value)}
+}
+
+// Float64 returns a float64 field
+func Float64(key string, value float64) Field {
+	return Field{Key: key, kind: fieldFloat, num: int64(math.Float64bits(value))}
+}
+
+// Bool returns a bool field
+func Bool(key string, value bool) Field {
+	f := Field{Key: key, kind: fieldBool}
+	if value {
+		f.num = 1
+	}
+	return f
+}
+
+// Dur returns a duration field, written in zerolog.DurationFieldUnit
+func Dur(key string, value time.Duration) Field {
+	return Field{Key: key, kind: fieldDur, num: int64(value)}
+}
+
+// Time returns a time field, written in zerolog.TimeFieldFormat
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, kind: fieldTime, t: value}
+}
+
+// Err returns the error field, under zerolog.ErrorFieldName. A nil error
+// adds nothing.
+func Err(err error) Field {
+	return Field{kind: fieldErr, val: err}
+}
+
+// Any returns a field of any value, written with reflection like a plain
+// key/value pair
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, kind: fieldAny, val: value}
+}
+
+// apply adds the field to evt
+func (f Field) apply(evt *zerolog.Event) *zerolog.Event {
+	switch f.kind {
+	case fieldStr:
+		return evt.Str(f.Key, f.str)
+	case fieldInt:
+		return evt.Int64(f.Key, f.num)
+	case fieldUint:
+		return evt.Uint64(f.Key, uint64(f.num))
+	case fieldFloat:
+		return evt.Float64(f.Key, math.Float64frombits(uint64(f.num)))
+	case fieldBool:
+		return evt.Bool(f.Key, f.num != 0)
+	case fieldDur:
+		return evt.Dur(f.Key, time.Duration(f.num))
+	case fieldTime:
+		return evt.Time(f.Key, f.t)
+	case fieldErr:
+		err, _ := f.val.(error)
+		return evt.Err(err)
+	}
+	return evt.Interface(f.Key, f.val)
+}
+
+// writeFields adds fields to evt and sends it with msg
+func writeFields(evt *zerolog.Event, msg string, fields []Field) {
+	for _, f := range fields {
+		evt = f.apply(evt)
+	}
+	evt.Msg(msg)
+}
+
+// InfoFields logs an info message with typed fields
+func InfoFields(msg string, fields ...Field) {
+	if st := std.state.Load(); st.enabled(zerolog.InfoLevel) {
+		writeFields(addCallerInfo(st.zl.Info(), st), msg, fields)
+	}
+}
+
+// WarnFields logs a warning message with typed fields
+func WarnFields(msg string, fields ...Field) {
+	if st := std.state.Load(); st.enabled(zerolog.WarnLevel) {
+		writeFields(addCallerInfo(st.zl.Warn(), st), msg, fields)
+	}
+}
+
+// ErrorFields logs an error message with typed fields
+func ErrorFields(err error, msg string, fields ...Field) {
+	if st := std.state.Load(); st.enabled(zerolog.ErrorLevel) {
+		writeFields(addCallerInfo(st.zl.Error().Err(err), st), msg, fields)
+	}
+}
+
+// InfoFields logs an info message with typed fields
+func (l *Logger) InfoFields(msg string, fields ...Field) {
+	if st := l.state.Load(); st.enabled(zerolog.InfoLevel) {
+		writeFields(addCallerInfo(st.zl.Info(), st), msg, fields)
+	}
+}
+
+// WarnFields logs a warning message with typed fields
+func (l *Logger) WarnFields(msg string, fields ...Field) {
+	if st := l.state.Load(); st.enabled(zerolog.WarnLevel) {
+		writeFields(addCallerInfo(st.zl.Warn(), st), msg, fields)
+	}
+}
+
+// ErrorFields logs an error message with typed fields
+func (l *Logger) ErrorFields(err error, msg string, fields ...Field) {
+	if st := l.state.Load(); st.enabled(zerolog.ErrorLevel) {
+		writeFields(addCallerInfo(st.zl.Error().Err(err), st), msg, fields)
+	}
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFields(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 10, 15, 1, 2, 3, 0, time.UTC)
+	l.InfoFields("served",
+		Str("path", "/a"),
+		Int("status", 200),
+		Int64("size", -1),
+		Uint64("id", math.MaxUint64),
+		Float64("ratio", 0.5),
+		Bool("cached", true),
+		Bool("gzip", false),
+		Dur("took", 1500*time.Millisecond),
+		Time("at", at),
+		Err(nil),
+		Any("tags", []string{"a"}),
+	)
+	want := `{"level":"info","path":"/a","status":200,"size":-1,"id":18446744073709551615,"ratio":0.5,"cached":true,"gzip":false,"took":1500,"at":"` + at.Format(time.RFC3339) + `","tags":["a"],"message":"served"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	l.WarnFields("slow", Err(errors.New("timeout")))
+	l.ErrorFields(errors.New("declined"), "charge failed", Int("order", 7))
+	want = `{"level":"warn","error":"timeout","message":"slow"}` + "\n" +
+		`{"level":"error","error":"declined","order":7,"message":"charge failed"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFieldsMixedWithPairs(t *testing.T) {
+	var buf bytes.Buffer
+	l, _ := New(Config{Output: &buf, NoTimestamp: true})
+	l.Info("100% done", Int("n", 1), "k", "v", Str("s", "x"))
+	if got, want := buf.String(), `{"level":"info","n":1,"k":"v","s":"x","message":"100% done"}`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestFieldsAllocations(t *testing.T) {
+	l, _ := New(Config{Output: io.Discard})
+	allocs := testing.AllocsPerRun(100, func() {
+		l.InfoFields("served", Str("path", "/a"), Int("status", 200), Dur("took", time.Millisecond), Bool("cached", true))
+	})
+	if allocs != 0 {
+		t.Errorf("%v allocations per call, want 0", allocs)
+	}
+}
+
+func TestGlobalFields(t *testing.T) {
+	var c Capture
+	useGlobal(t, Config{Output: &c})
+	InfoFields("one", Int("n", 1))
+	WarnFields("two")
+	ErrorFields(errors.New("boom"), "three")
+	if got := strings.Join(captured(&c), ","); got != "one,two,three" {
+		t.Errorf("captured %q", got)
+	}
+	if e := c.Entries(); len(e) != 3 || e[0]["n"] != 1.0 || e[2]["error"] != "boom" {
+		t.Errorf("entries %v", e)
+	}
+}
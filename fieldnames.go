@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+)
+
+// defaultFieldNames are zerolog's standard field keys
+var defaultFieldNames = FieldNames{
+	Time:    zerolog.TimestampFieldName,
+	Level:   zerolog.LevelFieldName,
+	Message: zerolog.MessageFieldName,
+	Error:   zerolog.ErrorFieldName,
+	Caller:  zerolog.CallerFieldName,
+}
+
+// withDefaults fills empty names with the standard keys
+func (n FieldNames) withDefaults() FieldNames {
+	if n.Time == "" {
+		n.Time = defaultFieldNames.Time
+	}
+	if n.Level == "" {
+		n.Level = defaultFieldNames.Level
+	}
+	if n.Message == "" {
+		n.Message = defaultFieldNames.Message
+	}
+	if n.Error == "" {
+		n.Error = defaultFieldNames.Error
+	}
+	if n.Caller == "" {
+		n.Caller = defaultFieldNames.Caller
+	}
+	return n
+}
+
+// install makes the names zerolog's process-wide field keys, which both
+// JSON and pretty output read. Callers must hold mu.
+func (n FieldNames) install() {
+	n = n.withDefaults()
+	zerolog.TimestampFieldName = n.Time
+	zerolog.LevelFieldName = n.Level
+	zerolog.MessageFieldName = n.Message
+	zerolog.ErrorFieldName = n.Error
+	zerolog.CallerFieldName = n.Caller
+}
+
+// validate rejects names that would make two standard fields collide
+func (n FieldNames) validate() error {
+	n = n.withDefaults()
+	seen := make(map[string]string, 5)
+	for _, f := range []struct{ field, name string }{
+		{"time", n.Time},
+		{"level", n.Level},
+		{"message", n.Message},
+		{"error", n.Error},
+		{"caller", n.Caller},
+	} {
+		if other, ok := seen[f.name]; ok {
+			return fmt.Errorf("logger: %s and %s fields both use the name %q", other, f.field, f.name)
+		}
+		seen[f.name] = f.field
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestFieldNames(t *testing.T) {
+	cfg, c := TestConfig()
+	cfg.FieldNames = FieldNames{Time: "@t", Level: "severity", Message: "msg", Error: "err"}
+	useGlobal(t, cfg)
+	Error(errors.New("boom"), "failed")
+
+	entries := c.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("got %q", c.String())
+	}
+	e := entries[0]
+	if e["severity"] != "error" || e["msg"] != "failed" || e["err"] != "boom" || e["@t"] == nil {
+		t.Errorf("got %v", e)
+	}
+	for _, key := range []string{"time", "level", "message", "error"} {
+		if _, ok := e[key]; ok {
+			t.Errorf("standard key %s still written", key)
+		}
+	}
+
+	Reconfigure(Config{Output: c})
+	if zerolog.MessageFieldName != "message" || zerolog.LevelFieldName != "level" {
+		t.Error("a config without field names did not restore the standard keys")
+	}
+}
+
+func TestFieldNamesValidate(t *testing.T) {
+	err := FieldNames{Message: "level"}.validate()
+	if err == nil || !strings.Contains(err.Error(), `"level"`) {
+		t.Errorf("got %v, want a collision on level", err)
+	}
+	if err := (Config{Scoped: true, FieldNames: FieldNames{Time: "ts"}}).Validate(); err == nil {
+		t.Error("no error for field names on a scoped config")
+	}
+}
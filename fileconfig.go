@@ -0,0 +1,399 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// FileConfig is the serializable form of Config read by InitFromFile
+// A YAML document looks like:
+//
+//	level: debug
+//	format: json
+//	caller: true
+//	outputs:
+//	  - path: stderr
+//	  - path: /var/log/app.log
+//	fields:
+//	  service: billing
+//	  env: prod
+//	loggers:
+//	  db:
+//	    level: warn
+//	  http:
+//	    outputs:
+//	      - path: /var/log/access.log
+type FileConfig struct {
+	Level           string                 `json:"level" yaml:"level" toml:"level"`                                  // Log level name
+	Format          string                 `json:"format" yaml:"format" toml:"format"`                               // Output format, see Config.Format
+	Caller          bool                   `json:"caller" yaml:"caller" toml:"caller"`                               // Include caller information
+	CallerSkip      int                    `json:"caller_skip" yaml:"caller_skip" toml:"caller_skip"`                // Extra frames to skip for the caller
+	TimeFormat      string                 `json:"time_format" yaml:"time_format" toml:"time_format"`                // Timestamp format
+	Timezone        string                 `json:"timezone" yaml:"timezone" toml:"timezone"`                         // Time zone name, host zone if empty
+	NoTimestamp     bool                   `json:"no_timestamp" yaml:"no_timestamp" toml:"no_timestamp"`             // Leave timestamps out
+	Deadline        bool                   `json:"deadline_ms" yaml:"deadline_ms" toml:"deadline_ms"`                // Add the time left until the context's deadline
+	Goroutine       bool                   `json:"goroutine" yaml:"goroutine" toml:"goroutine"`                      // Add the goroutine ID, for debugging only
+	Outputs         []OutputConfig         `json:"outputs" yaml:"outputs" toml:"outputs"`                            // Destinations, stderr if empty
+	ParallelOutputs bool                   `json:"parallel_outputs" yaml:"parallel_outputs" toml:"parallel_outputs"` // Write to all outputs at the same time
+	ErrorOutput     string                 `json:"error_output" yaml:"error_output" toml:"error_output"`             // Destination for error entries and above
+	Fields          map[string]interface{} `json:"fields" yaml:"fields" toml:"fields"`                               // Static fields for every entry
+	Loggers         map[string]FileConfig  `json:"loggers" yaml:"loggers" toml:"loggers"`                            // Named logger settings
+	FieldNames      FieldNames             `json:"field_names" yaml:"field_names" toml:"field_names"`                // Standard field keys
+	Scoped          bool                   `json:"scoped" yaml:"scoped" toml:"scoped"`                               // Leave zerolog's global settings alone
+	Disabled        bool                   `json:"disabled" yaml:"disabled" toml:"disabled"`                         // Turn logging off
+	Theme           string                 `json:"theme" yaml:"theme" toml:"theme"`                                  // Pretty colors: default, high-contrast or none
+	Schema          string                 `json:"schema" yaml:"schema" toml:"schema"`                               // Schema name stamped on every entry
+	SchemaVersion   string                 `json:"schema_version" yaml:"schema_version" toml:"schema_version"`       // Schema version stamped on every entry
+	CSVColumns      []string               `json:"csv_columns" yaml:"csv_columns" toml:"csv_columns"`                // Columns of the csv format
+	FieldOrder      []string               `json:"field_order" yaml:"field_order" toml:"field_order"`                // Fields pretty output writes first
+}
+
+// OutputConfig describes a single log destination in a FileConfig
+// Setting a rotation schedule, a size or retention limit, or a path with
+// template directives such as app-%Y%m%d.log makes a file output a
+// FileSink that rotates itself, as does a buffer size or sync policy.
+type OutputConfig struct {
+	Path           string `json:"path" yaml:"path" toml:"path"`                                        // stderr, stdout, split or a file path
+	Level          string `json:"level" yaml:"level" toml:"level"`                                     // Least severe level written to this output
+	Format         string `json:"format" yaml:"format" toml:"format"`                                  // Format of this output, the file's format if empty
+	Rotate         string `json:"rotate" yaml:"rotate" toml:"rotate"`                                  // Start a new file hourly or daily
+	MaxSizeMB      int    `json:"max_size_mb" yaml:"max_size_mb" toml:"max_size_mb"`                   // Rotate the file at this size
+	MaxBackups     int    `json:"max_backups" yaml:"max_backups" toml:"max_backups"`                   // Rotated files to keep
+	MaxAgeDays     int    `json:"max_age_days" yaml:"max_age_days" toml:"max_age_days"`                // Days to keep rotated files
+	MaxTotalSizeMB int    `json:"max_total_size_mb" yaml:"max_total_size_mb" toml:"max_total_size_mb"` // Space rotated files may take up
+	Compress       bool   `json:"compress" yaml:"compress" toml:"compress"`                            // Gzip rotated files
+	Verify         bool   `json:"verify" yaml:"verify" toml:"verify"`                                  // Check archives before deleting originals
+	Reopen         bool   `json:"reopen_on_signal" yaml:"reopen_on_signal" toml:"reopen_on_signal"`    // Reopen the file on SIGHUP
+	BufferKB       int    `json:"buffer_kb" yaml:"buffer_kb" toml:"buffer_kb"`                         // Buffer entries up to this size before writing
+	FlushMs        int    `json:"flush_interval_ms" yaml:"flush_interval_ms" toml:"flush_interval_ms"` // Longest a buffered entry waits, a second if 0
+	Sync           string `json:"sync" yaml:"sync" toml:"sync"`                                        // Sync the file to disk after error entries or always
+}
+
+// file returns the file settings of the output, leaving out how entries
+// are filtered and formatted
+func (oc OutputConfig) file() OutputConfig {
+	oc.Level, oc.Format = "", ""
+	return oc
+}
+
+// rotates reports whether the output needs a FileSink
+func (oc OutputConfig) rotates() bool {
+	return oc.Rotate != "" || strings.Contains(oc.Path, "%") ||
+		oc.MaxSizeMB != 0 || oc.MaxBackups != 0 || oc.MaxAgeDays != 0 || oc.MaxTotalSizeMB != 0 || oc.Compress || oc.Reopen ||
+		oc.BufferKB != 0 || oc.Sync != ""
+}
+
+// openOutputConfig resolves an output to a writer, opening a FileSink for
+// rotated files and a plain file otherwise
+func openOutputConfig(oc OutputConfig) (io.Writer, error) {
+	switch strings.ToLower(oc.Path) {
+	case "", "stderr", "stdout", "split":
+		return openOutput(oc.Path)
+	}
+	if !oc.rotates() {
+		return openFile(oc.Path)
+	}
+	sink := &FileSink{
+		Path:           oc.Path,
+		Schedule:       RotationSchedule(strings.ToLower(oc.Rotate)),
+		MaxSizeMB:      oc.MaxSizeMB,
+		MaxBackups:     oc.MaxBackups,
+		MaxAgeDays:     oc.MaxAgeDays,
+		MaxTotalSizeMB: oc.MaxTotalSizeMB,
+		Compress:       oc.Compress,
+		Verify:         oc.Verify,
+		ReopenOnSignal: oc.Reopen,
+		BufferKB:       oc.BufferKB,
+		FlushInterval:  time.Duration(oc.FlushMs) * time.Millisecond,
+		Sync:           SyncPolicy(strings.ToLower(oc.Sync)),
+	}
+	if err := sink.validate(); err != nil {
+		return nil, err
+	}
+	// Open right away so a bad path is reported with the config
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if err := sink.open(); err != nil {
+		return nil, err
+	}
+	return sink, nil
+}
+
+// FileOption configures how InitFromFile loads and watches a config file
+type FileOption func(*fileOptions)
+
+// fileOptions holds the settings collected from FileOption values
+type fileOptions struct {
+	watchSignals bool
+	watchFile    bool
+	onReload     func(error)
+}
+
+// WithWatchSignals makes InitFromFile re-read the config file whenever the
+// process receives SIGHUP and apply the new settings to the live logger.
+// It has no effect on platforms without SIGHUP.
+func WithWatchSignals() FileOption {
+	return func(o *fileOptions) {
+		o.watchSignals = true
+	}
+}
+
+// WithWatch makes InitFromFile watch the config file for changes and apply
+// the new settings to the live logger whenever the file is edited
+func WithWatch() FileOption {
+	return func(o *fileOptions) {
+		o.watchFile = true
+	}
+}
+
+// WithReloadCallback registers fn to be called after every reload triggered
+// by WithWatch or WithWatchSignals, with the error that made the reload fail
+// or nil on success
+func WithReloadCallback(fn func(error)) FileOption {
+	return func(o *fileOptions) {
+		o.onReload = fn
+	}
+}
+
+// errInitialized is returned by InitFromFile once the global logger is
+// initialized
+var errInitialized = errors.New("logger: already initialized")
+
+// InitFromFile initializes the global logger from a YAML, JSON or TOML file
+// The format is picked from the file extension (.yaml, .yml, .json or .toml).
+// Like InitLogger, only the first initialization takes effect: later calls
+// close the outputs they opened and return an error. Reloads requested
+// through options are applied with Reconfigure.
+func InitFromFile(path string, opts ...FileOption) error {
+	var o fileOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	r := &reloader{path: path, outputs: newOutputSet(), onReload: o.onReload}
+	cfg, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	// Set up the file watcher first so a failure leaves the logger untouched
+	var w *fsnotify.Watcher
+	if o.watchFile {
+		if w, err = r.newWatcher(); err != nil {
+			r.outputs.closeAll()
+			return err
+		}
+	}
+	// Only the first initialization takes effect; a later one must not
+	// leave the files and watcher it opened behind
+	err = cfg.Validate()
+	applied := false
+	if err == nil {
+		initOnce.Do(func() {
+			apply(cfg)
+			applied = true
+		})
+		if !applied {
+			err = errInitialized
+		}
+	}
+	if err != nil {
+		if w != nil {
+			w.Close()
+		}
+		r.outputs.closeAll()
+		return err
+	}
+
+	if w != nil {
+		go r.watchFile(w)
+	}
+	if o.watchSignals {
+		go r.watchSignals()
+	}
+	return nil
+}
+
+// LoadFile reads and parses a logger configuration file
+func LoadFile(path string) (FileConfig, error) {
+	var fc FileConfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fc, fmt.Errorf("logger: read config: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &fc)
+	case ".json":
+		err = json.Unmarshal(data, &fc)
+	case ".toml":
+		err = toml.Unmarshal(data, &fc)
+	default:
+		return fc, fmt.Errorf("logger: unsupported config file extension %q", ext)
+	}
+	if err != nil {
+		return fc, fmt.Errorf("logger: parse config %s: %w", path, err)
+	}
+	return fc, nil
+}
+
+// LoadTOML reads the logger configuration from a section of a TOML file
+// This lets the logger settings live inside an existing application config,
+// for example with section "logger":
+//
+//	[logger]
+//	level = "debug"
+//
+//	[[logger.outputs]]
+//	path = "/var/log/app.log"
+//
+//	[logger.fields]
+//	service = "billing"
+//
+// Nested sections are addressed with dots ("app.logger"). An empty section
+// decodes the whole document.
+func LoadTOML(path, section string) (FileConfig, error) {
+	var fc FileConfig
+	if section == "" {
+		if _, err := toml.DecodeFile(path, &fc); err != nil {
+			return fc, fmt.Errorf("logger: parse config %s: %w", path, err)
+		}
+		return fc, nil
+	}
+
+	// Walk down to the requested table without decoding the rest of the file
+	var table map[string]toml.Primitive
+	md, err := toml.DecodeFile(path, &table)
+	if err != nil {
+		return fc, fmt.Errorf("logger: parse config %s: %w", path, err)
+	}
+	keys := strings.Split(section, ".")
+	for i, key := range keys {
+		prim, ok := table[key]
+		if !ok {
+			return fc, fmt.Errorf("logger: section %q not found in %s", section, path)
+		}
+		if i == len(keys)-1 {
+			err = md.PrimitiveDecode(prim, &fc)
+		} else {
+			table = nil
+			err = md.PrimitiveDecode(prim, &table)
+		}
+		if err != nil {
+			return fc, fmt.Errorf("logger: parse config %s: %w", path, err)
+		}
+	}
+	return fc, nil
+}
+
+// Config converts the file configuration into a Config, opening any
+// file outputs it references
+func (fc FileConfig) Config() (Config, error) {
+	return fc.build(openOutputConfig)
+}
+
+// build converts the file configuration into a Config using open to
+// resolve outputs to writers
+func (fc FileConfig) build(open func(OutputConfig) (io.Writer, error)) (Config, error) {
+	cfg := Config{
+		Level:            fc.Level,
+		WithCaller:       fc.Caller,
+		CallerSkipFrames: fc.CallerSkip,
+		TimeFormat:       fc.TimeFormat,
+		Fields:           fc.Fields,
+		NoTimestamp:      fc.NoTimestamp,
+		FieldNames:       fc.FieldNames,
+		Scoped:           fc.Scoped,
+		Disabled:         fc.Disabled,
+		Schema:           fc.Schema,
+		SchemaVersion:    fc.SchemaVersion,
+		CSVColumns:       fc.CSVColumns,
+		PrettyFieldOrder: fc.FieldOrder,
+	}
+	cfg.DeadlineRemaining = fc.Deadline
+	cfg.Goroutine = fc.Goroutine
+
+	if fc.Timezone != "" {
+		loc, err := parseLocation(fc.Timezone)
+		if err != nil {
+			return cfg, fmt.Errorf("logger: invalid timezone: %w", err)
+		}
+		cfg.Location = loc
+	}
+
+	if fc.Theme != "" {
+		theme, err := parseTheme(fc.Theme)
+		if err != nil {
+			return cfg, fmt.Errorf("logger: invalid theme: %w", err)
+		}
+		cfg.Theme = theme
+	}
+
+	if fc.Format != "" {
+		format, err := parseFormat(fc.Format)
+		if err != nil {
+			return cfg, fmt.Errorf("logger: invalid format: %w", err)
+		}
+		cfg.Format = format
+	}
+
+	// Open every output, combining them when there is more than one. An
+	// output with its own level or format, or writing to them in parallel,
+	// turns them all into sinks.
+	var writers []io.Writer
+	sinks := fc.ParallelOutputs && len(fc.Outputs) > 1
+	cfg.ParallelSinks = fc.ParallelOutputs
+	for _, out := range fc.Outputs {
+		w, err := open(out)
+		if err != nil {
+			return cfg, err
+		}
+		writers = append(writers, w)
+		sinks = sinks || out.Level != "" || out.Format != ""
+	}
+	switch {
+	case sinks:
+		for i, out := range fc.Outputs {
+			format := out.Format
+			if format == "" {
+				format = fc.Format
+			}
+			cfg.Sinks = append(cfg.Sinks, SinkConfig{Writer: writers[i], MinLevel: out.Level, Format: format})
+		}
+	case len(writers) == 1:
+		cfg.Output = writers[0]
+	case len(writers) > 1:
+		cfg.Output = io.MultiWriter(writers...)
+	}
+
+	if fc.ErrorOutput != "" {
+		w, err := open(OutputConfig{Path: fc.ErrorOutput})
+		if err != nil {
+			return cfg, err
+		}
+		cfg.ErrorOutput = w
+	}
+
+	// Named loggers share the same way of opening outputs. The map is
+	// always set so a file without a loggers section clears them on reload.
+	cfg.Loggers = make(map[string]Config, len(fc.Loggers))
+	for name, sub := range fc.Loggers {
+		subCfg, err := sub.build(open)
+		if err != nil {
+			return cfg, fmt.Errorf("logger %q: %w", name, err)
+		}
+		cfg.Loggers[name] = subCfg
+	}
+	return cfg, nil
+}
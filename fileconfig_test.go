@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInitFromFileAfterInitClosesOutputs(t *testing.T) {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("open files cannot be counted:", err)
+	}
+	initOnce.Do(func() {}) // as if the logger had been initialized
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logger.yaml")
+	config := "outputs:\n  - path: " + filepath.Join(dir, "app.log") + "\n"
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := InitFromFile(path, WithWatch()); !errors.Is(err, errInitialized) {
+		t.Errorf("got %v, want errInitialized", err)
+	}
+	if after, _ := os.ReadDir("/proc/self/fd"); len(after) != len(fds) {
+		t.Errorf("%d files open after, %d before", len(after), len(fds))
+	}
+}
+
+// writeConfig writes a config file named name into a temporary directory
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	want := FileConfig{
+		Level:   "debug",
+		Format:  "json",
+		Caller:  true,
+		Outputs: []OutputConfig{{Path: "stderr"}, {Path: "/var/log/app.log", Level: "warn"}},
+		Fields:  map[string]interface{}{"service": "billing"},
+		Loggers: map[string]FileConfig{"db": {Level: "warn"}},
+	}
+	for name, content := range map[string]string{
+		"logger.yaml": "level: debug\nformat: json\ncaller: true\noutputs:\n  - path: stderr\n  - path: /var/log/app.log\n    level: warn\nfields:\n  service: billing\nloggers:\n  db:\n    level: warn\n",
+		"logger.json": `{"level":"debug","format":"json","caller":true,"outputs":[{"path":"stderr"},{"path":"/var/log/app.log","level":"warn"}],"fields":{"service":"billing"},"loggers":{"db":{"level":"warn"}}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			fc, err := LoadFile(writeConfig(t, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fc.Level != want.Level || fc.Format != want.Format || fc.Caller != want.Caller {
+				t.Errorf("got level %q format %q caller %v", fc.Level, fc.Format, fc.Caller)
+			}
+			if len(fc.Outputs) != 2 || fc.Outputs[0] != want.Outputs[0] || fc.Outputs[1] != want.Outputs[1] {
+				t.Errorf("Outputs = %+v, want %+v", fc.Outputs, want.Outputs)
+			}
+			if fc.Fields["service"] != "billing" || fc.Loggers["db"].Level != "warn" {
+				t.Errorf("got fields %v loggers %+v", fc.Fields, fc.Loggers)
+			}
+		})
+	}
+	if _, err := LoadFile(writeConfig(t, "logger.ini", "level=debug")); err == nil {
+		t.Error("no error for an unsupported extension")
+	}
+	if _, err := LoadFile(writeConfig(t, "logger.yaml", "level: [debug")); err == nil {
+		t.Error("no error for malformed YAML")
+	}
+}
+
+func TestFileConfigBuild(t *testing.T) {
+	var opened []string
+	open := func(oc OutputConfig) (io.Writer, error) {
+		opened = append(opened, oc.Path)
+		return io.Discard, nil
+	}
+	fc := FileConfig{
+		Level:       "info",
+		Format:      "logfmt",
+		Outputs:     []OutputConfig{{Path: "a.log"}, {Path: "b.log", Level: "error", Format: "json"}},
+		ErrorOutput: "errors.log",
+		Loggers:     map[string]FileConfig{"db": {Outputs: []OutputConfig{{Path: "db.log"}}}},
+	}
+	cfg, err := fc.build(open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Sinks) != 2 || cfg.Sinks[0].Format != "logfmt" || cfg.Sinks[1].Format != "json" || cfg.Sinks[1].MinLevel != "error" {
+		t.Errorf("Sinks = %+v, want a logfmt sink and an error json sink", cfg.Sinks)
+	}
+	if cfg.ErrorOutput == nil || cfg.Loggers["db"].Output == nil {
+		t.Error("error output or db logger output not opened")
+	}
+	if got := strings.Join(opened, ","); got != "a.log,b.log,errors.log,db.log" {
+		t.Errorf("opened %s", got)
+	}
+
+	if _, err := (FileConfig{Format: "yaml"}).build(open); err == nil {
+		t.Error("no error for an unknown format")
+	}
+	if _, err := (FileConfig{Loggers: map[string]FileConfig{"db": {Timezone: "Mars/Olympus"}}}).build(open); err == nil || !strings.Contains(err.Error(), `"db"`) {
+		t.Errorf("got %v, want an error naming logger db", err)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	path := writeConfig(t, "app.toml", `name = "billing"
+
+[app.logger]
+level = "warn"
+
+[[app.logger.outputs]]
+path = "/var/log/app.log"
+max_size_mb = 10
+
+[app.logger.fields]
+service = "billing"
+`)
+	fc, err := LoadTOML(path, "app.logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fc.Level != "warn" || len(fc.Outputs) != 1 || fc.Outputs[0].MaxSizeMB != 10 || fc.Fields["service"] != "billing" {
+		t.Errorf("got %+v", fc)
+	}
+	if _, err := LoadTOML(path, "app.missing"); err == nil {
+		t.Error("no error for a missing section")
+	}
+
+	whole, err := LoadTOML(writeConfig(t, "logger.toml", "level = \"error\"\n"), "")
+	if err != nil || whole.Level != "error" {
+		t.Errorf("whole document: got %+v, %v", whole, err)
+	}
+	if fc, err := LoadFile(writeConfig(t, "logger.toml", "level = \"debug\"\n")); err != nil || fc.Level != "debug" {
+		t.Errorf("LoadFile: got %+v, %v", fc, err)
+	}
+}
@@ -0,0 +1,742 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// backupTimeFormat is the timestamp put into rotated file names
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// RotationSchedule is how often a FileSink starts a new file
+type RotationSchedule string
+
+// Supported rotation schedules. Periods follow the local wall clock, so a
+// daily file covers a calendar day even when DST makes it 23 or 25 hours.
+const (
+	RotateHourly RotationSchedule = "hourly"
+	RotateDaily  RotationSchedule = "daily"
+)
+
+// SyncPolicy is when a FileSink syncs its file to disk, trading write
+// throughput for entries that survive a machine crash. With no policy
+// syncing is left to the operating system.
+type SyncPolicy string
+
+// Supported sync policies
+const (
+	SyncErrors SyncPolicy = "error"  // Sync after entries at error level and above
+	SyncAlways SyncPolicy = "always" // Sync after every write to the file
+)
+
+// next returns the end of the period containing t
+func (r RotationSchedule) next(t time.Time) time.Time {
+	switch r {
+	case RotateHourly:
+		// Step back on the wall clock rather than through time.Date, which
+		// picks the wrong instance of the hour repeated when DST ends
+		wall := time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+		return t.Add(time.Hour - wall)
+	case RotateDaily:
+		y, m, d := t.Date()
+		return time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+// FileSink is a log file that rotates itself by size, on a schedule, or both
+// Rotated files are renamed to name-<timestamp>.ext next to the log file,
+// cleaned up according to MaxBackups, MaxAgeDays and MaxTotalSizeMB and
+// optionally gzipped to name-<timestamp>.ext.gz, all in a background
+// goroutine so writes are not held up. The limits are enforced after every
+// rotation and every CleanupInterval, and each pass that removes files
+// logs how many and how much space was freed.
+//
+//	logger.InitLogger(logger.Config{
+//		Output: &logger.FileSink{Path: "/var/log/app.log", MaxSizeMB: 100, MaxBackups: 5},
+//	})
+//
+// The file name in Path may instead be a template such as app-%Y%m%d.log,
+// which is expanded with the current time using %Y, %m, %d, %H and %%. Each period
+// then writes to its own file and nothing is renamed unless MaxSizeMB is
+// also reached. Templates with %H rotate hourly and others daily unless
+// Schedule says otherwise.
+//
+// After a restart the sink appends to the current file, and rotates an
+// untemplated file first if it was last written in an earlier period.
+// The file is opened on the first write. The fields must not be changed
+// once the sink is in use.
+//
+// With BufferKB set, entries are gathered in memory and written once the
+// buffer is full, FlushInterval after the first of them, or right away
+// with an entry at error level and above, so a high-volume service makes
+// far fewer writes while problems still reach the file at once:
+//
+//	&logger.FileSink{Path: "/var/log/app.log", BufferKB: 256, FlushInterval: time.Second, Sync: logger.SyncErrors}
+//
+// Entries are never split across writes. Flush and Close write the
+// buffer too, and the package-level Flush and Close reach every buffered
+// sink. Only entries whose level is known, such as zerolog's own JSON,
+// are written early; pretty and other formatted outputs wait for the
+// buffer like info entries.
+type FileSink struct {
+	Path            string           // Log file path or name template
+	Schedule        RotationSchedule // Start a new file every hour or day, never if empty
+	MaxSizeMB       int              // Rotate before the file grows past this size, never if 0
+	MaxBackups      int              // Rotated files to keep, all if 0
+	MaxAgeDays      int              // Remove rotated files older than this many days, never if 0
+	MaxTotalSizeMB  int              // Remove the oldest rotated files once together they exceed this size, never if 0
+	CleanupInterval time.Duration    // How often limits are enforced besides after each rotation, hourly if 0
+	Compress        bool             // Gzip rotated files
+	Verify          bool             // Read compressed files back before deleting the originals
+	ReopenOnSignal  bool             // Reopen the file on SIGHUP, for rotation by logrotate
+	BufferKB        int              // Entries held in memory up to this size before writing, unbuffered if 0
+	FlushInterval   time.Duration    // Longest a buffered entry waits to be written, a second if 0
+	Sync            SyncPolicy       // When the file is synced to disk, left to the OS if empty
+
+	mu        sync.Mutex
+	file      *os.File
+	name      string         // path of the open file, Path with the template expanded
+	size      int64          // bytes in the open file
+	periodEnd time.Time      // when the schedule starts a new file
+	mill      chan struct{}  // wakes the cleanup goroutine, nil when it is not running
+	hup       chan os.Signal // receives SIGHUP while ReopenOnSignal is in effect
+	buf       []byte         // entries not written yet, only used with BufferKB
+	timer     *time.Timer    // writes the buffer once FlushInterval is over
+	recorded  bool           // whether Flush and Close reach the sink
+}
+
+// Write implements io.Writer for entries without a known level
+func (s *FileSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter, rotating the file first if p
+// would take it past MaxSizeMB. Entries at error level and above are
+// written past the buffer and synced with SyncErrors.
+func (s *FileSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.open(); err != nil {
+		return 0, err
+	}
+	if s.schedule() != "" && !time.Now().Before(s.periodEnd) {
+		if err := s.rollover(); err != nil {
+			return 0, err
+		}
+	}
+	if size := s.size + int64(len(s.buf)); s.maxSize() > 0 && size > 0 && size+int64(len(p)) > s.maxSize() {
+		if err := s.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	urgent := baseLevel(lvl) >= zerolog.ErrorLevel && baseLevel(lvl) <= zerolog.PanicLevel
+	if s.BufferKB <= 0 {
+		n, err := s.file.Write(p)
+		s.size += int64(n)
+		if err == nil && s.syncs(urgent) {
+			err = s.file.Sync()
+		}
+		return n, err
+	}
+
+	limit := s.BufferKB * 1024
+	if len(s.buf) > 0 && len(s.buf)+len(p) > limit {
+		if err := s.flushLocked(false); err != nil {
+			return 0, err
+		}
+	}
+	s.buf = append(s.buf, p...)
+	if urgent || len(s.buf) >= limit {
+		if err := s.flushLocked(urgent); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if s.timer == nil {
+		interval := s.FlushInterval
+		if interval <= 0 {
+			interval = time.Second
+		}
+		s.timer = time.AfterFunc(interval, s.timedFlush)
+	}
+	return len(p), nil
+}
+
+// Flush writes the buffered entries to the file and, with a Sync policy,
+// syncs it
+func (s *FileSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.flushLocked(false); err != nil {
+		return err
+	}
+	if s.Sync != "" && s.file != nil {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+// timedFlush writes the buffer once its first entry waited FlushInterval
+func (s *FileSink) timedFlush() {
+	s.mu.Lock()
+	err := s.flushLocked(false)
+	s.mu.Unlock()
+	if err != nil {
+		std.state.Load().zl.Error().Err(err).Str("path", s.Path).Msg("logger: write log file")
+	}
+}
+
+// flushLocked writes the buffer to the open file, syncing it if urgent
+// calls for it. Callers must hold s.mu.
+func (s *FileSink) flushLocked(urgent bool) error {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.buf) == 0 {
+		return nil
+	}
+	n, err := s.file.Write(s.buf)
+	s.size += int64(n)
+	s.buf = s.buf[:0]
+	if err == nil && s.syncs(urgent) {
+		err = s.file.Sync()
+	}
+	return err
+}
+
+// syncs reports whether a write to the file is followed by a sync, urgent
+// for entries at error level and above
+func (s *FileSink) syncs(urgent bool) bool {
+	return s.Sync == SyncAlways || (urgent && s.Sync == SyncErrors)
+}
+
+// Rotate closes the current file, moves it aside and starts a new one
+func (s *FileSink) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.open(); err != nil {
+		return err
+	}
+	return s.rotate()
+}
+
+// Reopen closes the file and opens Path again, for use after an external
+// tool such as logrotate has moved the file away
+//
+//	/var/log/app.log {
+//		daily
+//		rotate 7
+//		create
+//		postrotate
+//			kill -HUP $(cat /run/app.pid)
+//		endscript
+//	}
+//
+// With the create directive logrotate renames the file and the next write
+// goes to the new one, so no entries are lost to copytruncate's race.
+// ReopenOnSignal calls Reopen on every SIGHUP.
+func (s *FileSink) Reopen() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		if err := s.flushLocked(false); err != nil {
+			return fmt.Errorf("logger: reopen %s: %w", s.name, err)
+		}
+		err := s.file.Close()
+		s.file = nil
+		if err != nil {
+			return fmt.Errorf("logger: reopen %s: %w", s.name, err)
+		}
+	}
+	return s.open()
+}
+
+// watchSignal reopens the file on every SIGHUP until ch is closed
+func (s *FileSink) watchSignal(ch <-chan os.Signal) {
+	for range ch {
+		if err := s.Reopen(); err != nil {
+			std.state.Load().zl.Error().Err(err).Str("path", s.Path).Msg("logger: reopen log file")
+		}
+	}
+}
+
+// Close writes the buffer, closes the file and stops the background
+// goroutines. A later write opens the file again.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.recorded {
+		forgetBuffered(s)
+		s.recorded = false
+	}
+
+	if s.mill != nil {
+		close(s.mill)
+		s.mill = nil
+	}
+	if s.hup != nil {
+		signal.Stop(s.hup)
+		close(s.hup)
+		s.hup = nil
+	}
+	if s.file == nil {
+		return nil
+	}
+	err := s.flushLocked(false)
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	s.file = nil
+	return err
+}
+
+// maxSize returns the size limit in bytes
+func (s *FileSink) maxSize() int64 {
+	return int64(s.MaxSizeMB) * 1024 * 1024
+}
+
+// templated reports whether Path is a file name template
+func (s *FileSink) templated() bool {
+	return strings.Contains(s.Path, "%")
+}
+
+// schedule returns the rotation schedule, derived from the template if
+// Schedule is empty
+func (s *FileSink) schedule() RotationSchedule {
+	switch {
+	case s.Schedule != "" || !s.templated():
+		return s.Schedule
+	case strings.Contains(s.Path, "%H"):
+		return RotateHourly
+	}
+	return RotateDaily
+}
+
+// open opens the log file for appending if it is not open yet
+// Callers must hold s.mu.
+func (s *FileSink) open() error {
+	if s.file != nil {
+		return nil
+	}
+	now := time.Now()
+	name := expandTemplate(s.Path, now)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("logger: open output: %w", err)
+	}
+	f, err := openFile(name)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("logger: open output: %w", err)
+	}
+	s.file, s.name, s.size = f, name, info.Size()
+
+	// A file left over from an earlier period is rotated on the next write
+	start := now
+	if info.Size() > 0 && !s.templated() {
+		start = info.ModTime()
+	}
+	s.periodEnd = s.schedule().next(start)
+
+	// Enforce the limits right away and then periodically, not only after
+	// this process rotates, so files left by earlier runs are purged too
+	if s.retains() {
+		s.startMill()
+	}
+	if s.ReopenOnSignal && s.hup == nil {
+		s.hup = make(chan os.Signal, 1)
+		notifyReload(s.hup)
+		go s.watchSignal(s.hup)
+	}
+	if s.BufferKB > 0 && !s.recorded {
+		recordBufferedLast(s)
+		s.recorded = true
+	}
+	return nil
+}
+
+// rollover ends the current period, switching to the next file name for
+// templates and rotating the file otherwise. Callers must hold s.mu.
+func (s *FileSink) rollover() error {
+	if !s.templated() {
+		return s.rotate()
+	}
+	if err := s.flushLocked(false); err != nil {
+		return fmt.Errorf("logger: rotate %s: %w", s.name, err)
+	}
+	err := s.file.Close()
+	s.file = nil
+	s.startMill()
+	if err != nil {
+		return fmt.Errorf("logger: rotate %s: %w", s.name, err)
+	}
+	return s.open()
+}
+
+// rotate renames the open file to a backup name and opens a fresh one
+// Callers must hold s.mu.
+func (s *FileSink) rotate() error {
+	if err := s.flushLocked(false); err != nil {
+		return fmt.Errorf("logger: rotate %s: %w", s.name, err)
+	}
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("logger: rotate %s: %w", s.name, err)
+	}
+	s.file = nil
+	if err := os.Rename(s.name, s.backupName(time.Now())); err != nil {
+		return fmt.Errorf("logger: rotate %s: %w", s.name, err)
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	s.startMill()
+	return nil
+}
+
+// backupName returns the name the open file is moved to when rotated at t
+func (s *FileSink) backupName(t time.Time) string {
+	ext := filepath.Ext(s.name)
+	return strings.TrimSuffix(s.name, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// startMill wakes the cleanup goroutine, starting it if needed
+// Callers must hold s.mu.
+func (s *FileSink) startMill() {
+	if s.mill == nil {
+		s.mill = make(chan struct{}, 1)
+		go s.runMill(s.mill)
+	}
+	select {
+	case s.mill <- struct{}{}:
+	default:
+	}
+}
+
+// runMill cleans up rotated files every time it is woken and every
+// CleanupInterval until ch is closed
+func (s *FileSink) runMill(ch <-chan struct{}) {
+	interval := s.CleanupInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	tick := time.NewTicker(interval)
+	defer tick.Stop()
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-tick.C:
+		}
+		s.cleanup()
+	}
+}
+
+// retains reports whether the sink has limits on its rotated files
+func (s *FileSink) retains() bool {
+	return s.MaxBackups > 0 || s.MaxAgeDays > 0 || s.MaxTotalSizeMB > 0 || s.Compress
+}
+
+// backup is a rotated file found next to the log file
+type backup struct {
+	path string
+	t    time.Time
+	size int64
+}
+
+// backups lists the rotated files and, for templates, the files of earlier
+// periods, newest first
+func (s *FileSink) backups() ([]backup, error) {
+	s.mu.Lock()
+	active := s.name
+	s.mu.Unlock()
+
+	dir, base := filepath.Split(s.Path)
+	if dir == "" {
+		dir = "."
+	}
+	ext := filepath.Ext(base)
+	stem, fields := templatePattern(strings.TrimSuffix(base, ext))
+	re := regexp.MustCompile("^" + stem + `(?:-(\d{4}-\d{2}-\d{2}T\d{2}-\d{2}-\d{2}\.\d{3}))?` + regexp.QuoteMeta(ext) + `(?:\.gz)?$`)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var found []backup
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		m := re.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil || filepath.Clean(path) == filepath.Clean(active) {
+			continue
+		}
+		var t time.Time
+		if stamp := m[len(m)-1]; stamp != "" {
+			t, err = time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		} else {
+			t, err = templateTime(fields, m[1:len(m)-1])
+		}
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		found = append(found, backup{path: path, t: t, size: info.Size()})
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].t.After(found[j].t) })
+	return found, nil
+}
+
+// expandTemplate replaces the %Y, %m, %d, %H and %% directives in tpl
+// with the corresponding parts of t
+func expandTemplate(tpl string, t time.Time) string {
+	if !strings.Contains(tpl, "%") {
+		return tpl
+	}
+	return strings.NewReplacer(
+		"%Y", fmt.Sprintf("%04d", t.Year()),
+		"%m", fmt.Sprintf("%02d", int(t.Month())),
+		"%d", fmt.Sprintf("%02d", t.Day()),
+		"%H", fmt.Sprintf("%02d", t.Hour()),
+		"%%", "%",
+	).Replace(tpl)
+}
+
+// templatePattern turns a template into a regular expression with one
+// group per directive, returned in order
+func templatePattern(tpl string) (string, []byte) {
+	var b strings.Builder
+	var fields []byte
+	for i := 0; i < len(tpl); i++ {
+		if tpl[i] != '%' || i+1 == len(tpl) {
+			b.WriteString(regexp.QuoteMeta(tpl[i : i+1]))
+			continue
+		}
+		i++
+		switch c := tpl[i]; c {
+		case 'Y':
+			b.WriteString(`(\d{4})`)
+			fields = append(fields, c)
+		case 'm', 'd', 'H':
+			b.WriteString(`(\d{2})`)
+			fields = append(fields, c)
+		default:
+			b.WriteString(regexp.QuoteMeta(tpl[i : i+1]))
+		}
+	}
+	return b.String(), fields
+}
+
+// templateTime rebuilds the time a templated file name was created for
+// from the values matched for each directive
+func templateTime(fields []byte, values []string) (time.Time, error) {
+	parts := make(map[byte]int, len(fields))
+	for i, f := range fields {
+		n, err := strconv.Atoi(values[i])
+		if err != nil {
+			return time.Time{}, err
+		}
+		parts[f] = n
+	}
+	return time.Date(parts['Y'], time.Month(parts['m']), parts['d'], parts['H'], 0, 0, 0, time.Local), nil
+}
+
+// cleanup removes rotated files beyond MaxBackups or older than
+// MaxAgeDays and compresses the rest if Compress is set
+// Files over limits are removed oldest first. Compression happens before
+// the total size is checked, so the limit applies to what stays on disk.
+func (s *FileSink) cleanup() {
+	files, err := s.backups()
+	if err != nil {
+		return
+	}
+
+	// The sink may be the global logger's own output; the mill goroutine
+	// does not hold s.mu, so logging from here cannot deadlock
+	log := std.state.Load().zl
+	var removed int
+	var freed int64
+	remove := func(b backup) {
+		if err := os.Remove(b.path); err != nil {
+			log.Error().Err(err).Str("path", b.path).Msg("logger: remove rotated file")
+			return
+		}
+		removed++
+		freed += b.size
+	}
+
+	var keep []backup
+	cutoff := time.Now().AddDate(0, 0, -s.MaxAgeDays)
+	for i, b := range files {
+		if (s.MaxBackups > 0 && i >= s.MaxBackups) || (s.MaxAgeDays > 0 && b.t.Before(cutoff)) {
+			remove(b)
+			continue
+		}
+		keep = append(keep, b)
+	}
+
+	if s.Compress {
+		for i, b := range keep {
+			if strings.HasSuffix(b.path, ".gz") {
+				continue
+			}
+			if err := compressFile(b.path, s.Verify); err != nil {
+				log.Error().Err(err).Str("path", b.path).Msg("logger: compress rotated file")
+				continue
+			}
+			keep[i].path += ".gz"
+			if info, err := os.Stat(keep[i].path); err == nil {
+				keep[i].size = info.Size()
+			}
+		}
+	}
+
+	if limit := int64(s.MaxTotalSizeMB) * 1024 * 1024; limit > 0 {
+		var total int64
+		for _, b := range keep {
+			total += b.size
+			if total > limit {
+				remove(b)
+			}
+		}
+	}
+
+	if removed > 0 {
+		log.Info().
+			Str("path", s.Path).
+			Int("files", removed).
+			Int64("bytes", freed).
+			Msg("logger: purged rotated log files")
+	}
+}
+
+// compressFile gzips path to path.gz and removes the original
+// The archive is written under a temporary name and renamed into place
+// once complete, so a crash never leaves a truncated .gz next to a
+// deleted original. With verify the archive is also decompressed and
+// checked against the original before that is removed.
+func compressFile(path string, verify bool) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".gz.tmp"
+	if err := writeGzip(tmp, src); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if verify {
+		if err := verifyGzip(tmp, info.Size()); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	src.Close()
+	return os.Remove(path)
+}
+
+// writeGzip compresses src into a new file at path and syncs it to disk
+func writeGzip(path string, src io.Reader) error {
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	zw := gzip.NewWriter(dst)
+	if _, err := io.Copy(zw, src); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := dst.Sync(); err != nil {
+		return err
+	}
+	return dst.Close()
+}
+
+// verifyGzip decompresses the archive at path, which checks its CRC, and
+// makes sure it holds size bytes
+func verifyGzip(path string, size int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("logger: verify %s: %w", path, err)
+	}
+	n, err := io.Copy(io.Discard, zr)
+	if err != nil {
+		return fmt.Errorf("logger: verify %s: %w", path, err)
+	}
+	if n != size {
+		return fmt.Errorf("logger: verify %s: archive holds %d bytes, want %d", path, n, size)
+	}
+	return nil
+}
+
+// validate checks the sink's settings
+func (s *FileSink) validate() error {
+	switch {
+	case s.Path == "":
+		return fmt.Errorf("logger: file sink path is empty")
+	case s.MaxSizeMB < 0 || s.MaxBackups < 0 || s.MaxAgeDays < 0 || s.MaxTotalSizeMB < 0:
+		return fmt.Errorf("logger: file sink %s: limits must not be negative", s.Path)
+	case s.BufferKB < 0 || s.FlushInterval < 0:
+		return fmt.Errorf("logger: file sink %s: buffer settings must not be negative", s.Path)
+	case s.Sync != "" && s.Sync != SyncErrors && s.Sync != SyncAlways:
+		return fmt.Errorf("logger: file sink %s: unknown sync policy %q: want error or always", s.Path, s.Sync)
+	case s.Schedule != "" && s.Schedule != RotateHourly && s.Schedule != RotateDaily:
+		return fmt.Errorf("logger: file sink %s: unknown schedule %q: want hourly or daily", s.Path, s.Schedule)
+	}
+	if !s.templated() {
+		return nil
+	}
+	dir, base := filepath.Split(s.Path)
+	if strings.Contains(dir, "%") {
+		return fmt.Errorf("logger: file sink %s: only the file name can be a template", s.Path)
+	}
+	for _, d := range []string{"%Y", "%m", "%d"} {
+		if !strings.Contains(base, d) {
+			return fmt.Errorf("logger: file sink %s: template needs %%Y, %%m and %%d", s.Path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,382 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// logFiles returns the names of the files in dir
+func logFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestFileSinkRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+	s := &FileSink{Path: filepath.Join(dir, "app.log"), MaxSizeMB: 1}
+	defer s.Close()
+	line := append(bytes.Repeat([]byte("x"), 1023), '\n')
+	for i := 0; i < 1500; i++ {
+		if _, err := s.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := logFiles(t, dir)
+	if len(names) != 2 {
+		t.Fatalf("got files %v, want app.log and one backup", names)
+	}
+	var total int64
+	for _, name := range names {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 1024*1024 {
+			t.Errorf("%s is %d bytes, over the limit", name, info.Size())
+		}
+		if info.Size()%int64(len(line)) != 0 {
+			t.Errorf("%s holds a partial entry", name)
+		}
+		total += info.Size()
+	}
+	if total != 1500*int64(len(line)) {
+		t.Errorf("files hold %d bytes, want %d", total, 1500*len(line))
+	}
+}
+
+func TestFileSinkAppendsAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	for _, entry := range []string{"first\n", "second\n"} {
+		s := &FileSink{Path: path}
+		if _, err := s.Write([]byte(entry)); err != nil {
+			t.Fatal(err)
+		}
+		s.Close()
+	}
+	if data, _ := os.ReadFile(path); string(data) != "first\nsecond\n" {
+		t.Errorf("got %q", data)
+	}
+}
+
+func TestFileSinkValidate(t *testing.T) {
+	for _, s := range []*FileSink{
+		{},
+		{Path: "app.log", MaxSizeMB: -1},
+		{Path: "app.log", BufferKB: -1},
+		{Path: "app.log", Sync: "sometimes"},
+		{Path: "app.log", Schedule: "weekly"},
+		{Path: "logs-%Y/app.log"},
+		{Path: "app-%Y%m.log"},
+	} {
+		if err := s.validate(); err == nil {
+			t.Errorf("%+v: no error", s)
+		}
+	}
+	if err := (&FileSink{Path: "app-%Y%m%d.log", MaxSizeMB: 10}).validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFileSinkBuffered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	s := &FileSink{Path: path, BufferKB: 1, FlushInterval: time.Hour, Sync: SyncErrors}
+	defer s.Close()
+	contents := func() string {
+		data, _ := os.ReadFile(path)
+		return string(data)
+	}
+
+	s.WriteLevel(zerolog.InfoLevel, []byte(`{"level":"info"}`+"\n"))
+	if got := contents(); got != "" {
+		t.Errorf("info entry written at once: %q", got)
+	}
+	// Error entries are written right away, after the ones before them
+	s.WriteLevel(zerolog.ErrorLevel, []byte(`{"level":"error"}`+"\n"))
+	head := `{"level":"info"}` + "\n" + `{"level":"error"}` + "\n"
+	if got := contents(); got != head {
+		t.Errorf("after an error entry got %q", got)
+	}
+
+	// A full buffer is written, never splitting an entry
+	line := append(bytes.Repeat([]byte("x"), 599), '\n')
+	s.Write(line)
+	s.Write(line)
+	if got := len(contents()); got != len(head)+600 {
+		t.Errorf("file holds %d bytes, want the first 600-byte entry added", got)
+	}
+	// Flush reaches the sink, the package-level one too
+	Flush()
+	if got := len(contents()); got != len(head)+1200 {
+		t.Errorf("file holds %d bytes after Flush, want both entries", got)
+	}
+
+	s.Write([]byte("last\n"))
+	s.Close()
+	if got := contents(); !strings.HasSuffix(got, "x\nlast\n") {
+		t.Errorf("Close left %q", got[len(got)-10:])
+	}
+}
+
+func TestFileSinkFlushInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	s := &FileSink{Path: path, BufferKB: 64, FlushInterval: 10 * time.Millisecond, Sync: SyncAlways}
+	defer s.Close()
+	s.Write([]byte("entry\n"))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if data, _ := os.ReadFile(path); string(data) == "entry\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("buffer not written within 5s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestFileSinkBufferConfig(t *testing.T) {
+	w, err := openOutputConfig(OutputConfig{Path: filepath.Join(t.TempDir(), "app.log"), BufferKB: 4, FlushMs: 250, Sync: "Error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, ok := w.(*FileSink)
+	if !ok {
+		t.Fatalf("opened %T, want a FileSink", w)
+	}
+	defer s.Close()
+	if s.BufferKB != 4 || s.FlushInterval != 250*time.Millisecond || s.Sync != SyncErrors {
+		t.Errorf("opened %+v", s)
+	}
+}
+
+func TestRotationScheduleNext(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, tt := range []struct {
+		name     string
+		schedule RotationSchedule
+		t, want  time.Time
+	}{
+		{"hourly", RotateHourly, time.Date(2026, 10, 15, 9, 41, 5, 7, ny), time.Date(2026, 10, 15, 10, 0, 0, 0, ny)},
+		{"daily", RotateDaily, time.Date(2026, 10, 15, 9, 41, 5, 0, ny), time.Date(2026, 10, 16, 0, 0, 0, 0, ny)},
+		// 01:00-02:00 happens twice when DST ends; the second 01:30 ends
+		// an hour later than the first
+		{"hourly at DST end", RotateHourly, time.Date(2026, 11, 1, 1, 30, 0, 0, ny).Add(time.Hour), time.Date(2026, 11, 1, 1, 30, 0, 0, ny).Add(90 * time.Minute)},
+		{"23 hour day", RotateDaily, time.Date(2026, 3, 8, 0, 0, 0, 0, ny), time.Date(2026, 3, 8, 0, 0, 0, 0, ny).Add(23 * time.Hour)},
+	} {
+		if got := tt.schedule.next(tt.t); !got.Equal(tt.want) {
+			t.Errorf("%s: next(%v) = %v, want %v", tt.name, tt.t, got, tt.want)
+		}
+	}
+	if got := RotationSchedule("").next(time.Now()); !got.IsZero() {
+		t.Errorf("no schedule: next = %v, want zero", got)
+	}
+}
+
+func TestFileSinkTemplate(t *testing.T) {
+	now := time.Date(2026, 10, 15, 9, 0, 0, 0, time.Local)
+	if got := expandTemplate("app-%Y%m%d-%H-100%%.log", now); got != "app-20261015-09-100%.log" {
+		t.Errorf("expandTemplate = %q", got)
+	}
+
+	dir := t.TempDir()
+	s := &FileSink{Path: filepath.Join(dir, "app-%Y%m%d.log")}
+	if got := s.schedule(); got != RotateDaily {
+		t.Errorf("schedule %q, want daily from the template", got)
+	}
+	if got := (&FileSink{Path: "app-%Y%m%d%H.log"}).schedule(); got != RotateHourly {
+		t.Errorf("schedule %q, want hourly from %%H", got)
+	}
+
+	// Files of earlier periods are found, newest first, next to the
+	// backups of a size rotation
+	for _, name := range []string{"app-20261013.log", "app-20261014.log.gz", "app-20261014-2026-10-14T12-00-00.000.log", "other.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Write([]byte("today\n")); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if _, err := os.Stat(filepath.Join(dir, expandTemplate("app-%Y%m%d.log", time.Now()))); err != nil {
+		t.Error(err)
+	}
+	found, err := s.backups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, b := range found {
+		got = append(got, filepath.Base(b.path))
+	}
+	want := "app-20261014-2026-10-14T12-00-00.000.log,app-20261014.log.gz,app-20261013.log"
+	if strings.Join(got, ",") != want {
+		t.Errorf("backups = %v, want %s", got, want)
+	}
+}
+
+func TestFileSinkRotatesOnSchedule(t *testing.T) {
+	dir := t.TempDir()
+	s := &FileSink{Path: filepath.Join(dir, "app.log"), Schedule: RotateHourly}
+	defer s.Close()
+	s.Write([]byte("last hour\n"))
+	s.mu.Lock()
+	s.periodEnd = time.Now().Add(-time.Second)
+	s.mu.Unlock()
+	s.Write([]byte("this hour\n"))
+
+	names := logFiles(t, dir)
+	if len(names) != 2 {
+		t.Fatalf("got files %v, want app.log and one backup", names)
+	}
+	for _, name := range names {
+		data, _ := os.ReadFile(filepath.Join(dir, name))
+		want := "last hour\n"
+		if name == "app.log" {
+			want = "this hour\n"
+		}
+		if string(data) != want {
+			t.Errorf("%s holds %q, want %q", name, data, want)
+		}
+	}
+}
+
+func TestCompressFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app-2026-10-14T12-00-00.000.log")
+	content := bytes.Repeat([]byte(`{"level":"info","message":"served"}`+"\n"), 100)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := compressFile(path, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("original still there: %v", err)
+	}
+	f, err := os.Open(path + ".gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := io.ReadAll(zr); err != nil || !bytes.Equal(got, content) {
+		t.Errorf("archive holds %d bytes, %v, want the original", len(got), err)
+	}
+	if err := verifyGzip(path+".gz", int64(len(content))+1); err == nil {
+		t.Error("verifyGzip: no error for a size mismatch")
+	}
+}
+
+func TestFileSinkCompressesRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	s := &FileSink{Path: filepath.Join(dir, "app.log"), Compress: true, Verify: true}
+	defer s.Close()
+	s.Write([]byte("rotated\n"))
+	if err := s.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	s.Write([]byte("current\n"))
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(5 * time.Millisecond) {
+		names := logFiles(t, dir)
+		if len(names) == 2 && strings.HasSuffix(names[0], ".log.gz") && names[1] == "app.log" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("files %v, want a compressed backup and app.log", names)
+		}
+	}
+}
+
+// writeBackups creates rotated files of app.log in dir, each size bytes
+// and rotated the given number of days ago
+func writeBackups(t *testing.T, dir string, size int, daysAgo ...int) {
+	t.Helper()
+	now := time.Now()
+	for _, days := range daysAgo {
+		name := "app-" + now.AddDate(0, 0, -days).Format(backupTimeFormat) + ".log"
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFileSinkRetention(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		sink *FileSink
+		keep []int // days ago of the backups left
+	}{
+		{"max backups", &FileSink{MaxBackups: 2}, []int{1, 2}},
+		{"max age", &FileSink{MaxAgeDays: 3}, []int{1, 2}},
+		{"max total size", &FileSink{MaxTotalSizeMB: 1}, []int{1, 2}},
+		{"all limits", &FileSink{MaxBackups: 3, MaxAgeDays: 30, MaxTotalSizeMB: 1}, []int{1, 2}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeBackups(t, dir, 400*1024, 1, 2, 5, 40)
+			s := tt.sink
+			s.Path = filepath.Join(dir, "app.log")
+			s.cleanup()
+
+			want := map[string]bool{}
+			for _, days := range tt.keep {
+				want["app-"+time.Now().AddDate(0, 0, -days).Format("2006-01-02")] = true
+			}
+			names := logFiles(t, dir)
+			if len(names) != len(tt.keep) {
+				t.Fatalf("kept %v, want %d files", names, len(tt.keep))
+			}
+			for _, name := range names {
+				if !want[name[:len("app-2006-01-02")]] {
+					t.Errorf("kept %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestFileSinkRetentionOnOpen(t *testing.T) {
+	cfg, c := TestConfig()
+	useGlobal(t, cfg)
+	dir := t.TempDir()
+	writeBackups(t, dir, 10, 1, 2, 3)
+	s := &FileSink{Path: filepath.Join(dir, "app.log"), MaxBackups: 1}
+	defer s.Close()
+	s.Write([]byte("entry\n"))
+	// Cleaning up files left by an earlier run is logged once done
+	for deadline := time.Now().Add(5 * time.Second); len(c.Entries()) == 0; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no cleanup logged")
+		}
+	}
+	if e := c.Entries()[0]; e["message"] != "logger: purged rotated log files" || e["files"] != 2.0 {
+		t.Errorf("logged %v, want 2 files purged", e)
+	}
+	if names := logFiles(t, dir); len(names) != 2 {
+		t.Errorf("files %v, want app.log and the newest backup", names)
+	}
+}
@@ -0,0 +1,55 @@
+package logger
+
+// FlagSet is the part of a flag set RegisterFlags needs
+// It is satisfied by *flag.FlagSet from the standard library and by
+// *pflag.FlagSet, which cobra commands expose through Flags() and
+// PersistentFlags().
+type FlagSet interface {
+	StringVar(p *string, name, value, usage string)
+	BoolVar(p *bool, name string, value bool, usage string)
+}
+
+// Flags holds the values of the logging flags added by RegisterFlags
+type Flags struct {
+	Level  string
+	Pretty bool
+	Caller bool
+	Output string
+}
+
+// RegisterFlags adds -log-level, -log-pretty, -log-caller and -log-output
+// to fs and returns the values they are parsed into
+//
+//	flags := logger.RegisterFlags(flag.CommandLine)
+//	flag.Parse()
+//	cfg, err := flags.Config()
+//
+// With cobra the same call works on cmd.PersistentFlags().
+// Flags left unset keep their zero value, so the Config they produce can be
+// layered over other sources with Layers.
+func RegisterFlags(fs FlagSet) *Flags {
+	f := &Flags{}
+	fs.StringVar(&f.Level, "log-level", "", "log level: debug, info, warn, error, fatal, panic (default info)")
+	fs.BoolVar(&f.Pretty, "log-pretty", false, "human-readable log output")
+	fs.BoolVar(&f.Caller, "log-caller", false, "include the caller in log entries")
+	fs.StringVar(&f.Output, "log-output", "", "log destination: stderr, stdout, split or a file path (default stderr)")
+	return f
+}
+
+// Config builds a Config from the parsed flags, opening the output file if
+// one was given
+func (f *Flags) Config() (Config, error) {
+	cfg := Config{
+		Level:      f.Level,
+		Pretty:     f.Pretty,
+		WithCaller: f.Caller,
+	}
+	if f.Output != "" {
+		w, err := openOutput(f.Output)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Output = w
+	}
+	return cfg, nil
+}
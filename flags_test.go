@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"flag"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestRegisterFlags(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	flags := RegisterFlags(fs)
+	if err := fs.Parse([]string{"-log-level", "debug", "-log-pretty", "-log-caller", "-log-output", "stdout"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := flags.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != "debug" || !cfg.Pretty || !cfg.WithCaller || cfg.Output != os.Stdout {
+		t.Errorf("got %+v", cfg)
+	}
+
+	// Unset flags leave the Config empty so it can be layered
+	empty, err := RegisterFlags(flag.NewFlagSet("app", flag.ContinueOnError)).Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Level != "" || empty.Output != nil || empty.Pretty {
+		t.Errorf("got %+v, want a zero Config", empty)
+	}
+
+	if _, err := (&Flags{Output: t.TempDir() + "/missing/app.log"}).Config(); err == nil {
+		t.Error("no error for an output that cannot be opened")
+	}
+}
@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// FluentdSink ships entries to fluentd or Fluent Bit with the forward
+// protocol, in batches from a background goroutine
+//
+//	sink := &logger.FluentdSink{Addr: "fluentd:24224", Tag: "app.api", RequireAck: true}
+//	defer sink.Close()
+//	logger.InitLogger(logger.Config{Output: sink})
+//
+// Each batch is sent in Forward mode as one MessagePack message with the
+// entries as records and their time as EventTime. With RequireAck the
+// aggregator must acknowledge every batch; a batch that is not
+// acknowledged within AckTimeout is sent again on a new connection, so
+// entries are delivered at least once.
+type FluentdSink struct {
+	Network       string        // tcp if empty, or unix
+	Addr          string        // Aggregator address, localhost:24224 if empty
+	Tag           string        // Tag of every entry, "app" if empty
+	RequireAck    bool          // Ask for and wait for an ack of each batch
+	AckTimeout    time.Duration // How long to wait for an ack, 10s if zero
+	BatchSize     int           // Entries per message, 100 if zero
+	FlushInterval time.Duration // Longest wait before sending, 5s if zero
+	QueueWait     time.Duration // Longest a log call waits for room in a full queue, none if zero
+	OnError       func(error)   // Called when entries are lost, stderr if nil
+
+	setup sync.Once
+	batch entryBatcher
+	conn  net.Conn // owned by the batcher goroutine
+	r     *bufio.Reader
+}
+
+// Write implements io.Writer for entries without a known level
+func (s *FluentdSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *FluentdSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if err := s.batcher().add(lvl, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush sends the queued entries and waits until they are delivered
+func (s *FluentdSink) Flush() error {
+	s.batcher().flush()
+	return nil
+}
+
+// Close sends the queued entries, stops the background goroutine and
+// closes the connection
+func (s *FluentdSink) Close() error {
+	s.batcher().close()
+	if s.conn != nil {
+		return s.conn.Close()
+	}
+	return nil
+}
+
+// batcher returns the sink's batcher, configured from its fields on
+// first use
+func (s *FluentdSink) batcher() *entryBatcher {
+	s.setup.Do(func() {
+		s.batch = entryBatcher{send: s.send, size: s.BatchSize, interval: s.FlushInterval, wait: s.QueueWait, onError: s.OnError}
+	})
+	return &s.batch
+}
+
+// send writes one batch in Forward mode and waits for its ack if
+// required. Any failure drops the connection so the retry starts afresh.
+func (s *FluentdSink) send(batch []batchEntry) error {
+	if err := s.forward(batch); err != nil {
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn, s.r = nil, nil
+		}
+		return fmt.Errorf("logger: fluentd: %w", err)
+	}
+	return nil
+}
+
+// forward connects if needed and sends batch as one message
+func (s *FluentdSink) forward(batch []batchEntry) error {
+	if s.conn == nil {
+		network, addr := s.Network, s.Addr
+		if network == "" {
+			network = "tcp"
+		}
+		if addr == "" {
+			addr = "localhost:24224"
+		}
+		conn, err := net.DialTimeout(network, addr, 5*time.Second)
+		if err != nil {
+			return err
+		}
+		s.conn, s.r = conn, bufio.NewReader(conn)
+	}
+
+	tag := s.Tag
+	if tag == "" {
+		tag = "app"
+	}
+	var enc msgpackEncoder
+	enc.arrayHeader(3)
+	enc.str(tag)
+	enc.arrayHeader(len(batch))
+	for _, e := range batch {
+		_, record := decodeEntry(e.data)
+		t := e.time
+		if parsed, ok := sinkTime(record[zerolog.TimestampFieldName]); ok {
+			t = parsed
+			delete(record, zerolog.TimestampFieldName)
+		}
+		if _, ok := record[zerolog.LevelFieldName]; !ok && e.level != zerolog.NoLevel {
+			record[zerolog.LevelFieldName] = LevelString(e.level)
+		}
+		enc.arrayHeader(2)
+		enc.eventTime(t)
+		enc.value(record)
+	}
+	option := map[string]interface{}{"size": len(batch)}
+	var chunk string
+	if s.RequireAck {
+		var id [16]byte
+		rand.Read(id[:])
+		chunk = base64.StdEncoding.EncodeToString(id[:])
+		option["chunk"] = chunk
+	}
+	enc.value(option)
+
+	timeout := s.AckTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	s.conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := s.conn.Write(enc.buf); err != nil {
+		return err
+	}
+	if !s.RequireAck {
+		return nil
+	}
+	resp, err := readMsgpackStringMap(s.r)
+	if err != nil {
+		return fmt.Errorf("ack: %w", err)
+	}
+	if resp["ack"] != chunk {
+		return fmt.Errorf("ack for chunk %q, want %q", resp["ack"], chunk)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+// fluentdMessage is one Forward mode message as a fake aggregator read it
+type fluentdMessage struct {
+	tag     string
+	entries []interface{}
+	option  map[string]interface{}
+}
+
+// fluentdListener accepts forward connections, sending each message it
+// reads to the returned channel. The first ackAfter connections are
+// closed without acknowledging their message, the others ack it.
+func fluentdListener(t *testing.T, ackAfter int) (string, <-chan fluentdMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	msgs := make(chan fluentdMessage, 16)
+	go func() {
+		for n := 0; ; n++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					v, err := readMsgpackValue(r)
+					if err != nil {
+						return
+					}
+					a, _ := v.([]interface{})
+					if len(a) != 3 {
+						t.Errorf("message %v, want [tag, entries, option]", v)
+						return
+					}
+					tag, _ := a[0].(string)
+					entries, _ := a[1].([]interface{})
+					option, _ := a[2].(map[string]interface{})
+					msgs <- fluentdMessage{tag, entries, option}
+					if n < ackAfter {
+						return
+					}
+					if chunk, ok := option["chunk"].(string); ok {
+						var enc msgpackEncoder
+						enc.value(map[string]interface{}{"ack": chunk})
+						conn.Write(enc.buf)
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), msgs
+}
+
+func TestFluentdSinkForward(t *testing.T) {
+	addr, msgs := fluentdListener(t, 0)
+	s := &FluentdSink{Addr: addr, Tag: "app.api"}
+	s.Write([]byte(`{"level":"info","time":"2026-10-15T01:02:03.5Z","status":200,"message":"served"}` + "\n"))
+	s.Close()
+
+	m := <-msgs
+	if m.tag != "app.api" || m.option["size"] != int64(1) || m.option["chunk"] != nil {
+		t.Errorf("tag %q, option %v", m.tag, m.option)
+	}
+	entry, _ := m.entries[0].([]interface{})
+	if len(entry) != 2 {
+		t.Fatalf("entry %v, want [time, record]", m.entries[0])
+	}
+	if at, _ := entry[0].(time.Time); !at.Equal(time.Date(2026, 10, 15, 1, 2, 3, 5e8, time.UTC)) {
+		t.Errorf("event time %v", entry[0])
+	}
+	record, _ := entry[1].(map[string]interface{})
+	if len(record) != 3 || record["level"] != "info" || record["message"] != "served" || record["status"] != int64(200) {
+		t.Errorf("record %v", record)
+	}
+}
+
+func TestFluentdSinkResendsUnacknowledged(t *testing.T) {
+	addr, msgs := fluentdListener(t, 1)
+	var errs []error
+	s := &FluentdSink{Addr: addr, RequireAck: true, AckTimeout: time.Second, OnError: func(err error) { errs = append(errs, err) }}
+	s.Write([]byte(`{"message":"once"}` + "\n"))
+	s.Close()
+
+	first, second := <-msgs, <-msgs
+	for _, m := range []fluentdMessage{first, second} {
+		entry, _ := m.entries[0].([]interface{})
+		if chunk, _ := m.option["chunk"].(string); chunk == "" || len(entry) != 2 {
+			t.Fatalf("message %v, want an entry and a chunk to ack", m)
+		}
+		if record, _ := entry[1].(map[string]interface{}); record["message"] != "once" {
+			t.Errorf("record %v, want the unacknowledged batch sent again", record)
+		}
+	}
+	if len(errs) != 0 {
+		t.Errorf("reported %v, want the batch delivered", errs)
+	}
+}
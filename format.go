@@ -0,0 +1,256 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// formats maps each output format to the function wrapping a writer so it
+// receives entries in that format. json is zerolog's own output.
+var formats = map[string]func(w io.Writer, cfg Config) io.Writer{
+	"json":     func(w io.Writer, _ Config) io.Writer { return w },
+	"pretty":   consoleWriter,
+	"expanded": expandedWriter,
+	"logfmt":   logfmtWriter,
+	"cbor":     cborWriter,
+	"ecs":      ecsWriter,
+	"logstash": logstashWriter,
+	"otel":     otelWriter,
+	"gcp":      gcpWriter,
+	"cef":      cefWriter,
+	"rfc5424":  rfc5424Writer,
+	"msgpack":  msgpackWriter,
+	"protobuf": protobufWriter,
+	"common":   commonLogWriter,
+	"combined": combinedLogWriter,
+	"csv":      csvWriter,
+}
+
+// formatAliases maps alternative format names to their formats
+var formatAliases = map[string]string{
+	"console": "pretty",
+	"clf":     "common",
+}
+
+// parseFormat returns the canonical name of an output format
+func parseFormat(name string) (string, error) {
+	name = strings.ToLower(name)
+	if alias, ok := formatAliases[name]; ok {
+		name = alias
+	}
+	if _, ok := formats[name]; !ok {
+		names := make([]string, 0, len(formats))
+		for n := range formats {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("unknown format %q: want %s", name, strings.Join(names, ", "))
+	}
+	return name, nil
+}
+
+// formatWriter wraps w so it receives entries in the named format, json
+// if the name is empty or unknown
+func formatWriter(w io.Writer, name string, cfg Config) io.Writer {
+	name, err := parseFormat(name)
+	if err != nil {
+		return w // rejected by Validate
+	}
+	return formats[name](w, cfg)
+}
+
+// format returns the output format cfg asks for: Format if set, otherwise
+// pretty or json depending on Pretty
+func (cfg Config) format() string {
+	switch {
+	case cfg.Format != "":
+		return cfg.Format
+	case cfg.Pretty:
+		return "pretty"
+	}
+	return "json"
+}
+
+// encodingWriter rewrites the JSON entries zerolog produces in another
+// format before passing them on to w, keeping their level for writers that
+// route or filter on it
+type encodingWriter struct {
+	w      io.Writer
+	encode func(dst []byte, lvl zerolog.Level, entry []byte) []byte
+}
+
+// encodePool holds the buffers entries are encoded into
+var encodePool = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// Write implements io.Writer for entries without a known level
+func (e *encodingWriter) Write(p []byte) (int, error) {
+	return e.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (e *encodingWriter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	buf := encodePool.Get().(*[]byte)
+	defer encodePool.Put(buf)
+	*buf = e.encode((*buf)[:0], lvl, p)
+
+	var err error
+	if lw, ok := e.w.(zerolog.LevelWriter); ok {
+		_, err = lw.WriteLevel(lvl, *buf)
+	} else {
+		_, err = e.w.Write(*buf)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// entryLevel returns the level of an entry, read from its level field
+// when the writer was not told
+func entryLevel(lvl zerolog.Level, fields map[string]interface{}) zerolog.Level {
+	if lvl != zerolog.NoLevel {
+		return lvl
+	}
+	if name, ok := fields[zerolog.LevelFieldName].(string); ok {
+		if l, err := ParseLevel(name); err == nil {
+			return l
+		}
+	}
+	return zerolog.NoLevel
+}
+
+// lineLevel returns lvl, or the level in the entry's level field if lvl
+// is NoLevel, as for entries that reach a sink through plain Write
+func lineLevel(lvl zerolog.Level, line []byte) zerolog.Level {
+	if lvl != zerolog.NoLevel {
+		return lvl
+	}
+	_, fields := decodeEntry(line)
+	return entryLevel(lvl, fields)
+}
+
+// entryTime parses an entry's time field written in the given time format,
+// which may be one of zerolog's Unix formats
+func entryTime(v interface{}, format string) (time.Time, bool) {
+	switch v := v.(type) {
+	case string:
+		if format == "" {
+			format = time.RFC3339
+		}
+		t, err := time.Parse(format, v)
+		return t, err == nil
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		switch format {
+		case zerolog.TimeFormatUnix:
+			return time.Unix(n, 0), true
+		case zerolog.TimeFormatUnixMs:
+			return time.UnixMilli(n), true
+		case zerolog.TimeFormatUnixMicro:
+			return time.UnixMicro(n), true
+		case zerolog.TimeFormatUnixNano:
+			return time.Unix(0, n), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sinkTime parses the time field of an entry reaching a sink, which any
+// logger may have written: in RFC3339, or in a layout a logger was
+// configured with. A number is a Unix time in the unit its size suggests.
+func sinkTime(v interface{}) (time.Time, bool) {
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			break
+		}
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+		for _, layout := range configuredTimeFormats() {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			break
+		}
+		switch abs := max(n, -n); {
+		case abs < 1e11:
+			return time.Unix(n, 0), true
+		case abs < 1e14:
+			return time.UnixMilli(n), true
+		case abs < 1e17:
+			return time.UnixMicro(n), true
+		default:
+			return time.Unix(0, n), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// jsonObject builds a JSON object with its keys in the order they are
+// added, for formats whose consumers expect certain fields first
+type jsonObject struct {
+	buf []byte
+}
+
+// add appends the field key with the JSON encoding of v
+func (o *jsonObject) add(key string, v interface{}) {
+	if len(o.buf) == 0 {
+		o.buf = append(o.buf, '{')
+	} else {
+		o.buf = append(o.buf, ',')
+	}
+	o.buf = appendJSON(o.buf, key)
+	o.buf = append(o.buf, ':')
+	o.buf = appendJSON(o.buf, v)
+}
+
+// addSorted appends the fields in key order
+func (o *jsonObject) addSorted(fields map[string]interface{}) {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		o.add(k, fields[k])
+	}
+}
+
+// line appends the closed object and a newline to dst
+func (o *jsonObject) line(dst []byte) []byte {
+	if len(o.buf) == 0 {
+		return append(dst, "{}\n"...)
+	}
+	dst = append(dst, o.buf...)
+	return append(dst, '}', '\n')
+}
+
+// appendJSON appends the JSON encoding of v to dst without escaping HTML
+// characters, as zerolog does. Values JSON cannot represent are written as
+// their string form.
+func appendJSON(dst []byte, v interface{}) []byte {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		b.Reset()
+		enc.Encode(fmt.Sprint(v))
+	}
+	return append(dst, bytes.TrimSuffix(b.Bytes(), []byte("\n"))...)
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSinkTime(t *testing.T) {
+	addTimeFormat("02/01/2006 15:04:05")
+	want := time.Date(2026, 10, 15, 1, 2, 3, 0, time.UTC)
+	for _, tt := range []struct {
+		name string
+		v    interface{}
+		want time.Time
+	}{
+		{"rfc3339", "2026-10-15T01:02:03Z", want},
+		{"rfc3339 nano", "2026-10-15T01:02:03.5Z", want.Add(500 * time.Millisecond)},
+		{"configured layout", "15/10/2026 01:02:03", want},
+		{"unix", json.Number("1792026123"), want},
+		{"unix ms", json.Number("1792026123000"), want},
+		{"unix micro", json.Number("1792026123000000"), want},
+		{"unix nano", json.Number("1792026123000000000"), want},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sinkTime(tt.v)
+			if !ok || !got.Equal(tt.want) {
+				t.Errorf("sinkTime(%v) = %v, %v, want %v", tt.v, got, ok, tt.want)
+			}
+		})
+	}
+	for _, v := range []interface{}{"", "yesterday", json.Number("1.5"), nil} {
+		if got, ok := sinkTime(v); ok {
+			t.Errorf("sinkTime(%v) = %v, want no time", v, got)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Special fields of Cloud Logging's structured JSON
+const (
+	gcpTraceField          = "logging.googleapis.com/trace"
+	gcpSpanField           = "logging.googleapis.com/spanId"
+	gcpSampledField        = "logging.googleapis.com/trace_sampled"
+	gcpSourceLocationField = "logging.googleapis.com/sourceLocation"
+)
+
+// GCPSink writes entries to Google Cloud Logging through the entries.write
+// API, in batches from a background goroutine
+//
+//	src, _ := google.DefaultTokenSource(ctx, "https://www.googleapis.com/auth/logging.write")
+//	sink := &logger.GCPSink{
+//		ProjectID: "my-project",
+//		LogName:   "api",
+//		Token: func() (string, error) {
+//			t, err := src.Token()
+//			if err != nil {
+//				return "", err
+//			}
+//			return t.AccessToken, nil
+//		},
+//	}
+//	defer sink.Close()
+//
+// Levels map to Cloud Logging severities: trace and debug to DEBUG, info
+// to INFO, warn to WARNING, error to ERROR, fatal to CRITICAL and panic to
+// ALERT. The caller becomes the sourceLocation, and trace_id, span_id and
+// trace_sampled (or trace_flags) fields become the entry's trace, spanId
+// and traceSampled, so logs correlate with Cloud Trace. The remaining fields are sent as
+// jsonPayload.
+type GCPSink struct {
+	ProjectID     string                 // Project the logs belong to, required
+	LogName       string                 // Log ID within the project, "app" if empty
+	Resource      map[string]interface{} // Monitored resource, {"type": "global"} if nil
+	Labels        map[string]string      // Labels added to every entry
+	Token         func() (string, error) // Returns an OAuth2 access token, required
+	Client        *http.Client           // HTTP client, http.DefaultClient if nil
+	Endpoint      string                 // API URL, the public entries.write endpoint if empty
+	BatchSize     int                    // Entries per request, 100 if zero
+	FlushInterval time.Duration          // Longest wait before sending, 5s if zero
+	QueueWait     time.Duration          // Longest a log call waits for room in a full queue, none if zero
+	OnError       func(error)            // Called when entries are lost, stderr if nil
+
+	setup sync.Once
+	batch entryBatcher
+}
+
+// gcpEndpoint is the Cloud Logging entries.write URL
+const gcpEndpoint = "https://logging.googleapis.com/v2/entries:write"
+
+// Write implements io.Writer for entries without a known level
+func (s *GCPSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *GCPSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if err := s.batcher().add(lvl, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush sends the queued entries and waits until they are delivered
+func (s *GCPSink) Flush() error {
+	s.batcher().flush()
+	return nil
+}
+
+// Close sends the queued entries and stops the background goroutine
+func (s *GCPSink) Close() error {
+	s.batcher().close()
+	return nil
+}
+
+// batcher returns the sink's batcher, configured from its fields on
+// first use
+func (s *GCPSink) batcher() *entryBatcher {
+	s.setup.Do(func() {
+		s.batch = entryBatcher{send: s.send, size: s.BatchSize, interval: s.FlushInterval, wait: s.QueueWait, onError: s.OnError}
+	})
+	return &s.batch
+}
+
+// send posts one batch to entries.write
+func (s *GCPSink) send(batch []batchEntry) error {
+	if s.ProjectID == "" || s.Token == nil {
+		return permanentError{fmt.Errorf("logger: gcp: ProjectID and Token are required")}
+	}
+	logName := s.LogName
+	if logName == "" {
+		logName = "app"
+	}
+	resource := s.Resource
+	if resource == nil {
+		resource = map[string]interface{}{"type": "global"}
+	}
+	entries := make([]map[string]interface{}, len(batch))
+	for i, e := range batch {
+		entries[i] = gcpLogEntry(e, s.ProjectID)
+	}
+	req := map[string]interface{}{
+		"logName":        "projects/" + s.ProjectID + "/logs/" + url.PathEscape(logName),
+		"resource":       resource,
+		"entries":        entries,
+		"partialSuccess": true,
+	}
+	if len(s.Labels) > 0 {
+		req["labels"] = s.Labels
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return permanentError{err}
+	}
+
+	token, err := s.Token()
+	if err != nil {
+		return fmt.Errorf("logger: gcp: token: %w", err)
+	}
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = gcpEndpoint
+	}
+	post, err := http.NewRequestWithContext(context.Background(), http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	post.Header.Set("Content-Type", "application/json")
+	post.Header.Set("Authorization", "Bearer "+token)
+	if err := doHTTP(s.Client, post); err != nil {
+		return fmt.Errorf("logger: gcp: %w", err)
+	}
+	return nil
+}
+
+// gcpLogEntry turns a queued entry into a Cloud Logging LogEntry
+func gcpLogEntry(e batchEntry, project string) map[string]interface{} {
+	_, fields := decodeEntry(e.data)
+	t := e.time
+	if parsed, ok := sinkTime(fields[zerolog.TimestampFieldName]); ok {
+		t = parsed
+		delete(fields, zerolog.TimestampFieldName)
+	}
+	special := gcpSpecialFields(e.level, fields, project)
+
+	entry := map[string]interface{}{
+		"severity":    special[zerolog.LevelFieldName],
+		"timestamp":   t.UTC().Format(time.RFC3339Nano),
+		"jsonPayload": fields,
+	}
+	for from, to := range map[string]string{
+		gcpTraceField:          "trace",
+		gcpSpanField:           "spanId",
+		gcpSampledField:        "traceSampled",
+		gcpSourceLocationField: "sourceLocation",
+	} {
+		if v, ok := special[from]; ok {
+			entry[to] = v
+		}
+	}
+	return entry
+}
+
+// decodeEntry decodes a JSON entry, keeping numbers exact. A line that is
+// not a JSON object becomes the message field.
+func decodeEntry(line []byte) (string, map[string]interface{}) {
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil || fields == nil {
+		return string(line), map[string]interface{}{zerolog.MessageFieldName: string(line)}
+	}
+	msg, _ := fields[zerolog.MessageFieldName].(string)
+	return msg, fields
+}
+
+// gcpSpecialFields removes the level, caller and trace fields from fields
+// and returns them under zerolog's level name and Cloud Logging's special
+// field names, in the form Cloud Logging expects
+func gcpSpecialFields(lvl zerolog.Level, fields map[string]interface{}, project string) map[string]interface{} {
+	special := make(map[string]interface{}, 6)
+	if lvl == zerolog.NoLevel {
+		if name, ok := fields[zerolog.LevelFieldName].(string); ok {
+			if l, err := ParseLevel(name); err == nil {
+				lvl = l
+			}
+		}
+	}
+	special[zerolog.LevelFieldName] = gcpSeverity(lvl)
+	delete(fields, zerolog.LevelFieldName)
+	if caller, ok := fields[zerolog.CallerFieldName].(string); ok {
+		special[gcpSourceLocationField] = gcpSourceLocation(caller)
+		delete(fields, zerolog.CallerFieldName)
+	}
+	if id, ok := fields["trace_id"].(string); ok && id != "" {
+		trace := id
+		if project != "" {
+			trace = "projects/" + project + "/traces/" + id
+		}
+		special[gcpTraceField] = trace
+		delete(fields, "trace_id")
+	}
+	if id, ok := fields["span_id"].(string); ok && id != "" {
+		special[gcpSpanField] = id
+		delete(fields, "span_id")
+	}
+	if sampled, ok := fields["trace_sampled"].(bool); ok {
+		special[gcpSampledField] = sampled
+		delete(fields, "trace_sampled")
+	} else if flags, ok := fields["trace_flags"].(string); ok {
+		if f, err := strconv.ParseUint(flags, 16, 8); err == nil {
+			special[gcpSampledField] = f&1 == 1
+			delete(fields, "trace_flags")
+		}
+	}
+	// Entries may already use the special names, which take precedence
+	for _, name := range []string{gcpTraceField, gcpSpanField, gcpSampledField, gcpSourceLocationField} {
+		if v, ok := fields[name]; ok {
+			special[name] = v
+			delete(fields, name)
+		}
+	}
+	return special
+}
+
+// gcpWriter wraps w so entries are written as the structured JSON Cloud
+// Logging's agents parse on GKE, Cloud Run and Cloud Functions:
+//
+//	{"severity":"ERROR","message":"charge failed","time":"2024-05-01T12:00:00Z",
+//		"logging.googleapis.com/sourceLocation":{"file":"billing.go","line":"42"},
+//		"logging.googleapis.com/trace":"projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+//		"component":"billing","error":"card declined"}
+//
+// Levels, the caller and the trace fields are mapped as for GCPSink. A
+// trace is qualified with the project named by GOOGLE_CLOUD_PROJECT, which
+// Cloud Logging needs to link the entry to Cloud Trace.
+func gcpWriter(w io.Writer, cfg Config) io.Writer {
+	project := os.Getenv("GOOGLE_CLOUD_PROJECT")
+	return &encodingWriter{w: w, encode: func(dst []byte, lvl zerolog.Level, entry []byte) []byte {
+		return appendGCP(dst, lvl, entry, cfg.TimeFormat, project)
+	}}
+}
+
+// appendGCP appends entry, a zerolog JSON entry, in Cloud Logging's
+// structured form to dst
+func appendGCP(dst []byte, lvl zerolog.Level, entry []byte, timeFormat, project string) []byte {
+	msg, fields := decodeEntry(entry)
+	delete(fields, zerolog.MessageFieldName)
+	t, hasTime := entryTime(fields[zerolog.TimestampFieldName], timeFormat)
+	if hasTime {
+		delete(fields, zerolog.TimestampFieldName)
+	}
+	special := gcpSpecialFields(lvl, fields, project)
+
+	var o jsonObject
+	o.add("severity", special[zerolog.LevelFieldName])
+	o.add("message", msg)
+	if hasTime {
+		o.add("time", t.UTC().Format(time.RFC3339Nano))
+	}
+	for _, name := range []string{gcpSourceLocationField, gcpTraceField, gcpSpanField, gcpSampledField} {
+		if v, ok := special[name]; ok {
+			o.add(name, v)
+		}
+	}
+	o.addSorted(fields)
+	return o.line(dst)
+}
+
+// gcpSeverity maps a zerolog level to a Cloud Logging severity
+func gcpSeverity(lvl zerolog.Level) string {
+	switch baseLevel(lvl) {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return "DEBUG"
+	case zerolog.InfoLevel:
+		return "INFO"
+	case zerolog.WarnLevel:
+		return "WARNING"
+	case zerolog.ErrorLevel:
+		return "ERROR"
+	case zerolog.FatalLevel:
+		return "CRITICAL"
+	case zerolog.PanicLevel:
+		return "ALERT"
+	}
+	return "DEFAULT"
+}
+
+// gcpSourceLocation splits a file:line caller into a LogEntrySourceLocation
+func gcpSourceLocation(caller string) map[string]interface{} {
+	loc := map[string]interface{}{"file": caller}
+	if i := strings.LastIndexByte(caller, ':'); i > 0 {
+		if line, err := strconv.Atoi(caller[i+1:]); err == nil {
+			loc["file"], loc["line"] = caller[:i], strconv.Itoa(line)
+		}
+	}
+	return loc
+}
@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestGCPSeverity(t *testing.T) {
+	for lvl, want := range map[zerolog.Level]string{
+		zerolog.TraceLevel: "DEBUG",
+		zerolog.DebugLevel: "DEBUG",
+		zerolog.InfoLevel:  "INFO",
+		zerolog.WarnLevel:  "WARNING",
+		zerolog.ErrorLevel: "ERROR",
+		zerolog.FatalLevel: "CRITICAL",
+		zerolog.PanicLevel: "ALERT",
+		zerolog.NoLevel:    "DEFAULT",
+	} {
+		if got := gcpSeverity(lvl); got != want {
+			t.Errorf("gcpSeverity(%s) = %s, want %s", lvl, got, want)
+		}
+	}
+}
+
+func TestGCPSourceLocation(t *testing.T) {
+	for caller, want := range map[string]string{
+		"billing/charge.go:42": `{"file":"billing/charge.go","line":"42"}`,
+		"C:/src/main.go:7":     `{"file":"C:/src/main.go","line":"7"}`,
+		"main.go":              `{"file":"main.go"}`,
+	} {
+		got, _ := json.Marshal(gcpSourceLocation(caller))
+		if string(got) != want {
+			t.Errorf("gcpSourceLocation(%q) = %s, want %s", caller, got, want)
+		}
+	}
+}
+
+func TestGCPSink(t *testing.T) {
+	var mu sync.Mutex
+	var requests []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer ya29.token" {
+			t.Errorf("Authorization %q", got)
+		}
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s := &GCPSink{
+		ProjectID: "acme",
+		LogName:   "api/v1",
+		Labels:    map[string]string{"env": "prod"},
+		Token:     func() (string, error) { return "ya29.token", nil },
+		Endpoint:  srv.URL,
+		BatchSize: 10,
+	}
+	s.WriteLevel(zerolog.ErrorLevel, []byte(`{"level":"error","time":"2026-10-15T01:02:03Z","caller":"billing.go:42","trace_id":"4bf92f3577b34da6","span_id":"00f067aa0ba902b7","trace_flags":"01","component":"billing","message":"charge failed"}`+"\n"))
+	s.Write([]byte(`{"level":"warn","message":"slow"}` + "\n"))
+	s.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 1 {
+		t.Fatalf("%d requests, want 1", len(requests))
+	}
+	req := requests[0]
+	if req["logName"] != "projects/acme/logs/api%2Fv1" {
+		t.Errorf("logName %v", req["logName"])
+	}
+	if got, _ := json.Marshal(req["resource"]); string(got) != `{"type":"global"}` {
+		t.Errorf("resource %s", got)
+	}
+	if got, _ := json.Marshal(req["labels"]); string(got) != `{"env":"prod"}` {
+		t.Errorf("labels %s", got)
+	}
+	entries, _ := req["entries"].([]interface{})
+	if len(entries) != 2 {
+		t.Fatalf("%d entries, want 2", len(entries))
+	}
+	got, _ := json.Marshal(entries[0])
+	want := `{"jsonPayload":{"component":"billing","message":"charge failed"},"severity":"ERROR","sourceLocation":{"file":"billing.go","line":"42"},"spanId":"00f067aa0ba902b7","timestamp":"2026-10-15T01:02:03Z","trace":"projects/acme/traces/4bf92f3577b34da6","traceSampled":true}`
+	if string(got) != want {
+		t.Errorf("entry\n%s\nwant\n%s", got, want)
+	}
+	// Without a level from the logger, the entry's level field is used
+	if e, _ := entries[1].(map[string]interface{}); e["severity"] != "WARNING" {
+		t.Errorf("second entry severity %v, want WARNING", e["severity"])
+	}
+}
+
+func TestGCPSinkRequiresProject(t *testing.T) {
+	var errs []string
+	s := &GCPSink{OnError: func(err error) { errs = append(errs, err.Error()) }}
+	s.Write([]byte(`{"message":"lost"}` + "\n"))
+	s.Close()
+	if len(errs) != 1 || !strings.Contains(errs[0], "ProjectID and Token are required") {
+		t.Errorf("reported %q", errs)
+	}
+}
+
+func TestAppendGCP(t *testing.T) {
+	entry := `{"level":"error","time":"2026-10-15T01:02:03Z","caller":"billing.go:42","trace_id":"4bf92f35","span_id":"00f067aa","trace_sampled":true,"component":"billing","message":"charge failed"}`
+	want := `{"severity":"ERROR","message":"charge failed","time":"2026-10-15T01:02:03Z","logging.googleapis.com/sourceLocation":{"file":"billing.go","line":"42"},"logging.googleapis.com/trace":"projects/acme/traces/4bf92f35","logging.googleapis.com/spanId":"00f067aa","logging.googleapis.com/trace_sampled":true,"component":"billing"}` + "\n"
+	if got := string(appendGCP(nil, zerolog.ErrorLevel, []byte(entry), "", "acme")); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Without a project the trace ID is kept as it is
+	got := string(appendGCP(nil, zerolog.NoLevel, []byte(`{"level":"warn","trace_id":"4bf92f35","message":"slow"}`), "", ""))
+	if want := `{"severity":"WARNING","message":"slow","logging.googleapis.com/trace":"4bf92f35"}` + "\n"; got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGCPFormat(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "acme")
+	var buf strings.Builder
+	l, err := New(Config{Output: &buf, Format: "gcp", NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("served", "trace_id", "4bf92f35")
+	if want := `{"severity":"INFO","message":"served","logging.googleapis.com/trace":"projects/acme/traces/4bf92f35"}` + "\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
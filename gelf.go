@@ -0,0 +1,246 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// GELFCompression selects how GELF messages sent over UDP are compressed
+type GELFCompression int
+
+// Supported GELF compressions
+const (
+	GELFGzip GELFCompression = iota // gzip, the default
+	GELFZlib                        // zlib
+	GELFNone                        // uncompressed
+)
+
+// gelfMaxChunks is the most chunks a GELF message may be split into
+const gelfMaxChunks = 128
+
+// GELFSink sends entries to Graylog as GELF 1.1 messages
+//
+//	sink := &logger.GELFSink{Network: "udp", Addr: "graylog:12201"}
+//	logger.InitLogger(logger.Config{Output: sink})
+//
+// The message becomes short_message, its first line if it has several,
+// with the whole message as full_message. An error's stack, if any, is
+// added to full_message too. The level maps to a syslog severity and the
+// remaining fields are sent as additional fields with a leading
+// underscore. Over UDP messages are compressed and split into chunks of
+// at most ChunkSize bytes. Over TCP, optionally with TLS, they are sent
+// uncompressed and terminated by a null byte. A failed write reconnects
+// once before giving up.
+type GELFSink struct {
+	Network     string          // udp (the default) or tcp
+	Addr        string          // Graylog input address, required
+	TLSConfig   *tls.Config     // Use TLS over TCP when set
+	Compression GELFCompression // Compression of UDP messages, gzip by default
+	ChunkSize   int             // Largest UDP datagram, 1420 if zero
+	Host        string          // host field, the host's name if empty
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// gelfFieldName matches the characters GELF does not accept in
+// additional field names
+var gelfFieldName = regexp.MustCompile(`[^\w.\-]`)
+
+// Write implements io.Writer for entries without a known level
+func (s *GELFSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *GELFSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	msg, err := json.Marshal(s.message(lvl, bytes.TrimRight(p, "\n"), time.Now()))
+	if err != nil {
+		return 0, fmt.Errorf("logger: gelf: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.send(msg); err != nil {
+		// Graylog may have restarted; try once more on a new connection
+		s.closeConn()
+		if err := s.send(msg); err != nil {
+			return 0, fmt.Errorf("logger: gelf: %w", err)
+		}
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to Graylog
+func (s *GELFSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeConn()
+}
+
+// tcp reports whether messages are streamed rather than sent as datagrams
+func (s *GELFSink) tcp() bool {
+	return s.Network == "tcp" || s.TLSConfig != nil
+}
+
+// send writes one message, connecting first if needed. Callers must hold
+// s.mu.
+func (s *GELFSink) send(msg []byte) error {
+	if s.conn == nil {
+		conn, err := s.dial()
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	if s.tcp() {
+		_, err := s.conn.Write(append(msg, 0))
+		return err
+	}
+
+	data, err := s.compress(msg)
+	if err != nil {
+		return err
+	}
+	size := s.ChunkSize
+	if size <= 0 {
+		size = 1420
+	}
+	if len(data) <= size {
+		_, err := s.conn.Write(data)
+		return err
+	}
+
+	// Chunked: magic bytes, message ID, sequence number and count
+	const header = 12
+	per := size - header
+	count := (len(data) + per - 1) / per
+	if count > gelfMaxChunks {
+		return fmt.Errorf("message of %d bytes needs %d chunks, at most %d allowed", len(data), count, gelfMaxChunks)
+	}
+	var id [8]byte
+	rand.Read(id[:])
+	chunk := make([]byte, 0, size)
+	for i := 0; i < count; i++ {
+		end := min((i+1)*per, len(data))
+		chunk = append(chunk[:0], 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, data[i*per:end]...)
+		if _, err := s.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compress compresses a UDP message as configured
+func (s *GELFSink) compress(msg []byte) ([]byte, error) {
+	var b bytes.Buffer
+	switch s.Compression {
+	case GELFNone:
+		return msg, nil
+	case GELFZlib:
+		zw := zlib.NewWriter(&b)
+		zw.Write(msg)
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		gw := gzip.NewWriter(&b)
+		gw.Write(msg)
+		if err := gw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// dial connects to the Graylog input
+func (s *GELFSink) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if s.TLSConfig != nil {
+		return tls.DialWithDialer(dialer, "tcp", s.Addr, s.TLSConfig)
+	}
+	network := s.Network
+	if network == "" {
+		network = "udp"
+	}
+	return dialer.Dial(network, s.Addr)
+}
+
+// closeConn drops the connection. Callers must hold s.mu.
+func (s *GELFSink) closeConn() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// message maps an entry to a GELF message
+func (s *GELFSink) message(lvl zerolog.Level, line []byte, now time.Time) map[string]interface{} {
+	msg, fields := decodeEntry(line)
+	if lvl == zerolog.NoLevel {
+		if name, ok := fields[zerolog.LevelFieldName].(string); ok {
+			if l, err := ParseLevel(name); err == nil {
+				lvl = l
+			}
+		}
+	}
+	host := s.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+
+	short, full := msg, ""
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		short, full = msg[:i], msg
+	}
+	if stack, ok := fields[zerolog.ErrorStackFieldName]; ok {
+		full = strings.TrimPrefix(msg+"\n"+fieldString(stack), "\n")
+		delete(fields, zerolog.ErrorStackFieldName)
+	}
+	if short == "" {
+		short = "-"
+	}
+
+	out := map[string]interface{}{
+		"version":       "1.1",
+		"host":          host,
+		"short_message": short,
+		"timestamp":     float64(now.UnixMicro()) / 1e6,
+		"level":         syslogSeverity(lvl),
+	}
+	if full != "" {
+		out["full_message"] = full
+	}
+	if t, ok := sinkTime(fields[zerolog.TimestampFieldName]); ok {
+		out["timestamp"] = float64(t.UnixMicro()) / 1e6
+	}
+	delete(fields, zerolog.MessageFieldName)
+	delete(fields, zerolog.LevelFieldName)
+	delete(fields, zerolog.TimestampFieldName)
+	for k, v := range fields {
+		name := "_" + gelfFieldName.ReplaceAllString(k, "_")
+		if name == "_id" {
+			name = "_id_"
+		}
+		out[name] = v
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestGELFMessage(t *testing.T) {
+	s := &GELFSink{Host: "web-1"}
+	now := time.Date(2026, 10, 15, 1, 2, 3, 0, time.UTC)
+	for _, tt := range []struct {
+		lvl        zerolog.Level
+		line, want string
+	}{
+		{
+			zerolog.ErrorLevel,
+			`{"level":"error","time":"2026-10-15T01:02:03.25Z","id":7,"http.status":502,"user name":"ann","message":"upstream failed"}`,
+			`{"_http.status":502,"_id_":7,"_user_name":"ann","host":"web-1","level":3,"short_message":"upstream failed","timestamp":1792026123.25,"version":"1.1"}`,
+		},
+		{
+			zerolog.NoLevel,
+			`{"level":"warn","stack":"main.go:12","message":"retrying\nattempt 2"}`,
+			`{"full_message":"retrying\nattempt 2\nmain.go:12","host":"web-1","level":4,"short_message":"retrying","timestamp":1792026123,"version":"1.1"}`,
+		},
+		{
+			zerolog.InfoLevel,
+			`{"message":""}`,
+			`{"host":"web-1","level":6,"short_message":"-","timestamp":1792026123,"version":"1.1"}`,
+		},
+	} {
+		got, _ := json.Marshal(s.message(tt.lvl, []byte(tt.line), now))
+		if string(got) != tt.want {
+			t.Errorf("message(%s)\n%s\nwant\n%s", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestGELFSinkUDPChunks(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	s := &GELFSink{Addr: pc.LocalAddr().String(), ChunkSize: 100, Host: "web-1"}
+	defer s.Close()
+	// Squares make a message gzip cannot shrink into one datagram
+	var b strings.Builder
+	for i := 0; i < 500; i++ {
+		b.WriteString(strconv.Itoa(i * i))
+	}
+	long := b.String()
+	if _, err := s.Write([]byte(`{"message":"` + long + `","seq":1}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Chunks arrive in order over loopback
+	var data []byte
+	var id []byte
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for seq, count := 0, 1; seq < count; seq++ {
+		buf := make([]byte, 200)
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunk := buf[:n]
+		if n > 100 || chunk[0] != 0x1e || chunk[1] != 0x0f || int(chunk[10]) != seq {
+			t.Fatalf("chunk %d: %d bytes, header %x", seq, n, chunk[:12])
+		}
+		if id == nil {
+			id = chunk[2:10]
+		} else if !bytes.Equal(id, chunk[2:10]) {
+			t.Errorf("chunk %d: message ID %x, want %x", seq, chunk[2:10], id)
+		}
+		count = int(chunk[11])
+		data = append(data, chunk[12:]...)
+	}
+	if len(data) < 500 {
+		t.Errorf("%d compressed bytes, want several chunks", len(data))
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, _ := io.ReadAll(gz)
+	var fields map[string]interface{}
+	if err := json.Unmarshal(msg, &fields); err != nil || fields["short_message"] != long || fields["_seq"] != 1.0 {
+		t.Errorf("reassembled %.100s, %v", msg, err)
+	}
+}
+
+func TestGELFSinkTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	msgs := make(chan string, 4)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := r.ReadString(0)
+			if err != nil {
+				return
+			}
+			msgs <- msg
+		}
+	}()
+
+	s := &GELFSink{Network: "tcp", Addr: ln.Addr().String(), Host: "web-1"}
+	defer s.Close()
+	s.WriteLevel(zerolog.WarnLevel, []byte(`{"time":1792026123,"message":"slow"}`+"\n"))
+	want := `{"host":"web-1","level":4,"short_message":"slow","timestamp":1792026123,"version":"1.1"}` + "\x00"
+	if got := <-msgs; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"runtime/pprof"
+	"testing"
+)
+
+func TestGoroutine(t *testing.T) {
+	if !NewConfig(WithGoroutine()).Goroutine {
+		t.Error("WithGoroutine did not set Goroutine")
+	}
+	cfg, c := TestConfig()
+	cfg.Goroutine = true
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("worker", "7", "queue", "mail"))
+	l.Info("main")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.InfoCtx(ctx, "worker")
+	}()
+	<-done
+	l.InfoCtx(context.Background(), "background")
+
+	entries := c.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	main, worker := entries[0][GoroutineField], entries[1][GoroutineField]
+	if id, _ := main.(float64); id == 0 || main == worker || entries[2][GoroutineField] != main {
+		t.Errorf("goroutine IDs %v, %v, %v", main, worker, entries[2][GoroutineField])
+	}
+	labels, _ := entries[1][PprofLabelsField].(map[string]interface{})
+	if len(labels) != 2 || labels["worker"] != "7" || labels["queue"] != "mail" {
+		t.Errorf("%s = %v", PprofLabelsField, entries[1][PprofLabelsField])
+	}
+	for _, e := range []map[string]interface{}{entries[0], entries[2]} {
+		if _, ok := e[PprofLabelsField]; ok {
+			t.Errorf("labels on %v without any on its context", e)
+		}
+	}
+}
+
+func TestGoroutineSkipsDiscardedEntries(t *testing.T) {
+	l, err := New(Config{Output: io.Discard, Level: "warn", Goroutine: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("worker", "7"))
+	zl := l.Zerolog()
+	allocs := testing.AllocsPerRun(100, func() {
+		zl.Info().Ctx(ctx).Msg("discarded")
+	})
+	if allocs != 0 {
+		t.Errorf("%v allocations per discarded entry, want 0", allocs)
+	}
+}
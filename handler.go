@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// levelPayload is the body LevelHandler reads and writes
+type levelPayload struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler returns an HTTP handler for the global logger's level
+// GET responds with the current level as {"level":"info"}. PUT changes it,
+// taking either a JSON body of the same shape or a "level" form value:
+//
+//	mux.Handle("/debug/loglevel", logger.LevelHandler())
+//
+//	curl -X PUT -d '{"level":"debug"}' localhost:8080/debug/loglevel
+//	curl -X PUT -d level=debug localhost:8080/debug/loglevel
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(serveLevel)
+}
+
+// serveLevel implements LevelHandler
+func serveLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		level, err := requestedLevel(r)
+		if err != nil {
+			writeLevelError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := SetLevel(level); err != nil {
+			writeLevelError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		writeLevelError(w, http.StatusMethodNotAllowed, "only GET and PUT are supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(levelPayload{Level: GetLevel()})
+}
+
+// requestedLevel reads the level from a PUT request's body
+// The body is decoded as JSON when it looks like an object and as form
+// values otherwise, since curl -d labels both as form data.
+func requestedLevel(r *http.Request) (string, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<10))
+	if err != nil {
+		return "", err
+	}
+	body = bytes.TrimSpace(body)
+	if bytes.HasPrefix(body, []byte("{")) {
+		var p levelPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			return "", err
+		}
+		return p.Level, nil
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return "", err
+	}
+	return values.Get("level"), nil
+}
+
+// writeLevelError responds with status and {"error": msg}
+func writeLevelError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
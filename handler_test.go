@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	useGlobal(t, Config{Output: io.Discard, Level: "info"})
+	h := LevelHandler()
+	for _, tt := range []struct {
+		method, body string
+		status       int
+		want         string
+	}{
+		{http.MethodGet, "", http.StatusOK, `{"level":"info"}`},
+		{http.MethodPut, `{"level":"debug"}`, http.StatusOK, `{"level":"debug"}`},
+		{http.MethodPut, "level=warn", http.StatusOK, `{"level":"warn"}`},
+		{http.MethodPut, `{"level":"loud"}`, http.StatusBadRequest, `unknown level`},
+		{http.MethodPut, `{"level":`, http.StatusBadRequest, `"error"`},
+		{http.MethodPost, "level=debug", http.StatusMethodNotAllowed, `only GET and PUT`},
+		{http.MethodGet, "", http.StatusOK, `{"level":"warn"}`},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, "/debug/loglevel", strings.NewReader(tt.body)))
+		if rec.Code != tt.status || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s %q: got %d %s, want %d %s", tt.method, tt.body, rec.Code, rec.Body.String(), tt.status, tt.want)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("%s %q: Content-Type %q", tt.method, tt.body, ct)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// batchEntry is one queued entry of a batching sink
+type batchEntry struct {
+	level zerolog.Level
+	time  time.Time
+	data  []byte
+}
+
+// entryBatcher collects entries of an HTTP sink in the background and
+// hands them to send in batches of at most size entries, at least every
+// interval. Failed batches are retried with backoff unless send marks the
+// error permanent. When the queue is full, log calls wait up to wait for
+// room and then drop the entry, so a slow endpoint never holds logging up
+// unless the sink asks for it. Dropped entries are counted and reported to
+// onError. Sinks embed one and start it on first use.
+type entryBatcher struct {
+	send     func([]batchEntry) error
+	size     int
+	interval time.Duration
+	wait     time.Duration
+	onError  func(error)
+
+	once    sync.Once
+	queue   chan batchEntry
+	flushes chan chan struct{}
+	closing chan struct{} // closed first by close, waking log calls waiting for room
+	done    chan struct{} // closed once no log call can queue anymore
+	adding  sync.RWMutex  // held for reading by log calls queueing an entry
+	wg      sync.WaitGroup
+	closed  atomic.Bool
+	dropped atomic.Uint64
+}
+
+// batchRetries is how many times a failed batch is sent again
+const batchRetries = 3
+
+// start launches the background goroutine once
+func (b *entryBatcher) start() {
+	b.once.Do(func() {
+		if b.size <= 0 {
+			b.size = 100
+		}
+		if b.interval <= 0 {
+			b.interval = 5 * time.Second
+		}
+		b.queue = make(chan batchEntry, 16*b.size)
+		b.flushes = make(chan chan struct{})
+		b.closing = make(chan struct{})
+		b.done = make(chan struct{})
+		b.wg.Add(1)
+		go b.run()
+	})
+}
+
+// add queues a copy of p, waiting up to b.wait for room. An entry dropped
+// because the queue stayed full is only counted, as the error would end
+// up on stderr; it returns an error if the batcher is closed.
+func (b *entryBatcher) add(lvl zerolog.Level, p []byte) error {
+	b.start()
+	b.adding.RLock()
+	defer b.adding.RUnlock()
+	if b.closed.Load() {
+		return errSinkClosed
+	}
+	e := batchEntry{level: lvl, time: time.Now(), data: append([]byte(nil), bytes.TrimRight(p, "\n")...)}
+	select {
+	case b.queue <- e:
+		return nil
+	default:
+	}
+	if b.wait > 0 {
+		wait := time.NewTimer(b.wait)
+		defer wait.Stop()
+		select {
+		case b.queue <- e:
+			return nil
+		case <-wait.C:
+		case <-b.closing:
+			return errSinkClosed
+		}
+	}
+	b.dropped.Add(1)
+	return nil
+}
+
+// flush sends everything queued so far and waits for it
+func (b *entryBatcher) flush() {
+	if b.closed.Load() {
+		return
+	}
+	b.start()
+	reply := make(chan struct{})
+	select {
+	case b.flushes <- reply:
+		<-reply
+	case <-b.done:
+	}
+}
+
+// close sends what is queued and stops the goroutine. Log calls queueing
+// at the same time either get their entry in before the goroutine's last
+// batch or an error.
+func (b *entryBatcher) close() {
+	b.start()
+	if b.closed.CompareAndSwap(false, true) {
+		close(b.closing)
+		b.adding.Lock()
+		close(b.done)
+		b.adding.Unlock()
+	}
+	b.wg.Wait()
+}
+
+// run batches queued entries until the batcher is closed
+func (b *entryBatcher) run() {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([]batchEntry, 0, b.size)
+	drain := func() {
+		for {
+			select {
+			case e := <-b.queue:
+				if batch = append(batch, e); len(batch) == b.size {
+					b.deliver(batch)
+					batch = batch[:0]
+				}
+			default:
+				return
+			}
+		}
+	}
+	for {
+		select {
+		case e := <-b.queue:
+			if batch = append(batch, e); len(batch) == b.size {
+				b.deliver(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			b.deliver(batch)
+			batch = batch[:0]
+		case reply := <-b.flushes:
+			drain()
+			b.deliver(batch)
+			batch = batch[:0]
+			close(reply)
+		case <-b.done:
+			drain()
+			b.deliver(batch)
+			b.reportDropped()
+			return
+		}
+	}
+}
+
+// deliver sends one batch, retrying with backoff, and reports it as lost
+// if every attempt fails
+func (b *entryBatcher) deliver(batch []batchEntry) {
+	if len(batch) == 0 {
+		return
+	}
+	b.reportDropped()
+	backoff := time.Second
+	var err error
+	for attempt := 0; attempt <= batchRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-b.done:
+				// Closing; one last try without waiting
+			}
+			backoff *= 2
+		}
+		if err = b.send(batch); err == nil {
+			return
+		}
+		var perm permanentError
+		if errors.As(err, &perm) {
+			break
+		}
+	}
+	b.report(fmt.Errorf("logger: lost %d entries: %w", len(batch), err))
+}
+
+// reportDropped reports the entries dropped since the last call
+func (b *entryBatcher) reportDropped() {
+	if n := b.dropped.Swap(0); n > 0 {
+		b.report(fmt.Errorf("logger: dropped %d entries, queue full", n))
+	}
+}
+
+// report passes an asynchronous failure to onError, or prints it to
+// stderr since it cannot be returned from Write
+func (b *entryBatcher) report(err error) {
+	if b.onError != nil {
+		b.onError(err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
+
+// permanentError marks a failure that retrying will not fix, such as a
+// rejected request
+type permanentError struct{ error }
+
+func (e permanentError) Unwrap() error { return e.error }
+
+// doHTTP sends req and turns an unsuccessful status into an error, which is
+// permanent for client errors other than 408 and 429
+func doHTTP(client *http.Client, req *http.Request) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+	switch code := resp.StatusCode; {
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests, code >= 500:
+		return err
+	}
+	return permanentError{err}
+}
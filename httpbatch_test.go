@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// blockedBatcher returns a batcher whose sends wait for release, and the
+// errors it reports
+func blockedBatcher(wait time.Duration, sent *atomic.Int32) (b *entryBatcher, release chan struct{}, errs func() []string) {
+	var mu sync.Mutex
+	var reported []string
+	release = make(chan struct{})
+	b = &entryBatcher{
+		send: func(batch []batchEntry) error {
+			<-release
+			sent.Add(int32(len(batch)))
+			return nil
+		},
+		size:     1,
+		interval: time.Hour,
+		wait:     wait,
+		onError: func(err error) {
+			mu.Lock()
+			reported = append(reported, err.Error())
+			mu.Unlock()
+		},
+	}
+	return b, release, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return reported
+	}
+}
+
+func TestEntryBatcherDropsWhenFull(t *testing.T) {
+	var sent atomic.Int32
+	b, release, errs := blockedBatcher(0, &sent)
+	start := time.Now()
+	for i := 0; i < 40; i++ {
+		if err := b.add(zerolog.InfoLevel, []byte("{}\n")); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("adding to a full queue took %v", d)
+	}
+	close(release)
+	b.close()
+
+	// Up to one entry is being sent and 16 are queued, the rest is dropped
+	dropped := fmt.Sprintf("dropped %d entries", 40-sent.Load())
+	if reported := errs(); len(reported) != 1 || !strings.Contains(reported[0], dropped) {
+		t.Errorf("reported %q, want %s", reported, dropped)
+	}
+}
+
+func TestEntryBatcherWaitsForRoom(t *testing.T) {
+	var sent atomic.Int32
+	b, release, errs := blockedBatcher(time.Minute, &sent)
+	for i := 0; i < 17; i++ {
+		b.add(zerolog.InfoLevel, []byte("{}\n"))
+	}
+	added := make(chan struct{})
+	go func() {
+		b.add(zerolog.InfoLevel, []byte("{}\n"))
+		close(added)
+	}()
+	select {
+	case <-added:
+		t.Fatal("entry added to a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-added
+	b.close()
+	if reported := errs(); len(reported) != 0 || sent.Load() != 18 {
+		t.Errorf("sent %d, reported %q, want all 18 sent", sent.Load(), reported)
+	}
+}
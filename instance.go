@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// Logger is an independent logger with its own level, output and fields
+// The package-level functions log through the global logger configured by
+// InitLogger; loggers created with New are not affected by it, so two
+// subsystems can log at different levels to different destinations.
+type Logger struct {
+	state atomic.Pointer[loggerState]
+}
+
+// loggerState is the configuration a Logger currently logs with
+// It is replaced as a whole when a named logger is reconfigured.
+type loggerState struct {
+	zl         zerolog.Logger
+	level      *atomicLevel
+	withCaller bool
+	callerSkip int
+}
+
+// New validates cfg and creates an independent Logger from it
+func New(cfg Config) (*Logger, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	st, _ := newState(withDefaults(cfg.withEnvLevel())) // buffered writers are reached through Flush and Close
+	return newInstance(st), nil
+}
+
+// Nop returns a Logger that discards everything, at the cost of a level
+// check per call. Fatal still exits.
+func Nop() *Logger {
+	return newInstance(&loggerState{zl: zerolog.Nop(), level: newAtomicLevel(zerolog.Disabled)})
+}
+
+// newState builds the state of a logger configured by cfg, and returns
+// the AsyncWriters and BatchWriters made for it
+func newState(cfg Config) (*loggerState, []bufferedWriter) {
+	lvl, _ := cfg.level() // checked by Validate
+	level := newAtomicLevel(lvl)
+	lowerGlobalLevel(lvl)
+	zl, bufs := newLogger(cfg, level)
+	return &loggerState{
+		zl:         zl,
+		level:      level,
+		withCaller: cfg.WithCaller,
+		callerSkip: cfg.CallerSkipFrames,
+	}, bufs
+}
+
+// newInstance returns a Logger using st
+func newInstance(st *loggerState) *Logger {
+	l := &Logger{}
+	l.state.Store(st)
+	return l
+}
+
+// enabled reports whether entries at lvl pass the logger's level
+func (st *loggerState) enabled(lvl zerolog.Level) bool {
+	return lvl >= st.level.Load()
+}
+
+// derive returns a state sharing st's level and caller settings
+func (st *loggerState) derive(zl zerolog.Logger) *loggerState {
+	child := *st
+	child.zl = zl
+	return &child
+}
+
+// Info logs an info message
+func (l *Logger) Info(msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.InfoLevel) {
+		processArgs(addCallerInfo(st.zl.Info(), st), msg, args...)
+	}
+}
+
+// Warn logs a warning message
+func (l *Logger) Warn(msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.WarnLevel) {
+		processArgs(addCallerInfo(st.zl.Warn(), st), msg, args...)
+	}
+}
+
+// Error logs an error message
+func (l *Logger) Error(err error, msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.ErrorLevel) {
+		processArgs(addCallerInfo(st.zl.Error().Err(err), st), msg, args...)
+	}
+}
+
+// Fatal logs a fatal message and exits
+func (l *Logger) Fatal(err error, msg string, args ...interface{}) {
+	st := l.state.Load()
+	processArgs(addCallerInfo(st.zl.Fatal().Err(err), st), msg, args...)
+}
+
+// Infof logs an info message formatted with fmt.Sprintf
+func (l *Logger) Infof(format string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.InfoLevel) {
+		addCallerInfo(st.zl.Info(), st).Msgf(format, args...)
+	}
+}
+
+// Warnf logs a warning message formatted with fmt.Sprintf
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.WarnLevel) {
+		addCallerInfo(st.zl.Warn(), st).Msgf(format, args...)
+	}
+}
+
+// Errorf logs an error message formatted with fmt.Sprintf
+func (l *Logger) Errorf(err error, format string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.ErrorLevel) {
+		addCallerInfo(st.zl.Error().Err(err), st).Msgf(format, args...)
+	}
+}
+
+// Fatalf logs a fatal message formatted with fmt.Sprintf and exits
+func (l *Logger) Fatalf(err error, format string, args ...interface{}) {
+	st := l.state.Load()
+	addCallerInfo(st.zl.Fatal().Err(err), st).Msgf(format, args...)
+}
+
+// WithField returns a child logger that adds a field to every entry
+// The child shares the parent's level, but keeps the parent's output and
+// format as they were when the child was created.
+func (l *Logger) WithField(key string, value interface{}) *Logger {
+	st := l.state.Load()
+	return newInstance(st.derive(st.zl.With().Interface(key, value).Logger()))
+}
+
+// Component returns a child logger with the component field set
+func (l *Logger) Component(name string) *Logger {
+	st := l.state.Load()
+	return newInstance(st.derive(st.zl.With().Str("component", name).Logger()))
+}
+
+// WithCallerSkip returns a logger that skips n more stack frames when
+// reporting the caller, for use from helpers that wrap this logger
+func (l *Logger) WithCallerSkip(n int) *Logger {
+	st := l.state.Load()
+	child := st.derive(st.zl)
+	child.callerSkip += n
+	return newInstance(child)
+}
+
+// Zerolog returns the underlying zerolog logger, which honors the
+// logger's level
+func (l *Logger) Zerolog() zerolog.Logger {
+	return l.state.Load().zl
+}
@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNewIsIndependent(t *testing.T) {
+	var global, a, b bytes.Buffer
+	useGlobal(t, Config{Output: &global, Level: "info"})
+	la, err := New(Config{Output: &a, Level: "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb, err := New(Config{Output: &b, Level: "error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	la.Component("api").Info("a")
+	lb.Warn("dropped")
+	lb.WithField("attempt", 2).Error(nil, "b")
+	Info("global")
+
+	if got := strings.Join(messages(t, &a), ","); got != "a" {
+		t.Errorf("a got %q", got)
+	}
+	if got := strings.Join(messages(t, &b), ","); got != "b" {
+		t.Errorf("b got %q", got)
+	}
+	if got := strings.Join(messages(t, &global), ","); got != "global" {
+		t.Errorf("global logger got %q", got)
+	}
+
+	var e map[string]interface{}
+	if err := json.Unmarshal(a.Bytes(), &e); err != nil || e["component"] != "api" {
+		t.Errorf("a entry %v, %v, want component api", e, err)
+	}
+	if err := json.Unmarshal(b.Bytes(), &e); err != nil || e["attempt"] != 2.0 {
+		t.Errorf("b entry %v, %v, want attempt 2", e, err)
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	if l, err := New(Config{Level: "loud"}); err == nil || l != nil {
+		t.Errorf("got %v, %v, want an error", l, err)
+	}
+}
+
+func TestNop(t *testing.T) {
+	l := Nop()
+	l.WithField("k", "v").Info("nothing")
+	if allocs := testing.AllocsPerRun(100, func() { l.Warn("nothing") }); allocs != 0 {
+		t.Errorf("%v allocations per call, want 0", allocs)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, Level: "debug", Disabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Error(nil, "nothing")
+	if allocs := testing.AllocsPerRun(100, func() { l.Info("nothing") }); allocs != 0 {
+		t.Errorf("%v allocations per call, want 0", allocs)
+	}
+
+	useGlobal(t, Config{Output: &buf, Disabled: true})
+	Error(nil, "nothing")
+	if buf.Len() != 0 {
+		t.Errorf("got %q, want nothing written", buf.String())
+	}
+
+	t.Setenv(EnvDisabled, "true")
+	var cfg Config
+	if err := applyEnv(&cfg); err != nil || !cfg.Disabled {
+		t.Errorf("%s=true gave Disabled %v, %v", EnvDisabled, cfg.Disabled, err)
+	}
+}
+
+// wrappedInfo stands for a helper wrapping the logger in another package
+func wrappedInfo(l *Logger, msg string) {
+	l.Info(msg)
+}
+
+// callerLine returns the file:line of its caller
+func callerLine() string {
+	_, file, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+func TestCallerSkip(t *testing.T) {
+	cfg, c := TestConfig()
+	cfg.WithCaller = true
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipping, err := New(Config{Output: c, WithCaller: true, CallerSkipFrames: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string
+	l.Info("direct")
+	want = append(want, callerLine())
+	wrappedInfo(l.WithCallerSkip(1), "WithCallerSkip")
+	want = append(want, callerLine())
+	wrappedInfo(skipping, "CallerSkipFrames")
+	want = append(want, callerLine())
+
+	entries := c.Entries()
+	if len(entries) != len(want) {
+		t.Fatalf("got %s", c.String())
+	}
+	for i, e := range entries {
+		// The call sits on the line before callerLine
+		file, line, _ := strings.Cut(want[i], ":")
+		n, _ := strconv.Atoi(line)
+		if got, want := e["caller"], fmt.Sprintf("%s:%d", file, n-1); got != want {
+			t.Errorf("%s: caller %v, want %s", e["message"], got, want)
+		}
+	}
+}
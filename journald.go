@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog"
+)
+
+// journalSocket is where journald listens for native protocol messages
+const journalSocket = "/run/systemd/journal/socket"
+
+// JournaldSink writes entries to the systemd journal over its native
+// protocol, keeping the level as PRIORITY and every field as a journal
+// field instead of flattening them into text captured from stderr
+//
+//	logger.InitLogger(logger.Config{Output: &logger.JournaldSink{}, NoTimestamp: true})
+//
+// Field names are uppercased and anything outside A-Z, 0-9 and '_' becomes
+// '_', so "user_id" is found with journalctl USER_ID=42. The message goes
+// to MESSAGE, the caller to CODE_FILE and CODE_LINE. Entries too large for
+// a datagram are passed to journald in a sealed memory file on Linux.
+type JournaldSink struct {
+	Identifier string // SYSLOG_IDENTIFIER, the program name if empty
+	Socket     string // journald socket path, the standard one if empty
+
+	mu   sync.Mutex
+	conn *net.UnixConn
+}
+
+// Write implements io.Writer for entries without a known level
+func (s *JournaldSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *JournaldSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	msg := s.encode(lvl, p)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := s.dial()
+		if err != nil {
+			return 0, fmt.Errorf("logger: journald: %w", err)
+		}
+		s.conn = conn
+	}
+	_, err := s.conn.Write(msg)
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		err = sendJournalFD(s.conn, msg)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("logger: journald: %w", err)
+	}
+	return len(p), nil
+}
+
+// Close closes the journald socket
+func (s *JournaldSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// dial opens an unconnected datagram socket aimed at journald
+func (s *JournaldSink) dial() (*net.UnixConn, error) {
+	path := s.Socket
+	if path == "" {
+		path = journalSocket
+	}
+	return net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+}
+
+// encode renders an entry in the journal export format journald reads
+// from its socket
+func (s *JournaldSink) encode(lvl zerolog.Level, p []byte) []byte {
+	line := bytes.TrimRight(p, "\n")
+	msg, fields := splitEntry(line)
+
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", msg)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(syslogSeverity(lineLevel(lvl, line))))
+	identifier := s.Identifier
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", identifier)
+
+	if caller, ok := fields[zerolog.CallerFieldName].(string); ok {
+		delete(fields, zerolog.CallerFieldName)
+		file, line := caller, ""
+		if i := strings.LastIndexByte(caller, ':'); i > 0 {
+			file, line = caller[:i], caller[i+1:]
+		}
+		writeJournalField(&b, "CODE_FILE", file)
+		if line != "" {
+			writeJournalField(&b, "CODE_LINE", line)
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if name := journalName(k); name != "" {
+			writeJournalField(&b, name, fieldString(fields[k]))
+		}
+	}
+	return b.Bytes()
+}
+
+// writeJournalField writes one field, switching to the length-prefixed
+// binary form for values that contain newlines
+func writeJournalField(b *bytes.Buffer, name, value string) {
+	b.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// journalName converts a field name into a valid journal field name:
+// uppercase letters, digits and underscores, not starting with an
+// underscore or digit, at most 64 characters
+func journalName(key string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		if b.Len() == 64 {
+			break
+		}
+		switch {
+		case r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if b.Len() == 0 {
+				b.WriteString("F_")
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	// Leading underscores mark trusted fields journald sets itself
+	return strings.TrimLeft(b.String(), "_")
+}
@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// sendJournalFD passes an entry too large for a datagram to journald as a
+// sealed memory file, the way sd_journal_send does
+func sendJournalFD(conn *net.UnixConn, msg []byte) error {
+	fd, err := unix.MemfdCreate("logger-journal", unix.MFD_ALLOW_SEALING|unix.MFD_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("memfd: %w", err)
+	}
+	f := os.NewFile(uintptr(fd), "logger-journal")
+	defer f.Close()
+
+	if _, err := f.Write(msg); err != nil {
+		return err
+	}
+	if _, err := unix.FcntlInt(f.Fd(), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return fmt.Errorf("seal memfd: %w", err)
+	}
+	// net refuses WriteMsgUnix on a connected datagram socket, so send the
+	// descriptor with sendmsg directly
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sendErr error
+	err = raw.Write(func(sock uintptr) bool {
+		sendErr = unix.Sendmsg(int(sock), nil, unix.UnixRights(int(f.Fd())), nil, 0)
+		return sendErr != unix.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestJournaldSinkLargeEntry(t *testing.T) {
+	conn, path := journalListener(t)
+	s := &JournaldSink{Identifier: "billing", Socket: path}
+	defer s.Close()
+	message := bytes.Repeat([]byte("x"), 1<<20) // larger than any datagram
+	if _, err := s.Write([]byte(`{"message":"` + string(message) + `"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := conn.ReadMsgUnix(nil, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("got %d control messages, %v, want one", len(msgs), err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("got descriptors %v, %v, want one", fds, err)
+	}
+	f := os.NewFile(uintptr(fds[0]), "journal")
+	defer f.Close()
+	if _, err := f.Write([]byte("x")); err == nil {
+		t.Error("memory file not sealed")
+	}
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 2<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, s.encode(zerolog.NoLevel, []byte(`{"message":"`+string(message)+`"}`))) {
+		t.Errorf("memory file holds %d bytes, want the encoded entry", len(data))
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package logger
+
+import (
+	"errors"
+	"net"
+)
+
+// sendJournalFD is only available on Linux, where journald runs
+func sendJournalFD(conn *net.UnixConn, msg []byte) error {
+	return errors.New("entry too large for the journal socket")
+}
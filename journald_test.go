@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestJournaldSinkEncode(t *testing.T) {
+	s := &JournaldSink{Identifier: "billing"}
+	entry := `{"level":"warn","time":"2026-10-15T01:02:03Z","caller":"/src/db.go:42","user-id":42,"2fa":true,"_hidden":"x","message":"slow\nquery"}` + "\n"
+
+	var want bytes.Buffer
+	want.WriteString("MESSAGE\n")
+	binary.Write(&want, binary.LittleEndian, uint64(len("slow\nquery")))
+	want.WriteString("slow\nquery\n")
+	want.WriteString("PRIORITY=4\nSYSLOG_IDENTIFIER=billing\nCODE_FILE=/src/db.go\nCODE_LINE=42\n")
+	want.WriteString("F_2FA=true\nHIDDEN=x\nUSER_ID=42\n")
+
+	if got := s.encode(zerolog.NoLevel, []byte(entry)); !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("got\n%q\nwant\n%q", got, want.Bytes())
+	}
+}
+
+// journalListener listens on a datagram socket in a temporary directory
+func journalListener(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "journal") // short enough for a socket path
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, path
+}
+
+func TestJournaldSinkWrite(t *testing.T) {
+	conn, path := journalListener(t)
+	s := &JournaldSink{Identifier: "billing", Socket: path}
+	defer s.Close()
+	if _, err := s.WriteLevel(zerolog.ErrorLevel, []byte(`{"level":"error","message":"failed"}`+"\n")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4096)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != "MESSAGE=failed\nPRIORITY=3\nSYSLOG_IDENTIFIER=billing\n" {
+		t.Errorf("got %q", got)
+	}
+
+	if _, err := (&JournaldSink{Socket: filepath.Join(t.TempDir(), "missing")}).Write([]byte("{}\n")); err == nil {
+		t.Error("no error without a journald socket")
+	}
+}
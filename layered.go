@@ -0,0 +1,408 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/rs/zerolog"
+)
+
+// Layers lists the configuration sources merged by Resolve
+// A setting given by a later layer overrides the same setting from an
+// earlier one, and settings a layer leaves unset fall through:
+//
+//	defaults < File < environment (Env) < Options
+//
+// Note that InitFromEnv uses the opposite order for code and environment;
+// Layers is the way to let code have the final word.
+type Layers struct {
+	File    string   // Config file read like InitFromFile, skipped if empty
+	Env     bool     // Read the LOGGER_* environment variables
+	Options []Option // Programmatic settings
+}
+
+// Layer names reported in EffectiveConfig.Sources
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceCode    = "code"
+)
+
+// effectiveSources records which layer set each setting of the installed
+// global configuration, guarded by mu
+var effectiveSources map[string]string
+
+// Resolve merges the layers into a single validated Config
+func Resolve(l Layers) (Config, error) {
+	cfg, _, err := resolve(l)
+	return cfg, err
+}
+
+// InitLayered resolves the layers and initializes the global logger with
+// the result. Like InitLogger, only the first initialization takes effect.
+func InitLayered(l Layers) error {
+	cfg, sources, err := resolve(l)
+	if err != nil {
+		return err
+	}
+	applied := false
+	initOnce.Do(func() {
+		apply(cfg)
+		applied = true
+	})
+	if applied {
+		mu.Lock()
+		effectiveSources = sources
+		mu.Unlock()
+	}
+	return nil
+}
+
+// resolve merges the layers and reports the layer each setting came from
+func resolve(l Layers) (Config, map[string]string, error) {
+	var cfg Config
+	sources := make(map[string]string)
+	mark := func(keys []string, source string) {
+		for _, k := range keys {
+			sources[k] = source
+		}
+	}
+
+	if l.File != "" {
+		fc, err := LoadFile(l.File)
+		if err != nil {
+			return cfg, nil, err
+		}
+		fileCfg, err := fc.Config()
+		if err != nil {
+			return cfg, nil, err
+		}
+		cfg = overlay(cfg, fileCfg)
+		mark(fc.keys(), SourceFile)
+	}
+
+	if l.Env {
+		if err := applyEnv(&cfg); err != nil {
+			return cfg, nil, err
+		}
+		mark(envKeys(), SourceEnv)
+	}
+
+	if len(l.Options) > 0 {
+		codeCfg := NewConfig(l.Options...)
+		cfg = overlay(cfg, codeCfg)
+		mark(configKeys(codeCfg), SourceCode)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, nil, err
+	}
+	return cfg, sources, nil
+}
+
+// overlay returns base with every setting top gives a value replaced
+// Fields are merged key by key and a non-nil Loggers map replaces the
+// base one. Boolean options can only be turned on, not off.
+func overlay(base, top Config) Config {
+	cfg := base
+	if top.Level != "" {
+		cfg.Level = top.Level
+	}
+	if top.Output != nil {
+		cfg.Output = top.Output
+	}
+	if top.Format != "" {
+		cfg.Format = top.Format
+	} else if top.Pretty {
+		cfg.Format = ""
+	}
+	if top.TimeFormat != "" {
+		cfg.TimeFormat = top.TimeFormat
+	}
+	if top.CallerSkipFrames != 0 {
+		cfg.CallerSkipFrames = top.CallerSkipFrames
+	}
+	if top.Location != nil {
+		cfg.Location = top.Location
+	}
+	if top.Theme != nil {
+		cfg.Theme = top.Theme
+	}
+	if top.Schema != "" {
+		cfg.Schema = top.Schema
+	}
+	if top.SchemaVersion != "" {
+		cfg.SchemaVersion = top.SchemaVersion
+	}
+	if top.CSVColumns != nil {
+		cfg.CSVColumns = top.CSVColumns
+	}
+	if top.PrettyFieldOrder != nil {
+		cfg.PrettyFieldOrder = top.PrettyFieldOrder
+	}
+	cfg.Pretty = base.Pretty || top.Pretty
+	cfg.WithCaller = base.WithCaller || top.WithCaller
+	cfg.NoTimestamp = base.NoTimestamp || top.NoTimestamp
+	cfg.DeadlineRemaining = base.DeadlineRemaining || top.DeadlineRemaining
+	cfg.Goroutine = base.Goroutine || top.Goroutine
+	cfg.Scoped = base.Scoped || top.Scoped
+	cfg.EnvLevelOverride = base.EnvLevelOverride || top.EnvLevelOverride
+	cfg.ParallelSinks = base.ParallelSinks || top.ParallelSinks
+	cfg.Disabled = base.Disabled || top.Disabled
+
+	if len(top.Fields) > 0 {
+		cfg.Fields = make(map[string]interface{}, len(base.Fields)+len(top.Fields))
+		for k, v := range base.Fields {
+			cfg.Fields[k] = v
+		}
+		for k, v := range top.Fields {
+			cfg.Fields[k] = v
+		}
+	}
+
+	names := &cfg.FieldNames
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&names.Time, top.FieldNames.Time},
+		{&names.Level, top.FieldNames.Level},
+		{&names.Message, top.FieldNames.Message},
+		{&names.Error, top.FieldNames.Error},
+		{&names.Caller, top.FieldNames.Caller},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+
+	if top.ErrorOutput != nil {
+		cfg.ErrorOutput = top.ErrorOutput
+	}
+	if top.Sinks != nil {
+		cfg.Sinks = top.Sinks
+	}
+	if top.Async != nil {
+		cfg.Async = top.Async
+	}
+	if top.Batch != nil {
+		cfg.Batch = top.Batch
+	}
+	if top.Sampling != nil {
+		cfg.Sampling = top.Sampling
+	}
+	if top.RateLimit != nil {
+		cfg.RateLimit = top.RateLimit
+	}
+	if top.DedupWindow != 0 {
+		cfg.DedupWindow = top.DedupWindow
+	}
+	if top.Loggers != nil {
+		cfg.Loggers = top.Loggers
+	}
+	return cfg
+}
+
+// keys lists the settings present in the file, by their config file keys
+func (fc FileConfig) keys() []string {
+	var keys []string
+	add := func(set bool, key string) {
+		if set {
+			keys = append(keys, key)
+		}
+	}
+	add(fc.Level != "", "level")
+	add(fc.Format != "", "format")
+	add(fc.Caller, "caller")
+	add(fc.CallerSkip != 0, "caller_skip")
+	add(fc.TimeFormat != "", "time_format")
+	add(fc.Timezone != "", "timezone")
+	add(fc.NoTimestamp, "no_timestamp")
+	add(fc.Deadline, "deadline_ms")
+	add(fc.Goroutine, "goroutine")
+	add(len(fc.Outputs) > 0, "outputs")
+	add(fc.ErrorOutput != "", "error_output")
+	add(fc.ParallelOutputs, "parallel_outputs")
+	add(len(fc.Fields) > 0, "fields")
+	add(len(fc.Loggers) > 0, "loggers")
+	add(fc.FieldNames != FieldNames{}, "field_names")
+	add(fc.Scoped, "scoped")
+	add(fc.Disabled, "disabled")
+	add(fc.Theme != "", "theme")
+	add(fc.Schema != "", "schema")
+	add(fc.SchemaVersion != "", "schema_version")
+	add(len(fc.CSVColumns) > 0, "csv_columns")
+	add(len(fc.FieldOrder) > 0, "field_order")
+	return keys
+}
+
+// envKeys lists the settings given by LOGGER_* variables, by their config
+// file keys
+func envKeys() []string {
+	vars := map[string]string{
+		EnvLevel:       "level",
+		EnvPretty:      "format",
+		EnvFormat:      "format",
+		EnvOutput:      "outputs",
+		EnvCaller:      "caller",
+		EnvTimeFormat:  "time_format",
+		EnvFields:      "fields",
+		EnvTimezone:    "timezone",
+		EnvNoTimestamp: "no_timestamp",
+		EnvDisabled:    "disabled",
+	}
+	var keys []string
+	for name, key := range vars {
+		if _, ok := os.LookupEnv(name); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// configKeys lists the settings cfg gives a value, by their config file keys
+func configKeys(cfg Config) []string {
+	var keys []string
+	add := func(set bool, key string) {
+		if set {
+			keys = append(keys, key)
+		}
+	}
+	add(cfg.Level != "", "level")
+	add(cfg.Pretty || cfg.Format != "", "format")
+	add(cfg.WithCaller, "caller")
+	add(cfg.CallerSkipFrames != 0, "caller_skip")
+	add(cfg.TimeFormat != "", "time_format")
+	add(cfg.Location != nil, "timezone")
+	add(cfg.NoTimestamp, "no_timestamp")
+	add(cfg.DeadlineRemaining, "deadline_ms")
+	add(cfg.Goroutine, "goroutine")
+	add(cfg.Output != nil, "outputs")
+	add(len(cfg.Fields) > 0, "fields")
+	add(len(cfg.Loggers) > 0, "loggers")
+	add(cfg.FieldNames != FieldNames{}, "field_names")
+	add(len(cfg.Sinks) > 0, "outputs")
+	add(cfg.ErrorOutput != nil, "error_output")
+	add(cfg.ParallelSinks, "parallel_outputs")
+	add(cfg.Scoped, "scoped")
+	add(cfg.Disabled, "disabled")
+	add(cfg.Theme != nil, "theme")
+	add(cfg.Schema != "", "schema")
+	add(cfg.SchemaVersion != "", "schema_version")
+	add(len(cfg.CSVColumns) > 0, "csv_columns")
+	add(len(cfg.PrettyFieldOrder) > 0, "field_order")
+	return keys
+}
+
+// EffectiveConfig is a printable snapshot of the global logger's
+// configuration, for debugging which settings are actually in effect
+type EffectiveConfig struct {
+	Level       string                 `json:"level"`
+	Format      string                 `json:"format"`
+	Caller      bool                   `json:"caller"`
+	TimeFormat  string                 `json:"time_format"`
+	Timezone    string                 `json:"timezone"`
+	NoTimestamp bool                   `json:"no_timestamp"`
+	Output      string                 `json:"output"`
+	Sinks       []EffectiveSink        `json:"sinks,omitempty"`
+	Parallel    bool                   `json:"parallel_sinks,omitempty"`
+	ErrorOutput string                 `json:"error_output,omitempty"`
+	Fields      map[string]interface{} `json:"fields,omitempty"`
+	FieldNames  FieldNames             `json:"field_names"`
+	Loggers     []string               `json:"loggers,omitempty"`
+	Scoped      bool                   `json:"scoped"`
+	Disabled    bool                   `json:"disabled,omitempty"`
+
+	// Sources maps each setting to the layer it came from when the logger
+	// was set up with InitLayered; settings not listed use defaults
+	Sources map[string]string `json:"sources,omitempty"`
+}
+
+// EffectiveSink describes one of the sinks the global logger writes to
+type EffectiveSink struct {
+	Output   string `json:"output"`
+	MinLevel string `json:"min_level,omitempty"`
+	Format   string `json:"format"`
+}
+
+// Effective returns the configuration the global logger is running with
+func Effective() EffectiveConfig {
+	mu.RLock()
+	cfg, sources := rootConfig, effectiveSources
+	level := globalLevel.Load()
+	mu.RUnlock()
+
+	eff := EffectiveConfig{
+		Level:       LevelString(level),
+		Format:      cfg.format(),
+		Caller:      cfg.WithCaller,
+		TimeFormat:  cfg.TimeFormat,
+		Timezone:    "Local",
+		NoTimestamp: cfg.NoTimestamp,
+		Output:      describeWriter(cfg.Output),
+		Fields:      cfg.Fields,
+		FieldNames:  cfg.FieldNames.withDefaults(),
+		Scoped:      cfg.Scoped,
+		Disabled:    cfg.Disabled,
+		Sources:     sources,
+	}
+	if cfg.Location != nil {
+		eff.Timezone = cfg.Location.String()
+	}
+	if len(cfg.Sinks) > 0 {
+		eff.Output = "sinks"
+		eff.Parallel = cfg.ParallelSinks
+	}
+	if cfg.ErrorOutput != nil {
+		eff.ErrorOutput = describeWriter(cfg.ErrorOutput)
+	}
+	for _, sink := range cfg.Sinks {
+		s := EffectiveSink{Output: describeWriter(sink.Writer), MinLevel: sink.MinLevel, Format: "json"}
+		if format, err := parseFormat(sink.Format); err == nil {
+			s.Format = format
+		}
+		eff.Sinks = append(eff.Sinks, s)
+	}
+	for name := range cfg.Loggers {
+		eff.Loggers = append(eff.Loggers, name)
+	}
+	sort.Strings(eff.Loggers)
+	return eff
+}
+
+// String renders the configuration as indented JSON
+func (e EffectiveConfig) String() string {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		// Fields may hold values JSON cannot represent; print the plain
+		// struct, without this method
+		type plain EffectiveConfig
+		return fmt.Sprintf("%+v", plain(e))
+	}
+	return string(data)
+}
+
+// describeWriter names a writer for display
+func describeWriter(w io.Writer) string {
+	switch w {
+	case os.Stderr:
+		return "stderr"
+	case os.Stdout:
+		return "stdout"
+	}
+	switch w := w.(type) {
+	case *os.File:
+		return w.Name()
+	case *FileSink:
+		return w.Path
+	case *LevelRouter:
+		if w.High == os.Stderr && w.Low == os.Stdout && w.Threshold == zerolog.WarnLevel {
+			return "split"
+		}
+	}
+	return fmt.Sprintf("%T", w)
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	file := writeConfig(t, "logger.yaml", "level: debug\nformat: logfmt\ncaller: true\nfields:\n  service: billing\n  env: dev\n")
+	t.Setenv(EnvLevel, "warn")
+	t.Setenv(EnvFields, "env=prod")
+
+	cfg, sources, err := resolve(Layers{
+		File:    file,
+		Env:     true,
+		Options: []Option{WithFormat("json"), WithOutput(io.Discard)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != "warn" || cfg.Format != "json" || !cfg.WithCaller || cfg.Output != io.Discard {
+		t.Errorf("got level %q format %q caller %v output %v", cfg.Level, cfg.Format, cfg.WithCaller, cfg.Output)
+	}
+	if cfg.Fields["service"] != "billing" || cfg.Fields["env"] != "prod" {
+		t.Errorf("Fields = %v, want service billing and env prod", cfg.Fields)
+	}
+	for key, want := range map[string]string{
+		"level":   SourceEnv,
+		"format":  SourceCode,
+		"caller":  SourceFile,
+		"fields":  SourceEnv,
+		"outputs": SourceCode,
+	} {
+		if sources[key] != want {
+			t.Errorf("source of %s = %q, want %q", key, sources[key], want)
+		}
+	}
+
+	t.Setenv(EnvLevel, "loud")
+	if _, err := Resolve(Layers{Env: true}); err == nil {
+		t.Error("no error for an invalid resolved level")
+	}
+}
+
+func TestEffective(t *testing.T) {
+	useGlobal(t, Config{
+		Level:       "warn",
+		Output:      io.Discard,
+		Sinks:       []SinkConfig{{Writer: io.Discard, MinLevel: "error", Format: "logfmt"}},
+		ErrorOutput: io.Discard,
+		Loggers:     map[string]Config{"db": {}, "api": {}},
+	})
+	eff := Effective()
+	if eff.Level != "warn" || eff.Output != "sinks" || eff.Timezone != "Local" {
+		t.Errorf("got %+v", eff)
+	}
+	if len(eff.Sinks) != 1 || eff.Sinks[0].Format != "logfmt" || eff.Sinks[0].MinLevel != "error" {
+		t.Errorf("Sinks = %+v", eff.Sinks)
+	}
+	if len(eff.Loggers) != 2 || eff.Loggers[0] != "api" || eff.Loggers[1] != "db" {
+		t.Errorf("Loggers = %v, want sorted api, db", eff.Loggers)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(eff.String()), &decoded); err != nil {
+		t.Errorf("String is not JSON: %v", err)
+	}
+}
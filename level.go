@@ -0,0 +1,316 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SetLevel changes the level of the global logger at runtime
+// It is safe for concurrent use and applies immediately to the package
+// functions, to loggers returned by GetLogger and WithField, and to named
+// loggers that do not configure a level of their own.
+func SetLevel(level string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	setGlobalLevel(lvl)
+	return nil
+}
+
+// setGlobalLevel implements SetLevel for a parsed level
+func setGlobalLevel(lvl zerolog.Level) {
+	mu.Lock()
+	globalLevel.Store(lvl)
+	rootConfig.Level = LevelString(lvl)
+	if effectiveSources != nil {
+		sources := make(map[string]string, len(effectiveSources))
+		for k, v := range effectiveSources {
+			sources[k] = v
+		}
+		sources["level"] = SourceCode
+		effectiveSources = sources
+	}
+	syncGlobalLevel()
+	mu.Unlock()
+
+	// Named loggers copy the global level when they are built
+	registry.relevel()
+}
+
+// GetLevel returns the name of the global logger's current level
+func GetLevel() string {
+	return LevelString(globalLevel.Load())
+}
+
+// Enabled reports whether the global logger writes entries at level, so
+// callers can skip building values that would be thrown away
+//
+//	if logger.DebugEnabled() {
+//		logger.Debug("state", "dump", expensiveDump())
+//	}
+func Enabled(level zerolog.Level) bool {
+	return std.Enabled(level)
+}
+
+// DebugEnabled reports whether Debug writes entries, false in
+// logger_nodbg builds
+func DebugEnabled() bool {
+	return std.DebugEnabled()
+}
+
+// TraceEnabled reports whether Trace writes entries, false in
+// logger_nodbg builds
+func TraceEnabled() bool {
+	return std.TraceEnabled()
+}
+
+// Enabled reports whether the logger writes entries at level, following
+// runtime level changes
+func (l *Logger) Enabled(level zerolog.Level) bool {
+	return l.state.Load().enabled(baseLevel(level))
+}
+
+// DebugEnabled reports whether the logger's Debug writes entries
+func (l *Logger) DebugEnabled() bool {
+	return debugCompiled && l.Enabled(zerolog.DebugLevel)
+}
+
+// TraceEnabled reports whether the logger's Trace writes entries
+func (l *Logger) TraceEnabled() bool {
+	return debugCompiled && l.Enabled(zerolog.TraceLevel)
+}
+
+// debugWindow tracks a temporary switch to debug started by EnableDebugFor
+var debugWindow struct {
+	sync.Mutex
+	timer    *time.Timer
+	gen      int // guards against a timer that fired while being replaced
+	previous zerolog.Level
+}
+
+// EnableDebugFor switches the global logger to debug for d and then
+// restores the level it had before. Calling it again while a window is
+// open extends the window instead of stacking another one. Both changes
+// are logged.
+func EnableDebugFor(d time.Duration) {
+	debugWindow.Lock()
+	defer debugWindow.Unlock()
+
+	if debugWindow.timer != nil {
+		debugWindow.timer.Stop()
+	} else {
+		debugWindow.previous = globalLevel.Load()
+	}
+	setGlobalLevel(zerolog.DebugLevel)
+	debugWindow.gen++
+	gen := debugWindow.gen
+	debugWindow.timer = time.AfterFunc(d, func() { endDebugWindow(gen) })
+
+	std.state.Load().zl.Info().
+		Str("duration", d.String()).
+		Str("restore_level", LevelString(debugWindow.previous)).
+		Msg("debug logging enabled temporarily")
+}
+
+// endDebugWindow restores the level saved by EnableDebugFor unless the
+// window was extended after the timer for gen fired
+func endDebugWindow(gen int) {
+	debugWindow.Lock()
+	defer debugWindow.Unlock()
+	if gen != debugWindow.gen {
+		return
+	}
+
+	previous := debugWindow.previous
+	debugWindow.timer = nil
+	std.state.Load().zl.Info().
+		Str("restore_level", LevelString(previous)).
+		Msg("temporary debug logging ended, restoring level")
+	setGlobalLevel(previous)
+}
+
+// WatchLevelSignals makes SIGUSR1 switch the global logger one level more
+// verbose and SIGUSR2 one level less, logging every change, so operators
+// can turn up logging on a running daemon with kill -USR1. The steps are
+// the levels in Levels, in order. The returned function stops watching.
+// On platforms without these signals it does nothing.
+func WatchLevelSignals() (stop func()) {
+	ch := make(chan os.Signal, 1)
+	notifyLevel(ch)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				stepLevel(levelStep(sig))
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// stepLevel moves the global level by step places in the order of
+// LevelNames, stopping at either end
+func stepLevel(step int) {
+	names := LevelNames()
+	from := globalLevel.Load()
+	i := sort.Search(len(names), func(i int) bool { return Levels[names[i]] >= from })
+	i = max(0, min(len(names)-1, i+step))
+	to := Levels[names[i]]
+	if to == from {
+		return
+	}
+	setGlobalLevel(to)
+
+	// Log without a level so the notice gets through whichever level is
+	// now in effect
+	std.state.Load().zl.Log().
+		Str("from", LevelString(from)).
+		Str("to", LevelString(to)).
+		Msg("log level changed by signal")
+}
+
+// SetLevel changes the logger's level at runtime
+// Child loggers created with WithField or Component share the change. A
+// named logger keeps the level when the global level changes later.
+func (l *Logger) SetLevel(level string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	lowerGlobalLevel(lvl)
+	st := l.state.Load()
+	st.level.Store(lvl)
+	st.level.explicit.Store(true)
+	return nil
+}
+
+// GetLevel returns the name of the logger's current level
+func (l *Logger) GetLevel() string {
+	return LevelString(l.state.Load().level.Load())
+}
+
+// ParseLevel maps a level name from Levels to a zerolog level, ignoring
+// case, and returns an error listing the supported names for anything else
+func ParseLevel(name string) (zerolog.Level, error) {
+	lvl, ok := Levels[strings.ToLower(name)]
+	if !ok {
+		return zerolog.NoLevel, fmt.Errorf("logger: unknown level %q, want one of %s", name, strings.Join(LevelNames(), ", "))
+	}
+	return lvl, nil
+}
+
+// LevelString returns the name ParseLevel accepts for lvl, the name of a
+// custom level, or zerolog's own name for any other level
+func LevelString(lvl zerolog.Level) string {
+	if c, ok := customLevelMap()[lvl]; ok {
+		return c.name
+	}
+	for name, l := range Levels {
+		if l == lvl {
+			return name
+		}
+	}
+	return lvl.String()
+}
+
+// LevelNames returns the names in Levels from the most to the least verbose
+func LevelNames() []string {
+	names := make([]string, 0, len(Levels))
+	for name := range Levels {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return Levels[names[i]] < Levels[names[j]]
+	})
+	return names
+}
+
+// atomicLevel is a log level that can be read and changed concurrently
+type atomicLevel struct {
+	v        atomic.Int32
+	explicit atomic.Bool // set with SetLevel, which global level changes keep
+}
+
+// newAtomicLevel returns an atomicLevel set to lvl
+func newAtomicLevel(lvl zerolog.Level) *atomicLevel {
+	a := &atomicLevel{}
+	a.Store(lvl)
+	return a
+}
+
+// Load returns the current level
+func (a *atomicLevel) Load() zerolog.Level {
+	return zerolog.Level(a.v.Load())
+}
+
+// Store sets the current level
+func (a *atomicLevel) Store(lvl zerolog.Level) {
+	a.v.Store(int32(lvl))
+}
+
+// levelHook discards entries below a level that can change at runtime
+// zerolog bakes a logger's level in when it is derived, so loggers that
+// must follow later level changes are built at TraceLevel and filtered by
+// this hook instead.
+type levelHook struct {
+	level *atomicLevel
+}
+
+// Run implements zerolog.Hook
+// A level set on the event's context with ContextWithLevel takes
+// precedence over the logger's.
+func (h levelHook) Run(e *zerolog.Event, lvl zerolog.Level, _ string) {
+	threshold, ok := LevelFromContext(e.GetCtx())
+	if !ok {
+		threshold = h.level.Load()
+	}
+	if baseLevel(lvl) < threshold {
+		e.Discard()
+	}
+}
+
+// leveled returns zl filtered by level, following later changes to it
+func leveled(zl zerolog.Logger, level *atomicLevel) zerolog.Logger {
+	return zl.Level(zerolog.TraceLevel).Hook(levelHook{level: level})
+}
+
+// lowerGlobalLevel makes sure zerolog's global level lets entries at lvl
+// through. zerolog drops anything below its global level before any of
+// our hooks run, so it has to stay at or below the most verbose level
+// any logger of this package uses.
+func lowerGlobalLevel(lvl zerolog.Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	if lvl < instanceFloor {
+		instanceFloor = lvl
+		syncGlobalLevel()
+	}
+}
+
+// syncGlobalLevel sets zerolog's global level to the most verbose of the
+// global logger's level and the levels of independent loggers, unless the
+// global logger is scoped. Callers must hold mu.
+func syncGlobalLevel() {
+	if scoped {
+		return
+	}
+	zerolog.SetGlobalLevel(min(globalLevel.Load(), instanceFloor))
+}
@@ -0,0 +1,187 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestSetLevel(t *testing.T) {
+	cfg, c := TestConfig()
+	cfg.Level = "info"
+	useGlobal(t, cfg)
+	child := WithField("request_id", "r1") // derived before the change
+	component := GetLogger("api")
+
+	if err := SetLevel("WARN"); err != nil {
+		t.Fatal(err)
+	}
+	if got := GetLevel(); got != "warn" {
+		t.Errorf("GetLevel = %s, want warn", got)
+	}
+	Info("dropped")
+	child.Info().Msg("dropped")
+	component.Info().Msg("dropped")
+	Warn("global")
+	child.Warn().Msg("child")
+	component.Warn().Msg("component")
+
+	if got := strings.Join(captured(c), ","); got != "global,child,component" {
+		t.Errorf("got %q", got)
+	}
+	if err := SetLevel("loud"); err == nil || GetLevel() != "warn" {
+		t.Errorf("SetLevel(loud) = %v, level now %s", err, GetLevel())
+	}
+}
+
+func TestSetLevelConcurrent(t *testing.T) {
+	useGlobal(t, Config{Output: &Capture{}})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				SetLevel([]string{"debug", "warn"}[j%2])
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Info("entry")
+				GetLevel()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLoggerSetLevel(t *testing.T) {
+	cfg, c := TestConfig()
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := l.Component("db")
+	if err := l.SetLevel("error"); err != nil {
+		t.Fatal(err)
+	}
+	child.Warn("dropped")
+	child.Error(nil, "kept")
+	if got := strings.Join(captured(c), ","); got != "kept" || l.GetLevel() != "error" {
+		t.Errorf("got %q at %s", got, l.GetLevel())
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	l, err := New(Config{Output: &Capture{}, Level: "info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for lvl, want := range map[zerolog.Level]bool{
+		zerolog.DebugLevel: false,
+		zerolog.InfoLevel:  true,
+		zerolog.ErrorLevel: true,
+		testAudit:          true, // custom levels count as their base
+	} {
+		if got := l.Enabled(lvl); got != want {
+			t.Errorf("Enabled(%s) = %v at info", LevelString(lvl), got)
+		}
+	}
+	if l.DebugEnabled() || l.TraceEnabled() {
+		t.Error("debug or trace enabled at info")
+	}
+	l.SetLevel("trace")
+	// Always false when the logger_nodbg tag compiles them out
+	if l.DebugEnabled() != debugCompiled || l.TraceEnabled() != debugCompiled {
+		t.Errorf("at trace DebugEnabled %v, TraceEnabled %v", l.DebugEnabled(), l.TraceEnabled())
+	}
+
+	useGlobal(t, Config{Output: &Capture{}, Level: "warn"})
+	if Enabled(zerolog.InfoLevel) || !Enabled(zerolog.WarnLevel) {
+		t.Error("global Enabled does not follow the warn level")
+	}
+	SetLevel("debug")
+	if DebugEnabled() != debugCompiled || TraceEnabled() {
+		t.Errorf("at debug DebugEnabled %v, TraceEnabled %v", DebugEnabled(), TraceEnabled())
+	}
+}
+
+func TestEnableDebugFor(t *testing.T) {
+	cfg, c := TestConfig()
+	cfg.Level = "warn"
+	useGlobal(t, cfg)
+
+	EnableDebugFor(100 * time.Millisecond)
+	EnableDebugFor(time.Second) // extends the window, keeping warn to restore
+	if got := GetLevel(); got != "debug" {
+		t.Fatalf("level %s in the window, want debug", got)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := GetLevel(); got != "debug" {
+		t.Fatalf("level %s after the first window, want debug until the extended one ends", got)
+	}
+	for deadline := time.Now().Add(5 * time.Second); GetLevel() != "warn"; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("level %s, want warn restored", GetLevel())
+		}
+	}
+	if got := strings.Join(captured(c), ","); !strings.Contains(got, "debug logging enabled temporarily") || !strings.HasSuffix(got, "temporary debug logging ended, restoring level") {
+		t.Errorf("got %q, want both changes logged", got)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for name, want := range map[string]zerolog.Level{
+		"trace": zerolog.TraceLevel,
+		"Debug": zerolog.DebugLevel,
+		"INFO":  zerolog.InfoLevel,
+		"warn":  zerolog.WarnLevel,
+		"panic": zerolog.PanicLevel,
+	} {
+		if got, err := ParseLevel(name); err != nil || got != want {
+			t.Errorf("ParseLevel(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	_, err := ParseLevel("warning")
+	if err == nil || !strings.Contains(err.Error(), "trace, debug, info, warn, error, fatal, panic, disabled") {
+		t.Errorf("got %v, want the supported names listed", err)
+	}
+	for _, name := range LevelNames() {
+		lvl, err := ParseLevel(name)
+		if err != nil || LevelString(lvl) != name {
+			t.Errorf("LevelString(ParseLevel(%q)) = %q, %v", name, LevelString(lvl), err)
+		}
+	}
+	if got := LevelString(zerolog.NoLevel); got != zerolog.NoLevel.String() {
+		t.Errorf("LevelString(NoLevel) = %q", got)
+	}
+}
+
+func TestStepLevel(t *testing.T) {
+	cfg, c := TestConfig()
+	cfg.Level = "info"
+	useGlobal(t, cfg)
+	for _, tt := range []struct {
+		step int
+		want string
+	}{
+		{-1, "debug"},
+		{-1, "trace"},
+		{-1, "trace"}, // stops at the most verbose level
+		{3, "warn"},
+		{1, "error"},
+	} {
+		stepLevel(tt.step)
+		if got := GetLevel(); got != tt.want {
+			t.Fatalf("step %d: level %s, want %s", tt.step, got, tt.want)
+		}
+	}
+	// Every change is logged, even once the level is above info
+	if got := strings.Count(c.String(), "log level changed by signal"); got != 4 {
+		t.Errorf("%d changes logged, want 4: %s", got, c.String())
+	}
+}
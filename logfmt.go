@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/rs/zerolog"
+)
+
+// logfmtWriter is the "logfmt" output format: each entry becomes a line of
+// key=value pairs, as Heroku's logfmt and the tools reading it expect
+//
+//	time=2026-10-14T13:55:36Z level=info message="request handled" component=http status=200
+//
+// The time, level, caller and message come first and the other fields
+// follow sorted by key. Values with spaces, quotes, equal signs or control
+// characters are quoted, and nested values are written as quoted JSON.
+func logfmtWriter(w io.Writer, _ Config) io.Writer {
+	return &encodingWriter{w: w, encode: appendLogfmt}
+}
+
+// appendLogfmt appends entry, a zerolog JSON entry, as a logfmt line to dst
+func appendLogfmt(dst []byte, lvl zerolog.Level, entry []byte) []byte {
+	_, fields := decodeEntry(bytes.TrimRight(entry, "\n"))
+	if lvl = entryLevel(lvl, fields); lvl != zerolog.NoLevel {
+		fields[zerolog.LevelFieldName] = LevelString(lvl)
+	}
+
+	first := []string{zerolog.TimestampFieldName, zerolog.LevelFieldName, zerolog.CallerFieldName, zerolog.MessageFieldName}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	start := len(dst)
+	pair := func(k string) {
+		if len(dst) > start {
+			dst = append(dst, ' ')
+		}
+		dst = appendLogfmtValue(append(dst, k...), fields[k])
+	}
+	for _, k := range first {
+		if _, ok := fields[k]; ok {
+			pair(k)
+			delete(fields, k)
+		}
+	}
+	for _, k := range keys {
+		if _, ok := fields[k]; ok {
+			pair(k)
+		}
+	}
+	return append(dst, '\n')
+}
+
+// appendLogfmtValue appends =v to dst, quoting v when needed
+func appendLogfmtValue(dst []byte, v interface{}) []byte {
+	dst = append(dst, '=')
+	if v == nil {
+		return dst
+	}
+	s := fieldString(v)
+	if s == "" || logfmtNeedsQuote(s) {
+		return strconv.AppendQuote(dst, s)
+	}
+	return append(dst, s...)
+}
+
+// logfmtNeedsQuote reports whether s has characters that end a bare value
+func logfmtNeedsQuote(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c <= ' ' || c == '=' || c == '"' || c == '\\' || c == 0x7f {
+			return true
+		}
+	}
+	return false
+}
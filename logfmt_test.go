@@ -0,0 +1,20 @@
+package logger
+
+import (
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestAppendLogfmt(t *testing.T) {
+	entry := `{"zone":"eu","message":"request handled","caller":"main.go:7","time":"2026-10-15T01:02:03Z","empty":"","none":null,"path":"/a=b","quote":"say \"hi\"","tab":"a\tb","http":{"status":200},"component":"http"}`
+	want := `time=2026-10-15T01:02:03Z level=warn caller=main.go:7 message="request handled" component=http empty="" http="{\"status\":200}" none= path="/a=b" quote="say \"hi\"" tab="a\tb" zone=eu` + "\n"
+	if got := string(appendLogfmt(nil, zerolog.WarnLevel, []byte(entry))); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Without a level from the logger, the entry's level field is used
+	if got := string(appendLogfmt([]byte("> "), zerolog.NoLevel, []byte(`{"level":"error","message":"boom"}`))); got != "> level=error message=boom\n" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"io"
 	"os"
 	"runtime"
-	"strings"
 	"sync"
 	"time"
 
@@ -19,6 +18,33 @@ var (
 
 	initOnce sync.Once
 
+	// std is the global logger behind the package-level functions. Its
+	// state is swapped as a whole by Reconfigure.
+	std = &Logger{}
+
+	// mu guards DefaultLogger and the global configuration so Reconfigure
+	// can swap them while other goroutines are logging
+	mu sync.RWMutex
+
+	// globalLevel is the level of the global logger
+	globalLevel = newAtomicLevel(zerolog.DebugLevel)
+
+	// instanceFloor is the most verbose level requested by any independent
+	// Logger, guarded by mu
+	instanceFloor = zerolog.Disabled
+
+	// scoped reports whether the global logger was installed with
+	// Config.Scoped, guarded by mu
+	scoped bool
+
+	// globalBuffered are the AsyncWriters and BatchWriters of the global
+	// logger, closed when it is reconfigured, guarded by mu
+	globalBuffered []bufferedWriter
+
+	// rootConfig is the configuration the global logger was built from,
+	// guarded by mu. Named loggers inherit unset fields from it.
+	rootConfig = withDefaults(Config{})
+
 	defaultConfig = Config{
 		Level:      "info",
 		Pretty:     false,
@@ -30,15 +56,169 @@ var (
 
 // Config defines configuration options for the logger
 type Config struct {
-	Level      string    // Log level: debug, info, warn, error, fatal, panic
+	Level      string    // Log level: trace, debug, info, warn, error, fatal, panic
 	Pretty     bool      // Enable pretty (human-readable) logging
 	WithCaller bool      // Include caller information in logs as a custom field
 	TimeFormat string    // Timestamp format
 	Output     io.Writer // Output writer (defaults to stderr)
+
+	// Format is the output format, replacing Pretty when set:
+	//
+	//	json     zerolog's JSON, the default
+	//	pretty   human-readable text, as with Pretty
+	//	expanded pretty with each field on a line of its own below the
+	//	         message, nested values as indented JSON and the stack one
+	//	         frame per line, for reading entries during development
+	//	logfmt   key=value lines, for tools that read Heroku's logfmt
+	//	cbor     the fields as a CBOR map per entry, like msgpack
+	//	ecs      Elastic Common Schema, with @timestamp, log.level,
+	//	         error.message, service.name, trace.id and the like, which
+	//	         Kibana reads without an ingest pipeline
+	//	logstash JSON with @timestamp and @version, as Logstash's json codec
+	//	         and logrus-logstash-hook have it
+	//	otel     OpenTelemetry log data model, with Body, SeverityNumber,
+	//	         Attributes, Resource and TraceId, which collectors ingest
+	//	         without transformation
+	//	gcp      Cloud Logging's structured JSON, with severity, time,
+	//	         sourceLocation and trace, for stdout on GKE and Cloud Run
+	//	cef      ArcSight's Common Event Format for SIEMs, see CEFWriter
+	//	rfc5424  syslog lines with the fields as structured data, see
+	//	         RFC5424Writer
+	//	msgpack  the fields as a MessagePack map per entry, more compact
+	//	         than JSON, see MsgpackDecoder
+	//	protobuf length-prefixed LogEntry messages of logentry.proto, see
+	//	         ProtoDecoder
+	//	common   NCSA Common Log Format lines of request entries, for access
+	//	         logs read by awstats or goaccess, see AccessLogWriter
+	//	combined Apache's Combined Log Format, common with the referer and
+	//	         user agent
+	//	csv      CSV rows of the CSVColumns, see CSVWriter
+	Format string
+
+	// CSVColumns are the columns of the csv format, DefaultCSVColumns if
+	// empty
+	CSVColumns []string
+
+	// Theme sets the colors of the pretty format, zerolog's own if nil
+	Theme *Theme
+
+	// PrettyFieldOrder lists fields the pretty and expanded formats write
+	// first, in this order, such as request_id, component and duration.
+	// The error still leads and the remaining fields follow sorted by key.
+	PrettyFieldOrder []string
+
+	// CallerSkipFrames is the number of extra stack frames to skip when
+	// reporting the caller, for code that wraps this package's functions
+	CallerSkipFrames int
+
+	// Location is the time zone timestamps are written in, the host's
+	// local zone if nil
+	Location *time.Location
+
+	// NoTimestamp leaves the timestamp out of entries, for collectors such
+	// as journald or Docker that stamp lines themselves
+	NoTimestamp bool
+
+	// DeadlineRemaining adds deadline_remaining_ms, the milliseconds left
+	// until the context's deadline, to entries logged with a context that
+	// has one, for following timeouts as they cascade through calls
+	DeadlineRemaining bool
+
+	// Goroutine adds the ID of the logging goroutine to every entry, and
+	// the runtime/pprof labels on the context of entries logged with one,
+	// for untangling the entries of concurrent work. Reading the ID costs
+	// a stack trace header per entry, so it is meant for debugging rather
+	// than for production.
+	Goroutine bool
+
+	// FieldNames renames the standard entry fields. zerolog keeps these
+	// names process-wide, so they are taken from the global logger's
+	// configuration and shared by every logger.
+	FieldNames FieldNames
+
+	// Scoped confines the configuration to this package's loggers: zerolog's
+	// global level, time format and field names and its log.Logger are left
+	// alone, so other code using zerolog directly is not affected. Settings
+	// installed by an earlier non-scoped configuration are not undone.
+	// FieldNames cannot be used with Scoped.
+	Scoped bool
+
+	// EnvLevelOverride lets the LOG_LEVEL environment variable replace
+	// Level, so operators can change the verbosity of a deployment without
+	// a code change. An unset or empty LOG_LEVEL keeps Level.
+	EnvLevelOverride bool
+
+	// ErrorOutput, if set, receives error, fatal and panic entries instead
+	// of Output or Sinks, in the same format
+	ErrorOutput io.Writer
+
+	// Sinks replace Output and Pretty with several destinations, each with
+	// its own threshold and format. If Level is empty it defaults to the
+	// most verbose threshold so every sink gets the entries it asks for.
+	Sinks []SinkConfig
+
+	// ParallelSinks writes each entry to all sinks at the same time rather
+	// than one after another, so a slow sink holds up the others less
+	ParallelSinks bool
+
+	// Async, if set, writes entries from a background goroutine so log
+	// calls do not wait for the output, see AsyncWriter. It covers Output,
+	// ErrorOutput and all Sinks together; SinkConfig.Async covers one sink.
+	// Call Flush or Close before exiting so buffered entries are written.
+	Async *AsyncConfig
+
+	// Batch, if set, gathers the entries for Output and ErrorOutput into
+	// larger writes, see BatchWriter. SinkConfig.Batch covers a sink.
+	Batch *BatchConfig
+
+	// Sampling keeps only some of the entries of the levels it names, from
+	// trace to error, see SampleRule
+	Sampling map[string]SampleRule
+
+	// RateLimit, if set, passes on only so many entries sharing a message,
+	// component or other field per interval, see RateLimitWriter
+	RateLimit *RateLimitConfig
+
+	// DedupWindow, if set, collapses runs of consecutive entries with the
+	// same level and message within the window into the first entry and
+	// one with a repeat_count, see DedupWriter
+	DedupWindow time.Duration
+
+	// Fields are static fields added to every log entry, including entries
+	// from loggers returned by GetLogger and WithField
+	Fields map[string]interface{}
+
+	// Schema and SchemaVersion, when set, are stamped on every entry as
+	// its first fields, schema and schema_version, so parsers downstream
+	// can tell which contract an entry follows as the program's fields
+	// evolve. JSONSchema describes the entries.
+	Schema        string
+	SchemaVersion string
+
+	// Disabled turns the logger into a no-op: every call returns after a
+	// level check, without building or writing an entry. Fatal still
+	// exits. Libraries can default to it so logging costs nothing until the
+	// program configures it.
+	Disabled bool
+
+	// Loggers configures the named loggers returned by Get, keyed by name
+	// A nil map keeps the named configuration already in place.
+	Loggers map[string]Config
+}
+
+// FieldNames holds the keys of the standard entry fields
+// Empty names keep the defaults: time, level, message, error and caller.
+type FieldNames struct {
+	Time    string `json:"time" yaml:"time" toml:"time"`
+	Level   string `json:"level" yaml:"level" toml:"level"`
+	Message string `json:"message" yaml:"message" toml:"message"`
+	Error   string `json:"error" yaml:"error" toml:"error"`
+	Caller  string `json:"caller" yaml:"caller" toml:"caller"`
 }
 
 // Standard log levels mapped to zerolog levels
 var Levels = map[string]zerolog.Level{
+	"trace":    zerolog.TraceLevel,
 	"debug":    zerolog.DebugLevel,
 	"info":     zerolog.InfoLevel,
 	"warn":     zerolog.WarnLevel,
@@ -53,138 +233,321 @@ var Levels = map[string]zerolog.Level{
 // including libraries that import this package.
 // This method is safe to call multiple times, but only the first call
 // will take effect to prevent configuration conflicts.
+// Use Reconfigure to change settings after the first initialization.
 func InitLogger(cfg Config) {
 	initOnce.Do(func() {
-		// Fill in defaults for any missing config values
-		if cfg.Output == nil {
-			cfg.Output = defaultConfig.Output
-		}
-		if cfg.TimeFormat == "" {
-			cfg.TimeFormat = defaultConfig.TimeFormat
-		}
+		apply(cfg)
+	})
+}
 
-		// Set global time format for all loggers
-		zerolog.TimeFieldFormat = cfg.TimeFormat
+// Reconfigure replaces the global logger settings with cfg, even if
+// InitLogger has already run. The level, output and format are swapped
+// in one step, so concurrent log calls see either the old or the new
+// configuration, never a mix of both.
+// A later InitLogger call will not override the settings applied here.
+func Reconfigure(cfg Config) {
+	// Consume the once so a late InitLogger doesn't undo the reconfiguration
+	initOnce.Do(func() {})
+	apply(cfg)
+}
 
-		// Set global log level - this affects ALL zerolog instances
-		level := zerolog.InfoLevel
-		if lvl, ok := Levels[strings.ToLower(cfg.Level)]; ok {
-			level = lvl
-		}
-		zerolog.SetGlobalLevel(level)
-
-		// Create and configure the logger
-		var logger zerolog.Logger
-		if cfg.Pretty {
-			logger = zerolog.New(zerolog.ConsoleWriter{
-				Out:        cfg.Output,
-				TimeFormat: cfg.TimeFormat,
-			})
-		} else {
-			logger = zerolog.New(cfg.Output)
-		}
+// apply builds a logger from cfg and installs it as the global logger
+func apply(cfg Config) {
+	cfg = withDefaults(cfg.withEnvLevel())
+	logger, bufs := newLogger(cfg, globalLevel)
+	level, levelErr := cfg.level()
 
-		// Add timestamp to all logs
-		logger = logger.With().Timestamp().Logger()
+	mu.Lock()
 
-		// Store caller setting in defaultConfig for use in log methods
-		// We'll handle caller differently by adding a custom field
-		defaultConfig.WithCaller = cfg.WithCaller
+	// Set global time format and field names for all loggers
+	// Scoped loggers write their own timestamps and leave these alone
+	scoped = cfg.Scoped
+	if !scoped {
+		zerolog.TimeFieldFormat = cfg.TimeFormat
+		cfg.FieldNames.install()
+	}
 
-		// Set both our package-level DefaultLogger and zerolog's global logger
-		// This ensures ALL code using either one will get the same configuration
-		DefaultLogger = logger
-		log.Logger = logger
+	// Set global log level - this affects ALL zerolog instances
+	// Independent loggers may hold it lower, the hook on DefaultLogger
+	// still enforces this level for the global logger itself
+	globalLevel.Store(level)
+	syncGlobalLevel()
+
+	// Set both our package-level DefaultLogger and zerolog's global logger
+	// This ensures ALL code using either one will get the same configuration
+	// The caller settings are kept with the state used by the log methods,
+	// which add the caller as a custom field
+	DefaultLogger = logger
+	std.state.Store(&loggerState{
+		zl:         DefaultLogger,
+		level:      globalLevel,
+		withCaller: cfg.WithCaller,
+		callerSkip: cfg.CallerSkipFrames,
 	})
+	if !scoped {
+		log.Logger = DefaultLogger
+	}
+	rootConfig = cfg
+	effectiveSources = nil
+	replaced := globalBuffered
+	globalBuffered = bufs
+	mu.Unlock()
+
+	// Entries logged through the old configuration are still buffered
+	closeBuffered(replaced)
+
+	// InitLogger has no error to return, so say why the level is not the
+	// one asked for rather than falling back silently
+	if levelErr != nil {
+		logger.Warn().Err(levelErr).Msg("logger: using info level")
+	}
+
+	// Rebuild the named loggers on top of the new global configuration
+	registry.reconfigure(cfg.Loggers)
+}
+
+// withDefaults fills in defaults for any missing config values
+func withDefaults(cfg Config) Config {
+	if cfg.Output == nil {
+		cfg.Output = defaultConfig.Output
+	}
+	if cfg.TimeFormat == "" {
+		cfg.TimeFormat = defaultConfig.TimeFormat
+	}
+	if cfg.Level == "" && len(cfg.Sinks) > 0 {
+		cfg.Level = sinksLevel(cfg.Sinks)
+	}
+	return cfg
+}
+
+// newLogger creates a zerolog logger writing to cfg.Output or cfg.Sinks in
+// the configured format, with a timestamp and the static fields on every
+// entry and the values carried by the context of entries logged with one.
+// It also returns the AsyncWriters and BatchWriters made for cfg, in the
+// order they were made. Entries below level, if not nil, are discarded
+// before any other hook runs.
+func newLogger(cfg Config, level *atomicLevel) (zerolog.Logger, []bufferedWriter) {
+	if cfg.Disabled {
+		return zerolog.Nop(), nil
+	}
+	var out io.Writer
+	var bufs []bufferedWriter
+	if len(cfg.Sinks) > 0 {
+		out = sinksWriter(cfg, &bufs)
+	} else {
+		out = formatWriter(batchWriter(cfg.Output, cfg.Batch, &bufs), cfg.format(), cfg)
+	}
+	if cfg.ErrorOutput != nil {
+		out = NewLevelRouter(formatWriter(batchWriter(cfg.ErrorOutput, cfg.Batch, &bufs), cfg.format(), cfg), out)
+	}
+	if cfg.RateLimit != nil {
+		out = rateLimitWriter(out, *cfg.RateLimit)
+	}
+	if cfg.DedupWindow > 0 {
+		out = &DedupWriter{Out: out, Window: cfg.DedupWindow}
+	}
+	if cfg.Async != nil {
+		a := newAsyncWriter(out, *cfg.Async)
+		out = a
+		bufs = append(bufs, a)
+	}
+	logger := zerolog.New(exitCloser{out: out})
+	if level != nil {
+		logger = leveled(logger, level)
+	}
+	if s := sampler(cfg.Sampling); s != nil {
+		logger = logger.Sample(s)
+	}
+
+	// Add the schema stamp, static fields and timestamp to all logs
+	if cfg.Schema != "" || cfg.SchemaVersion != "" {
+		logger = logger.With().Str(SchemaField, cfg.Schema).Str(SchemaVersionField, cfg.SchemaVersion).Logger()
+	}
+	if len(cfg.Fields) > 0 {
+		logger = logger.With().Fields(cfg.Fields).Logger()
+	}
+	if !cfg.NoTimestamp {
+		logger = withTimestamp(logger, cfg)
+	}
+	logger = logger.Hook(ctxHook{deadline: cfg.DeadlineRemaining})
+	if cfg.Goroutine {
+		logger = logger.Hook(goroutineHook{})
+	}
+	return logger, bufs
+}
+
+// consoleWriter formats entries for humans before writing them to w
+// A LevelRouter is formatted on both of its sides, since the console
+// writer would hide the entries' levels from it.
+func consoleWriter(w io.Writer, cfg Config) io.Writer {
+	if r, ok := w.(*LevelRouter); ok {
+		return &LevelRouter{High: consoleWriter(r.High, cfg), Low: consoleWriter(r.Low, cfg), Threshold: r.Threshold}
+	}
+	cw := zerolog.ConsoleWriter{
+		Out:          w,
+		TimeFormat:   cfg.TimeFormat,
+		TimeLocation: cfg.Location,
+		FieldsOrder:  cfg.PrettyFieldOrder,
+	}
+	if cfg.NoTimestamp {
+		cw.PartsExclude = []string{zerolog.TimestampFieldName}
+	}
+	if cfg.Theme != nil {
+		cfg.Theme.apply(&cw, cfg.TimeFormat)
+	}
+	return cw
+}
+
+// level returns the level cfg logs at, zerolog.Disabled if it is disabled
+func (cfg Config) level() (zerolog.Level, error) {
+	if cfg.Disabled {
+		return zerolog.Disabled, nil
+	}
+	return levelOf(cfg.Level)
+}
+
+// levelOf maps a level name to a zerolog level
+// An empty name means info. Unknown names fall back to info as well, with
+// the error from ParseLevel so the caller can report it.
+func levelOf(name string) (zerolog.Level, error) {
+	if name == "" {
+		return zerolog.InfoLevel, nil
+	}
+	lvl, err := ParseLevel(name)
+	if err != nil {
+		return zerolog.InfoLevel, err
+	}
+	return lvl, nil
 }
 
 // GetLogger returns a contextualized logger with the component field set
 // This is useful for identifying which module generated a log entry
 func GetLogger(component string) zerolog.Logger {
 	// Add component information and caller if enabled
-	context := DefaultLogger.With()
-	context = addCallerToContext(context).Str("component", component)
+	st := std.state.Load()
+	context := st.zl.With()
+	context = addCallerToContext(context, st).Str("component", component)
 	return context.Logger()
 }
 
 // addCallerInfo adds caller information to the event if WithCaller is enabled
-// skipFrames is the number of additional stack frames to skip (1 for this function)
-func addCallerInfo(evt *zerolog.Event) *zerolog.Event {
-	if defaultConfig.WithCaller {
+// st.callerSkip is the number of additional stack frames to skip
+func addCallerInfo(evt *zerolog.Event, st *loggerState) *zerolog.Event {
+	if st.withCaller {
 		// Get the caller's location (skipping the wrapper function)
-		_, file, line, ok := runtime.Caller(2) // Skip this function + caller
+		_, file, line, ok := runtime.Caller(2 + st.callerSkip) // Skip this function + caller
 		if ok {
-			evt = evt.Str("caller", fmt.Sprintf("%s:%d", file, line))
+			evt = evt.Str(zerolog.CallerFieldName, fmt.Sprintf("%s:%d", file, line))
 		}
 	}
 	return evt
 }
 
 // addCallerToContext adds caller information to the context if WithCaller is enabled
-func addCallerToContext(ctx zerolog.Context) zerolog.Context {
-	if defaultConfig.WithCaller {
+func addCallerToContext(ctx zerolog.Context, st *loggerState) zerolog.Context {
+	if st.withCaller {
 		// Get the caller's location (skipping the wrapper function)
-		_, file, line, ok := runtime.Caller(2) // Skip this function + caller
+		_, file, line, ok := runtime.Caller(2 + st.callerSkip) // Skip this function + caller
 		if ok {
-			ctx = ctx.Str("caller", fmt.Sprintf("%s:%d", file, line))
+			ctx = ctx.Str(zerolog.CallerFieldName, fmt.Sprintf("%s:%d", file, line))
 		}
 	}
 	return ctx
 }
 
-// processArgs handles the different argument formats for log messages
+// processArgs adds args to evt and sends it with msg. Fields are written
+// with their typed methods; other arguments are key/value pairs. msg is
+// never treated as a format string, so "disk 90% full" stays intact; the
+// f variants such as Infof format theirs.
 func processArgs(evt *zerolog.Event, msg string, args ...interface{}) {
-	if len(args) > 0 && strings.Contains(msg, "%") {
-		evt.Msgf(msg, args...)
-	} else if len(args) > 0 {
-		for i := 0; i < len(args); i += 2 {
-			if i+1 < len(args) {
-				evt = evt.Interface(fmt.Sprint(args[i]), args[i+1])
+	for i := 0; i < len(args); i++ {
+		if f, ok := args[i].(Field); ok {
+			evt = f.apply(evt)
+			continue
+		}
+		if i+1 < len(args) {
+			key, ok := args[i].(string)
+			if !ok {
+				key = fmt.Sprint(args[i])
 			}
+			evt = evt.Interface(key, args[i+1])
+			i++
 		}
-		evt.Msg(msg)
-	} else {
-		evt.Msg(msg)
 	}
-}
-
-// Debug logs a debug message
-func Debug(msg string, args ...interface{}) {
-	evt := addCallerInfo(DefaultLogger.Debug())
-	processArgs(evt, msg, args...)
+	evt.Msg(msg)
 }
 
 // Info logs an info message
 func Info(msg string, args ...interface{}) {
-	evt := addCallerInfo(DefaultLogger.Info())
-	processArgs(evt, msg, args...)
+	if st := std.state.Load(); st.enabled(zerolog.InfoLevel) {
+		processArgs(addCallerInfo(st.zl.Info(), st), msg, args...)
+	}
 }
 
 // Warn logs a warning message
 func Warn(msg string, args ...interface{}) {
-	evt := addCallerInfo(DefaultLogger.Warn())
-	processArgs(evt, msg, args...)
+	if st := std.state.Load(); st.enabled(zerolog.WarnLevel) {
+		processArgs(addCallerInfo(st.zl.Warn(), st), msg, args...)
+	}
 }
 
 // Error logs an error message
 func Error(err error, msg string, args ...interface{}) {
-	evt := addCallerInfo(DefaultLogger.Error().Err(err))
-	processArgs(evt, msg, args...)
+	if st := std.state.Load(); st.enabled(zerolog.ErrorLevel) {
+		processArgs(addCallerInfo(st.zl.Error().Err(err), st), msg, args...)
+	}
 }
 
 // Fatal logs a fatal message and exits
 func Fatal(err error, msg string, args ...interface{}) {
-	evt := addCallerInfo(DefaultLogger.Fatal().Err(err))
-	processArgs(evt, msg, args...)
+	st := std.state.Load()
+	processArgs(addCallerInfo(st.zl.Fatal().Err(err), st), msg, args...)
+}
+
+// Infof logs an info message formatted with fmt.Sprintf
+func Infof(format string, args ...interface{}) {
+	if st := std.state.Load(); st.enabled(zerolog.InfoLevel) {
+		addCallerInfo(st.zl.Info(), st).Msgf(format, args...)
+	}
+}
+
+// Warnf logs a warning message formatted with fmt.Sprintf
+func Warnf(format string, args ...interface{}) {
+	if st := std.state.Load(); st.enabled(zerolog.WarnLevel) {
+		addCallerInfo(st.zl.Warn(), st).Msgf(format, args...)
+	}
+}
+
+// Errorf logs an error message formatted with fmt.Sprintf
+func Errorf(err error, format string, args ...interface{}) {
+	if st := std.state.Load(); st.enabled(zerolog.ErrorLevel) {
+		addCallerInfo(st.zl.Error().Err(err), st).Msgf(format, args...)
+	}
+}
+
+// Fatalf logs a fatal message formatted with fmt.Sprintf and exits
+func Fatalf(err error, format string, args ...interface{}) {
+	st := std.state.Load()
+	addCallerInfo(st.zl.Fatal().Err(err), st).Msgf(format, args...)
 }
 
 // WithField adds a field to the logger context
 func WithField(key string, value interface{}) zerolog.Logger {
-	context := DefaultLogger.With()
-	context = addCallerToContext(context).Interface(key, value)
+	st := std.state.Load()
+	context := st.zl.With()
+	context = addCallerToContext(context, st).Interface(key, value)
 	return context.Logger()
 }
 
+// WithCallerSkip returns a logger writing through the global logger that
+// skips n more stack frames when reporting the caller. Wrappers around this
+// package use it so the caller field points at their own callers:
+//
+//	func LogInfo(msg string) { logger.WithCallerSkip(1).Info(msg) }
+func WithCallerSkip(n int) *Logger {
+	return std.WithCallerSkip(n)
+}
+
 // FormatError creates a formatted error string
 func FormatError(err error) string {
 	if err == nil {
@@ -197,6 +560,7 @@ func init() {
 	// Initialize with default configuration
 	// This ensures logger works before explicit initialization
 	// The first call to InitLogger will override these settings
-	DefaultLogger = zerolog.New(os.Stderr).With().Timestamp().Logger()
+	DefaultLogger = leveled(zerolog.New(os.Stderr), globalLevel).With().Timestamp().Logger()
 	log.Logger = DefaultLogger
+	std.state.Store(&loggerState{zl: DefaultLogger, level: globalLevel})
 }
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// useGlobal reconfigures the global logger with cfg until the test ends
+func useGlobal(t *testing.T, cfg Config) {
+	t.Helper()
+	Reconfigure(cfg)
+	t.Cleanup(func() { Reconfigure(Config{Output: io.Discard, Loggers: map[string]Config{}}) })
+}
+
+// captured returns the messages of the entries in c
+func captured(c *Capture) []string {
+	var msgs []string
+	for _, e := range c.Entries() {
+		msg, _ := e["message"].(string)
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func TestReconfigure(t *testing.T) {
+	var first, second Capture
+	useGlobal(t, Config{Level: "info", Output: &first})
+	Info("one")
+	Reconfigure(Config{Level: "warn", Output: &second})
+	Info("dropped")
+	Warn("two")
+
+	// InitLogger after Reconfigure leaves the settings alone
+	InitLogger(Config{Level: "debug", Output: &first})
+	Warn("three")
+
+	if got := captured(&first); len(got) != 1 || got[0] != "one" {
+		t.Errorf("first output got %q, want one", got)
+	}
+	if got := captured(&second); len(got) != 2 || got[0] != "two" || got[1] != "three" {
+		t.Errorf("second output got %q, want two, three", got)
+	}
+}
+
+func TestDefaultFields(t *testing.T) {
+	cfg, c := TestConfig()
+	cfg.Fields = map[string]interface{}{"service": "billing", "version": 3}
+	useGlobal(t, cfg)
+	Info("plain")
+	Error(errors.New("boom"), "failed")
+	l := WithField("request_id", "r1")
+	l.Info().Msg("child")
+
+	entries := c.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3: %s", len(entries), c.String())
+	}
+	for _, e := range entries {
+		if e["service"] != "billing" || e["version"] != 3.0 {
+			t.Errorf("%v: static fields missing", e)
+		}
+	}
+	if entries[2]["request_id"] != "r1" {
+		t.Errorf("%v: child field missing", entries[2])
+	}
+}
+
+func TestScopedLeavesZerologAlone(t *testing.T) {
+	useGlobal(t, Config{Output: io.Discard})
+	globalLevel, timeFormat, messageKey := zerolog.GlobalLevel(), zerolog.TimeFieldFormat, zerolog.MessageFieldName
+
+	cfg, c := TestConfig()
+	cfg.Level = "error"
+	cfg.Scoped = true
+	cfg.TimeFormat = "2006-01-02"
+	Reconfigure(cfg)
+	Warn("dropped")
+	Error(nil, "kept")
+
+	if zerolog.GlobalLevel() != globalLevel || zerolog.TimeFieldFormat != timeFormat || zerolog.MessageFieldName != messageKey {
+		t.Error("scoped configuration changed zerolog's global settings")
+	}
+	log.Error().Msg("through zerolog's log.Logger")
+	entries := c.Entries()
+	if len(entries) != 1 || entries[0]["message"] != "kept" {
+		t.Fatalf("got %s, want only kept", c.String())
+	}
+	if ts, _ := entries[0]["time"].(string); len(ts) != len("2006-01-02") {
+		t.Errorf("time %q, want the scoped format", ts)
+	}
+}
+
+func TestFormattedVariants(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Infof("served %d of %s", 3, "requests")
+	l.Warnf("disk %d%% full", 90)
+	l.Errorf(errors.New("declined"), "charge %s failed", "A-17")
+	// Plain messages are never format strings, with or without args
+	l.Info("disk 90% full")
+	l.Info("rate %d", "n", 5)
+	want := `{"level":"info","message":"served 3 of requests"}` + "\n" +
+		`{"level":"warn","message":"disk 90% full"}` + "\n" +
+		`{"level":"error","error":"declined","message":"charge A-17 failed"}` + "\n" +
+		`{"level":"info","message":"disk 90% full"}` + "\n" +
+		`{"level":"info","n":5,"message":"rate %d"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	var c Capture
+	useGlobal(t, Config{Output: &c})
+	Infof("a%d", 1)
+	Warnf("b%d", 2)
+	Errorf(nil, "c%d", 3)
+	if got := strings.Join(captured(&c), ","); got != "a1,b2,c3" {
+		t.Errorf("global got %q", got)
+	}
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"io"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// logstashWriter wraps w so entries are written the way Logstash's json
+// and json_lines codecs expect them, as logrus-logstash-hook writes them:
+//
+//	{"@timestamp":"2024-05-01T12:00:00.000Z","@version":"1","level":"warning","message":"retrying",
+//		"component":"billing","attempt":2,"order":{"id":"A-17","total":42}}
+//
+// The time becomes @timestamp with millisecond precision, the level keeps
+// logrus's names, so warn is written as warning, and the other fields stay
+// at the top level with nested objects kept nested. Fields named
+// @timestamp or @version are moved to fields.@timestamp and
+// fields.@version, as the hook does for clashing fields.
+func logstashWriter(w io.Writer, cfg Config) io.Writer {
+	return &encodingWriter{w: w, encode: func(dst []byte, lvl zerolog.Level, entry []byte) []byte {
+		return appendLogstash(dst, lvl, entry, cfg.TimeFormat)
+	}}
+}
+
+// appendLogstash appends entry, a zerolog JSON entry, in Logstash form to
+// dst
+func appendLogstash(dst []byte, lvl zerolog.Level, entry []byte, timeFormat string) []byte {
+	msg, fields := decodeEntry(entry)
+	var o jsonObject
+
+	// Logstash stamps entries without one with the time it reads them,
+	// which is later than they were logged
+	t, ok := entryTime(fields[zerolog.TimestampFieldName], timeFormat)
+	if !ok {
+		t = time.Now()
+	}
+	delete(fields, zerolog.TimestampFieldName)
+	o.add("@timestamp", t.Format("2006-01-02T15:04:05.000Z07:00"))
+	o.add("@version", "1")
+	if lvl = entryLevel(lvl, fields); lvl != zerolog.NoLevel {
+		name := LevelString(lvl)
+		if lvl == zerolog.WarnLevel {
+			name = "warning"
+		}
+		o.add("level", name)
+	}
+	delete(fields, zerolog.LevelFieldName)
+	o.add("message", msg)
+	delete(fields, zerolog.MessageFieldName)
+
+	for _, key := range []string{"@timestamp", "@version"} {
+		if v, ok := fields[key]; ok {
+			fields["fields."+key] = v
+			delete(fields, key)
+		}
+	}
+	o.addSorted(fields)
+	return o.line(dst)
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestAppendLogstash(t *testing.T) {
+	entry := `{"level":"warn","time":"2026-10-15T01:02:03.5Z","component":"billing","order":{"id":"A-17","total":42},"@version":"2","@timestamp":"then","message":"retrying"}`
+	want := `{"@timestamp":"2026-10-15T01:02:03.500Z","@version":"1","level":"warning","message":"retrying","component":"billing","fields.@timestamp":"then","fields.@version":"2","order":{"id":"A-17","total":42}}` + "\n"
+	if got := string(appendLogstash(nil, zerolog.NoLevel, []byte(entry), "")); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Other levels keep their names, and the level from the logger wins
+	got := string(appendLogstash(nil, zerolog.ErrorLevel, []byte(`{"level":"info","time":1792026123,"message":"boom"}`), zerolog.TimeFormatUnix))
+	stamp := time.Unix(1792026123, 0).Format("2006-01-02T15:04:05.000Z07:00")
+	if want := `{"@timestamp":"` + stamp + `","@version":"1","level":"error","message":"boom"}` + "\n"; got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLogstashFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, Format: "logstash", NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().Truncate(time.Millisecond)
+	l.Info("served", "status", 200)
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	// Entries without a time are stamped when written
+	ts, err := time.Parse(time.RFC3339, entry["@timestamp"].(string))
+	if err != nil || ts.Before(before) || ts.After(time.Now()) {
+		t.Errorf("@timestamp %v, %v", entry["@timestamp"], err)
+	}
+	if entry["@version"] != "1" || entry["level"] != "info" || entry["message"] != "served" || entry["status"] != 200.0 {
+		t.Errorf("entry %v", entry)
+	}
+}
@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// LokiSink pushes entries to Grafana Loki's push API in batches from a
+// background goroutine, so small deployments need no promtail
+//
+//	sink := &logger.LokiSink{
+//		URL:    "http://loki:3100",
+//		Labels: map[string]string{"service": "api"},
+//	}
+//	defer sink.Close()
+//	logger.InitLogger(logger.Config{Output: sink})
+//
+// Each entry is pushed as its JSON line. Its stream is identified by the
+// static Labels plus one label per name in LabelFields taken from the
+// entry, level and component unless set; entries without the field leave
+// that label out. Label names are sanitized to Loki's rules. Failed pushes
+// are retried with backoff.
+type LokiSink struct {
+	URL           string            // Loki base URL, such as http://loki:3100, required
+	Labels        map[string]string // Labels of every stream, such as service or env
+	LabelFields   []string          // Entry fields that become labels, level and component if nil
+	TenantID      string            // Sent as X-Scope-OrgID for multi-tenant Loki
+	Username      string            // Basic auth user, such as a Grafana Cloud instance ID
+	Password      string            // Basic auth password or API token
+	Client        *http.Client      // HTTP client, http.DefaultClient if nil
+	BatchSize     int               // Entries per push, 100 if zero
+	FlushInterval time.Duration     // Longest wait before pushing, 5s if zero
+	QueueWait     time.Duration     // Longest a log call waits for room in a full queue, none if zero
+	OnError       func(error)       // Called when entries are lost, stderr if nil
+
+	setup sync.Once
+	batch entryBatcher
+}
+
+// lokiStream is one stream of a push request
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// Write implements io.Writer for entries without a known level
+func (s *LokiSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *LokiSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if err := s.batcher().add(lvl, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush pushes the queued entries and waits until they are delivered
+func (s *LokiSink) Flush() error {
+	s.batcher().flush()
+	return nil
+}
+
+// Close pushes the queued entries and stops the background goroutine
+func (s *LokiSink) Close() error {
+	s.batcher().close()
+	return nil
+}
+
+// batcher returns the sink's batcher, configured from its fields on
+// first use
+func (s *LokiSink) batcher() *entryBatcher {
+	s.setup.Do(func() {
+		s.batch = entryBatcher{send: s.send, size: s.BatchSize, interval: s.FlushInterval, wait: s.QueueWait, onError: s.OnError}
+	})
+	return &s.batch
+}
+
+// send pushes one batch, grouped into streams by label set
+func (s *LokiSink) send(batch []batchEntry) error {
+	if s.URL == "" {
+		return permanentError{fmt.Errorf("logger: loki: URL is required")}
+	}
+	streams := map[string]*lokiStream{}
+	var order []string
+	for _, e := range batch {
+		labels := s.labels(e)
+		key := lokiKey(labels)
+		st, ok := streams[key]
+		if !ok {
+			st = &lokiStream{Stream: labels}
+			streams[key] = st
+			order = append(order, key)
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(e.time.UnixNano(), 10), string(e.data)})
+	}
+	push := struct {
+		Streams []*lokiStream `json:"streams"`
+	}{}
+	for _, key := range order {
+		push.Streams = append(push.Streams, streams[key])
+	}
+	body, err := json.Marshal(push)
+	if err != nil {
+		return permanentError{err}
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, strings.TrimRight(s.URL, "/")+"/loki/api/v1/push", bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", s.TenantID)
+	}
+	if s.Username != "" || s.Password != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+	if err := doHTTP(s.Client, req); err != nil {
+		return fmt.Errorf("logger: loki: %w", err)
+	}
+	return nil
+}
+
+// labels returns the stream labels of one entry
+func (s *LokiSink) labels(e batchEntry) map[string]string {
+	labels := make(map[string]string, len(s.Labels)+2)
+	for k, v := range s.Labels {
+		labels[lokiLabel(k)] = v
+	}
+	names := s.LabelFields
+	if names == nil {
+		names = []string{zerolog.LevelFieldName, "component"}
+	}
+	var fields map[string]interface{}
+	for _, name := range names {
+		if name == zerolog.LevelFieldName && e.level != zerolog.NoLevel {
+			labels[lokiLabel(name)] = LevelString(e.level)
+			continue
+		}
+		if fields == nil {
+			_, fields = decodeEntry(e.data)
+		}
+		if v, ok := fields[name]; ok {
+			labels[lokiLabel(name)] = fieldString(v)
+		}
+	}
+	return labels
+}
+
+// lokiKey identifies a label set
+func lokiKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k + "\x00" + labels[k] + "\x00")
+	}
+	return b.String()
+}
+
+// lokiLabel makes name a valid Prometheus label name
+func lokiLabel(name string) string {
+	label := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if label == "" || label[0] >= '0' && label[0] <= '9' {
+		label = "_" + label
+	}
+	return label
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestLokiLabel(t *testing.T) {
+	for name, want := range map[string]string{
+		"level":       "level",
+		"http.method": "http_method",
+		"k8s-pod":     "k8s_pod",
+		"2xx":         "_2xx",
+		"":            "_",
+	} {
+		if got := lokiLabel(name); got != want {
+			t.Errorf("lokiLabel(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestLokiSinkStreams(t *testing.T) {
+	var mu sync.Mutex
+	var pushes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/loki/api/v1/push" || r.Header.Get("X-Scope-OrgID") != "acme" {
+			t.Errorf("push to %s as %q", r.URL.Path, r.Header.Get("X-Scope-OrgID"))
+		}
+		if user, pass, _ := r.BasicAuth(); user != "1234" || pass != "token" {
+			t.Errorf("basic auth %q:%q", user, pass)
+		}
+		var push struct {
+			Streams []struct {
+				Stream map[string]string `json:"stream"`
+				Values [][2]string       `json:"values"`
+			} `json:"streams"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, st := range push.Streams {
+			labels, _ := json.Marshal(st.Stream)
+			for _, v := range st.Values {
+				pushes = append(pushes, string(labels)+" "+v[1])
+			}
+		}
+	}))
+	defer srv.Close()
+
+	s := &LokiSink{
+		URL:      srv.URL + "/",
+		Labels:   map[string]string{"service.name": "api"},
+		TenantID: "acme",
+		Username: "1234",
+		Password: "token",
+	}
+	s.WriteLevel(zerolog.InfoLevel, []byte(`{"component":"db","message":"a"}`+"\n"))
+	s.WriteLevel(zerolog.ErrorLevel, []byte(`{"message":"b"}`+"\n"))
+	s.WriteLevel(zerolog.InfoLevel, []byte(`{"component":"db","message":"c"}`+"\n"))
+	s.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		`{"component":"db","level":"info","service_name":"api"} {"component":"db","message":"a"}`,
+		`{"component":"db","level":"info","service_name":"api"} {"component":"db","message":"c"}`,
+		`{"level":"error","service_name":"api"} {"message":"b"}`,
+	}
+	if len(pushes) != len(want) {
+		t.Fatalf("pushed %q, want %q", pushes, want)
+	}
+	for i := range want {
+		if pushes[i] != want[i] {
+			t.Errorf("push %d\n%s\nwant\n%s", i, pushes[i], want[i])
+		}
+	}
+}
+
+func TestLokiSinkLabelFields(t *testing.T) {
+	s := &LokiSink{LabelFields: []string{"level", "status"}}
+	got := s.labels(batchEntry{level: zerolog.NoLevel, data: []byte(`{"level":"warn","status":503,"component":"db"}`)})
+	if b, _ := json.Marshal(got); string(b) != `{"level":"warn","status":"503"}` {
+		t.Errorf("labels %s", b)
+	}
+}
@@ -0,0 +1,368 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// MQTT 3.1.1 control packet types, shifted into the fixed header
+const (
+	mqttConnect    = 0x10
+	mqttConnack    = 0x20
+	mqttPublish    = 0x30
+	mqttPuback     = 0x40
+	mqttPubrec     = 0x50
+	mqttPubrel     = 0x62 // with its reserved flags
+	mqttPubcomp    = 0x70
+	mqttPingreq    = 0xc0
+	mqttPingresp   = 0xd0
+	mqttDisconnect = 0xe0
+)
+
+// MQTTSink publishes each entry to an MQTT broker, for devices that report
+// into an IoT broker anyway
+//
+//	sink := &logger.MQTTSink{
+//		Broker:      "broker.local:1883",
+//		Topic:       "fleet/device-42/logs/{level}",
+//		QoS:         1,
+//		WillTopic:   "fleet/device-42/status",
+//		WillMessage: "offline",
+//	}
+//	defer sink.Close()
+//
+// Topic may contain placeholders like NATSSink's subject, such as
+// {component} or {level}. With QoS 1 or 2 each publish waits for the
+// broker's acknowledgment. The will message is published by the broker
+// when the connection drops without Close, for example when the device
+// loses power. The sink speaks MQTT 3.1.1 itself, pings the broker to keep
+// the connection alive and reconnects once when a publish fails.
+type MQTTSink struct {
+	Broker      string        // host:port of the broker, required
+	TLSConfig   *tls.Config   // Connect with TLS when set
+	ClientID    string        // Client identifier, derived from the host and program if empty
+	Username    string        // Sent in CONNECT when set
+	Password    string        // Sent in CONNECT when set
+	Topic       string        // Topic template, "logs" if empty
+	QoS         byte          // 0, 1 or 2
+	Retain      bool          // Publish entries as retained messages
+	WillTopic   string        // Topic of the last will, no will if empty
+	WillMessage string        // Payload of the last will
+	WillRetain  bool          // Retain the last will
+	KeepAlive   time.Duration // Keep-alive interval, 60s if zero
+
+	mu     sync.Mutex
+	conn   net.Conn
+	r      *bufio.Reader
+	nextID uint16
+	active time.Time // of the last packet sent
+	stop   chan struct{}
+}
+
+// mqttTimeout bounds connecting and waiting for acknowledgments
+const mqttTimeout = 10 * time.Second
+
+// Write implements io.Writer for entries without a known level
+func (s *MQTTSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *MQTTSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	payload := bytes.TrimRight(p, "\n")
+	topic := s.topic(lvl, payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.publish(topic, payload); err != nil {
+		// The broker may have restarted; try once more on a new connection
+		s.closeConn()
+		if err := s.publish(topic, payload); err != nil {
+			return 0, fmt.Errorf("logger: mqtt: %w", err)
+		}
+	}
+	return len(p), nil
+}
+
+// Close disconnects cleanly, so the broker does not publish the will
+func (s *MQTTSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	s.conn.Write([]byte{mqttDisconnect, 0})
+	return s.closeConn()
+}
+
+// topic expands the topic template for one entry
+func (s *MQTTSink) topic(lvl zerolog.Level, entry []byte) string {
+	t := s.Topic
+	if t == "" {
+		t = "logs"
+	}
+	if !strings.Contains(t, "{") {
+		return t
+	}
+	var fields map[string]interface{}
+	return expandPlaceholders(t, func(name string) string {
+		if name == "level" && lvl != zerolog.NoLevel {
+			return LevelString(lvl)
+		}
+		if fields == nil {
+			_, fields = decodeEntry(entry)
+		}
+		if name == "level" {
+			name = zerolog.LevelFieldName
+		}
+		v, ok := fields[name]
+		if !ok || fieldString(v) == "" {
+			return "unknown"
+		}
+		// Keep values to one topic level without wildcards
+		return strings.NewReplacer("/", "_", "+", "_", "#", "_").Replace(fieldString(v))
+	})
+}
+
+// publish sends one PUBLISH and completes its QoS flow, connecting first
+// if needed. Callers must hold s.mu.
+func (s *MQTTSink) publish(topic string, payload []byte) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+	qos := min(s.QoS, 2)
+	var b bytes.Buffer
+	mqttString(&b, topic)
+	var id uint16
+	if qos > 0 {
+		s.nextID++
+		if s.nextID == 0 {
+			s.nextID = 1
+		}
+		id = s.nextID
+		binary.Write(&b, binary.BigEndian, id)
+	}
+	b.Write(payload)
+	header := byte(mqttPublish) | qos<<1
+	if s.Retain {
+		header |= 1
+	}
+	if err := s.send(header, b.Bytes()); err != nil {
+		return err
+	}
+
+	switch qos {
+	case 1:
+		return s.expect(mqttPuback, id)
+	case 2:
+		if err := s.expect(mqttPubrec, id); err != nil {
+			return err
+		}
+		if err := s.send(mqttPubrel, binary.BigEndian.AppendUint16(nil, id)); err != nil {
+			return err
+		}
+		return s.expect(mqttPubcomp, id)
+	}
+	return nil
+}
+
+// connect dials the broker, sends CONNECT with the will and starts the
+// keep-alive pinger. Callers must hold s.mu.
+func (s *MQTTSink) connect() error {
+	dialer := &net.Dialer{Timeout: mqttTimeout}
+	var conn net.Conn
+	var err error
+	if s.TLSConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.Broker, s.TLSConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", s.Broker)
+	}
+	if err != nil {
+		return err
+	}
+	s.conn, s.r = conn, bufio.NewReader(conn)
+
+	keepAlive := s.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = time.Minute
+	}
+	var flags byte = 0x02 // clean session
+	var payload bytes.Buffer
+	mqttString(&payload, s.clientID())
+	if s.WillTopic != "" {
+		flags |= 0x04 | min(s.QoS, 2)<<3
+		if s.WillRetain {
+			flags |= 0x20
+		}
+		mqttString(&payload, s.WillTopic)
+		mqttString(&payload, s.WillMessage)
+	}
+	if s.Username != "" {
+		flags |= 0x80
+		mqttString(&payload, s.Username)
+	}
+	if s.Password != "" {
+		flags |= 0x40
+		mqttString(&payload, s.Password)
+	}
+	var b bytes.Buffer
+	mqttString(&b, "MQTT")
+	b.WriteByte(4) // protocol level 3.1.1
+	b.WriteByte(flags)
+	binary.Write(&b, binary.BigEndian, uint16(keepAlive/time.Second))
+	b.Write(payload.Bytes())
+	if err := s.send(mqttConnect, b.Bytes()); err != nil {
+		s.closeConn()
+		return err
+	}
+
+	kind, body, err := s.read()
+	if err == nil && (kind != mqttConnack || len(body) != 2) {
+		err = fmt.Errorf("unexpected packet 0x%02x instead of CONNACK", kind)
+	}
+	if err == nil && body[1] != 0 {
+		err = fmt.Errorf("connection refused with return code %d", body[1])
+	}
+	if err != nil {
+		s.closeConn()
+		return err
+	}
+
+	s.stop = make(chan struct{})
+	go s.ping(s.stop, keepAlive)
+	return nil
+}
+
+// ping sends PINGREQ when the connection has been idle for half the
+// keep-alive interval, until stop is closed
+func (s *MQTTSink) ping(stop chan struct{}, keepAlive time.Duration) {
+	ticker := time.NewTicker(keepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		s.mu.Lock()
+		if s.conn != nil && time.Since(s.active) >= keepAlive/2 {
+			err := s.send(mqttPingreq, nil)
+			if err == nil {
+				_, _, err = s.read()
+			}
+			if err != nil {
+				s.closeConn()
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// send writes one packet. Callers must hold s.mu.
+func (s *MQTTSink) send(header byte, body []byte) error {
+	packet := []byte{header}
+	for n := len(body); ; {
+		digit := byte(n % 128)
+		if n /= 128; n > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if n == 0 {
+			break
+		}
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(mqttTimeout))
+	_, err := s.conn.Write(append(packet, body...))
+	s.active = time.Now()
+	return err
+}
+
+// read reads one packet and returns its type and body. Callers must hold
+// s.mu.
+func (s *MQTTSink) read() (byte, []byte, error) {
+	s.conn.SetReadDeadline(time.Now().Add(mqttTimeout))
+	kind, err := s.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, shift := 0, 0
+	for {
+		digit, err := s.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length |= int(digit&0x7f) << shift
+		if digit&0x80 == 0 {
+			break
+		}
+		if shift += 7; shift > 21 {
+			return 0, nil, fmt.Errorf("malformed remaining length")
+		}
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(s.r, body); err != nil {
+		return 0, nil, err
+	}
+	return kind, body, nil
+}
+
+// expect reads packets until the acknowledgment of kind for id arrives.
+// Callers must hold s.mu.
+func (s *MQTTSink) expect(kind byte, id uint16) error {
+	for {
+		got, body, err := s.read()
+		if err != nil {
+			return err
+		}
+		if got == kind && len(body) >= 2 && binary.BigEndian.Uint16(body) == id {
+			return nil
+		}
+		if got == mqttPingresp {
+			continue
+		}
+		return fmt.Errorf("unexpected packet 0x%02x waiting for 0x%02x", got, kind)
+	}
+}
+
+// closeConn drops the connection and stops the pinger. Callers must hold
+// s.mu.
+func (s *MQTTSink) closeConn() error {
+	if s.conn == nil {
+		return nil
+	}
+	if s.stop != nil {
+		close(s.stop)
+	}
+	err := s.conn.Close()
+	s.conn, s.r, s.stop = nil, nil, nil
+	return err
+}
+
+// clientID returns the client identifier to connect with
+func (s *MQTTSink) clientID() string {
+	if s.ClientID != "" {
+		return s.ClientID
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%s-%d", filepath.Base(os.Args[0]), host, os.Getpid())
+}
+
+// mqttString writes a length-prefixed UTF-8 string
+func mqttString(b *bytes.Buffer, s string) {
+	binary.Write(b, binary.BigEndian, uint16(len(s)))
+	b.WriteString(s)
+}
@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// fakeBroker is an MQTT broker acknowledging every packet the way
+// MQTTSink expects, or refusing connections with refuse, and recording the
+// bytes it received
+type fakeBroker struct {
+	ln     net.Listener
+	refuse byte
+	mu     sync.Mutex
+	raw    bytes.Buffer
+}
+
+func newFakeBroker(t *testing.T, refuse byte) *fakeBroker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &fakeBroker{ln: ln, refuse: refuse}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	s := &MQTTSink{conn: conn, r: bufio.NewReader(io.TeeReader(conn, lockedWriter{&b.mu, &b.raw}))}
+	for {
+		kind, body, err := s.read()
+		if err != nil {
+			return
+		}
+		var reply []byte
+		switch kind & 0xf0 {
+		case mqttConnect:
+			reply = []byte{mqttConnack, 2, 0, b.refuse}
+		case mqttPublish:
+			if qos := kind >> 1 & 3; qos > 0 {
+				topicLen := int(body[0])<<8 | int(body[1])
+				id := body[2+topicLen : 4+topicLen]
+				reply = append([]byte{mqttPuback, 2}, id...)
+				if qos == 2 {
+					reply[0] = mqttPubrec
+				}
+			}
+		case mqttPubrel & 0xf0:
+			reply = append([]byte{mqttPubcomp, 2}, body...)
+		case mqttPingreq:
+			reply = []byte{mqttPingresp, 0}
+		case mqttDisconnect:
+			return
+		}
+		conn.Write(reply)
+	}
+}
+
+// received returns the bytes sent to the broker so far
+func (b *fakeBroker) received() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]byte(nil), b.raw.Bytes()...)
+}
+
+// waitReceived waits until the broker received n bytes
+func (b *fakeBroker) waitReceived(t *testing.T, n int) []byte {
+	deadline := time.Now().Add(5 * time.Second)
+	for got := b.received(); ; got = b.received() {
+		if len(got) >= n || time.Now().After(deadline) {
+			return got
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMQTTSinkPackets(t *testing.T) {
+	b := newFakeBroker(t, 0)
+	s := &MQTTSink{
+		Broker:      b.ln.Addr().String(),
+		ClientID:    "dev42",
+		Username:    "u",
+		Password:    "p",
+		Topic:       "logs/{level}",
+		QoS:         1,
+		Retain:      true,
+		WillTopic:   "st",
+		WillMessage: "off",
+		WillRetain:  true,
+		KeepAlive:   30 * time.Second,
+	}
+	if _, err := s.WriteLevel(zerolog.WarnLevel, []byte(`{"m":1}`+"\n")); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	want := []byte{
+		// CONNECT: protocol name and level, flags (clean session, will
+		// with QoS 1 retained, password, username), keep-alive, then the
+		// client ID, will topic, will message, username and password
+		0x10, 32, 0, 4, 'M', 'Q', 'T', 'T', 4, 0xee, 0, 30,
+		0, 5, 'd', 'e', 'v', '4', '2', 0, 2, 's', 't', 0, 3, 'o', 'f', 'f', 0, 1, 'u', 0, 1, 'p',
+		// PUBLISH with QoS 1, retained: topic, packet ID 1, payload
+		0x33, 20, 0, 9, 'l', 'o', 'g', 's', '/', 'w', 'a', 'r', 'n', 0, 1, '{', '"', 'm', '"', ':', '1', '}',
+		// DISCONNECT
+		0xe0, 0,
+	}
+	if got := b.waitReceived(t, len(want)); !bytes.Equal(got, want) {
+		t.Errorf("sent\n% x\nwant\n% x", got, want)
+	}
+}
+
+func TestMQTTSinkQoS2(t *testing.T) {
+	b := newFakeBroker(t, 0)
+	s := &MQTTSink{Broker: b.ln.Addr().String(), ClientID: "c", QoS: 2}
+	defer s.Close()
+	for i := 0; i < 2; i++ {
+		if _, err := s.Write([]byte("{}\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// After CONNECT: PUBLISH with QoS 2 and PUBREL for packet IDs 1 and 2
+	got := b.received()
+	got = got[2+int(got[1]):]
+	want := []byte{
+		0x34, 10, 0, 4, 'l', 'o', 'g', 's', 0, 1, '{', '}', 0x62, 2, 0, 1,
+		0x34, 10, 0, 4, 'l', 'o', 'g', 's', 0, 2, '{', '}', 0x62, 2, 0, 2,
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("sent\n% x\nwant\n% x", got, want)
+	}
+}
+
+func TestMQTTSinkRemainingLength(t *testing.T) {
+	b := newFakeBroker(t, 0)
+	s := &MQTTSink{Broker: b.ln.Addr().String(), ClientID: "c"}
+	defer s.Close()
+	payload := bytes.Repeat([]byte("x"), 200)
+	if _, err := s.Write(payload); err != nil {
+		t.Fatal(err)
+	}
+	got := b.waitReceived(t, 15+3+206) // CONNECT and PUBLISH
+	got = got[2+int(got[1]):]
+	// 206 bytes of topic and payload take two bytes of remaining length
+	if want := []byte{0x30, 0xce, 0x01, 0, 4, 'l', 'o', 'g', 's'}; !bytes.HasPrefix(got, want) || len(got) != 3+206 {
+		t.Errorf("sent % x..., %d bytes, want % x... and 209 bytes", got[:min(len(got), 9)], len(got), want)
+	}
+}
+
+func TestMQTTSinkRefused(t *testing.T) {
+	b := newFakeBroker(t, 5)
+	s := &MQTTSink{Broker: b.ln.Addr().String(), ClientID: "c"}
+	defer s.Close()
+	if _, err := s.Write([]byte("{}\n")); err == nil || !strings.Contains(err.Error(), "return code 5") {
+		t.Errorf("got %v, want a refused connection", err)
+	}
+}
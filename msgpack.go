@@ -0,0 +1,462 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// msgpackWriter is the "msgpack" output format: each entry becomes one
+// MessagePack map with the entry's fields, written without separators as
+// the encoding delimits itself. MsgpackDecoder reads them back.
+func msgpackWriter(w io.Writer, _ Config) io.Writer {
+	return &encodingWriter{w: w, encode: func(dst []byte, _ zerolog.Level, entry []byte) []byte {
+		_, fields := decodeEntry(bytes.TrimRight(entry, "\n"))
+		e := msgpackEncoder{buf: dst}
+		e.value(fields)
+		return e.buf
+	}}
+}
+
+// MsgpackDecoder reads the entries of a stream written in the msgpack
+// format, such as a file or a collector's input
+//
+//	dec := logger.NewMsgpackDecoder(f)
+//	for {
+//		entry, err := dec.Decode()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//	}
+//
+// Integers decode as int64, or uint64 when they do not fit, floats as
+// float64, binary data as []byte and timestamps, both MessagePack's own
+// and fluentd's EventTime, as time.Time.
+type MsgpackDecoder struct {
+	r *bufio.Reader
+}
+
+// NewMsgpackDecoder returns a MsgpackDecoder reading from r
+func NewMsgpackDecoder(r io.Reader) *MsgpackDecoder {
+	return &MsgpackDecoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next entry, returning io.EOF at the end of the stream
+func (d *MsgpackDecoder) Decode() (map[string]interface{}, error) {
+	if _, err := d.r.Peek(1); err != nil {
+		return nil, err
+	}
+	v, err := readMsgpackValue(d.r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("msgpack: want an entry map, got %T", v)
+	}
+	return m, nil
+}
+
+// msgpackEncoder appends MessagePack values to a buffer. It covers the
+// types decoded JSON entries are made of, which is all the sinks need.
+type msgpackEncoder struct {
+	buf []byte
+}
+
+// value appends any decoded JSON value. Map keys are sorted so equal
+// entries encode the same way.
+func (e *msgpackEncoder) value(v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		e.buf = append(e.buf, 0xc0)
+	case bool:
+		if v {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case string:
+		e.str(v)
+	case []byte:
+		e.bin(v)
+	case int:
+		e.int(int64(v))
+	case int64:
+		e.int(v)
+	case uint64:
+		e.uint(v)
+	case float64:
+		e.float(v)
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			e.int(i)
+		} else if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			e.uint(u)
+		} else if f, err := v.Float64(); err == nil {
+			e.float(f)
+		} else {
+			e.str(string(v))
+		}
+	case time.Time:
+		e.eventTime(v)
+	case []interface{}:
+		e.arrayHeader(len(v))
+		for _, item := range v {
+			e.value(item)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		e.mapHeader(len(keys))
+		for _, k := range keys {
+			e.str(k)
+			e.value(v[k])
+		}
+	case map[string]string:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		e.mapHeader(len(keys))
+		for _, k := range keys {
+			e.str(k)
+			e.str(v[k])
+		}
+	default:
+		e.str(fmt.Sprint(v))
+	}
+}
+
+// int appends a signed integer in its shortest form
+func (e *msgpackEncoder) int(i int64) {
+	switch {
+	case i >= 0:
+		e.uint(uint64(i))
+	case i >= -32:
+		e.buf = append(e.buf, byte(i))
+	case i >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd2), uint32(i))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd3), uint64(i))
+	}
+}
+
+// uint appends an unsigned integer in its shortest form
+func (e *msgpackEncoder) uint(u uint64) {
+	switch {
+	case u < 128:
+		e.buf = append(e.buf, byte(u))
+	case u <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xce), uint32(u))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcf), u)
+	}
+}
+
+// float appends a float64
+func (e *msgpackEncoder) float(f float64) {
+	e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcb), math.Float64bits(f))
+}
+
+// str appends a string
+func (e *msgpackEncoder) str(s string) {
+	switch n := len(s); {
+	case n < 32:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xda), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xdb), uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+// bin appends a byte slice
+func (e *msgpackEncoder) bin(b []byte) {
+	switch n := len(b); {
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xc5), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xc6), uint32(n))
+	}
+	e.buf = append(e.buf, b...)
+}
+
+// arrayHeader starts an array of n values
+func (e *msgpackEncoder) arrayHeader(n int) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xdc), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xdd), uint32(n))
+	}
+}
+
+// mapHeader starts a map of n key-value pairs
+func (e *msgpackEncoder) mapHeader(n int) {
+	switch {
+	case n < 16:
+		e.buf = append(e.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xde), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xdf), uint32(n))
+	}
+}
+
+// eventTime appends t as fluentd's EventTime extension: type 0 holding
+// seconds and nanoseconds as two big-endian uint32
+func (e *msgpackEncoder) eventTime(t time.Time) {
+	e.buf = append(e.buf, 0xd7, 0x00)
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(t.Unix()))
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(t.Nanosecond()))
+}
+
+// readMsgpackValue reads any value. Map keys must be strings.
+func readMsgpackValue(r *bufio.Reader) (interface{}, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xe0 == 0xa0, b == 0xd9, b == 0xda, b == 0xdb:
+		r.UnreadByte()
+		return readMsgpackString(r)
+	case b&0xf0 == 0x90:
+		return readMsgpackArray(r, int(b&0x0f))
+	case b&0xf0 == 0x80:
+		return readMsgpackMap(r, int(b&0x0f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2, 0xc3:
+		return b == 0xc3, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := readMsgpackLen(r, 1<<(b-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, n)
+		_, err = io.ReadFull(r, buf)
+		return buf, err
+	case 0xca, 0xcb:
+		buf := make([]byte, 4<<(b-0xca))
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		if b == 0xca {
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(buf))), nil
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(buf)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf, 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << ((b - 0xcc) % 4)
+		var buf [8]byte
+		if _, err := io.ReadFull(r, buf[8-size:]); err != nil {
+			return nil, err
+		}
+		u := binary.BigEndian.Uint64(buf[:])
+		if b >= 0xd0 {
+			// Sign-extend from the encoded size
+			shift := 64 - 8*size
+			return int64(u<<shift) >> shift, nil
+		}
+		if u > math.MaxInt64 {
+			return u, nil
+		}
+		return int64(u), nil
+	case 0xdc, 0xdd:
+		n, err := readMsgpackLen(r, 2<<(b-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackArray(r, n)
+	case 0xde, 0xdf:
+		n, err := readMsgpackLen(r, 2<<(b-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackMap(r, n)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return readMsgpackExt(r, 1<<(b-0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := readMsgpackLen(r, 1<<(b-0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return readMsgpackExt(r, n)
+	}
+	return nil, fmt.Errorf("msgpack: unknown type 0x%02x", b)
+}
+
+// readMsgpackArray reads the n values of an array
+func readMsgpackArray(r *bufio.Reader, n int) ([]interface{}, error) {
+	a := make([]interface{}, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		v, err := readMsgpackValue(r)
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+	}
+	return a, nil
+}
+
+// readMsgpackMap reads the n key-value pairs of a map
+func readMsgpackMap(r *bufio.Reader, n int) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, min(n, 1024))
+	for i := 0; i < n; i++ {
+		k, err := readMsgpackString(r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := readMsgpackValue(r)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+// readMsgpackExt reads the type and n data bytes of an extension value.
+// Timestamps become time.Time and other extensions their raw data.
+func readMsgpackExt(r *bufio.Reader, n int) (interface{}, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	switch {
+	case int8(typ) == -1 && n == 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), nil
+	case int8(typ) == -1 && n == 8:
+		v := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)), nil
+	case int8(typ) == -1 && n == 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(binary.BigEndian.Uint32(data))), nil
+	case typ == 0 && n == 8:
+		// fluentd's EventTime, as written by eventTime
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), int64(binary.BigEndian.Uint32(data[4:]))), nil
+	}
+	return data, nil
+}
+
+// readMsgpackStringMap reads a map of strings to strings, such as a
+// fluentd ack response
+func readMsgpackStringMap(r *bufio.Reader) (map[string]string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	var n int
+	switch {
+	case b&0xf0 == 0x80:
+		n = int(b & 0x0f)
+	case b == 0xde:
+		n, err = readMsgpackLen(r, 2)
+	case b == 0xdf:
+		n, err = readMsgpackLen(r, 4)
+	default:
+		return nil, fmt.Errorf("msgpack: want a map, got type 0x%02x", b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		k, err := readMsgpackString(r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := readMsgpackString(r)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+// readMsgpackString reads a str or bin value
+func readMsgpackString(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	var n int
+	switch {
+	case b&0xe0 == 0xa0:
+		n = int(b & 0x1f)
+	case b == 0xd9, b == 0xc4:
+		n, err = readMsgpackLen(r, 1)
+	case b == 0xda, b == 0xc5:
+		n, err = readMsgpackLen(r, 2)
+	case b == 0xdb, b == 0xc6:
+		n, err = readMsgpackLen(r, 4)
+	default:
+		return "", fmt.Errorf("msgpack: want a string, got type 0x%02x", b)
+	}
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// readMsgpackLen reads a big-endian length of size bytes
+func readMsgpackLen(r *bufio.Reader, size int) (int, error) {
+	var buf [4]byte
+	if _, err := io.ReadFull(r, buf[:size]); err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return int(buf[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(buf[:2])), nil
+	}
+	return int(binary.BigEndian.Uint32(buf[:4])), nil
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMsgpackEncoding(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{nil, "c0"},
+		{true, "c3"},
+		{false, "c2"},
+		{0, "00"},
+		{127, "7f"},
+		{128, "cc80"},
+		{-1, "ff"},
+		{-32, "e0"},
+		{-33, "d0df"},
+		{json.Number("65536"), "ce00010000"},
+		{json.Number("18446744073709551615"), "cfffffffffffffffff"},
+		{json.Number("1.5"), "cb3ff8000000000000"},
+		{"", "a0"},
+		{strings.Repeat("a", 32), "d920" + strings.Repeat("61", 32)},
+		{[]byte{1, 2}, "c4020102"},
+		{[]interface{}{1, "a"}, "9201a161"},
+		{map[string]interface{}{"b": 2, "a": 1}, "82a16101a16202"},
+		{time.Unix(1792026123, 5e8), "d700" + "6ad0260b" + "1dcd6500"},
+	} {
+		var e msgpackEncoder
+		e.value(tt.v)
+		if got := hex.EncodeToString(e.buf); got != tt.want {
+			t.Errorf("%v encoded as %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	entry := map[string]interface{}{
+		"level":   "info",
+		"message": strings.Repeat("long ", 100),
+		"count":   int64(math.MinInt64),
+		"max":     uint64(math.MaxUint64),
+		"ratio":   0.25,
+		"ok":      true,
+		"none":    nil,
+		"tags":    []interface{}{"a", int64(-200), int64(70000)},
+		"http":    map[string]interface{}{"status": int64(503)},
+		"at":      time.Unix(1792026123, 5e8),
+	}
+	var e msgpackEncoder
+	e.value(entry)
+	e.value(map[string]interface{}{"message": "second"})
+
+	dec := NewMsgpackDecoder(bytes.NewReader(e.buf))
+	got, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at, ok := got["at"].(time.Time); !ok || !at.Equal(entry["at"].(time.Time)) {
+		t.Errorf("at decoded as %v", got["at"])
+	}
+	delete(got, "at")
+	delete(entry, "at")
+	if !reflect.DeepEqual(got, entry) {
+		t.Errorf("decoded\n%v\nwant\n%v", got, entry)
+	}
+	if got, err := dec.Decode(); err != nil || got["message"] != "second" {
+		t.Errorf("second entry %v, %v", got, err)
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("got %v at the end, want io.EOF", err)
+	}
+}
+
+func TestMsgpackDecoderErrors(t *testing.T) {
+	for input, want := range map[string]string{
+		"\x92\x01\x02":    "want an entry map",
+		"\x81\xa1a":       io.ErrUnexpectedEOF.Error(),
+		"\x81\xa1a\xc1":   "unknown type 0xc1",
+		"\xda\x00\x05abc": io.ErrUnexpectedEOF.Error(),
+	} {
+		_, err := NewMsgpackDecoder(strings.NewReader(input)).Decode()
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Decode(%x) = %v, want %s", input, err, want)
+		}
+	}
+}
+
+func TestMsgpackFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, Format: "msgpack", NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("served", "status", 200)
+	l.Warn("slow")
+	dec := NewMsgpackDecoder(&buf)
+	for _, want := range []map[string]interface{}{
+		{"level": "info", "message": "served", "status": int64(200)},
+		{"level": "warn", "message": "slow"},
+	} {
+		got, err := dec.Decode()
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("decoded %v, %v, want %v", got, err, want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Publisher publishes a message on a subject. *nats.Conn implements it, so
+// the package does not depend on the NATS client.
+type Publisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// PublisherFunc adapts a function to Publisher, for clients whose publish
+// method has a different signature, such as JetStream's
+//
+//	js, _ := nc.JetStream()
+//	sink := &logger.NATSSink{
+//		Publisher: logger.PublisherFunc(func(subject string, data []byte) error {
+//			_, err := js.Publish(subject, data)
+//			return err
+//		}),
+//		Subject: "logs.{component}.{level}",
+//	}
+type PublisherFunc func(subject string, data []byte) error
+
+// Publish calls f(subject, data)
+func (f PublisherFunc) Publish(subject string, data []byte) error {
+	return f(subject, data)
+}
+
+// NATSSink publishes each entry as one message on a NATS subject
+//
+//	nc, _ := nats.Connect(nats.DefaultURL)
+//	sink := &logger.NATSSink{Publisher: nc, Subject: "logs.{component}.{level}"}
+//	logger.InitLogger(logger.Config{Output: sink})
+//
+// Subject may contain placeholders in braces naming a field of the entry,
+// such as {component} or {request_id}; {level} is the entry's level. A
+// missing field becomes "unknown" and characters NATS reserves in subject
+// tokens, such as '.', '*' and '>', become '_'. For persistence, publish
+// through a JetStream context with PublisherFunc.
+type NATSSink struct {
+	Publisher Publisher // Connection to publish on, required
+	Subject   string    // Subject template, "logs" if empty
+}
+
+// errNoPublisher is returned when a NATSSink has no Publisher
+var errNoPublisher = errors.New("logger: nats: no publisher")
+
+// Write implements io.Writer for entries without a known level
+func (s *NATSSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *NATSSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if s.Publisher == nil {
+		return 0, errNoPublisher
+	}
+	// Publishers may keep the data after returning, zerolog reuses it
+	data := append([]byte(nil), bytes.TrimRight(p, "\n")...)
+	if err := s.Publisher.Publish(s.subject(lvl, data), data); err != nil {
+		return 0, fmt.Errorf("logger: nats: %w", err)
+	}
+	return len(p), nil
+}
+
+// subject expands the subject template for one entry
+func (s *NATSSink) subject(lvl zerolog.Level, entry []byte) string {
+	subject := s.Subject
+	if subject == "" {
+		subject = "logs"
+	}
+	if !strings.Contains(subject, "{") {
+		return subject
+	}
+	var fields map[string]interface{}
+	return expandPlaceholders(subject, func(name string) string {
+		if name == "level" && lvl != zerolog.NoLevel {
+			return LevelString(lvl)
+		}
+		if fields == nil {
+			fields = map[string]interface{}{}
+			dec := json.NewDecoder(bytes.NewReader(entry))
+			dec.UseNumber()
+			dec.Decode(&fields)
+		}
+		if name == "level" {
+			name = zerolog.LevelFieldName
+		}
+		v, ok := fields[name]
+		if !ok {
+			return "unknown"
+		}
+		return subjectToken(fieldString(v))
+	})
+}
+
+// expandPlaceholders replaces every {name} in tmpl with value(name). Braces
+// without a closing brace are kept as they are.
+func expandPlaceholders(tmpl string, value func(name string) string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			break
+		}
+		b.WriteString(tmpl[:start])
+		b.WriteString(value(tmpl[start+1 : start+end]))
+		tmpl = tmpl[start+end+1:]
+	}
+	b.WriteString(tmpl)
+	return b.String()
+}
+
+// subjectToken makes v usable as one token of a NATS subject
+func subjectToken(v string) string {
+	if v == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '.', r == '*', r == '>', r <= ' ', r == 0x7f:
+			return '_'
+		}
+		return r
+	}, v)
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestNATSSinkSubject(t *testing.T) {
+	for _, tt := range []struct {
+		subject string
+		lvl     zerolog.Level
+		entry   string
+		want    string
+	}{
+		{"", zerolog.InfoLevel, `{"message":"m"}`, "logs"},
+		{"logs.{component}.{level}", zerolog.WarnLevel, `{"component":"db","message":"m"}`, "logs.db.warn"},
+		{"logs.{level}", zerolog.NoLevel, `{"level":"error","message":"m"}`, "logs.error"},
+		{"logs.{component}", zerolog.InfoLevel, `{"message":"m"}`, "logs.unknown"},
+		{"logs.{host}", zerolog.InfoLevel, `{"host":"web.1 >*"}`, "logs.web_1___"},
+		{"logs.{status}", zerolog.InfoLevel, `{"status":503}`, "logs.503"},
+		{"logs.{open", zerolog.InfoLevel, `{}`, "logs.{open"},
+	} {
+		var got, data string
+		s := &NATSSink{
+			Subject: tt.subject,
+			Publisher: PublisherFunc(func(subject string, d []byte) error {
+				got, data = subject, string(d)
+				return nil
+			}),
+		}
+		if _, err := s.WriteLevel(tt.lvl, []byte(tt.entry+"\n")); err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || data != tt.entry {
+			t.Errorf("%s with %s: published %q on %q, want %q", tt.subject, tt.entry, data, got, tt.want)
+		}
+	}
+}
+
+func TestNATSSinkErrors(t *testing.T) {
+	if _, err := (&NATSSink{}).Write([]byte("{}\n")); !errors.Is(err, errNoPublisher) {
+		t.Errorf("without a Publisher: got %v", err)
+	}
+	failed := errors.New("no responders")
+	s := &NATSSink{Publisher: PublisherFunc(func(string, []byte) error { return failed })}
+	if _, err := s.Write([]byte("{}\n")); !errors.Is(err, failed) {
+		t.Errorf("got %v, want the publisher's error", err)
+	}
+}
@@ -0,0 +1,229 @@
+package logger
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NetworkSink sends entries to a TCP or UDP collector from a background
+// goroutine, so a slow or unreachable collector never blocks logging
+//
+//	sink := logger.NewNetworkSink("tcp", "collector:5170")
+//	defer sink.Close()
+//	logger.InitLogger(logger.Config{Output: sink})
+//
+// Entries are queued while the connection is down and sent once it is
+// back. The sink reconnects with exponential backoff. When the queue is
+// full, new entries are dropped and counted rather than blocking the
+// caller. Stats reports how the sink is doing.
+//
+// Over TCP, entries written just before the collector goes away can be
+// accepted by the kernel and still be lost; the sink only notices a broken
+// connection when a write fails.
+type NetworkSink struct {
+	network, addr string
+	dialTimeout   time.Duration
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+
+	queue chan []byte
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+	dials int // successful connections, owned by run
+
+	closed     atomic.Bool
+	connected  atomic.Bool
+	sent       atomic.Uint64
+	dropped    atomic.Uint64
+	reconnects atomic.Uint64
+}
+
+// NetworkStats is a snapshot of a NetworkSink's counters
+type NetworkStats struct {
+	Connected  bool   // Whether the sink holds a connection right now
+	Queued     int    // Entries waiting to be sent
+	Sent       uint64 // Entries written to the connection
+	Dropped    uint64 // Entries discarded because the queue was full
+	Reconnects uint64 // Connections made after the first one
+}
+
+// NetworkOption configures a NetworkSink
+type NetworkOption func(*NetworkSink)
+
+// WithQueueSize sets how many entries are held while the collector is
+// unreachable, 1024 by default
+func WithQueueSize(n int) NetworkOption {
+	return func(s *NetworkSink) {
+		if n > 0 {
+			s.queue = make(chan []byte, n)
+		}
+	}
+}
+
+// WithBackoff sets the first and the longest wait between reconnection
+// attempts, 100ms and 30s by default
+func WithBackoff(first, longest time.Duration) NetworkOption {
+	return func(s *NetworkSink) {
+		s.minBackoff, s.maxBackoff = first, longest
+	}
+}
+
+// WithDialTimeout limits how long a connection attempt may take, 5s by
+// default
+func WithDialTimeout(d time.Duration) NetworkOption {
+	return func(s *NetworkSink) {
+		s.dialTimeout = d
+	}
+}
+
+// networkFlushTimeout is how long Close waits for queued entries to be sent
+const networkFlushTimeout = 5 * time.Second
+
+// errSinkClosed is returned when writing to a closed sink
+var errSinkClosed = errors.New("logger: sink is closed")
+
+// NewNetworkSink returns a sink sending entries to addr over network,
+// such as "tcp", "udp", "unix" or "unixgram", and starts its background goroutine. The
+// connection is made lazily and retried until Close.
+func NewNetworkSink(network, addr string, opts ...NetworkOption) *NetworkSink {
+	s := &NetworkSink{
+		network:     network,
+		addr:        addr,
+		dialTimeout: 5 * time.Second,
+		minBackoff:  100 * time.Millisecond,
+		maxBackoff:  30 * time.Second,
+		queue:       make(chan []byte, 1024),
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// NewUnixSocketSink returns a NetworkSink writing newline-delimited JSON to
+// the Unix socket at path, for local collectors such as Vector or Fluent
+// Bit. With datagram set every entry is sent as one unixgram datagram,
+// otherwise entries are streamed. The sink reconnects when the collector
+// restarts, like any NetworkSink.
+func NewUnixSocketSink(path string, datagram bool, opts ...NetworkOption) *NetworkSink {
+	network := "unix"
+	if datagram {
+		network = "unixgram"
+	}
+	return NewNetworkSink(network, path, opts...)
+}
+
+// Write queues a copy of p to be sent, dropping it if the queue is full
+func (s *NetworkSink) Write(p []byte) (int, error) {
+	if s.closed.Load() {
+		return 0, errSinkClosed
+	}
+	entry := append([]byte(nil), p...)
+	select {
+	case s.queue <- entry:
+	default:
+		s.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Stats returns the sink's current counters
+func (s *NetworkSink) Stats() NetworkStats {
+	return NetworkStats{
+		Connected:  s.connected.Load(),
+		Queued:     len(s.queue),
+		Sent:       s.sent.Load(),
+		Dropped:    s.dropped.Load(),
+		Reconnects: s.reconnects.Load(),
+	}
+}
+
+// Close stops accepting entries, gives the queued ones a few seconds to
+// be sent and closes the connection
+func (s *NetworkSink) Close() error {
+	s.once.Do(func() {
+		s.closed.Store(true)
+		close(s.done)
+	})
+	s.wg.Wait()
+	return nil
+}
+
+// run sends queued entries, reconnecting whenever the connection fails
+func (s *NetworkSink) run() {
+	defer s.wg.Done()
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+		s.connected.Store(false)
+	}()
+
+	var deadline <-chan time.Time // set once Close is called
+	for {
+		var entry []byte
+		select {
+		case entry = <-s.queue:
+		case <-s.done:
+			if len(s.queue) == 0 {
+				return
+			}
+			if deadline == nil {
+				deadline = time.After(networkFlushTimeout)
+			}
+			entry = <-s.queue
+		}
+
+		// Hold on to the entry until it is written or the sink is closed
+		for {
+			if conn == nil {
+				conn = s.connect(deadline)
+				if conn == nil {
+					return
+				}
+			}
+			if _, err := conn.Write(entry); err == nil {
+				s.sent.Add(1)
+				break
+			}
+			conn.Close()
+			conn = nil
+			s.connected.Store(false)
+		}
+	}
+}
+
+// connect dials until it succeeds, backing off between attempts. It gives
+// up and returns nil once the sink is closed and the flush deadline, if
+// any, has passed.
+func (s *NetworkSink) connect(deadline <-chan time.Time) net.Conn {
+	backoff := s.minBackoff
+	for {
+		conn, err := net.DialTimeout(s.network, s.addr, s.dialTimeout)
+		if err == nil {
+			if s.dials++; s.dials > 1 {
+				s.reconnects.Add(1)
+			}
+			s.connected.Store(true)
+			return conn
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-s.done:
+			return nil
+		case <-deadline:
+			return nil
+		}
+		backoff = min(backoff*2, s.maxBackoff)
+	}
+}
@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// acceptLines accepts connections on ln and sends every line received,
+// closing each connection after perConn lines if perConn is positive
+func acceptLines(ln net.Listener, perConn int) <-chan string {
+	lines := make(chan string, 100)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sc := bufio.NewScanner(conn)
+				for n := 0; sc.Scan(); n++ {
+					lines <- sc.Text()
+					if perConn > 0 && n+1 == perConn {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return lines
+}
+
+// receive returns the next n lines, failing the test if they take too long
+func receive(t *testing.T, lines <-chan string, n int) []string {
+	t.Helper()
+	var got []string
+	for len(got) < n {
+		select {
+		case line := <-lines:
+			got = append(got, line)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("received %q, want %d lines", got, n)
+		}
+	}
+	return got
+}
+
+func TestNetworkSinkQueuesUntilConnected(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close() // the collector is down
+
+	s := NewNetworkSink("tcp", addr, WithBackoff(10*time.Millisecond, 20*time.Millisecond))
+	defer s.Close()
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(s, "entry %d\n", i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if st := s.Stats(); st.Connected || st.Sent != 0 {
+		t.Errorf("stats %+v while the collector is down", st)
+	}
+
+	if ln, err = net.Listen("tcp", addr); err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	got := receive(t, acceptLines(ln, 0), 3)
+	if fmt.Sprint(got) != "[entry 0 entry 1 entry 2]" {
+		t.Errorf("received %q", got)
+	}
+	if st := s.Stats(); !st.Connected || st.Sent != 3 || st.Dropped != 0 {
+		t.Errorf("stats %+v, want connected with 3 sent", st)
+	}
+}
+
+func TestNetworkSinkReconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	lines := acceptLines(ln, 1) // the collector drops every connection after one entry
+
+	s := NewNetworkSink("tcp", ln.Addr().String(), WithBackoff(time.Millisecond, 10*time.Millisecond))
+	defer s.Close()
+	fmt.Fprintln(s, "first")
+	receive(t, lines, 1)
+	// Writes to the dropped connection may seem to succeed, so keep
+	// writing until one gets through on a new connection
+	for deadline := time.Now().Add(5 * time.Second); s.Stats().Reconnects == 0 || len(lines) == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("stats %+v, want a reconnect", s.Stats())
+		}
+		fmt.Fprintln(s, "again")
+	}
+	if got := receive(t, lines, 1); got[0] != "again" {
+		t.Errorf("received %q after reconnecting", got)
+	}
+}
+
+func TestNetworkSinkDropsWhenFull(t *testing.T) {
+	s := NewNetworkSink("tcp", "127.0.0.1:1", WithQueueSize(2), WithBackoff(time.Hour, time.Hour), WithDialTimeout(time.Second))
+	for i := 0; i < 10; i++ {
+		if _, err := fmt.Fprintln(s, "entry"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The sending goroutine may hold one entry besides the full queue
+	if st := s.Stats(); st.Queued != 2 || st.Dropped < 7 {
+		t.Errorf("stats %+v, want a full queue and the rest dropped", st)
+	}
+	s.Close()
+	if _, err := s.Write([]byte("late\n")); !errors.Is(err, errSinkClosed) {
+		t.Errorf("got %v after Close, want errSinkClosed", err)
+	}
+}
+
+func TestUnixSocketSink(t *testing.T) {
+	dir, err := os.MkdirTemp("", "sock") // short enough for a socket path
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	t.Run("stream", func(t *testing.T) {
+		path := filepath.Join(dir, "stream.sock")
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			t.Skip(err)
+		}
+		lines := acceptLines(ln, 1) // the collector goes away after one entry
+		s := NewUnixSocketSink(path, false, WithBackoff(time.Millisecond, 10*time.Millisecond))
+		defer s.Close()
+		fmt.Fprintln(s, `{"message":"one"}`)
+		receive(t, lines, 1)
+
+		// and comes back on the same path
+		ln.Close()
+		os.Remove(path)
+		if ln, err = net.Listen("unix", path); err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		lines = acceptLines(ln, 0)
+		for deadline := time.Now().Add(5 * time.Second); len(lines) == 0; time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("stats %+v, nothing received after the collector restarted", s.Stats())
+			}
+			fmt.Fprintln(s, `{"message":"two"}`)
+		}
+		if got := receive(t, lines, 1); got[0] != `{"message":"two"}` {
+			t.Errorf("received %q", got)
+		}
+	})
+
+	t.Run("datagram", func(t *testing.T) {
+		path := filepath.Join(dir, "dgram.sock")
+		conn, err := net.ListenPacket("unixgram", path)
+		if err != nil {
+			t.Skip(err)
+		}
+		defer conn.Close()
+		s := NewUnixSocketSink(path, true)
+		defer s.Close()
+		fmt.Fprintln(s, `{"message":"one"}`)
+		fmt.Fprintln(s, `{"message":"two"}`)
+		buf := make([]byte, 1024)
+		for _, want := range []string{`{"message":"one"}` + "\n", `{"message":"two"}` + "\n"} {
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(buf[:n]); got != want {
+				t.Errorf("got datagram %q, want %q", got, want)
+			}
+		}
+	})
+}
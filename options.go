@@ -0,0 +1,241 @@
+package logger
+
+import (
+	"io"
+	"time"
+)
+
+// Option modifies a Config. Options let callers configure the logger
+// incrementally without having to know every Config field.
+type Option func(*Config)
+
+// Init initializes the global logger from functional options
+// Options are applied in order, so a later option overrides an earlier one.
+// Like InitLogger, only the first initialization takes effect.
+func Init(opts ...Option) {
+	InitLogger(NewConfig(opts...))
+}
+
+// NewConfig returns a Config built by applying opts to an empty Config
+// Fields left unset get the usual defaults when the config is applied.
+func NewConfig(opts ...Option) Config {
+	var cfg Config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithLevel sets the log level (debug, info, warn, error, fatal, panic)
+func WithLevel(level string) Option {
+	return func(cfg *Config) {
+		cfg.Level = level
+	}
+}
+
+// WithOutput sets the writer log entries are written to
+func WithOutput(w io.Writer) Option {
+	return func(cfg *Config) {
+		cfg.Output = w
+	}
+}
+
+// WithPretty enables pretty (human-readable) logging
+func WithPretty() Option {
+	return func(cfg *Config) {
+		cfg.Pretty = true
+	}
+}
+
+// WithFormat sets the output format, see Config.Format
+func WithFormat(name string) Option {
+	return func(cfg *Config) {
+		cfg.Format = name
+	}
+}
+
+// WithTheme sets the colors of pretty output
+func WithTheme(t Theme) Option {
+	return func(cfg *Config) {
+		cfg.Theme = &t
+	}
+}
+
+// WithSchema stamps every entry with the schema name and version
+func WithSchema(name, version string) Option {
+	return func(cfg *Config) {
+		cfg.Schema, cfg.SchemaVersion = name, version
+	}
+}
+
+// WithErrorOutput sends error, fatal and panic entries to w instead of the
+// regular output
+func WithErrorOutput(w io.Writer) Option {
+	return func(cfg *Config) {
+		cfg.ErrorOutput = w
+	}
+}
+
+// WithSink adds a destination that gets entries at minLevel and above in
+// the given format. Sinks replace WithOutput and WithPretty.
+func WithSink(w io.Writer, minLevel, format string) Option {
+	return func(cfg *Config) {
+		cfg.Sinks = append(cfg.Sinks, SinkConfig{Writer: w, MinLevel: minLevel, Format: format})
+	}
+}
+
+// WithParallelSinks writes each entry to all sinks at the same time
+func WithParallelSinks() Option {
+	return func(cfg *Config) {
+		cfg.ParallelSinks = true
+	}
+}
+
+// WithAsync writes entries from a background goroutine buffering up to
+// capacity entries and writing them at least every flushInterval, see
+// AsyncWriter
+func WithAsync(capacity int, flushInterval time.Duration) Option {
+	return func(cfg *Config) {
+		if cfg.Async == nil {
+			cfg.Async = &AsyncConfig{}
+		}
+		cfg.Async.Capacity, cfg.Async.FlushInterval = capacity, flushInterval
+	}
+}
+
+// WithBatch gathers entries into writes of up to maxBytes, waiting at most
+// maxDelay, see BatchWriter
+func WithBatch(maxBytes int, maxDelay time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Batch = &BatchConfig{MaxBytes: maxBytes, MaxDelay: maxDelay}
+	}
+}
+
+// WithDropWhenFull makes the asynchronous writer of WithAsync discard the
+// least severe entries instead of blocking when its buffer is full
+func WithDropWhenFull() Option {
+	return func(cfg *Config) {
+		if cfg.Async == nil {
+			cfg.Async = &AsyncConfig{}
+		}
+		cfg.Async.DropWhenFull = true
+	}
+}
+
+// WithSampling keeps only some of the entries at level, see SampleRule
+func WithSampling(level string, rule SampleRule) Option {
+	return func(cfg *Config) {
+		sampling := make(map[string]SampleRule, len(cfg.Sampling)+1)
+		for name, r := range cfg.Sampling {
+			sampling[name] = r
+		}
+		sampling[level] = rule
+		cfg.Sampling = sampling
+	}
+}
+
+// WithRateLimit passes on at most limit entries per interval among
+// entries sharing the value of the key field, see RateLimitWriter
+func WithRateLimit(key string, limit int, interval time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.RateLimit = &RateLimitConfig{Key: key, Limit: limit, Interval: interval}
+	}
+}
+
+// WithDedup collapses runs of identical consecutive entries within window,
+// see DedupWriter
+func WithDedup(window time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.DedupWindow = window
+	}
+}
+
+// WithCaller includes caller information in every log entry
+func WithCaller() Option {
+	return func(cfg *Config) {
+		cfg.WithCaller = true
+	}
+}
+
+// WithCallerSkipFrames skips n extra stack frames when reporting the caller
+func WithCallerSkipFrames(n int) Option {
+	return func(cfg *Config) {
+		cfg.CallerSkipFrames = n
+	}
+}
+
+// WithTimeFormat sets the timestamp format
+func WithTimeFormat(format string) Option {
+	return func(cfg *Config) {
+		cfg.TimeFormat = format
+	}
+}
+
+// WithLocation sets the time zone timestamps are written in, for example
+// time.UTC to keep logs from different regions comparable
+func WithLocation(loc *time.Location) Option {
+	return func(cfg *Config) {
+		cfg.Location = loc
+	}
+}
+
+// WithoutTimestamp leaves the timestamp out of entries
+func WithoutTimestamp() Option {
+	return func(cfg *Config) {
+		cfg.NoTimestamp = true
+	}
+}
+
+// WithDeadlineRemaining adds the milliseconds left until the context's
+// deadline to entries logged with a context that has one
+func WithDeadlineRemaining() Option {
+	return func(cfg *Config) {
+		cfg.DeadlineRemaining = true
+	}
+}
+
+// WithGoroutine adds the goroutine ID, and pprof labels on the context, to
+// entries. It is meant for debugging, see Config.Goroutine.
+func WithGoroutine() Option {
+	return func(cfg *Config) {
+		cfg.Goroutine = true
+	}
+}
+
+// WithDisabled makes the logger a no-op that drops every entry after a
+// level check
+func WithDisabled() Option {
+	return func(cfg *Config) {
+		cfg.Disabled = true
+	}
+}
+
+// WithScoped confines the configuration to this package's loggers and
+// leaves zerolog's global settings alone
+func WithScoped() Option {
+	return func(cfg *Config) {
+		cfg.Scoped = true
+	}
+}
+
+// WithEnvLevelOverride lets the LOG_LEVEL environment variable replace the
+// configured level
+func WithEnvLevelOverride() Option {
+	return func(cfg *Config) {
+		cfg.EnvLevelOverride = true
+	}
+}
+
+// WithDefaultFields adds static fields stamped on every log entry, such as
+// the service name or environment. Fields from earlier calls are kept
+// unless overridden by key.
+func WithDefaultFields(fields map[string]interface{}) Option {
+	return func(cfg *Config) {
+		if cfg.Fields == nil {
+			cfg.Fields = make(map[string]interface{}, len(fields))
+		}
+		for k, v := range fields {
+			cfg.Fields[k] = v
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestNewConfig(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := NewConfig(
+		WithLevel("debug"),
+		WithLevel("warn"),
+		WithOutput(&buf),
+		WithAsync(64, time.Second),
+		WithDropWhenFull(),
+		WithDefaultFields(map[string]interface{}{"service": "api", "env": "dev"}),
+		WithDefaultFields(map[string]interface{}{"env": "prod"}),
+		WithSampling("info", SampleRule{Every: 10}),
+	)
+	if cfg.Level != "warn" {
+		t.Errorf("Level = %q, want the later option's warn", cfg.Level)
+	}
+	if cfg.Output != &buf {
+		t.Error("Output not set")
+	}
+	if cfg.Async == nil || cfg.Async.Capacity != 64 || cfg.Async.FlushInterval != time.Second || !cfg.Async.DropWhenFull {
+		t.Errorf("Async = %+v, want capacity 64, 1s and drop when full", cfg.Async)
+	}
+	if cfg.Fields["service"] != "api" || cfg.Fields["env"] != "prod" {
+		t.Errorf("Fields = %v, want service api and env prod", cfg.Fields)
+	}
+	if cfg.Sampling["info"].Every != 10 {
+		t.Errorf("Sampling = %v, want info every 10", cfg.Sampling)
+	}
+}
+
+func TestWithSamplingCopiesMap(t *testing.T) {
+	base := NewConfig(WithSampling("info", SampleRule{Every: 2}))
+	derived := base
+	WithSampling("debug", SampleRule{Every: 5})(&derived)
+	if _, ok := base.Sampling["debug"]; ok {
+		t.Error("WithSampling changed the map of the config it was copied from")
+	}
+	if len(derived.Sampling) != 2 {
+		t.Errorf("derived Sampling = %v, want info and debug", derived.Sampling)
+	}
+}
@@ -0,0 +1,154 @@
+package logger
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// otelWriter wraps w so entries are written as records of the
+// OpenTelemetry log data model:
+//
+//	{"Timestamp":"1714564800000000000","ObservedTimestamp":"1714564800000012000",
+//		"TraceId":"4bf92f3577b34da6a3ce929d0e0e4736","SpanId":"00f067aa0ba902b7",
+//		"SeverityText":"error","SeverityNumber":17,"Body":"charge failed",
+//		"Resource":{"service.name":"billing"},"InstrumentationScope":{"Name":"payments"},
+//		"Attributes":{"exception.message":"card declined","amount":42}}
+//
+// The component becomes the instrumentation scope, trace_id, span_id and
+// trace_flags the trace context, and the caller, error and stack the
+// code.* and exception.* attributes of the semantic conventions. The
+// resource is read once from OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES,
+// as OpenTelemetry SDKs do, with a service field of the entry taking over
+// service.name. The other fields are attributes.
+func otelWriter(w io.Writer, cfg Config) io.Writer {
+	resource := otelResource()
+	return &encodingWriter{w: w, encode: func(dst []byte, lvl zerolog.Level, entry []byte) []byte {
+		return appendOTel(dst, lvl, entry, cfg.TimeFormat, resource)
+	}}
+}
+
+// appendOTel appends entry, a zerolog JSON entry, as an OpenTelemetry log
+// record to dst
+func appendOTel(dst []byte, lvl zerolog.Level, entry []byte, timeFormat string, resource map[string]interface{}) []byte {
+	msg, fields := decodeEntry(entry)
+	delete(fields, zerolog.MessageFieldName)
+	lvl = entryLevel(lvl, fields)
+	delete(fields, zerolog.LevelFieldName)
+	var o jsonObject
+
+	observed := time.Now()
+	if t, ok := entryTime(fields[zerolog.TimestampFieldName], timeFormat); ok {
+		o.add("Timestamp", strconv.FormatInt(t.UnixNano(), 10))
+		delete(fields, zerolog.TimestampFieldName)
+	}
+	o.add("ObservedTimestamp", strconv.FormatInt(observed.UnixNano(), 10))
+	if id, ok := fields["trace_id"].(string); ok {
+		o.add("TraceId", id)
+		delete(fields, "trace_id")
+	}
+	if id, ok := fields["span_id"].(string); ok {
+		o.add("SpanId", id)
+		delete(fields, "span_id")
+	}
+	if flags, ok := fields["trace_flags"].(string); ok {
+		if f, err := strconv.ParseUint(flags, 16, 8); err == nil {
+			o.add("TraceFlags", f)
+			delete(fields, "trace_flags")
+		}
+	}
+	if lvl != zerolog.NoLevel {
+		o.add("SeverityText", LevelString(lvl))
+		o.add("SeverityNumber", otelSeverity(lvl))
+	}
+	o.add("Body", msg)
+
+	if service, ok := fields["service"]; ok {
+		merged := make(map[string]interface{}, len(resource)+1)
+		for k, v := range resource {
+			merged[k] = v
+		}
+		merged["service.name"] = service
+		resource = merged
+		delete(fields, "service")
+	}
+	if len(resource) > 0 {
+		o.add("Resource", resource)
+	}
+	if component, ok := fields["component"]; ok {
+		o.add("InstrumentationScope", map[string]interface{}{"Name": component})
+		delete(fields, "component")
+	}
+
+	if caller, ok := fields[zerolog.CallerFieldName].(string); ok {
+		file, line := caller, ""
+		if i := strings.LastIndexByte(caller, ':'); i > 0 {
+			file, line = caller[:i], caller[i+1:]
+		}
+		fields["code.file.path"] = file
+		if n, err := strconv.Atoi(line); err == nil {
+			fields["code.line.number"] = n
+		}
+		delete(fields, zerolog.CallerFieldName)
+	}
+	if v, ok := fields[zerolog.ErrorFieldName]; ok {
+		fields["exception.message"] = fieldString(v)
+		delete(fields, zerolog.ErrorFieldName)
+	}
+	if v, ok := fields[zerolog.ErrorStackFieldName]; ok {
+		fields["exception.stacktrace"] = stackText(v)
+		delete(fields, zerolog.ErrorStackFieldName)
+	}
+	if len(fields) > 0 {
+		o.add("Attributes", fields)
+	}
+	return o.line(dst)
+}
+
+// otelSeverity maps a zerolog level to an OpenTelemetry severity number,
+// the first of the range for its severity
+func otelSeverity(lvl zerolog.Level) int {
+	switch baseLevel(lvl) {
+	case zerolog.TraceLevel:
+		return 1
+	case zerolog.DebugLevel:
+		return 5
+	case zerolog.InfoLevel:
+		return 9
+	case zerolog.WarnLevel:
+		return 13
+	case zerolog.ErrorLevel:
+		return 17
+	case zerolog.FatalLevel:
+		return 21
+	case zerolog.PanicLevel:
+		return 24
+	}
+	return 0
+}
+
+// otelResource returns the resource attributes given by the standard
+// OTEL_RESOURCE_ATTRIBUTES and OTEL_SERVICE_NAME variables
+func otelResource() map[string]interface{} {
+	resource := make(map[string]interface{})
+	for _, pair := range strings.Split(os.Getenv("OTEL_RESOURCE_ATTRIBUTES"), ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			continue
+		}
+		// Values are percent-encoded, like URL query values
+		if decoded, err := url.PathUnescape(strings.TrimSpace(v)); err == nil {
+			v = decoded
+		}
+		resource[strings.TrimSpace(k)] = v
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		resource["service.name"] = name
+	}
+	return resource
+}
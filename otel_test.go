@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// otelObserved matches the ObservedTimestamp, which is the time of writing
+var otelObserved = regexp.MustCompile(`"ObservedTimestamp":"\d+"`)
+
+func TestAppendOTel(t *testing.T) {
+	resource := map[string]interface{}{"service.name": "api", "deployment.environment": "prod"}
+	for _, tt := range []struct {
+		name  string
+		lvl   zerolog.Level
+		entry string
+		want  string
+	}{
+		{
+			"trace context and conventions",
+			zerolog.ErrorLevel,
+			`{"level":"error","time":"2026-10-15T01:02:03Z","trace_id":"4bf92f35","span_id":"00f067aa","trace_flags":"01","component":"payments","caller":"charge.go:42","error":"card declined","amount":42,"message":"charge failed"}`,
+			`{"Timestamp":"1792026123000000000","ObservedTimestamp":"0","TraceId":"4bf92f35","SpanId":"00f067aa","TraceFlags":1,"SeverityText":"error","SeverityNumber":17,"Body":"charge failed","Resource":{"deployment.environment":"prod","service.name":"api"},"InstrumentationScope":{"Name":"payments"},"Attributes":{"amount":42,"code.file.path":"charge.go","code.line.number":42,"exception.message":"card declined"}}`,
+		},
+		{
+			"service field and no time",
+			zerolog.NoLevel,
+			`{"level":"warn","service":"billing","message":"slow"}`,
+			`{"ObservedTimestamp":"0","SeverityText":"warn","SeverityNumber":13,"Body":"slow","Resource":{"deployment.environment":"prod","service.name":"billing"}}`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := appendOTel(nil, tt.lvl, []byte(tt.entry), "", resource)
+			got = otelObserved.ReplaceAll(got, []byte(`"ObservedTimestamp":"0"`))
+			if string(got) != tt.want+"\n" {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+	if resource["service.name"] != "api" {
+		t.Errorf("resource changed to %v by an entry's service", resource)
+	}
+}
+
+func TestOTelSeverity(t *testing.T) {
+	for lvl, want := range map[zerolog.Level]int{
+		zerolog.TraceLevel: 1,
+		zerolog.DebugLevel: 5,
+		zerolog.InfoLevel:  9,
+		zerolog.WarnLevel:  13,
+		zerolog.ErrorLevel: 17,
+		zerolog.FatalLevel: 21,
+		zerolog.PanicLevel: 24,
+		zerolog.NoLevel:    0,
+	} {
+		if got := otelSeverity(lvl); got != want {
+			t.Errorf("otelSeverity(%s) = %d, want %d", lvl, got, want)
+		}
+	}
+}
+
+func TestOTelResource(t *testing.T) {
+	t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "service.name=ignored, host.name = web-1,team=a%2Cb,,novalue")
+	t.Setenv("OTEL_SERVICE_NAME", "api")
+	got, _ := json.Marshal(otelResource())
+	if want := `{"host.name":"web-1","service.name":"api","team":"a,b"}`; string(got) != want {
+		t.Errorf("otelResource() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,535 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// PostgresSink stores entries in a PostgreSQL table, loading each batch
+// with COPY from a background goroutine
+//
+//	sink := &logger.PostgresSink{
+//		Addr:      "db:5432",
+//		User:      "app",
+//		Password:  os.Getenv("PGPASSWORD"),
+//		Database:  "app",
+//		Table:     "audit_log",
+//		Retention: 400 * 24 * time.Hour,
+//	}
+//	defer sink.Close()
+//
+// The sink creates the table and its index if they do not exist each time
+// it connects:
+//
+//	CREATE TABLE logs (id bigserial PRIMARY KEY, timestamp timestamptz NOT NULL,
+//		level text, component text, message text, fields jsonb NOT NULL)
+//
+// fields holds the remaining fields of the entry. With Retention set, rows
+// older than that are deleted after a batch at most every
+// RetentionInterval. The sink speaks the PostgreSQL protocol itself,
+// authenticating with SCRAM-SHA-256, MD5 or a cleartext password, and
+// reconnects when the connection breaks. A batch the server rejects, for
+// example because an entry holds a \u0000 jsonb cannot store, is not sent
+// again.
+type PostgresSink struct {
+	Addr              string        // host:port, localhost:5432 if empty
+	TLSConfig         *tls.Config   // Connect with TLS when set
+	User              string        // Role to connect as, required
+	Password          string        // Password of User
+	Database          string        // Database, the same as User if empty
+	Table             string        // Table name, "logs" if empty
+	Retention         time.Duration // Age of rows to keep, no limit if zero
+	RetentionInterval time.Duration // Least time between deletions, 1 hour if zero
+	BatchSize         int           // Entries per COPY, 100 if zero
+	FlushInterval     time.Duration // Longest wait before sending, 5s if zero
+	QueueWait         time.Duration // Longest a log call waits for room in a full queue, none if zero
+	OnError           func(error)   // Called when entries are lost, stderr if nil
+
+	setup  sync.Once
+	batch  entryBatcher
+	conn   net.Conn // owned by the batcher goroutine, like r and pruned
+	r      *bufio.Reader
+	pruned time.Time
+}
+
+// postgresTimeout bounds connecting and each statement
+const postgresTimeout = 30 * time.Second
+
+// postgresError is an ErrorResponse from the server
+type postgresError struct {
+	severity, code, message string
+}
+
+func (e postgresError) Error() string {
+	return fmt.Sprintf("%s: %s (SQLSTATE %s)", e.severity, e.message, e.code)
+}
+
+// retryable reports whether the error may go away on its own, such as a
+// server shutting down or running out of connections
+func (e postgresError) retryable() bool {
+	switch e.code[:min(len(e.code), 2)] {
+	case "08", "40", "53", "57", "58":
+		return true
+	}
+	return false
+}
+
+// copyEscaper escapes a value for COPY's text format
+var copyEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`, "\x00", "")
+
+// Write implements io.Writer for entries without a known level
+func (s *PostgresSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *PostgresSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if err := s.batcher().add(lvl, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush sends the queued entries and waits until they are stored
+func (s *PostgresSink) Flush() error {
+	s.batcher().flush()
+	return nil
+}
+
+// Close sends the queued entries, stops the background goroutine and
+// disconnects
+func (s *PostgresSink) Close() error {
+	s.batcher().close()
+	if s.conn == nil {
+		return nil
+	}
+	s.conn.Write(pgMessage('X', nil))
+	return s.closeConn()
+}
+
+// batcher returns the sink's batcher, configured from its fields on
+// first use
+func (s *PostgresSink) batcher() *entryBatcher {
+	s.setup.Do(func() {
+		s.batch = entryBatcher{send: s.send, size: s.BatchSize, interval: s.FlushInterval, wait: s.QueueWait, onError: s.OnError}
+	})
+	return &s.batch
+}
+
+// tableName returns the table name, unquoted
+func (s *PostgresSink) tableName() string {
+	if s.Table == "" {
+		return "logs"
+	}
+	return s.Table
+}
+
+// table returns the quoted table name
+func (s *PostgresSink) table() string {
+	return quoteIdent(s.tableName())
+}
+
+// send loads one batch with COPY and runs the retention deletion when due
+func (s *PostgresSink) send(batch []batchEntry) error {
+	if s.User == "" {
+		return permanentError{fmt.Errorf("logger: postgres: User is required")}
+	}
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return s.failed(err)
+		}
+	}
+
+	var rows bytes.Buffer
+	for _, e := range batch {
+		msg, fields := decodeEntry(e.data)
+		t := e.time
+		if parsed, ok := sinkTime(fields[zerolog.TimestampFieldName]); ok {
+			t = parsed
+		}
+		level := sqlColumn(fields, zerolog.LevelFieldName)
+		if level == nil && e.level != zerolog.NoLevel {
+			level = LevelString(e.level)
+		}
+		component := sqlColumn(fields, "component")
+		delete(fields, zerolog.MessageFieldName)
+		delete(fields, zerolog.TimestampFieldName)
+		rest, err := json.Marshal(fields)
+		if err != nil {
+			return permanentError{fmt.Errorf("logger: postgres: %w", err)}
+		}
+		for i, v := range []interface{}{t.Format(time.RFC3339Nano), level, component, msg, string(rest)} {
+			if i > 0 {
+				rows.WriteByte('\t')
+			}
+			if v == nil {
+				rows.WriteString(`\N`)
+			} else {
+				copyEscaper.WriteString(&rows, v.(string))
+			}
+		}
+		rows.WriteByte('\n')
+	}
+	err := s.query("COPY "+s.table()+" (timestamp, level, component, message, fields) FROM STDIN", rows.Bytes())
+	if err != nil {
+		return s.failed(err)
+	}
+
+	// The batch is stored; a failed deletion must not have it sent again
+	if err := s.prune(); err != nil {
+		s.batch.report(fmt.Errorf("logger: postgres: retention: %w", err))
+		var perr postgresError
+		if !errors.As(err, &perr) {
+			s.closeConn()
+		}
+	}
+	return nil
+}
+
+// failed wraps err for the batcher. Errors the server reports are
+// permanent unless they look transient; anything else breaks the
+// connection and is retried on a new one.
+func (s *PostgresSink) failed(err error) error {
+	err = fmt.Errorf("logger: postgres: %w", err)
+	var perr postgresError
+	if !errors.As(err, &perr) {
+		s.closeConn()
+		return err
+	}
+	if perr.retryable() {
+		return err
+	}
+	return permanentError{err}
+}
+
+// prune deletes rows older than Retention if the last deletion is at
+// least RetentionInterval ago
+func (s *PostgresSink) prune() error {
+	interval := s.RetentionInterval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	if s.Retention <= 0 || time.Since(s.pruned) < interval {
+		return nil
+	}
+	seconds := strconv.FormatInt(int64(s.Retention/time.Second), 10)
+	if err := s.query("DELETE FROM "+s.table()+" WHERE timestamp < now() - interval '"+seconds+" seconds'", nil); err != nil {
+		return err
+	}
+	s.pruned = time.Now()
+	return nil
+}
+
+// connect dials the server, authenticates and creates the table
+func (s *PostgresSink) connect() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = "localhost:5432"
+	}
+	conn, err := net.DialTimeout("tcp", addr, postgresTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(postgresTimeout))
+	if s.TLSConfig != nil {
+		// SSLRequest: length 8 and the magic code 80877103
+		if _, err := conn.Write([]byte{0, 0, 0, 8, 0x04, 0xd2, 0x16, 0x2f}); err != nil {
+			conn.Close()
+			return err
+		}
+		var answer [1]byte
+		if _, err := io.ReadFull(conn, answer[:]); err != nil {
+			conn.Close()
+			return err
+		}
+		if answer[0] != 'S' {
+			conn.Close()
+			return fmt.Errorf("server does not support TLS")
+		}
+		cfg := s.TLSConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		conn = tls.Client(conn, cfg)
+	}
+	s.conn, s.r = conn, bufio.NewReader(conn)
+
+	database := s.Database
+	if database == "" {
+		database = s.User
+	}
+	var startup bytes.Buffer
+	binary.Write(&startup, binary.BigEndian, uint32(196608)) // protocol 3.0
+	for _, kv := range [][2]string{{"user", s.User}, {"database", database}, {"application_name", "logger"}} {
+		startup.WriteString(kv[0] + "\x00" + kv[1] + "\x00")
+	}
+	startup.WriteByte(0)
+	msg := binary.BigEndian.AppendUint32(nil, uint32(4+startup.Len()))
+	if _, err := s.conn.Write(append(msg, startup.Bytes()...)); err != nil {
+		s.closeConn()
+		return err
+	}
+	if err := s.authenticate(); err != nil {
+		s.closeConn()
+		return err
+	}
+
+	for _, stmt := range []string{
+		"CREATE TABLE IF NOT EXISTS " + s.table() + " (id bigserial PRIMARY KEY, timestamp timestamptz NOT NULL, level text, component text, message text, fields jsonb NOT NULL)",
+		"CREATE INDEX IF NOT EXISTS " + quoteIdent(s.tableName()+"_timestamp") + " ON " + s.table() + " (timestamp)",
+	} {
+		if err := s.query(stmt, nil); err != nil {
+			s.closeConn()
+			return fmt.Errorf("migrate: %w", err)
+		}
+	}
+	return nil
+}
+
+// authenticate answers the server's authentication requests and waits
+// until it is ready for queries
+func (s *PostgresSink) authenticate() error {
+	var scram *scramSHA256
+	for {
+		kind, body, err := s.read()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case 'E':
+			return parsePostgresError(body)
+		case 'Z':
+			return nil
+		case 'R':
+		default:
+			continue // ParameterStatus, BackendKeyData, notices
+		}
+		if len(body) < 4 {
+			return fmt.Errorf("malformed authentication request")
+		}
+		var reply []byte
+		switch code, data := binary.BigEndian.Uint32(body), body[4:]; code {
+		case 0: // AuthenticationOk
+			continue
+		case 3: // cleartext password
+			reply = append([]byte(s.Password), 0)
+		case 5: // MD5 with a 4-byte salt
+			inner := md5.Sum([]byte(s.Password + s.User))
+			outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), data...))
+			reply = append([]byte("md5"+hex.EncodeToString(outer[:])), 0)
+		case 10: // SASL, with the mechanisms the server offers
+			if !bytes.Contains(data, []byte("SCRAM-SHA-256\x00")) {
+				return fmt.Errorf("server offers no supported SASL mechanism")
+			}
+			scram = newSCRAM(s.Password)
+			first := scram.clientFirst("")
+			reply = append([]byte("SCRAM-SHA-256\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(first)))...)
+			reply = append(reply, first...)
+		case 11: // SASL continue
+			if scram == nil {
+				return fmt.Errorf("unexpected SASL continue")
+			}
+			final, err := scram.clientFinal(string(data))
+			if err != nil {
+				return err
+			}
+			reply = []byte(final)
+		case 12: // SASL final
+			if scram == nil || !scram.verify(string(data)) {
+				return fmt.Errorf("server signature does not match")
+			}
+			continue
+		default:
+			return fmt.Errorf("unsupported authentication method %d", code)
+		}
+		if _, err := s.conn.Write(pgMessage('p', reply)); err != nil {
+			return err
+		}
+	}
+}
+
+// query runs one statement with the simple query protocol and waits until
+// the server is ready again. A COPY FROM STDIN is fed copyData.
+func (s *PostgresSink) query(sql string, copyData []byte) error {
+	s.conn.SetDeadline(time.Now().Add(postgresTimeout))
+	if _, err := s.conn.Write(pgMessage('Q', append([]byte(sql), 0))); err != nil {
+		return err
+	}
+	var qerr error
+	for {
+		kind, body, err := s.read()
+		if err != nil {
+			return err
+		}
+		switch kind {
+		case 'E':
+			qerr = parsePostgresError(body)
+		case 'G': // CopyInResponse
+			data := append(pgMessage('d', copyData), pgMessage('c', nil)...)
+			if copyData == nil {
+				data = pgMessage('f', []byte("no data\x00"))
+			}
+			if _, err := s.conn.Write(data); err != nil {
+				return err
+			}
+		case 'Z':
+			return qerr
+		}
+	}
+}
+
+// read reads one backend message and returns its type and body
+func (s *PostgresSink) read() (byte, []byte, error) {
+	var head [5]byte
+	if _, err := io.ReadFull(s.r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	n := int(binary.BigEndian.Uint32(head[1:])) - 4
+	if n < 0 || n > 1<<24 {
+		return 0, nil, fmt.Errorf("malformed message length %d", n)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(s.r, body); err != nil {
+		return 0, nil, err
+	}
+	return head[0], body, nil
+}
+
+// closeConn drops the connection
+func (s *PostgresSink) closeConn() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn, s.r = nil, nil
+	return err
+}
+
+// pgMessage frames a frontend message
+func pgMessage(kind byte, body []byte) []byte {
+	msg := binary.BigEndian.AppendUint32([]byte{kind}, uint32(4+len(body)))
+	return append(msg, body...)
+}
+
+// parsePostgresError decodes the fields of an ErrorResponse
+func parsePostgresError(body []byte) postgresError {
+	var e postgresError
+	for len(body) > 1 {
+		kind := body[0]
+		end := bytes.IndexByte(body[1:], 0)
+		if end < 0 {
+			break
+		}
+		value := string(body[1 : 1+end])
+		body = body[2+end:]
+		switch kind {
+		case 'S':
+			e.severity = value
+		case 'C':
+			e.code = value
+		case 'M':
+			e.message = value
+		}
+	}
+	return e
+}
+
+// scramSHA256 is the client side of a SCRAM-SHA-256 exchange (RFC 7677)
+type scramSHA256 struct {
+	password        string
+	nonce           string
+	clientFirstBare string
+	serverSignature []byte
+}
+
+// newSCRAM starts an exchange with a random nonce
+func newSCRAM(password string) *scramSHA256 {
+	var b [18]byte
+	rand.Read(b[:])
+	return &scramSHA256{password: password, nonce: base64.StdEncoding.EncodeToString(b[:])}
+}
+
+// clientFirst returns the client-first-message. PostgreSQL takes the user
+// from the startup message, so it is usually empty.
+func (c *scramSHA256) clientFirst(user string) string {
+	c.clientFirstBare = "n=" + user + ",r=" + c.nonce
+	return "n,," + c.clientFirstBare
+}
+
+// clientFinal answers the server-first-message with the client proof
+func (c *scramSHA256) clientFinal(serverFirst string) (string, error) {
+	var nonce, salt string
+	iterations := 0
+	for _, attr := range strings.Split(serverFirst, ",") {
+		if len(attr) < 2 || attr[1] != '=' {
+			continue
+		}
+		switch attr[0] {
+		case 'r':
+			nonce = attr[2:]
+		case 's':
+			salt = attr[2:]
+		case 'i':
+			iterations, _ = strconv.Atoi(attr[2:])
+		}
+	}
+	saltBytes, err := base64.StdEncoding.DecodeString(salt)
+	if !strings.HasPrefix(nonce, c.nonce) || err != nil || iterations <= 0 {
+		return "", fmt.Errorf("malformed SCRAM server-first-message")
+	}
+
+	salted := pbkdf2SHA256([]byte(c.password), saltBytes, iterations)
+	clientKey := hmacSHA256(salted, "Client Key")
+	storedKey := sha256.Sum256(clientKey)
+	withoutProof := "c=biws,r=" + nonce
+	authMessage := c.clientFirstBare + "," + serverFirst + "," + withoutProof
+	proof := hmacSHA256(storedKey[:], authMessage)
+	for i := range proof {
+		proof[i] ^= clientKey[i]
+	}
+	c.serverSignature = hmacSHA256(hmacSHA256(salted, "Server Key"), authMessage)
+	return withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof), nil
+}
+
+// verify checks the server-final-message against the expected signature
+func (c *scramSHA256) verify(serverFinal string) bool {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(serverFinal, "v="))
+	return err == nil && c.serverSignature != nil && hmac.Equal(sig, c.serverSignature)
+}
+
+// pbkdf2SHA256 derives a 32-byte key with PBKDF2-HMAC-SHA256
+func pbkdf2SHA256(password, salt []byte, iterations int) []byte {
+	mac := hmac.New(sha256.New, password)
+	mac.Write(salt)
+	mac.Write([]byte{0, 0, 0, 1})
+	u := mac.Sum(nil)
+	key := append([]byte(nil), u...)
+	for i := 1; i < iterations; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range key {
+			key[j] ^= u[j]
+		}
+	}
+	return key
+}
@@ -0,0 +1,314 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestSCRAMSHA256RFC7677(t *testing.T) {
+	// The example exchange of RFC 7677, section 3
+	c := &scramSHA256{password: "pencil", nonce: "rOprNGfwEbeRWgbNEkqO"}
+	if got, want := c.clientFirst("user"), "n,,n=user,r=rOprNGfwEbeRWgbNEkqO"; got != want {
+		t.Errorf("client-first-message %q, want %q", got, want)
+	}
+	final, err := c.clientFinal("r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="; final != want {
+		t.Errorf("client-final-message %q, want %q", final, want)
+	}
+	if !c.verify("v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=") {
+		t.Error("server signature of the RFC rejected")
+	}
+	if c.verify("v=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=") {
+		t.Error("wrong server signature accepted")
+	}
+
+	for _, serverFirst := range []string{
+		"r=someoneelse,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096",
+		"r=rOprNGfwEbeRWgbNEkqOsrv,s=not base64,i=4096",
+		"r=rOprNGfwEbeRWgbNEkqOsrv,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=0",
+	} {
+		if _, err := c.clientFinal(serverFirst); err == nil {
+			t.Errorf("server-first-message %q accepted", serverFirst)
+		}
+	}
+}
+
+// fakePostgres is a PostgreSQL server speaking just enough of the protocol
+// for PostgresSink, authenticating with auth ("password", "md5" or
+// "scram") and failing every COPY with copyError when it is set
+type fakePostgres struct {
+	t        *testing.T
+	ln       net.Listener
+	auth     string
+	password string
+
+	mu        sync.Mutex
+	copyError string
+	queries   []string
+	copies    []string
+}
+
+func newFakePostgres(t *testing.T, auth, password string) *fakePostgres {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakePostgres{t: t, ln: ln, auth: auth, password: password}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakePostgres) sink() *PostgresSink {
+	return &PostgresSink{Addr: f.ln.Addr().String(), User: "app", Password: "secret"}
+}
+
+// received returns the statements run and the data of each COPY
+func (f *fakePostgres) received() (queries, copies []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.queries, f.copies
+}
+
+func (f *fakePostgres) failCopies(code string) {
+	f.mu.Lock()
+	f.copyError = code
+	f.mu.Unlock()
+}
+
+func (f *fakePostgres) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return
+	}
+	startup := make([]byte, binary.BigEndian.Uint32(size[:])-4)
+	if _, err := io.ReadFull(r, startup); err != nil {
+		return
+	}
+	if !bytes.Contains(startup, []byte("user\x00app\x00database\x00app\x00")) {
+		f.t.Errorf("startup message %q", startup)
+	}
+
+	if !f.authenticate(conn, r) {
+		conn.Write(pgMessage('E', []byte("SFATAL\x00C28P01\x00Mpassword authentication failed\x00\x00")))
+		return
+	}
+	conn.Write(pgMessage('R', []byte{0, 0, 0, 0}))
+	conn.Write(pgMessage('S', []byte("server_version\x0016.0\x00")))
+	conn.Write(pgMessage('Z', []byte("I")))
+
+	for {
+		kind, body, err := readFrontend(r)
+		if err != nil || kind == 'X' {
+			return
+		}
+		if kind != 'Q' {
+			f.t.Errorf("unexpected message %q", kind)
+			return
+		}
+		sql := strings.TrimSuffix(string(body), "\x00")
+		if !strings.HasPrefix(sql, "COPY ") {
+			f.mu.Lock()
+			f.queries = append(f.queries, sql)
+			f.mu.Unlock()
+			conn.Write(pgMessage('C', []byte("OK\x00")))
+			conn.Write(pgMessage('Z', []byte("I")))
+			continue
+		}
+
+		conn.Write(pgMessage('G', []byte{0, 0, 0}))
+		var data []byte
+		for kind != 'c' && kind != 'f' {
+			if kind, body, err = readFrontend(r); err != nil {
+				return
+			}
+			if kind == 'd' {
+				data = append(data, body...)
+			}
+		}
+		f.mu.Lock()
+		code := f.copyError
+		if code == "" {
+			f.copies = append(f.copies, string(data))
+		}
+		f.mu.Unlock()
+		if code != "" {
+			conn.Write(pgMessage('E', []byte("SERROR\x00C"+code+"\x00Mcopy failed\x00\x00")))
+		} else {
+			conn.Write(pgMessage('C', []byte("COPY 1\x00")))
+		}
+		conn.Write(pgMessage('Z', []byte("I")))
+	}
+}
+
+// authenticate runs the server side of f.auth and reports whether the
+// client knew the password
+func (f *fakePostgres) authenticate(conn net.Conn, r *bufio.Reader) bool {
+	switch f.auth {
+	case "password":
+		conn.Write(pgMessage('R', []byte{0, 0, 0, 3}))
+		_, body, err := readFrontend(r)
+		return err == nil && string(body) == f.password+"\x00"
+	case "md5":
+		salt := []byte{1, 2, 3, 4}
+		conn.Write(pgMessage('R', append([]byte{0, 0, 0, 5}, salt...)))
+		_, body, err := readFrontend(r)
+		inner := md5.Sum([]byte(f.password + "app"))
+		outer := md5.Sum(append([]byte(hex.EncodeToString(inner[:])), salt...))
+		return err == nil && string(body) == "md5"+hex.EncodeToString(outer[:])+"\x00"
+	}
+
+	conn.Write(pgMessage('R', []byte("\x00\x00\x00\x0aSCRAM-SHA-256\x00\x00")))
+	_, body, err := readFrontend(r)
+	mechanism, rest, _ := bytes.Cut(body, []byte{0})
+	if err != nil || string(mechanism) != "SCRAM-SHA-256" || len(rest) < 4 {
+		return false
+	}
+	clientFirstBare := strings.TrimPrefix(string(rest[4:]), "n,,")
+	nonce := clientFirstBare[strings.Index(clientFirstBare, "r=")+2:] + "server"
+	salt := []byte("pepper")
+	serverFirst := "r=" + nonce + ",s=" + base64.StdEncoding.EncodeToString(salt) + ",i=4096"
+	conn.Write(pgMessage('R', append([]byte{0, 0, 0, 11}, serverFirst...)))
+
+	_, body, err = readFrontend(r)
+	withoutProof, proof, ok := strings.Cut(string(body), ",p=")
+	if err != nil || !ok || withoutProof != "c=biws,r="+nonce {
+		return false
+	}
+	clientProof, _ := base64.StdEncoding.DecodeString(proof)
+	if len(clientProof) != sha256.Size {
+		return false
+	}
+	salted := pbkdf2SHA256([]byte(f.password), salt, 4096)
+	storedKey := sha256.Sum256(hmacSHA256(salted, "Client Key"))
+	authMessage := clientFirstBare + "," + serverFirst + "," + withoutProof
+	clientKey := hmacSHA256(storedKey[:], authMessage)
+	for i := range clientKey {
+		clientKey[i] ^= clientProof[i]
+	}
+	if sha256.Sum256(clientKey) != storedKey {
+		return false
+	}
+	signature := hmacSHA256(hmacSHA256(salted, "Server Key"), authMessage)
+	conn.Write(pgMessage('R', append([]byte{0, 0, 0, 12}, "v="+base64.StdEncoding.EncodeToString(signature)...)))
+	return true
+}
+
+// readFrontend reads one message the client sent
+func readFrontend(r *bufio.Reader) (byte, []byte, error) {
+	var head [5]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, binary.BigEndian.Uint32(head[1:])-4)
+	_, err := io.ReadFull(r, body)
+	return head[0], body, err
+}
+
+func postgresBatch(lines ...string) []batchEntry {
+	var batch []batchEntry
+	for _, line := range lines {
+		batch = append(batch, batchEntry{level: zerolog.NoLevel, time: time.Now(), data: []byte(line + "\n")})
+	}
+	return batch
+}
+
+func TestPostgresSinkAuthentication(t *testing.T) {
+	for _, auth := range []string{"password", "md5", "scram"} {
+		t.Run(auth, func(t *testing.T) {
+			f := newFakePostgres(t, auth, "secret")
+			s := f.sink()
+			defer s.Close()
+			if err := s.send(postgresBatch(`{"level":"info","message":"hello"}`)); err != nil {
+				t.Fatal(err)
+			}
+			queries, copies := f.received()
+			if len(copies) != 1 {
+				t.Errorf("%d COPYs, want 1", len(copies))
+			}
+			if len(queries) != 2 || !strings.HasPrefix(queries[0], `CREATE TABLE IF NOT EXISTS "logs"`) {
+				t.Errorf("migration queries %q", queries)
+			}
+
+			s.Password = "wrong"
+			s.closeConn()
+			err := s.send(postgresBatch(`{"message":"hello"}`))
+			var perr postgresError
+			if !errors.As(err, &perr) || perr.code != "28P01" || !errors.As(err, new(permanentError)) {
+				t.Errorf("wrong password: got %v, want a permanent 28P01", err)
+			}
+		})
+	}
+}
+
+func TestPostgresSinkCopyEscaping(t *testing.T) {
+	f := newFakePostgres(t, "password", "secret")
+	s := f.sink()
+	defer s.Close()
+	err := s.send(postgresBatch(
+		`{"level":"warn","component":"tab\there","path":"C:\\logs\\new","time":"2026-10-15T01:02:03Z","message":"line\none\r\n"}`,
+		`{"time":"2026-10-15T01:02:03Z","message":"nul\u0000"}`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2026-10-15T01:02:03Z\twarn\ttab\\there\tline\\none\\r\\n\t{\"path\":\"C:\\\\\\\\logs\\\\\\\\new\"}\n" +
+		"2026-10-15T01:02:03Z\t\\N\t\\N\tnul\t{}\n"
+	if _, copies := f.received(); len(copies) != 1 || copies[0] != want {
+		t.Errorf("COPY data\n%q\nwant\n%q", copies, want)
+	}
+}
+
+func TestPostgresSinkErrors(t *testing.T) {
+	if err := (&PostgresSink{}).send(postgresBatch(`{}`)); !errors.As(err, new(permanentError)) {
+		t.Errorf("without User: got %v, want a permanent error", err)
+	}
+
+	// A rejected batch is dropped, a transient failure is retried
+	f := newFakePostgres(t, "password", "secret")
+	s := f.sink()
+	defer s.Close()
+	f.failCopies("22P02")
+	if err := s.send(postgresBatch(`{}`)); !errors.As(err, new(permanentError)) {
+		t.Errorf("invalid input: got %v, want a permanent error", err)
+	}
+	f.failCopies("57P01")
+	err := s.send(postgresBatch(`{}`))
+	if err == nil || errors.As(err, new(permanentError)) {
+		t.Errorf("admin shutdown: got %v, want a retryable error", err)
+	}
+
+	// A server that cannot be reached is retried too
+	f.ln.Close()
+	s.closeConn()
+	if err := s.send(postgresBatch(`{}`)); err == nil || errors.As(err, new(permanentError)) {
+		t.Errorf("unreachable: got %v, want a retryable error", err)
+	}
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	if cfg := DevelopmentConfig(); cfg.Level != "debug" || !cfg.Pretty || !cfg.WithCaller {
+		t.Errorf("DevelopmentConfig = %+v", cfg)
+	}
+	if cfg := ProductionConfig(); cfg.Level != "info" || cfg.Pretty {
+		t.Errorf("ProductionConfig = %+v", cfg)
+	}
+
+	var file bytes.Buffer
+	cfg := ConsoleAndFileConfig(&file, "", "debug")
+	if len(cfg.Sinks) != 2 {
+		t.Fatalf("got %d sinks, want 2", len(cfg.Sinks))
+	}
+	console, f := cfg.Sinks[0], cfg.Sinks[1]
+	if console.Writer != os.Stderr || console.MinLevel != "info" || console.Format != "pretty" {
+		t.Errorf("console sink = %+v", console)
+	}
+	if f.Writer != &file || f.MinLevel != "debug" || f.Format != "" {
+		t.Errorf("file sink = %+v", f)
+	}
+	for _, cfg := range []Config{DevelopmentConfig(), ProductionConfig(), cfg} {
+		if err := cfg.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestCapture(t *testing.T) {
+	cfg, c := TestConfig()
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Info(fmt.Sprintf("entry %d", i))
+		}()
+	}
+	wg.Wait()
+	c.Write([]byte("not json\n"))
+
+	if got := len(c.Entries()); got != 8 {
+		t.Errorf("got %d entries, want 8", got)
+	}
+	c.Reset()
+	if c.String() != "" || len(c.Entries()) != 0 {
+		t.Errorf("Reset left %q", c.String())
+	}
+}
@@ -0,0 +1,381 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// LogEntry is an entry in the protobuf output format, the Go form of the
+// LogEntry message in logentry.proto
+//
+//	dec := logger.NewProtoDecoder(conn)
+//	for {
+//		entry, err := dec.Decode()
+//		if err == io.EOF {
+//			break
+//		}
+//		...
+//		fmt.Println(entry.Timestamp, entry.Level, entry.Message)
+//	}
+//
+// Programs in other languages generate their types from logentry.proto.
+// Fields holds what google.protobuf.Struct can: nil, float64, string,
+// bool, map[string]interface{} and []interface{}; numbers in other Go
+// types are encoded as float64 and anything else as its string form.
+type LogEntry struct {
+	Timestamp time.Time // When the entry was logged, zero if unset
+	Level     string    // Level name
+	Message   string
+	Component string                 // Component of loggers returned by GetLogger
+	Fields    map[string]interface{} // The other fields
+}
+
+// Field numbers of logentry.proto and the well-known types it uses
+const (
+	protoEntryTimestamp = 1
+	protoEntryLevel     = 2
+	protoEntryMessage   = 3
+	protoEntryComponent = 4
+	protoEntryFields    = 5
+
+	protoValueNull   = 1
+	protoValueNumber = 2
+	protoValueString = 3
+	protoValueBool   = 4
+	protoValueStruct = 5
+	protoValueList   = 6
+)
+
+// Protobuf wire types
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// maxProtoEntry bounds the length prefix a ProtoDecoder accepts, so a
+// corrupt stream cannot make it allocate without limit
+const maxProtoEntry = 64 << 20
+
+// MarshalBinary implements encoding.BinaryMarshaler with the protobuf
+// encoding of the entry, without a length prefix
+func (e *LogEntry) MarshalBinary() ([]byte, error) {
+	return e.appendProto(nil), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, decoding one
+// protobuf-encoded entry without a length prefix
+func (e *LogEntry) UnmarshalBinary(data []byte) error {
+	*e = LogEntry{}
+	return protoFields(data, func(num int, typ byte, v uint64, b []byte) error {
+		switch {
+		case num == protoEntryTimestamp && typ == protoBytes:
+			var sec, nsec int64
+			err := protoFields(b, func(num int, typ byte, v uint64, _ []byte) error {
+				switch {
+				case num == 1 && typ == protoVarint:
+					sec = int64(v)
+				case num == 2 && typ == protoVarint:
+					nsec = int64(int32(v))
+				}
+				return nil
+			})
+			e.Timestamp = time.Unix(sec, nsec)
+			return err
+		case num == protoEntryLevel && typ == protoBytes:
+			e.Level = string(b)
+		case num == protoEntryMessage && typ == protoBytes:
+			e.Message = string(b)
+		case num == protoEntryComponent && typ == protoBytes:
+			e.Component = string(b)
+		case num == protoEntryFields && typ == protoBytes:
+			fields, err := decodeProtoStruct(b)
+			if err != nil {
+				return err
+			}
+			e.Fields = fields
+		}
+		return nil
+	})
+}
+
+// appendProto appends the protobuf encoding of the entry to dst
+func (e *LogEntry) appendProto(dst []byte) []byte {
+	if !e.Timestamp.IsZero() {
+		var ts []byte
+		if sec := e.Timestamp.Unix(); sec != 0 {
+			ts = protowireVarint(ts, 1, uint64(sec))
+		}
+		if nsec := e.Timestamp.Nanosecond(); nsec != 0 {
+			ts = protowireVarint(ts, 2, uint64(nsec))
+		}
+		dst = protowireBytes(dst, protoEntryTimestamp, ts)
+	}
+	for _, f := range []struct {
+		num int
+		s   string
+	}{{protoEntryLevel, e.Level}, {protoEntryMessage, e.Message}, {protoEntryComponent, e.Component}} {
+		if f.s != "" {
+			dst = protowireBytes(dst, f.num, []byte(f.s))
+		}
+	}
+	if len(e.Fields) > 0 {
+		dst = protowireBytes(dst, protoEntryFields, appendProtoStruct(nil, e.Fields))
+	}
+	return dst
+}
+
+// protobufWriter is the "protobuf" output format: each entry becomes a
+// LogEntry message preceded by its length as a varint
+func protobufWriter(w io.Writer, cfg Config) io.Writer {
+	return &encodingWriter{w: w, encode: func(dst []byte, lvl zerolog.Level, entry []byte) []byte {
+		msg, fields := decodeEntry(bytes.TrimRight(entry, "\n"))
+		e := LogEntry{Message: msg, Fields: fields}
+		delete(fields, zerolog.MessageFieldName)
+		if t, ok := entryTime(fields[zerolog.TimestampFieldName], cfg.TimeFormat); ok {
+			e.Timestamp = t
+			delete(fields, zerolog.TimestampFieldName)
+		}
+		if lvl = entryLevel(lvl, fields); lvl != zerolog.NoLevel {
+			e.Level = LevelString(lvl)
+		}
+		delete(fields, zerolog.LevelFieldName)
+		if c, ok := fields["component"].(string); ok {
+			e.Component = c
+			delete(fields, "component")
+		}
+		data := e.appendProto(nil)
+		dst = binary.AppendUvarint(dst, uint64(len(data)))
+		return append(dst, data...)
+	}}
+}
+
+// ProtoDecoder reads the length-prefixed entries of a stream written in
+// the protobuf format
+type ProtoDecoder struct {
+	r *bufio.Reader
+}
+
+// NewProtoDecoder returns a ProtoDecoder reading from r
+func NewProtoDecoder(r io.Reader) *ProtoDecoder {
+	return &ProtoDecoder{r: bufio.NewReader(r)}
+}
+
+// Decode reads the next entry, returning io.EOF at the end of the stream
+func (d *ProtoDecoder) Decode() (*LogEntry, error) {
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		if err == io.EOF {
+			return nil, err
+		}
+		return nil, fmt.Errorf("protobuf: length: %w", err)
+	}
+	if n > maxProtoEntry {
+		return nil, fmt.Errorf("protobuf: entry of %d bytes is too large", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("protobuf: %w", err)
+	}
+	e := new(LogEntry)
+	if err := e.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// appendProtoStruct appends a google.protobuf.Struct holding fields, in
+// key order so equal entries encode the same way
+func appendProtoStruct(dst []byte, fields map[string]interface{}) []byte {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		entry := protowireBytes(nil, 1, []byte(k))
+		entry = protowireBytes(entry, 2, appendProtoValue(nil, fields[k]))
+		dst = protowireBytes(dst, 1, entry)
+	}
+	return dst
+}
+
+// appendProtoValue appends a google.protobuf.Value holding v
+func appendProtoValue(dst []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return protowireVarint(dst, protoValueNull, 0)
+	case string:
+		return protowireBytes(dst, protoValueString, []byte(v))
+	case bool:
+		b := uint64(0)
+		if v {
+			b = 1
+		}
+		return protowireVarint(dst, protoValueBool, b)
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return protowireDouble(dst, protoValueNumber, f)
+		}
+		return protowireBytes(dst, protoValueString, []byte(v))
+	case float64:
+		return protowireDouble(dst, protoValueNumber, v)
+	case float32:
+		return protowireDouble(dst, protoValueNumber, float64(v))
+	case int:
+		return protowireDouble(dst, protoValueNumber, float64(v))
+	case int64:
+		return protowireDouble(dst, protoValueNumber, float64(v))
+	case uint64:
+		return protowireDouble(dst, protoValueNumber, float64(v))
+	case map[string]interface{}:
+		return protowireBytes(dst, protoValueStruct, appendProtoStruct(nil, v))
+	case []interface{}:
+		var list []byte
+		for _, item := range v {
+			list = protowireBytes(list, 1, appendProtoValue(nil, item))
+		}
+		return protowireBytes(dst, protoValueList, list)
+	}
+	return protowireBytes(dst, protoValueString, []byte(fmt.Sprint(v)))
+}
+
+// decodeProtoStruct decodes a google.protobuf.Struct
+func decodeProtoStruct(data []byte) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	err := protoFields(data, func(num int, typ byte, _ uint64, b []byte) error {
+		if num != 1 || typ != protoBytes {
+			return nil
+		}
+		var key string
+		var value interface{}
+		err := protoFields(b, func(num int, typ byte, _ uint64, b []byte) error {
+			var err error
+			switch {
+			case num == 1 && typ == protoBytes:
+				key = string(b)
+			case num == 2 && typ == protoBytes:
+				value, err = decodeProtoValue(b)
+			}
+			return err
+		})
+		fields[key] = value
+		return err
+	})
+	return fields, err
+}
+
+// decodeProtoValue decodes a google.protobuf.Value
+func decodeProtoValue(data []byte) (interface{}, error) {
+	var value interface{}
+	err := protoFields(data, func(num int, typ byte, v uint64, b []byte) error {
+		var err error
+		switch {
+		case num == protoValueNull:
+			value = nil
+		case num == protoValueNumber && typ == protoFixed64:
+			value = math.Float64frombits(v)
+		case num == protoValueString && typ == protoBytes:
+			value = string(b)
+		case num == protoValueBool && typ == protoVarint:
+			value = v != 0
+		case num == protoValueStruct && typ == protoBytes:
+			value, err = decodeProtoStruct(b)
+		case num == protoValueList && typ == protoBytes:
+			list := []interface{}{}
+			err = protoFields(b, func(num int, typ byte, _ uint64, b []byte) error {
+				if num != 1 || typ != protoBytes {
+					return nil
+				}
+				item, err := decodeProtoValue(b)
+				list = append(list, item)
+				return err
+			})
+			value = list
+		}
+		return err
+	})
+	return value, err
+}
+
+// errProtoTruncated reports a message that ends inside a field
+var errProtoTruncated = errors.New("protobuf: truncated message")
+
+// protoFields calls f with each field of an encoded message: its number,
+// its wire type, and its value as v for varint and fixed fields or as b
+// for length-delimited ones
+func protoFields(data []byte, f func(num int, typ byte, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errProtoTruncated
+		}
+		data = data[n:]
+		num, typ := int(tag>>3), byte(tag&7)
+		var v uint64
+		var b []byte
+		switch typ {
+		case protoVarint:
+			v, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errProtoTruncated
+			}
+			data = data[n:]
+		case protoFixed64:
+			if len(data) < 8 {
+				return errProtoTruncated
+			}
+			v, data = binary.LittleEndian.Uint64(data), data[8:]
+		case protoFixed32:
+			if len(data) < 4 {
+				return errProtoTruncated
+			}
+			v, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case protoBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || size > uint64(len(data)-n) {
+				return errProtoTruncated
+			}
+			b, data = data[n:n+int(size)], data[n+int(size):]
+		default:
+			return fmt.Errorf("protobuf: unsupported wire type %d", typ)
+		}
+		if err := f(num, typ, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// protowireVarint appends a varint field
+func protowireVarint(dst []byte, num int, v uint64) []byte {
+	dst = binary.AppendUvarint(dst, uint64(num)<<3|protoVarint)
+	return binary.AppendUvarint(dst, v)
+}
+
+// protowireDouble appends a double field
+func protowireDouble(dst []byte, num int, f float64) []byte {
+	dst = binary.AppendUvarint(dst, uint64(num)<<3|protoFixed64)
+	return binary.LittleEndian.AppendUint64(dst, math.Float64bits(f))
+}
+
+// protowireBytes appends a length-delimited field
+func protowireBytes(dst []byte, num int, b []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(num)<<3|protoBytes)
+	dst = binary.AppendUvarint(dst, uint64(len(b)))
+	return append(dst, b...)
+}
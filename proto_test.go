@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogEntryMarshalBinary(t *testing.T) {
+	e := &LogEntry{Timestamp: time.Unix(1, 0), Level: "info", Message: "hi", Fields: map[string]interface{}{"n": 1.5}}
+	data, _ := e.MarshalBinary()
+	// timestamp {seconds: 1}, level, message, fields {"n": number_value 1.5}
+	want := "0a020801" + "1204696e666f" + "1a026869" + "2a10" + "0a0e" + "0a016e" + "1209" + "11000000000000f83f"
+	if got := hex.EncodeToString(data); got != want {
+		t.Errorf("encoded\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestLogEntryRoundTrip(t *testing.T) {
+	e := &LogEntry{
+		Timestamp: time.Date(2026, 10, 15, 1, 2, 3, 500, time.UTC),
+		Level:     "error",
+		Message:   "charge failed",
+		Component: "billing",
+		Fields: map[string]interface{}{
+			"amount": 42.5,
+			"ok":     false,
+			"none":   nil,
+			"tags":   []interface{}{"a", 1.0, true},
+			"http":   map[string]interface{}{"status": 503.0, "path": "/charge"},
+		},
+	}
+	data, _ := e.MarshalBinary()
+	var got LogEntry
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Timestamp.Equal(e.Timestamp) || got.Level != e.Level || got.Message != e.Message || got.Component != e.Component {
+		t.Errorf("decoded %+v, want %+v", got, e)
+	}
+	if !reflect.DeepEqual(got.Fields, e.Fields) {
+		t.Errorf("fields\n%v\nwant\n%v", got.Fields, e.Fields)
+	}
+}
+
+func TestProtobufFormat(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, Format: "protobuf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().Truncate(time.Second)
+	l.Component("api").Info("served", "status", 200)
+	l.Warn("slow")
+
+	dec := NewProtoDecoder(&buf)
+	e, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Level != "info" || e.Message != "served" || e.Component != "api" || e.Timestamp.Before(before) || !reflect.DeepEqual(e.Fields, map[string]interface{}{"status": 200.0}) {
+		t.Errorf("first entry %+v", e)
+	}
+	if e, err := dec.Decode(); err != nil || e.Level != "warn" || e.Message != "slow" || len(e.Fields) != 0 {
+		t.Errorf("second entry %+v, %v", e, err)
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("got %v at the end, want io.EOF", err)
+	}
+}
+
+func TestProtoDecoderErrors(t *testing.T) {
+	huge := binary.AppendUvarint(nil, maxProtoEntry+1)
+	for input, want := range map[string]string{
+		string(huge):     "too large",
+		"\x05\x12\x04in": "unexpected EOF",
+		"\x80":           "length",
+	} {
+		if _, err := NewProtoDecoder(strings.NewReader(input)).Decode(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Decode(%x) = %v, want %q", input, err, want)
+		}
+	}
+}
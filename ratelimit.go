@@ -0,0 +1,239 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// RateLimitWriter passes on at most Limit entries per Interval among
+// entries sharing the value of the Key field, so a retry loop logging the
+// same failure thousands of times a second cannot flood the output
+//
+//	w := &logger.RateLimitWriter{Out: os.Stderr, Key: "component", Limit: 10, Interval: time.Second}
+//
+// When a key's interval ends, the number of entries it suppressed is
+// written as one entry at their level, whether or not the key logs again,
+// with the key's value in rate_limit_key, the count in suppressed and the
+// message "suppressed N similar messages". Entries without the Key field
+// are not limited.
+//
+// Config.RateLimit puts one in front of the output.
+type RateLimitWriter struct {
+	Out      io.Writer     // Destination, required
+	Key      string        // Field entries are grouped by, the message if empty
+	Limit    int           // Entries per key passed on each Interval
+	Interval time.Duration // Window of Limit, a second if zero
+
+	mu     sync.Mutex
+	keys   map[string]*rateWindow
+	swept  time.Time
+	expiry []rateSummary // reused by the sweep
+	timer  *time.Timer   // writes the summaries owed once intervals end
+}
+
+// RateLimitConfig limits entries sharing a key, see RateLimitWriter
+type RateLimitConfig struct {
+	Key      string        // Field entries are grouped by: message, component or any other, the message if empty
+	Limit    int           // Entries per key passed on each Interval, required
+	Interval time.Duration // Window of Limit, a second if zero
+}
+
+// rateWindow counts the entries of one key in the current interval
+type rateWindow struct {
+	start      time.Time
+	count      int
+	suppressed int
+	level      zerolog.Level
+}
+
+// rateSummary is a suppression count due to be written
+type rateSummary struct {
+	key        string
+	suppressed int
+	level      zerolog.Level
+}
+
+// Keys of the summary entries
+const (
+	RateLimitKeyField   = "rate_limit_key"
+	RateLimitCountField = "suppressed"
+)
+
+// validate rejects a missing limit and a negative interval
+func (rc *RateLimitConfig) validate() error {
+	switch {
+	case rc == nil:
+		return nil
+	case rc.Limit <= 0:
+		return errors.New("logger: rate limit must be positive")
+	case rc.Interval < 0:
+		return fmt.Errorf("logger: rate limit interval %s is negative", rc.Interval)
+	}
+	return nil
+}
+
+// rateLimitWriter wraps w in a RateLimitWriter configured by rc
+func rateLimitWriter(w io.Writer, rc RateLimitConfig) *RateLimitWriter {
+	return &RateLimitWriter{Out: w, Key: rc.Key, Limit: rc.Limit, Interval: rc.Interval}
+}
+
+// Write implements io.Writer for entries without a known level
+func (w *RateLimitWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (w *RateLimitWriter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	key, ok := w.key(p)
+	if !ok {
+		return w.writeOut(lvl, p)
+	}
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	now := time.Now()
+
+	w.mu.Lock()
+	if w.keys == nil {
+		w.keys = make(map[string]*rateWindow)
+	}
+	due := w.sweep(now, interval)
+	win, ok := w.keys[key]
+	if !ok {
+		win = &rateWindow{start: now}
+		w.keys[key] = win
+	} else if now.Sub(win.start) >= interval {
+		if win.suppressed > 0 {
+			due = append(due, rateSummary{key: key, suppressed: win.suppressed, level: win.level})
+		}
+		*win = rateWindow{start: now}
+	}
+	win.count++
+	pass := win.count <= w.Limit
+	if !pass {
+		win.suppressed++
+		win.level = lvl
+		if w.timer == nil {
+			w.timer = time.AfterFunc(win.start.Add(interval).Sub(now), w.expire)
+		}
+	}
+	for _, s := range due {
+		w.writeSummary(s)
+	}
+	w.mu.Unlock()
+
+	if !pass {
+		return len(p), nil
+	}
+	return w.writeOut(lvl, p)
+}
+
+// Flush writes the summaries of keys that suppressed entries so far,
+// then flushes Out if it has a Flush method
+func (w *RateLimitWriter) Flush() error {
+	w.mu.Lock()
+	for key, win := range w.keys {
+		if win.suppressed > 0 {
+			w.writeSummary(rateSummary{key: key, suppressed: win.suppressed, level: win.level})
+			win.suppressed = 0
+		}
+	}
+	w.mu.Unlock()
+	if f, ok := w.Out.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// expire writes the summaries of the intervals that ended, so a burst
+// that stopped is reported without waiting for the next entry of its key,
+// and waits for the next interval that owes one
+func (w *RateLimitWriter) expire() {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	now := time.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.timer = nil
+	w.swept = time.Time{}
+	for _, s := range w.sweep(now, interval) {
+		w.writeSummary(s)
+	}
+	var next time.Time
+	for _, win := range w.keys {
+		if end := win.start.Add(interval); win.suppressed > 0 && (next.IsZero() || end.Before(next)) {
+			next = end
+		}
+	}
+	if !next.IsZero() {
+		w.timer = time.AfterFunc(next.Sub(now), w.expire)
+	}
+}
+
+// key returns the value of the Key field of entry p
+func (w *RateLimitWriter) key(p []byte) (string, bool) {
+	name := w.Key
+	if name == "" || name == "message" {
+		name = zerolog.MessageFieldName
+	}
+	_, fields := decodeEntry(p)
+	v, ok := fields[name]
+	if !ok {
+		return "", false
+	}
+	if s, ok := v.(string); ok {
+		return s, true
+	}
+	return fmt.Sprint(v), true
+}
+
+// sweep forgets keys whose interval ended, at most once an interval, and
+// returns the summaries they owe. Callers must hold w.mu.
+func (w *RateLimitWriter) sweep(now time.Time, interval time.Duration) []rateSummary {
+	if now.Sub(w.swept) < interval {
+		return nil
+	}
+	w.swept = now
+	due := w.expiry[:0]
+	for key, win := range w.keys {
+		if now.Sub(win.start) < interval {
+			continue
+		}
+		if win.suppressed > 0 {
+			due = append(due, rateSummary{key: key, suppressed: win.suppressed, level: win.level})
+		}
+		delete(w.keys, key)
+	}
+	w.expiry = due
+	return due
+}
+
+// writeSummary writes the entry reporting suppressed entries. Callers
+// must hold w.mu so summaries are not interleaved.
+func (w *RateLimitWriter) writeSummary(s rateSummary) {
+	var buf bytes.Buffer
+	zl := zerolog.New(&buf)
+	zl.WithLevel(s.level).Timestamp().
+		Str(RateLimitKeyField, s.key).
+		Int(RateLimitCountField, s.suppressed).
+		Msgf("suppressed %d similar messages", s.suppressed)
+	w.writeOut(s.level, buf.Bytes())
+}
+
+// writeOut passes one entry on to Out, keeping its level
+func (w *RateLimitWriter) writeOut(lvl zerolog.Level, p []byte) (int, error) {
+	if lw, ok := w.Out.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(lvl, p)
+	}
+	return w.Out.Write(p)
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestRateLimitWriter(t *testing.T) {
+	out := &entryRecorder{}
+	w := &RateLimitWriter{Out: out, Key: "component", Limit: 2, Interval: time.Hour}
+	for i := 0; i < 5; i++ {
+		w.WriteLevel(zerolog.ErrorLevel, []byte(`{"level":"error","component":"db","message":"retry"}`+"\n"))
+	}
+	w.WriteLevel(zerolog.InfoLevel, []byte(`{"level":"info","component":"api","message":"retry"}`+"\n"))
+	w.Write([]byte(`{"message":"unkeyed"}` + "\n"))
+	if got := len(out.written()); got != 4 {
+		t.Fatalf("passed %d entries, want 2 db, 1 api and the unkeyed one: %q", got, out.written())
+	}
+
+	// Flush writes the suppression count owed so far, at the level of the
+	// suppressed entries
+	w.Flush()
+	got := out.written()
+	if len(got) != 5 || out.levels[4] != zerolog.ErrorLevel {
+		t.Fatalf("wrote %q", got)
+	}
+	var summary map[string]interface{}
+	if err := json.Unmarshal([]byte(got[4]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary["level"] != "error" || summary[RateLimitKeyField] != "db" || summary[RateLimitCountField] != 3.0 || summary["message"] != "suppressed 3 similar messages" {
+		t.Errorf("summary %v", summary)
+	}
+	w.Flush()
+	if got := len(out.written()); got != 5 {
+		t.Errorf("second Flush wrote %d more entries, want none", got-5)
+	}
+}
+
+func TestRateLimitWriterIntervalEnds(t *testing.T) {
+	out := &entryRecorder{}
+	w := &RateLimitWriter{Out: out, Limit: 1, Interval: 20 * time.Millisecond}
+	for i := 0; i < 3; i++ {
+		w.Write([]byte(`{"level":"warn","message":"retry"}` + "\n"))
+	}
+
+	// The summary is written once the interval ends, without another entry
+	deadline := time.Now().Add(5 * time.Second)
+	for len(out.written()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("no summary within 5s, wrote %q", out.written())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := out.written()[1]; !strings.Contains(got, `"rate_limit_key":"retry","suppressed":2`) {
+		t.Errorf("summary %s", got)
+	}
+
+	// The key then starts a new interval
+	w.Write([]byte(`{"message":"retry"}` + "\n"))
+	if got := len(out.written()); got != 3 {
+		t.Errorf("wrote %d entries, want the new interval's first passed", got)
+	}
+}
+
+func TestRateLimitWriterKey(t *testing.T) {
+	var buf bytes.Buffer
+	w := &RateLimitWriter{Out: &buf, Key: "code", Limit: 1, Interval: time.Hour}
+	w.Write([]byte(`{"code":503,"message":"a"}` + "\n"))
+	w.Write([]byte(`{"code":503,"message":"b"}` + "\n"))
+	w.Write([]byte(`{"code":"503","message":"c"}` + "\n"))
+	w.Flush()
+	// Values are compared as text
+	if got := strings.Join(messages(t, &buf), ","); got != "a,suppressed 2 similar messages" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestConfigRateLimit(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(NewConfig(WithOutput(&buf), WithRateLimit("message", 1, time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("retry")
+	l.Info("retry")
+	l.Info("other")
+	if got := strings.Join(messages(t, &buf), ","); got != "retry,other" {
+		t.Errorf("got %q", got)
+	}
+
+	for _, rc := range []RateLimitConfig{{}, {Limit: 1, Interval: -time.Second}} {
+		if _, err := New(Config{Output: io.Discard, RateLimit: &rc}); err == nil || !strings.Contains(err.Error(), "rate limit") {
+			t.Errorf("New with RateLimit %+v: %v", rc, err)
+		}
+	}
+}
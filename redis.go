@@ -0,0 +1,227 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// RedisSink appends each entry to a Redis stream with XADD
+//
+//	sink := &logger.RedisSink{Addr: "localhost:6379", Stream: "logs", MaxLen: 100000}
+//	logger.InitLogger(logger.Config{Output: sink})
+//
+// The top-level fields of the entry become the fields of the stream entry,
+// with nested values as JSON. A line that is not a JSON object is stored
+// in the message field. With MaxLen set the stream is trimmed on every
+// XADD. Trimming is approximate, which lets Redis drop whole nodes and is
+// much cheaper, unless ExactTrim is set. The sink speaks the Redis protocol itself and
+// reconnects once when a command fails.
+type RedisSink struct {
+	Addr      string // host:port, localhost:6379 if empty
+	Password  string // Sent with AUTH when set
+	Username  string // ACL user for AUTH, the default user if empty
+	DB        int    // Database selected after connecting
+	Stream    string // Stream key, "logs" if empty
+	MaxLen    int64  // Trim the stream to about this many entries, no trimming if zero
+	ExactTrim bool   // Trim to exactly MaxLen entries
+
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// redisTimeout bounds connecting and each command
+const redisTimeout = 5 * time.Second
+
+// Write implements io.Writer
+func (s *RedisSink) Write(p []byte) (int, error) {
+	args := []string{"XADD", s.stream()}
+	if s.MaxLen > 0 {
+		args = append(args, "MAXLEN")
+		if !s.ExactTrim {
+			args = append(args, "~")
+		}
+		args = append(args, strconv.FormatInt(s.MaxLen, 10))
+	}
+	args = append(append(args, "*"), streamFields(bytes.TrimRight(p, "\n"))...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.do(args...); err != nil {
+		var rerr redisError
+		if errors.As(err, &rerr) {
+			return 0, fmt.Errorf("logger: redis: %w", err)
+		}
+		// The server may have restarted; try once more on a new connection
+		s.closeConn()
+		if _, err := s.do(args...); err != nil {
+			return 0, fmt.Errorf("logger: redis: %w", err)
+		}
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to Redis
+func (s *RedisSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeConn()
+}
+
+// stream returns the stream key
+func (s *RedisSink) stream() string {
+	if s.Stream == "" {
+		return "logs"
+	}
+	return s.Stream
+}
+
+// do sends one command and reads its reply, connecting first if needed.
+// Callers must hold s.mu.
+func (s *RedisSink) do(args ...string) (interface{}, error) {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return nil, err
+		}
+	}
+	return s.command(args...)
+}
+
+// connect dials the server, authenticates and selects the database.
+// Callers must hold s.mu.
+func (s *RedisSink) connect() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	conn, err := net.DialTimeout("tcp", addr, redisTimeout)
+	if err != nil {
+		return err
+	}
+	s.conn, s.r = conn, bufio.NewReader(conn)
+
+	if s.Password != "" {
+		args := []string{"AUTH", s.Password}
+		if s.Username != "" {
+			args = []string{"AUTH", s.Username, s.Password}
+		}
+		if _, err := s.command(args...); err != nil {
+			s.closeConn()
+			return err
+		}
+	}
+	if s.DB != 0 {
+		if _, err := s.command("SELECT", strconv.Itoa(s.DB)); err != nil {
+			s.closeConn()
+			return err
+		}
+	}
+	return nil
+}
+
+// command writes args as a RESP array and reads the reply. Callers must
+// hold s.mu.
+func (s *RedisSink) command(args ...string) (interface{}, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	s.conn.SetDeadline(time.Now().Add(redisTimeout))
+	if _, err := s.conn.Write(b.Bytes()); err != nil {
+		return nil, err
+	}
+	return readRESP(s.r)
+}
+
+// closeConn drops the connection. Callers must hold s.mu.
+func (s *RedisSink) closeConn() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn, s.r = nil, nil
+	return err
+}
+
+// redisError is an error reply from the server, as opposed to a failed
+// connection
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// readRESP reads one RESP reply: a simple string, error, integer, bulk
+// string or array of those
+func readRESP(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unknown reply type %q", kind)
+}
+
+// streamFields flattens a JSON entry into alternating field names and
+// values, sorted by name
+func streamFields(line []byte) []string {
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil || len(fields) == 0 {
+		return []string{zerolog.MessageFieldName, string(line)}
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		out = append(out, k, fieldString(fields[k]))
+	}
+	return out
+}
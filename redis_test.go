@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRedis is a Redis server that answers each command with the next of
+// replies, or +OK, and records the bytes it received
+type fakeRedis struct {
+	ln      net.Listener
+	mu      sync.Mutex
+	raw     bytes.Buffer
+	replies []string
+}
+
+func newFakeRedis(t *testing.T, replies ...string) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{ln: ln, replies: replies}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(io.TeeReader(conn, lockedWriter{&f.mu, &f.raw}))
+	for {
+		if _, err := readRESP(r); err != nil {
+			return
+		}
+		f.mu.Lock()
+		reply := "+OK\r\n"
+		if len(f.replies) > 0 {
+			reply, f.replies = f.replies[0], f.replies[1:]
+		}
+		f.mu.Unlock()
+		conn.Write([]byte(reply))
+	}
+}
+
+// received returns the bytes sent to the server so far
+func (f *fakeRedis) received() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.raw.String()
+}
+
+// lockedWriter writes to w holding mu
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (l lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+func TestRedisSinkCommands(t *testing.T) {
+	f := newFakeRedis(t, "+OK\r\n", "+OK\r\n", "$15\r\n1792026123000-0\r\n")
+	s := &RedisSink{Addr: f.ln.Addr().String(), Username: "app", Password: "secret", DB: 2, Stream: "audit", MaxLen: 1000}
+	defer s.Close()
+	if _, err := s.Write([]byte(`{"level":"warn","user":{"id":7},"message":"denied"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := "*3\r\n$4\r\nAUTH\r\n$3\r\napp\r\n$6\r\nsecret\r\n" +
+		"*2\r\n$6\r\nSELECT\r\n$1\r\n2\r\n" +
+		"*12\r\n$4\r\nXADD\r\n$5\r\naudit\r\n$6\r\nMAXLEN\r\n$1\r\n~\r\n$4\r\n1000\r\n$1\r\n*\r\n" +
+		"$5\r\nlevel\r\n$4\r\nwarn\r\n$7\r\nmessage\r\n$6\r\ndenied\r\n$4\r\nuser\r\n$8\r\n{\"id\":7}\r\n"
+	if got := f.received(); got != want {
+		t.Errorf("sent\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRedisSinkExactTrimAndPlainLine(t *testing.T) {
+	f := newFakeRedis(t)
+	s := &RedisSink{Addr: f.ln.Addr().String(), MaxLen: 10, ExactTrim: true}
+	defer s.Close()
+	if _, err := s.Write([]byte("not json\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := "*7\r\n$4\r\nXADD\r\n$4\r\nlogs\r\n$6\r\nMAXLEN\r\n$2\r\n10\r\n$1\r\n*\r\n$7\r\nmessage\r\n$8\r\nnot json\r\n"
+	if got := f.received(); got != want {
+		t.Errorf("sent\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRedisSinkErrorReply(t *testing.T) {
+	f := newFakeRedis(t, "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n")
+	s := &RedisSink{Addr: f.ln.Addr().String()}
+	defer s.Close()
+	_, err := s.Write([]byte(`{"message":"m"}`))
+	var rerr redisError
+	if !errors.As(err, &rerr) || !strings.HasPrefix(string(rerr), "WRONGTYPE") {
+		t.Errorf("got %v, want the WRONGTYPE reply", err)
+	}
+	// An error reply is not a broken connection, so it is not sent again
+	if n := strings.Count(f.received(), "XADD"); n != 1 {
+		t.Errorf("XADD sent %d times, want once", n)
+	}
+}
+
+func TestReadRESP(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("+OK\r\n:42\r\n$5\r\nhello\r\n$-1\r\n*2\r\n$1\r\na\r\n:1\r\n-ERR bad\r\n"))
+	for _, want := range []interface{}{"OK", int64(42), "hello", nil, []interface{}{"a", int64(1)}} {
+		got, err := readRESP(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalRESP(got, want) {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+	}
+	if _, err := readRESP(r); err == nil || err.Error() != "ERR bad" {
+		t.Errorf("got %v, want ERR bad", err)
+	}
+	for _, malformed := range []string{"OK\n", "?x\r\n", "$5\r\nhi\r\n"} {
+		if _, err := readRESP(bufio.NewReader(strings.NewReader(malformed))); err == nil {
+			t.Errorf("%q accepted", malformed)
+		}
+	}
+}
+
+func equalRESP(a, b interface{}) bool {
+	as, aok := a.([]interface{})
+	bs, bok := b.([]interface{})
+	if !aok || !bok {
+		return a == b
+	}
+	if len(as) != len(bs) {
+		return false
+	}
+	for i := range as {
+		if !equalRESP(as[i], bs[i]) {
+			return false
+		}
+	}
+	return true
+}
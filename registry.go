@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// registry holds the named loggers returned by Get
+var registry = &namedRegistry{
+	loggers: make(map[string]*Logger),
+	configs: make(map[string]Config),
+	nodes:   make(map[string]*loggerState),
+	bufs:    make(map[string][]bufferedWriter),
+}
+
+// namedRegistry keeps one Logger per name and the central configuration
+// they are built from. Names form a dot-separated hierarchy: "db.postgres"
+// uses the configuration of "db" unless it is configured itself, and names
+// without any configured ancestor follow the global logger.
+type namedRegistry struct {
+	mu      sync.Mutex
+	loggers map[string]*Logger          // every logger handed out by Get
+	configs map[string]Config           // configured names
+	nodes   map[string]*loggerState     // state built for each configured name
+	bufs    map[string][]bufferedWriter // buffered writers built for each node
+}
+
+// Get returns the named logger, creating it on first use
+// The same Logger is returned for a name every time, and it picks up later
+// changes made through Configure, Config.Loggers or a config file reload.
+// Entries carry the name in the component field.
+func Get(name string) *Logger {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if l, ok := registry.loggers[name]; ok {
+		return l
+	}
+	l := newInstance(registry.resolve(name))
+	registry.loggers[name] = l
+	return l
+}
+
+// Configure sets the configuration of the named logger and its descendants
+// Unset fields are inherited from the closest configured ancestor, or from
+// the global logger's configuration. Boolean options can only be turned on
+// by a descendant, not off.
+func Configure(name string, cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.configs[name] = cfg
+	registry.rebuild()
+	return nil
+}
+
+// reconfigure rebuilds the named loggers after the global logger changed
+// A non-nil configs replaces the whole named configuration.
+func (r *namedRegistry) reconfigure(configs map[string]Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if configs != nil {
+		r.configs = make(map[string]Config, len(configs))
+		for name, cfg := range configs {
+			r.configs[name] = cfg
+		}
+	}
+	r.rebuild()
+}
+
+// relevel applies a change of the global level to the configured nodes
+// that inherit it, leaving their writers as they are. Nodes configured
+// with a level or set with SetLevel keep theirs, and their descendants
+// follow them rather than the global level.
+func (r *namedRegistry) relevel() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names, resolved := r.resolveConfigs()
+	for _, name := range names {
+		node := r.nodes[name]
+		if r.configs[name].Level != "" || node.level.explicit.Load() {
+			continue
+		}
+		lvl, _ := resolved[name].level() // checked by Validate
+		if p, ok := nearestParent(name, r.nodes); ok {
+			lvl = r.nodes[p].level.Load()
+		}
+		lowerGlobalLevel(lvl)
+		node.level.Store(lvl)
+	}
+}
+
+// rebuild recreates the configured nodes and swaps the state of every
+// logger handed out so far, then closes the buffered writers of the
+// nodes it replaced. Callers must hold r.mu.
+func (r *namedRegistry) rebuild() {
+	names, resolved := r.resolveConfigs()
+	replaced := r.bufs
+	r.nodes = make(map[string]*loggerState, len(names))
+	r.bufs = make(map[string][]bufferedWriter, len(names))
+	for _, name := range names {
+		r.nodes[name], r.bufs[name] = newState(resolved[name])
+	}
+
+	for name, l := range r.loggers {
+		l.state.Store(r.resolve(name))
+	}
+
+	// Entries logged through the old nodes are still buffered
+	for _, bufs := range replaced {
+		closeBuffered(bufs)
+	}
+}
+
+// resolveConfigs returns the configured names, parents first, and the
+// configuration of each with the global one and its ancestors' applied.
+// Callers must hold r.mu.
+func (r *namedRegistry) resolveConfigs() ([]string, map[string]Config) {
+	mu.RLock()
+	root := rootConfig
+	mu.RUnlock()
+
+	// Resolve parents before children so every node can inherit from the
+	// already resolved configuration of its closest configured ancestor
+	names := make([]string, 0, len(r.configs))
+	for name := range r.configs {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		di, dj := strings.Count(names[i], "."), strings.Count(names[j], ".")
+		if di != dj {
+			return di < dj
+		}
+		return names[i] < names[j]
+	})
+
+	resolved := make(map[string]Config, len(names))
+	for _, name := range names {
+		parent := root
+		if p, ok := nearestParent(name, resolved); ok {
+			parent = resolved[p]
+		}
+		resolved[name] = inherit(parent, r.configs[name])
+	}
+	return names, resolved
+}
+
+// resolve builds the state for a named logger from its closest configured
+// ancestor, or from the global logger. Callers must hold r.mu.
+func (r *namedRegistry) resolve(name string) *loggerState {
+	if node, ok := r.nodes[name]; ok {
+		return node.derive(node.zl.With().Str("component", name).Logger())
+	}
+	if p, ok := nearestParent(name, r.nodes); ok {
+		node := r.nodes[p]
+		return node.derive(node.zl.With().Str("component", name).Logger())
+	}
+
+	// Follow the global logger, sharing its level so runtime level changes
+	// apply to unconfigured names as well
+	st := std.state.Load()
+	return st.derive(st.zl.With().Str("component", name).Logger())
+}
+
+// nearestParent returns the longest proper ancestor of name present in m
+func nearestParent[V any](name string, m map[string]V) (string, bool) {
+	for {
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return "", false
+		}
+		name = name[:i]
+		if _, ok := m[name]; ok {
+			return name, true
+		}
+	}
+}
+
+// inherit returns child with unset fields taken from parent
+func inherit(parent, child Config) Config {
+	cfg := overlay(parent, child)
+	cfg.Loggers = nil
+	return cfg
+}
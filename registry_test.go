@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestGlobalLevelKeepsSetLevel(t *testing.T) {
+	previous := GetLevel()
+	defer func() {
+		SetLevel(previous)
+		registry.reconfigure(map[string]Config{})
+	}()
+	for _, name := range []string{"svc", "svc.db", "other"} {
+		if err := Configure(name, Config{Output: io.Discard}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := Get("svc").SetLevel("error"); err != nil {
+		t.Fatal(err)
+	}
+	SetLevel("debug")
+
+	for name, want := range map[string]string{
+		"svc":    "error", // set with SetLevel
+		"svc.db": "error", // inherits from svc
+		"other":  "debug", // inherits the global level
+	} {
+		if got := Get(name).GetLevel(); got != want {
+			t.Errorf("%s: level %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestNamedLoggers(t *testing.T) {
+	var global, db bytes.Buffer
+	useGlobal(t, Config{Output: &global, Level: "info"})
+	defer registry.reconfigure(map[string]Config{})
+
+	pg := Get("db.postgres") // handed out before db is configured
+	if Get("db.postgres") != pg {
+		t.Error("Get returned a different Logger for the same name")
+	}
+	pg.Info("before")
+	if err := Configure("db", Config{Output: &db, Level: "warn", Fields: map[string]interface{}{"tier": "data"}}); err != nil {
+		t.Fatal(err)
+	}
+	pg.Info("dropped")
+	pg.Warn("after")
+	Get("http").Info("http")
+
+	if got := strings.Join(messages(t, &global), ","); got != "before,http" {
+		t.Errorf("global output got %q, want before,http", got)
+	}
+	var e map[string]interface{}
+	if err := json.Unmarshal(db.Bytes(), &e); err != nil {
+		t.Fatalf("%v: %q", err, db.String())
+	}
+	if e["message"] != "after" || e["component"] != "db.postgres" || e["tier"] != "data" {
+		t.Errorf("db output got %v", e)
+	}
+
+	if err := Configure("db", Config{Level: "loud"}); err == nil {
+		t.Error("no error for an invalid level")
+	}
+}
@@ -0,0 +1,227 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloader re-applies a config file to the global logger
+type reloader struct {
+	path     string
+	outputs  *outputSet
+	onReload func(error)
+
+	// mu serializes reloads triggered from different sources
+	mu sync.Mutex
+}
+
+// load reads the config file and builds a Config from it, without
+// installing it
+func (r *reloader) load() (Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := r.build()
+	if err != nil {
+		return cfg, err
+	}
+	r.outputs.commit()
+	return cfg, nil
+}
+
+// reload reads the config file and swaps the live logger configuration
+// Files that are still referenced stay open across the swap, so entries
+// being written while the new settings are applied are not lost.
+func (r *reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := r.build()
+	if err != nil {
+		return err
+	}
+	Reconfigure(cfg)
+	r.outputs.commit()
+	return nil
+}
+
+// build parses the config file, rolling back any files it opened on failure
+func (r *reloader) build() (Config, error) {
+	fc, err := LoadFile(r.path)
+	if err != nil {
+		return Config{}, err
+	}
+	r.outputs.begin()
+	cfg, err := fc.build(r.outputs.open)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		r.outputs.rollback()
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// report logs the outcome of a reload through the reloaded logger and
+// passes it on to the reload callback
+func (r *reloader) report(err error) {
+	logger := std.state.Load().zl
+	if err != nil {
+		logger.Error().Err(err).Str("path", r.path).Msg("logger configuration reload failed")
+	} else {
+		logger.Info().Str("path", r.path).Msg("logger configuration reloaded")
+	}
+	if r.onReload != nil {
+		r.onReload(err)
+	}
+}
+
+// watchSignals reloads the configuration every time SIGHUP is received
+func (r *reloader) watchSignals() {
+	ch := make(chan os.Signal, 1)
+	notifyReload(ch)
+	for range ch {
+		r.report(r.reload())
+	}
+}
+
+// reloadDelay is how long the file watcher waits for a burst of change
+// events to settle before reloading, since editors often write a file in
+// several steps
+const reloadDelay = 100 * time.Millisecond
+
+// newWatcher creates a watcher for the directory holding the config file
+// Watching the directory rather than the file itself keeps working when
+// editors replace the file by renaming a new one over it.
+func (r *reloader) newWatcher() (*fsnotify.Watcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("logger: watch config: %w", err)
+	}
+	if err := w.Add(filepath.Dir(r.path)); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("logger: watch config: %w", err)
+	}
+	return w, nil
+}
+
+// watchFile reloads the configuration whenever the config file changes
+func (r *reloader) watchFile(w *fsnotify.Watcher) {
+	defer w.Close()
+
+	target := filepath.Clean(r.path)
+	var timer *time.Timer
+	for {
+		select {
+		case evt, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(evt.Name) != target || !evt.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				continue
+			}
+			// Restart the delay on every event so a burst causes one reload
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(reloadDelay, func() {
+				r.report(r.reload())
+			})
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			r.report(fmt.Errorf("logger: watch config: %w", err))
+		}
+	}
+}
+
+// outputSet tracks the files opened for a config file across reloads
+type outputSet struct {
+	files map[OutputConfig]io.WriteCloser // every open file by its settings
+	live  map[OutputConfig]bool           // files used by the installed configuration
+	next  map[OutputConfig]bool           // files used by the configuration being built
+}
+
+// newOutputSet returns an empty outputSet
+func newOutputSet() *outputSet {
+	return &outputSet{
+		files: make(map[OutputConfig]io.WriteCloser),
+		live:  make(map[OutputConfig]bool),
+	}
+}
+
+// begin starts tracking the outputs of a new configuration
+func (s *outputSet) begin() {
+	s.next = make(map[OutputConfig]bool)
+}
+
+// open resolves an output, reusing a file that is already open with the
+// same settings
+func (s *outputSet) open(oc OutputConfig) (io.Writer, error) {
+	switch strings.ToLower(oc.Path) {
+	case "", "stderr", "stdout", "split":
+		return openOutput(oc.Path)
+	}
+
+	key := oc.file()
+	s.next[key] = true
+	if f, ok := s.files[key]; ok {
+		return f, nil
+	}
+	w, err := openOutputConfig(key)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := w.(io.WriteCloser)
+	if !ok {
+		return w, nil
+	}
+	s.files[key] = f
+	return f, nil
+}
+
+// commit marks the new configuration as installed and closes the files
+// only the previous one used
+func (s *outputSet) commit() {
+	for key := range s.live {
+		if !s.next[key] {
+			s.close(key)
+		}
+	}
+	s.live, s.next = s.next, nil
+}
+
+// rollback closes the files opened only for a configuration that failed
+func (s *outputSet) rollback() {
+	for key := range s.next {
+		if !s.live[key] {
+			s.close(key)
+		}
+	}
+	s.next = nil
+}
+
+// closeAll closes every file in the set
+func (s *outputSet) closeAll() {
+	for key := range s.files {
+		s.close(key)
+	}
+	s.live = make(map[OutputConfig]bool)
+}
+
+// close closes and forgets the file opened with key
+func (s *outputSet) close(key OutputConfig) {
+	if f, ok := s.files[key]; ok {
+		f.Close()
+		delete(s.files, key)
+	}
+}
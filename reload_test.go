@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReloaderReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logger.yaml")
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("outputs:\n  - path: " + first + "\n")
+	r := &reloader{path: path, outputs: newOutputSet()}
+	cfg, err := r.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	useGlobal(t, cfg)
+	t.Cleanup(r.outputs.closeAll)
+	kept := r.outputs.files[OutputConfig{Path: first}]
+
+	// The same file stays open when a reload still uses it
+	write("level: warn\noutputs:\n  - path: " + first + "\n  - path: " + second + "\n")
+	if err := r.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if r.outputs.files[OutputConfig{Path: first}] != kept {
+		t.Error("first.log reopened by a reload that kept it")
+	}
+	Info("dropped")
+	Warn("both")
+
+	// A broken file leaves the running configuration alone
+	write("level: loud\noutputs:\n  - path: " + filepath.Join(dir, "third.log") + "\n")
+	if err := r.reload(); err == nil {
+		t.Fatal("no error for an invalid level")
+	}
+	if len(r.outputs.files) != 2 {
+		t.Errorf("%d files open after a failed reload, want 2", len(r.outputs.files))
+	}
+	Warn("still both")
+
+	// A file no longer used is closed
+	write("outputs:\n  - path: " + second + "\n")
+	if err := r.reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.outputs.files[OutputConfig{Path: first}]; ok {
+		t.Error("first.log still open after a reload dropped it")
+	}
+
+	for file, want := range map[string]string{first: "both,still both", second: "both,still both"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			msg, _ := decodeEntry([]byte(line))
+			got = append(got, msg)
+		}
+		if strings.Join(got, ",") != want {
+			t.Errorf("%s got %q, want %q", filepath.Base(file), got, want)
+		}
+	}
+}
+
+func TestReloaderWatchFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logger.yaml")
+	if err := os.WriteFile(path, []byte("level: info\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := make(chan error, 10)
+	r := &reloader{path: path, outputs: newOutputSet(), onReload: func(err error) { reloaded <- err }}
+	cfg, err := r.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Output = io.Discard
+	useGlobal(t, cfg)
+
+	w, err := r.newWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		r.watchFile(w)
+		close(done)
+	}()
+	defer func() {
+		w.Close()
+		<-done
+	}()
+
+	// Other files in the directory are ignored
+	if err := os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// A file renamed over the config, as editors do, is picked up, and
+	// the burst of events causes a single reload
+	tmp := filepath.Join(dir, "logger.yaml.tmp")
+	if err := os.WriteFile(tmp, []byte("level: error\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-reloaded:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload after the config file changed")
+	}
+	if got := GetLevel(); got != "error" {
+		t.Errorf("level %s after reload, want error", got)
+	}
+	select {
+	case <-reloaded:
+		t.Error("more than one reload for one change")
+	case <-time.After(2 * reloadDelay):
+	}
+}
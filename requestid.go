@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDField is the key of the request ID added to entries logged
+// with a context carrying one
+const RequestIDField = "request_id"
+
+// RequestIDHeader is the HTTP header request IDs are read from and echoed
+// in
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen is the longest request ID taken from a header
+const maxRequestIDLen = 128
+
+// requestIDKey is the context key of a request ID
+type requestIDKey struct{}
+
+// NewRequestID returns a random request ID of 32 hex digits
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ContextWithRequestID returns a copy of ctx carrying id, which InfoCtx
+// and the other Ctx functions add to their entries as request_id, as do
+// zerolog events of this package's loggers that carry ctx through
+// Event.Ctx
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID set on ctx with
+// ContextWithRequestID
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// RequestID returns the X-Request-ID header of r, or a new ID if it is
+// missing or not a sensible ID. Header values longer than 128 bytes or
+// holding anything but printable ASCII are replaced rather than trusted,
+// so a client cannot forge entries through them.
+func RequestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); validRequestID(id) {
+		return id
+	}
+	return NewRequestID()
+}
+
+// validRequestID reports whether id can be taken from a header as is
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDHandler wraps next so every request carries a request ID on its
+// context, taken from the X-Request-ID header or generated, and echoes it
+// in the response's header so clients can quote it
+//
+//	http.ListenAndServe(":8080", logger.RequestIDHandler(mux))
+//
+//	func handle(w http.ResponseWriter, r *http.Request) {
+//		logger.InfoCtx(r.Context(), "order placed") // has request_id
+//	}
+func RequestIDHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := RequestID(r)
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), id)))
+	})
+}
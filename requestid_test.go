@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var requestIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+func TestRequestID(t *testing.T) {
+	if a, b := NewRequestID(), NewRequestID(); !requestIDPattern.MatchString(a) || a == b {
+		t.Errorf("NewRequestID() = %q, %q", a, b)
+	}
+	for header, kept := range map[string]bool{
+		"abc-123":                   true,
+		"":                          false,
+		"has space":                 false,
+		"forged\n{\"level\":\"x\"}": false,
+		"café":                      false,
+		strings.Repeat("a", 128):    true,
+		strings.Repeat("a", 129):    false,
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		if header != "" {
+			r.Header.Set(RequestIDHeader, header)
+		}
+		got := RequestID(r)
+		if kept && got != header || !kept && !requestIDPattern.MatchString(got) {
+			t.Errorf("RequestID with header %q = %q", header, got)
+		}
+	}
+}
+
+func TestRequestIDHandler(t *testing.T) {
+	cfg, c := TestConfig()
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := RequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.InfoCtx(r.Context(), "handled")
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(RequestIDHeader, "req-7")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if got := w.Header().Get(RequestIDHeader); got != "req-7" {
+		t.Errorf("echoed %q", got)
+	}
+
+	// Without a header one is made up and echoed
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	generated := w.Header().Get(RequestIDHeader)
+	if !requestIDPattern.MatchString(generated) {
+		t.Errorf("echoed %q", generated)
+	}
+
+	entries := c.Entries()
+	if len(entries) != 2 || entries[0][RequestIDField] != "req-7" || entries[1][RequestIDField] != generated {
+		t.Errorf("entries %v", entries)
+	}
+}
+
+func TestContextWithRequestID(t *testing.T) {
+	ctx := ContextWithRequestID(context.Background(), "r1")
+	if id, ok := RequestIDFromContext(ctx); !ok || id != "r1" {
+		t.Errorf("RequestIDFromContext = %q, %v", id, ok)
+	}
+	for _, ctx := range []context.Context{context.Background(), ContextWithRequestID(context.Background(), "")} {
+		if id, ok := RequestIDFromContext(ctx); ok {
+			t.Errorf("RequestIDFromContext = %q on a context without an ID", id)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"io"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// RingBufferSink keeps the most recent entries in memory, so the debug
+// context leading up to a failure can be written out after the fact
+//
+//	recent := logger.NewRingBufferSink(500)
+//	recent.DumpTo = os.Stderr
+//	logger.InitLogger(logger.Config{Sinks: []logger.SinkConfig{
+//		{Writer: os.Stderr, MinLevel: "info"},
+//		{Writer: recent, MinLevel: "debug"},
+//	}})
+//
+// The sink keeps every entry it is given, whatever its level. Added next to
+// the real outputs with a lower MinLevel, as above, it records the debug
+// entries they leave out. When DumpTo is set, an error, fatal or panic
+// entry writes the buffered entries to it, ending with the entry that
+// triggered the dump, and empties the buffer so the next dump only holds
+// what came after. The zero value keeps the last
+// defaultRingBufferCapacity entries.
+type RingBufferSink struct {
+	DumpTo io.Writer // Receives the buffered entries on error, no automatic dump if nil
+
+	mu      sync.Mutex
+	entries [][]byte // oldest at next once full
+	next    int
+	full    bool
+}
+
+// defaultRingBufferCapacity is the capacity of a zero RingBufferSink
+const defaultRingBufferCapacity = 1000
+
+// NewRingBufferSink returns a RingBufferSink keeping the last capacity
+// entries, at least one
+func NewRingBufferSink(capacity int) *RingBufferSink {
+	return &RingBufferSink{entries: make([][]byte, max(capacity, 1))}
+}
+
+// Write implements io.Writer for entries without a known level
+func (r *RingBufferSink) Write(p []byte) (int, error) {
+	return r.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (r *RingBufferSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.entries == nil {
+		r.entries = make([][]byte, defaultRingBufferCapacity)
+	}
+	r.entries[r.next] = append(r.entries[r.next][:0], p...)
+	r.next++
+	if r.next == len(r.entries) {
+		r.next, r.full = 0, true
+	}
+	if lvl = baseLevel(lvl); r.DumpTo != nil && lvl >= zerolog.ErrorLevel && lvl < zerolog.NoLevel {
+		if err := r.dump(r.DumpTo); err != nil {
+			return 0, err
+		}
+		r.next, r.full = 0, false
+	}
+	return len(p), nil
+}
+
+// DumpRecent writes the buffered entries to w, oldest first, and keeps
+// them buffered
+func (r *RingBufferSink) DumpRecent(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dump(w)
+}
+
+// Len returns the number of buffered entries
+func (r *RingBufferSink) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.full {
+		return len(r.entries)
+	}
+	return r.next
+}
+
+// dump writes the buffered entries to w, oldest first. Callers must hold
+// r.mu.
+func (r *RingBufferSink) dump(w io.Writer) error {
+	var order [][]byte
+	if r.full {
+		order = append(order, r.entries[r.next:]...)
+	}
+	order = append(order, r.entries[:r.next]...)
+	for _, entry := range order {
+		if _, err := w.Write(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestRingBufferSinkKeepsLatest(t *testing.T) {
+	r := NewRingBufferSink(3)
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(r, "%d\n", i)
+	}
+	var buf bytes.Buffer
+	if err := r.DumpRecent(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "3\n4\n5\n" || r.Len() != 3 {
+		t.Errorf("dumped %q with %d buffered, want the last 3", got, r.Len())
+	}
+}
+
+func TestRingBufferSinkDumpsOnError(t *testing.T) {
+	var dumped bytes.Buffer
+	r := NewRingBufferSink(10)
+	r.DumpTo = &dumped
+	r.WriteLevel(zerolog.DebugLevel, []byte("debug\n"))
+	r.WriteLevel(zerolog.WarnLevel, []byte("warn\n"))
+	if dumped.Len() != 0 {
+		t.Fatalf("dumped %q before an error", dumped.String())
+	}
+	r.WriteLevel(zerolog.ErrorLevel, []byte("error\n"))
+	if got := dumped.String(); got != "debug\nwarn\nerror\n" || r.Len() != 0 {
+		t.Errorf("dumped %q with %d left buffered, want everything once", got, r.Len())
+	}
+}
+
+func TestRingBufferSinkZeroValue(t *testing.T) {
+	var r RingBufferSink
+	for i := 0; i < defaultRingBufferCapacity+1; i++ {
+		r.Write([]byte("entry\n"))
+	}
+	if r.Len() != defaultRingBufferCapacity {
+		t.Errorf("%d buffered, want %d", r.Len(), defaultRingBufferCapacity)
+	}
+	var empty RingBufferSink
+	if err := empty.DumpRecent(&bytes.Buffer{}); err != nil || empty.Len() != 0 {
+		t.Errorf("empty zero value: %v, %d buffered", err, empty.Len())
+	}
+}
@@ -0,0 +1,368 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// S3Sink archives entries to S3 or S3-compatible storage as gzipped NDJSON
+// segments
+//
+//	sink := &logger.S3Sink{
+//		Region:          "eu-west-1",
+//		Bucket:          "acme-logs",
+//		Prefix:          "api/%Y/%m/%d/",
+//		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+//		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+//		SpoolDir:        "/var/spool/api-logs",
+//	}
+//	defer sink.Close()
+//
+// Entries are compressed into the current segment as they are written. A
+// segment is uploaded once it holds MaxSegmentMB of entries or is
+// MaxSegmentAge old, whichever comes first, from a background goroutine.
+// Objects are named Prefix plus the segment's start time, with Prefix
+// expanded like a FileSink template. A segment that cannot be uploaded is
+// kept in SpoolDir and uploaded after the next successful upload, so an
+// outage of the store costs disk space rather than entries. Requests are
+// signed with AWS Signature Version 4; set Endpoint and PathStyle for
+// other S3-compatible stores such as MinIO.
+type S3Sink struct {
+	Region          string        // Bucket region, us-east-1 if empty
+	Bucket          string        // Bucket name, required
+	Prefix          string        // Object key prefix, may contain %Y, %m, %d and %H
+	Endpoint        string        // Store URL, AWS S3 in Region if empty
+	PathStyle       bool          // Address the bucket in the path instead of the host name
+	AccessKeyID     string        // Access key, required
+	SecretAccessKey string        // Secret key, required
+	SessionToken    string        // Session token of temporary credentials
+	MaxSegmentMB    int           // Uncompressed size that triggers an upload, 64 if zero
+	MaxSegmentAge   time.Duration // Age that triggers an upload, 5 minutes if zero
+	SpoolDir        string        // Keeps segments that failed to upload, dropped if empty
+	Client          *http.Client  // HTTP client, http.DefaultClient if nil
+	OnError         func(error)   // Called when an upload fails, stderr if nil
+
+	mu      sync.Mutex
+	seg     *s3Segment   // being written
+	pending []*s3Segment // cut and waiting for the uploader
+	closed  bool
+	once    sync.Once
+	wake    chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// s3Segment is a gzipped run of entries being built or uploaded
+type s3Segment struct {
+	start time.Time
+	buf   bytes.Buffer
+	gz    *gzip.Writer
+	size  int // uncompressed bytes
+}
+
+// Write implements io.Writer
+func (s *S3Sink) Write(p []byte) (int, error) {
+	s.once.Do(s.start)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return 0, errSinkClosed
+	}
+	if s.seg == nil {
+		s.seg = &s3Segment{start: time.Now()}
+		s.seg.gz = gzip.NewWriter(&s.seg.buf)
+	}
+	if _, err := s.seg.gz.Write(p); err != nil {
+		return 0, err
+	}
+	s.seg.size += len(p)
+	limit := s.MaxSegmentMB
+	if limit <= 0 {
+		limit = 64
+	}
+	if s.seg.size >= limit*1024*1024 {
+		s.cut()
+	}
+	return len(p), nil
+}
+
+// Flush uploads the current segment without waiting for its thresholds
+func (s *S3Sink) Flush() error {
+	s.once.Do(s.start)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cut()
+	return nil
+}
+
+// Close uploads the current segment and waits for pending uploads
+func (s *S3Sink) Close() error {
+	s.once.Do(s.start)
+	s.mu.Lock()
+	if !s.closed {
+		s.cut()
+		s.closed = true
+		close(s.done)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+// start launches the uploader and the age check
+func (s *S3Sink) start() {
+	s.wake = make(chan struct{}, 1)
+	s.done = make(chan struct{})
+	s.wg.Add(1)
+	go s.run()
+}
+
+// cut hands the current segment to the uploader. Callers must hold s.mu.
+func (s *S3Sink) cut() {
+	if s.seg == nil {
+		return
+	}
+	s.seg.gz.Close()
+	s.pending = append(s.pending, s.seg)
+	s.seg = nil
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run uploads cut segments and cuts segments that got too old
+func (s *S3Sink) run() {
+	defer s.wg.Done()
+	age := s.MaxSegmentAge
+	if age <= 0 {
+		age = 5 * time.Minute
+	}
+	ticker := time.NewTicker(min(age, time.Minute) / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.wake:
+			s.uploadPending()
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.seg != nil && time.Since(s.seg.start) >= age {
+				s.cut()
+			}
+			s.mu.Unlock()
+		case <-s.done:
+			s.uploadPending()
+			return
+		}
+	}
+}
+
+// uploadPending uploads the segments cut so far
+func (s *S3Sink) uploadPending() {
+	s.mu.Lock()
+	segs := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	for _, seg := range segs {
+		s.upload(seg)
+	}
+}
+
+// upload stores one segment, spooling it on failure and retrying the
+// spool on success
+func (s *S3Sink) upload(seg *s3Segment) {
+	key := s.key(seg.start)
+	if err := s.put(key, seg.buf.Bytes()); err != nil {
+		s.report(fmt.Errorf("logger: s3: upload %s: %w", key, err))
+		s.spool(key, seg.buf.Bytes())
+		return
+	}
+	s.retrySpool()
+}
+
+// key returns the object key of a segment started at t
+func (s *S3Sink) key(t time.Time) string {
+	t = t.UTC()
+	var id [4]byte
+	rand.Read(id[:])
+	return expandTemplate(s.Prefix, t) + t.Format("20060102T150405.000000Z") + "-" + hex.EncodeToString(id[:]) + ".ndjson.gz"
+}
+
+// spool keeps a segment that failed to upload in SpoolDir
+func (s *S3Sink) spool(key string, data []byte) {
+	if s.SpoolDir == "" {
+		return
+	}
+	if err := os.MkdirAll(s.SpoolDir, 0o755); err != nil {
+		s.report(fmt.Errorf("logger: s3: spool: %w", err))
+		return
+	}
+	path := filepath.Join(s.SpoolDir, url.PathEscape(key))
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		s.report(fmt.Errorf("logger: s3: spool: %w", err))
+	}
+}
+
+// retrySpool uploads spooled segments, oldest first, until one fails
+func (s *S3Sink) retrySpool() {
+	if s.SpoolDir == "" {
+		return
+	}
+	entries, err := os.ReadDir(s.SpoolDir)
+	if err != nil {
+		return
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		key, err := url.PathUnescape(name)
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(s.SpoolDir, name)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if err := s.put(key, data); err != nil {
+			return
+		}
+		os.Remove(path)
+	}
+}
+
+// report passes an upload failure to OnError or stderr
+func (s *S3Sink) report(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
+
+// put uploads one object with a signed PUT request
+func (s *S3Sink) put(key string, data []byte) error {
+	if s.Bucket == "" || s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return fmt.Errorf("Bucket, AccessKeyID and SecretAccessKey are required")
+	}
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	path := "/" + awsURIEncode(key)
+	if s.PathStyle {
+		path = "/" + s.Bucket + path
+	} else {
+		u.Host = s.Bucket + "." + u.Host
+	}
+	u.Path, u.RawPath = "", ""
+	target := u.String() + path
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/gzip")
+	signS3(req, data, region, s.AccessKeyID, s.SecretAccessKey, s.SessionToken, time.Now())
+	return doHTTP(s.Client, req)
+}
+
+// signS3 signs req for S3 with AWS Signature Version 4
+func signS3(req *http.Request, payload []byte, region, accessKey, secretKey, token string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	sum := sha256.Sum256(payload)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if token != "" {
+		req.Header.Set("x-amz-security-token", token)
+	}
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signed,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + region + "/s3/aws4_request"
+	canonicalSum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalSum[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+", SignedHeaders="+signed+", Signature="+signature)
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsURIEncode percent-encodes an object key the way SigV4 expects,
+// leaving slashes and unreserved characters alone
+func awsURIEncode(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
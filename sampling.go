@@ -0,0 +1,187 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SampleRule thins the entries of one level so high-volume logging can
+// stay on in production. Burst entries per Period are kept, then one in
+// Every:
+//
+//	Sampling: map[string]logger.SampleRule{
+//		"debug": {Every: 100},                                // 1 in 100
+//		"info":  {Burst: 5, Period: time.Second, Every: 1000}, // 5 a second, then 1 in 1000
+//	}
+//
+// Counters are shared by the loggers derived from a configuration, so
+// rules set on a named logger in Config.Loggers apply to that component
+// alone.
+type SampleRule struct {
+	Every  uint32        // Keep one entry in Every once the burst is used up, none if zero
+	Burst  uint32        // Entries kept per Period before Every applies
+	Period time.Duration // Window of Burst, a second if zero
+}
+
+// Sampler returns the zerolog sampler implementing the rule, also for use
+// with Logger.WithSampler
+func (r SampleRule) Sampler() zerolog.Sampler {
+	var every zerolog.Sampler
+	if r.Every > 0 {
+		every = &zerolog.BasicSampler{N: r.Every}
+	}
+	if r.Burst == 0 {
+		return every
+	}
+	period := r.Period
+	if period <= 0 {
+		period = time.Second
+	}
+	return &zerolog.BurstSampler{Burst: r.Burst, Period: period, NextSampler: every}
+}
+
+// validate rejects a rule that would drop every entry
+func (r SampleRule) validate() error {
+	switch {
+	case r.Every == 0 && r.Burst == 0:
+		return errors.New("logger: sample rule needs every or burst")
+	case r.Period < 0:
+		return fmt.Errorf("logger: sample period %s is negative", r.Period)
+	}
+	return nil
+}
+
+// sampler returns a sampler applying the rules to the levels they are set
+// for, nil if there are none
+func sampler(rules map[string]SampleRule) zerolog.Sampler {
+	if len(rules) == 0 {
+		return nil
+	}
+	ls := zerolog.LevelSampler{}
+	for name, rule := range rules {
+		lvl, _ := ParseLevel(name) // checked by Validate
+		switch lvl {
+		case zerolog.TraceLevel:
+			ls.TraceSampler = rule.Sampler()
+		case zerolog.DebugLevel:
+			ls.DebugSampler = rule.Sampler()
+		case zerolog.InfoLevel:
+			ls.InfoSampler = rule.Sampler()
+		case zerolog.WarnLevel:
+			ls.WarnSampler = rule.Sampler()
+		case zerolog.ErrorLevel:
+			ls.ErrorSampler = rule.Sampler()
+		}
+	}
+	return ls
+}
+
+// validateSampling checks the level names and rules of Config.Sampling
+func validateSampling(rules map[string]SampleRule) error {
+	var errs []error
+	for name, rule := range rules {
+		lvl, err := ParseLevel(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("sampling: %w", err))
+			continue
+		}
+		if lvl < zerolog.TraceLevel || lvl > zerolog.ErrorLevel {
+			errs = append(errs, fmt.Errorf("logger: sampling: %s entries cannot be sampled", name))
+			continue
+		}
+		if err := rule.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("sampling %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// callSites counts the entries of each Sampled call site, keyed by the
+// program counter of the call
+var callSites sync.Map
+
+// sampledHere reports whether the caller of a Sampled function should log,
+// keeping the first of every n calls from the same line
+func sampledHere(n uint32) bool {
+	if n <= 1 {
+		return true
+	}
+	var pc [1]uintptr
+	runtime.Callers(3, pc[:]) // Skip Callers, this function and the Sampled one
+	c, ok := callSites.Load(pc[0])
+	if !ok {
+		c, _ = callSites.LoadOrStore(pc[0], new(atomic.Uint32))
+	}
+	return (c.(*atomic.Uint32).Add(1)-1)%n == 0
+}
+
+// InfoSampled logs an info message on the first of every n calls from the
+// same line, for hot loops that would otherwise flood the log
+func InfoSampled(n uint32, msg string, args ...interface{}) {
+	if st := std.state.Load(); st.enabled(zerolog.InfoLevel) && sampledHere(n) {
+		processArgs(addCallerInfo(st.zl.Info(), st), msg, args...)
+	}
+}
+
+// InfoSampled logs an info message on the first of every n calls from the
+// same line
+func (l *Logger) InfoSampled(n uint32, msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.InfoLevel) && sampledHere(n) {
+		processArgs(addCallerInfo(st.zl.Info(), st), msg, args...)
+	}
+}
+
+// WarnSampled logs a warning on the first of every n calls from the same
+// line
+func WarnSampled(n uint32, msg string, args ...interface{}) {
+	if st := std.state.Load(); st.enabled(zerolog.WarnLevel) && sampledHere(n) {
+		processArgs(addCallerInfo(st.zl.Warn(), st), msg, args...)
+	}
+}
+
+// WarnSampled logs a warning on the first of every n calls from the same
+// line
+func (l *Logger) WarnSampled(n uint32, msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.WarnLevel) && sampledHere(n) {
+		processArgs(addCallerInfo(st.zl.Warn(), st), msg, args...)
+	}
+}
+
+// ErrorSampled logs an error message on the first of every n calls from
+// the same line, for a failure retried in a tight loop
+func ErrorSampled(n uint32, err error, msg string, args ...interface{}) {
+	if st := std.state.Load(); st.enabled(zerolog.ErrorLevel) && sampledHere(n) {
+		processArgs(addCallerInfo(st.zl.Error().Err(err), st), msg, args...)
+	}
+}
+
+// ErrorSampled logs an error message on the first of every n calls from
+// the same line
+func (l *Logger) ErrorSampled(n uint32, err error, msg string, args ...interface{}) {
+	if st := l.state.Load(); st.enabled(zerolog.ErrorLevel) && sampledHere(n) {
+		processArgs(addCallerInfo(st.zl.Error().Err(err), st), msg, args...)
+	}
+}
+
+// WithSampler returns a child logger that keeps only the entries s lets
+// through, such as a zerolog.BurstSampler or a SampleRule's, so one hot
+// path can thin its own entries without changing the configuration. It
+// replaces the sampling of Config.Sampling for the child.
+//
+//	hot := logger.Get("ingest").WithSampler(&zerolog.BasicSampler{N: 100})
+func (l *Logger) WithSampler(s zerolog.Sampler) *Logger {
+	st := l.state.Load()
+	return newInstance(st.derive(st.zl.Sample(s)))
+}
+
+// WithSampler returns a logger writing through the global logger that
+// keeps only the entries s lets through
+func WithSampler(s zerolog.Sampler) *Logger {
+	return std.WithSampler(s)
+}
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestConfigSampling(t *testing.T) {
+	var buf bytes.Buffer
+	l, err := New(Config{
+		Output: &buf,
+		Sampling: map[string]SampleRule{
+			"info":  {Every: 3},
+			"warn":  {Burst: 2, Period: time.Hour},
+			"error": {Burst: 1, Period: time.Hour, Every: 2},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 9; i++ {
+		l.Info("info")
+		l.Warn("warn")
+		l.Error(nil, "error")
+	}
+	counts := map[string]int{}
+	for _, msg := range messages(t, &buf) {
+		counts[msg]++
+	}
+	// info: 1 in 3; warn: the burst of 2; error: the burst of 1, then 1 in
+	// 2 of the other 8
+	if counts["info"] != 3 || counts["warn"] != 2 || counts["error"] != 5 {
+		t.Errorf("kept %v", counts)
+	}
+}
+
+func TestSampleRuleSampler(t *testing.T) {
+	if s := (SampleRule{Every: 10}).Sampler(); s.(*zerolog.BasicSampler).N != 10 {
+		t.Errorf("Every alone gave %#v", s)
+	}
+	s, ok := (SampleRule{Burst: 5}).Sampler().(*zerolog.BurstSampler)
+	if !ok || s.Burst != 5 || s.Period != time.Second || s.NextSampler != nil {
+		t.Errorf("Burst alone gave %#v, want a burst per second and nothing after", s)
+	}
+}
+
+func TestValidateSampling(t *testing.T) {
+	for want, rules := range map[string]map[string]SampleRule{
+		`unknown level "loud"`:          {"loud": {Every: 2}},
+		"fatal entries cannot":          {"fatal": {Every: 2}},
+		"needs every or burst":          {"info": {}},
+		"sample period -1s is negative": {"debug": {Burst: 1, Period: -time.Second}},
+	} {
+		if err := validateSampling(rules); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("validateSampling(%v) = %v, want %q", rules, err, want)
+		}
+	}
+	if _, err := New(Config{Sampling: map[string]SampleRule{"info": {}}}); err == nil {
+		t.Error("New accepted an empty sample rule")
+	}
+}
+
+func TestSampled(t *testing.T) {
+	callSites.Clear() // counts outlive a run with -count
+	var buf bytes.Buffer
+	l, _ := New(Config{Output: &buf})
+	for i := 0; i < 7; i++ {
+		l.InfoSampled(3, "a")
+		l.WarnSampled(2, "b")
+		l.ErrorSampled(0, nil, "c")
+	}
+	// Each call site counts on its own, keeping the first of every n
+	for i := 0; i < 2; i++ {
+		l.InfoSampled(3, "d")
+	}
+	counts := map[string]int{}
+	for _, msg := range messages(t, &buf) {
+		counts[msg]++
+	}
+	if counts["a"] != 3 || counts["b"] != 4 || counts["c"] != 7 || counts["d"] != 1 {
+		t.Errorf("kept %v", counts)
+	}
+}
+
+func TestGlobalSampled(t *testing.T) {
+	callSites.Clear()
+	var c Capture
+	useGlobal(t, Config{Output: &c})
+	for i := 0; i < 4; i++ {
+		InfoSampled(4, "info")
+		WarnSampled(2, "warn")
+		ErrorSampled(4, nil, "error")
+	}
+	if got := strings.Join(captured(&c), ","); got != "info,warn,error,warn" {
+		t.Errorf("captured %q", got)
+	}
+}
+
+func TestWithSampler(t *testing.T) {
+	var buf bytes.Buffer
+	l, _ := New(Config{Output: &buf})
+	hot := l.WithSampler(&zerolog.BasicSampler{N: 2})
+	for i := 0; i < 4; i++ {
+		hot.Info("hot")
+		l.Info("cold")
+	}
+	if got := strings.Join(messages(t, &buf), ","); got != "hot,cold,cold,hot,cold,cold" {
+		t.Errorf("got %q, want every other hot entry", got)
+	}
+
+	var c Capture
+	useGlobal(t, Config{Output: &c})
+	global := WithSampler(SampleRule{Burst: 1, Period: time.Hour}.Sampler())
+	global.Info("one")
+	global.Info("two")
+	if got := strings.Join(captured(&c), ","); got != "one" {
+		t.Errorf("captured %q", got)
+	}
+}
@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/rs/zerolog"
+)
+
+// Names of the fields Config.Schema and Config.SchemaVersion are stamped as
+const (
+	SchemaField        = "schema"
+	SchemaVersionField = "schema_version"
+)
+
+// JSONSchema returns a JSON Schema (draft 2020-12) for the JSON entries a
+// logger built from cfg writes, to publish next to the logs so consumers
+// can validate them or generate parsers
+//
+//	cfg := logger.Config{Schema: "billing", SchemaVersion: "3", WithCaller: true}
+//	os.WriteFile("billing-log.schema.json", cfg.JSONSchema(), 0o644)
+//
+// The standard fields are a stable contract: whatever else changes, an
+// entry holds these fields with these types under the names FieldNames
+// gives them, and at most adds new ones.
+//
+//	time            string in TimeFormat, or an integer for the Unix formats; unless NoTimestamp
+//	level           one of the level names, including custom levels; absent for entries without a level
+//	message         string, absent when empty
+//	error           string
+//	stack           the error's stack, in the form of zerolog.ErrorStackMarshaler
+//	caller          string "file:line", when WithCaller is set
+//	component       string, on loggers returned by GetLogger
+//	schema          the string Schema, when set
+//	schema_version  the string SchemaVersion, when set
+//
+// Static Fields are listed with the type of their value. Fields added at
+// call sites are allowed but not described. Entry field names are
+// process-wide, so the schema uses the current ones.
+func (cfg Config) JSONSchema() []byte {
+	props := map[string]interface{}{
+		zerolog.LevelFieldName:      map[string]interface{}{"type": "string", "enum": schemaLevels()},
+		zerolog.MessageFieldName:    map[string]interface{}{"type": "string"},
+		zerolog.ErrorFieldName:      map[string]interface{}{"type": "string"},
+		zerolog.ErrorStackFieldName: map[string]interface{}{"description": "Stack of the error, as rendered by zerolog.ErrorStackMarshaler"},
+		"component":                 map[string]interface{}{"type": "string", "description": "Component of loggers returned by GetLogger"},
+	}
+	var required []string
+	if !cfg.NoTimestamp {
+		props[zerolog.TimestampFieldName] = schemaTime(cfg.TimeFormat)
+		required = append(required, zerolog.TimestampFieldName)
+	}
+	if cfg.WithCaller {
+		props[zerolog.CallerFieldName] = map[string]interface{}{"type": "string", "description": "Source file and line, file:line"}
+	}
+	for k, v := range cfg.Fields {
+		props[k] = map[string]interface{}{"type": schemaType(v)}
+		required = append(required, k)
+	}
+	if cfg.Schema != "" || cfg.SchemaVersion != "" {
+		props[SchemaField] = map[string]interface{}{"const": cfg.Schema}
+		props[SchemaVersionField] = map[string]interface{}{"const": cfg.SchemaVersion}
+		required = append(required, SchemaField, SchemaVersionField)
+	}
+	sort.Strings(required)
+
+	schema := map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                "Log entry",
+		"type":                 "object",
+		"properties":           props,
+		"required":             required,
+		"additionalProperties": true,
+	}
+	if cfg.Schema != "" {
+		schema["title"] = cfg.Schema + " log entry"
+	}
+	if cfg.SchemaVersion != "" {
+		schema["description"] = "Version " + cfg.SchemaVersion
+	}
+	data, _ := json.MarshalIndent(schema, "", "  ") // plain maps and strings
+	return append(data, '\n')
+}
+
+// schemaLevels lists the level names entries can carry
+func schemaLevels() []string {
+	var names []string
+	for _, name := range LevelNames() {
+		if name != "disabled" {
+			names = append(names, name)
+		}
+	}
+	var custom []string
+	for _, c := range customLevelMap() {
+		custom = append(custom, c.name)
+	}
+	sort.Strings(custom)
+	return append(names, custom...)
+}
+
+// schemaTime describes the time field written in format
+func schemaTime(format string) map[string]interface{} {
+	// An empty format is the default rather than zerolog's TimeFormatUnix
+	if format == "" {
+		format = defaultConfig.TimeFormat
+	}
+	switch format {
+	case zerolog.TimeFormatUnixMs, zerolog.TimeFormatUnixMicro, zerolog.TimeFormatUnixNano:
+		return map[string]interface{}{"type": "integer", "description": "Unix time, " + format}
+	}
+	return map[string]interface{}{"type": "string", "description": "Time in the Go layout " + format}
+}
+
+// schemaType returns the JSON Schema type of a static field's value
+func schemaType(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil || len(data) == 0 {
+		return "string"
+	}
+	switch data[0] {
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	}
+	var n json.Number
+	if json.Unmarshal(data, &n) == nil {
+		if _, err := n.Int64(); err == nil {
+			return "integer"
+		}
+	}
+	return "number"
+}
@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestSchemaStamp(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := NewConfig(WithOutput(&buf), WithSchema("billing", "3"), WithDefaultFields(map[string]interface{}{"env": "prod"}))
+	cfg.NoTimestamp = true
+	l, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("charged")
+	if got, want := buf.String(), `{"level":"info","schema":"billing","schema_version":"3","env":"prod","message":"charged"}`+"\n"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	cfg := Config{
+		Schema:        "billing",
+		SchemaVersion: "3",
+		WithCaller:    true,
+		TimeFormat:    zerolog.TimeFormatUnixMs,
+		Fields:        map[string]interface{}{"env": "prod", "shard": 2},
+	}
+	var schema struct {
+		Title       string
+		Description string
+		Required    []string
+		Properties  map[string]map[string]interface{}
+	}
+	if err := json.Unmarshal(cfg.JSONSchema(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Title != "billing log entry" || schema.Description != "Version 3" {
+		t.Errorf("title %q, description %q", schema.Title, schema.Description)
+	}
+	if want := []string{"env", "schema", "schema_version", "shard", "time"}; !reflect.DeepEqual(schema.Required, want) {
+		t.Errorf("required %v, want %v", schema.Required, want)
+	}
+	props := schema.Properties
+	for name, want := range map[string]interface{}{
+		"time":           "integer",
+		"caller":         "string",
+		"env":            "string",
+		"shard":          "integer",
+		"schema":         nil,
+		"schema_version": nil,
+	} {
+		if p, ok := props[name]; !ok || p["type"] != want {
+			t.Errorf("property %s = %v, want type %v", name, p, want)
+		}
+	}
+	if props["schema"]["const"] != "billing" || props["schema_version"]["const"] != "3" {
+		t.Errorf("schema stamps %v, %v", props["schema"], props["schema_version"])
+	}
+
+	levels, _ := props["level"]["enum"].([]interface{})
+	seen := map[interface{}]int{}
+	for _, name := range levels {
+		seen[name]++
+	}
+	for _, name := range []string{"trace", "debug", "info", "warn", "error", "fatal", "panic", "test-audit", "test-security"} {
+		if seen[name] != 1 {
+			t.Errorf("level %s listed %d times in %v", name, seen[name], levels)
+		}
+	}
+	if seen["disabled"] != 0 {
+		t.Errorf("levels %v include disabled", levels)
+	}
+
+	// Without a timestamp or stamps the time and schema fields are left out
+	var bare struct {
+		Title      string
+		Required   []string
+		Properties map[string]interface{}
+	}
+	json.Unmarshal(Config{NoTimestamp: true}.JSONSchema(), &bare)
+	if bare.Title != "Log entry" || len(bare.Required) != 0 {
+		t.Errorf("bare schema title %q, required %v", bare.Title, bare.Required)
+	}
+	for _, name := range []string{"time", "caller", "schema", "schema_version"} {
+		if _, ok := bare.Properties[name]; ok {
+			t.Errorf("bare schema has %s", name)
+		}
+	}
+}
+
+func TestSchemaTime(t *testing.T) {
+	for format, want := range map[string]string{
+		"":                          "string",
+		"2006-01-02":                "string",
+		zerolog.TimeFormatUnixMs:    "integer",
+		zerolog.TimeFormatUnixMicro: "integer",
+		zerolog.TimeFormatUnixNano:  "integer",
+	} {
+		if got := schemaTime(format)["type"]; got != want {
+			t.Errorf("schemaTime(%q) type %v, want %s", format, got, want)
+		}
+	}
+}
+
+func TestSchemaType(t *testing.T) {
+	for _, tt := range []struct {
+		v    interface{}
+		want string
+	}{
+		{"a", "string"},
+		{true, "boolean"},
+		{nil, "null"},
+		{map[string]int{}, "object"},
+		{[]int{1}, "array"},
+		{42, "integer"},
+		{1.5, "number"},
+		{func() {}, "string"},
+	} {
+		if got := schemaType(tt.v); got != tt.want {
+			t.Errorf("schemaType(%#v) = %s, want %s", tt.v, got, tt.want)
+		}
+	}
+}
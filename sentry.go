@@ -0,0 +1,252 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SentrySink forwards error, fatal and panic entries to Sentry as events
+//
+//	sink := &logger.SentrySink{DSN: os.Getenv("SENTRY_DSN"), Environment: "prod", TagFields: []string{"component"}}
+//	defer sink.Close()
+//	logger.InitLogger(logger.Config{Sinks: []logger.SinkConfig{
+//		{Writer: os.Stdout},
+//		{Writer: sink, MinLevel: "error"},
+//	}})
+//
+// Entries below MinLevel are ignored, so the sink can also sit behind a
+// plain io.MultiWriter. The error becomes the event's exception, with an
+// error stack (as produced by zerolog's pkgerrors marshaler) as its
+// stacktrace. Fields named in TagFields become tags and the others extra
+// data. Error events are sampled with SampleRate and sent in the
+// background. Fatal and panic entries are always sent, synchronously and
+// after everything queued, so they reach Sentry before the process exits.
+type SentrySink struct {
+	DSN          string        // Project DSN, https://key@host/project, required
+	Environment  string        // environment of every event
+	Release      string        // release of every event
+	ServerName   string        // server_name, the host's name if empty
+	MinLevel     zerolog.Level // Least severe level sent, error if zero
+	SampleRate   float64       // Fraction of error events sent, all if zero
+	TagFields    []string      // Entry fields sent as tags rather than extra data
+	Client       *http.Client  // HTTP client, http.DefaultClient if nil
+	FlushTimeout time.Duration // Bound on sending a fatal or panic event, 2s if zero
+	QueueWait    time.Duration // Longest a log call waits for room in a full queue, none if zero
+	OnError      func(error)   // Called when events are lost, stderr if nil
+
+	setup sync.Once
+	batch entryBatcher
+}
+
+// Write implements io.Writer for entries without a known level
+func (s *SentrySink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *SentrySink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	base := baseLevel(lvl)
+	threshold := s.MinLevel
+	if threshold == zerolog.DebugLevel { // the zero value
+		threshold = zerolog.ErrorLevel
+	}
+	if base < threshold || base >= zerolog.NoLevel {
+		return len(p), nil
+	}
+
+	if base >= zerolog.FatalLevel {
+		// The process is about to exit: send what is queued, then this
+		s.batcher().flush()
+		timeout := s.FlushTimeout
+		if timeout <= 0 {
+			timeout = 2 * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		e := batchEntry{level: lvl, time: time.Now(), data: bytes.TrimRight(p, "\n")}
+		if err := s.post(ctx, e); err != nil {
+			return 0, fmt.Errorf("logger: sentry: %w", err)
+		}
+		return len(p), nil
+	}
+
+	if s.SampleRate > 0 && s.SampleRate < 1 && rand.Float64() >= s.SampleRate {
+		return len(p), nil
+	}
+	if err := s.batcher().add(lvl, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush sends the queued events and waits until they are delivered
+func (s *SentrySink) Flush() error {
+	s.batcher().flush()
+	return nil
+}
+
+// Close sends the queued events and stops the background goroutine
+func (s *SentrySink) Close() error {
+	s.batcher().close()
+	return nil
+}
+
+// batcher returns the sink's batcher, configured from its fields on
+// first use
+func (s *SentrySink) batcher() *entryBatcher {
+	s.setup.Do(func() {
+		s.batch = entryBatcher{send: s.send, size: 20, interval: time.Second, wait: s.QueueWait, onError: s.OnError}
+	})
+	return &s.batch
+}
+
+// send posts the events of a batch one by one, as Sentry takes one event
+// per envelope. Events already sent are not sent again on retry.
+func (s *SentrySink) send(batch []batchEntry) error {
+	for len(batch) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := s.post(ctx, batch[0])
+		cancel()
+		if err != nil {
+			return fmt.Errorf("logger: sentry: %w", err)
+		}
+		batch = batch[1:]
+	}
+	return nil
+}
+
+// post sends one entry as an event envelope
+func (s *SentrySink) post(ctx context.Context, e batchEntry) error {
+	dsn, err := url.Parse(s.DSN)
+	if err != nil || dsn.User == nil || dsn.Host == "" {
+		return permanentError{fmt.Errorf("invalid DSN %q", s.DSN)}
+	}
+	project := strings.TrimPrefix(dsn.Path, "/")
+	prefix := ""
+	if i := strings.LastIndexByte(project, '/'); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+	endpoint := dsn.Scheme + "://" + dsn.Host + prefix + "/api/" + project + "/envelope/"
+
+	event := s.event(e)
+	header, _ := json.Marshal(map[string]string{"event_id": event["event_id"].(string), "sent_at": time.Now().UTC().Format(time.RFC3339Nano)})
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return permanentError{err}
+	}
+	var body bytes.Buffer
+	body.Write(header)
+	body.WriteString("\n{\"type\":\"event\"}\n")
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", "Sentry sentry_version=7, sentry_client=minya-logger/1.0, sentry_key="+dsn.User.Username())
+	return doHTTP(s.Client, req)
+}
+
+// event maps an entry to a Sentry event
+func (s *SentrySink) event(e batchEntry) map[string]interface{} {
+	msg, fields := decodeEntry(e.data)
+	var id [16]byte
+	crand.Read(id[:])
+
+	level := "error"
+	switch baseLevel(e.level) {
+	case zerolog.FatalLevel, zerolog.PanicLevel:
+		level = "fatal"
+	case zerolog.WarnLevel:
+		level = "warning"
+	case zerolog.InfoLevel:
+		level = "info"
+	case zerolog.DebugLevel, zerolog.TraceLevel:
+		level = "debug"
+	}
+	server := s.ServerName
+	if server == "" {
+		server, _ = os.Hostname()
+	}
+	event := map[string]interface{}{
+		"event_id":    hex.EncodeToString(id[:]),
+		"timestamp":   e.time.UTC().Format(time.RFC3339Nano),
+		"level":       level,
+		"platform":    "go",
+		"logger":      "minya/logger",
+		"server_name": server,
+		"message":     map[string]string{"formatted": msg},
+	}
+	if s.Environment != "" {
+		event["environment"] = s.Environment
+	}
+	if s.Release != "" {
+		event["release"] = s.Release
+	}
+
+	if errMsg, ok := fields[zerolog.ErrorFieldName].(string); ok {
+		exception := map[string]interface{}{"type": "error", "value": errMsg}
+		if frames := sentryFrames(fields[zerolog.ErrorStackFieldName]); frames != nil {
+			exception["stacktrace"] = map[string]interface{}{"frames": frames}
+			delete(fields, zerolog.ErrorStackFieldName)
+		}
+		event["exception"] = map[string]interface{}{"values": []interface{}{exception}}
+		delete(fields, zerolog.ErrorFieldName)
+	}
+	for _, name := range []string{zerolog.MessageFieldName, zerolog.LevelFieldName, zerolog.TimestampFieldName} {
+		delete(fields, name)
+	}
+
+	tags := map[string]string{}
+	for _, name := range s.TagFields {
+		if v, ok := fields[name]; ok {
+			tags[name] = fieldString(v)
+			delete(fields, name)
+		}
+	}
+	if len(tags) > 0 {
+		event["tags"] = tags
+	}
+	if len(fields) > 0 {
+		event["extra"] = fields
+	}
+	return event
+}
+
+// sentryFrames turns a pkgerrors-style stack, a list of objects with func,
+// source and line, into Sentry frames, oldest call first
+func sentryFrames(stack interface{}) []map[string]interface{} {
+	list, ok := stack.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil
+	}
+	frames := make([]map[string]interface{}, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		f, ok := list[i].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		frame := map[string]interface{}{"function": f["func"], "filename": f["source"]}
+		if n, err := strconv.Atoi(fieldString(f["line"])); err == nil {
+			frame["lineno"] = n
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
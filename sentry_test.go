@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// sentryServer records the events posted to it
+type sentryServer struct {
+	mu     sync.Mutex
+	events []map[string]interface{}
+}
+
+func (s *sentryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sc := bufio.NewScanner(r.Body)
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if len(lines) != 3 || lines[1] != `{"type":"event"}` {
+		http.Error(w, "bad envelope", http.StatusBadRequest)
+		return
+	}
+	var event map[string]interface{}
+	json.Unmarshal([]byte(lines[2]), &event)
+	event["path"] = r.URL.Path
+	event["auth"] = r.Header.Get("X-Sentry-Auth")
+	s.mu.Lock()
+	s.events = append(s.events, event)
+	s.mu.Unlock()
+}
+
+func (s *sentryServer) received() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.events...)
+}
+
+func TestSentrySink(t *testing.T) {
+	var events sentryServer
+	srv := httptest.NewServer(&events)
+	defer srv.Close()
+
+	s := &SentrySink{
+		DSN:         strings.Replace(srv.URL, "://", "://public@", 1) + "/sentry/42",
+		Environment: "prod",
+		ServerName:  "web-1",
+		TagFields:   []string{"component"},
+	}
+	s.WriteLevel(zerolog.InfoLevel, []byte(`{"level":"info","message":"ignored"}`+"\n"))
+	s.WriteLevel(zerolog.ErrorLevel, []byte(`{"level":"error","component":"billing","order":7,"error":"card declined","stack":[{"func":"charge","source":"billing.go","line":"42"},{"func":"main","source":"main.go","line":"9"}],"message":"charge failed"}`+"\n"))
+	s.Close()
+
+	got := events.received()
+	if len(got) != 1 {
+		t.Fatalf("%d events, want 1", len(got))
+	}
+	e := got[0]
+	if e["path"] != "/sentry/api/42/envelope/" || !strings.Contains(e["auth"].(string), "sentry_key=public") {
+		t.Errorf("posted to %v with %v", e["path"], e["auth"])
+	}
+	for _, k := range []string{"path", "auth", "event_id", "timestamp"} {
+		delete(e, k)
+	}
+	b, _ := json.Marshal(e)
+	want := `{"environment":"prod","exception":{"values":[{"stacktrace":{"frames":[{"filename":"main.go","function":"main","lineno":9},{"filename":"billing.go","function":"charge","lineno":42}]},"type":"error","value":"card declined"}]},"extra":{"order":7},"level":"error","logger":"minya/logger","message":{"formatted":"charge failed"},"platform":"go","server_name":"web-1","tags":{"component":"billing"}}`
+	if string(b) != want {
+		t.Errorf("event\n%s\nwant\n%s", b, want)
+	}
+}
+
+func TestSentrySinkFatalIsSynchronous(t *testing.T) {
+	var events sentryServer
+	srv := httptest.NewServer(&events)
+	defer srv.Close()
+
+	s := &SentrySink{DSN: strings.Replace(srv.URL, "://", "://public@", 1) + "/1"}
+	defer s.Close()
+	s.WriteLevel(zerolog.ErrorLevel, []byte(`{"message":"queued"}`+"\n"))
+	if _, err := s.WriteLevel(zerolog.FatalLevel, []byte(`{"message":"exiting"}`+"\n")); err != nil {
+		t.Fatal(err)
+	}
+	// Both are delivered, queued events first, before the fatal write returns
+	got := events.received()
+	if len(got) != 2 || got[0]["level"] != "error" || got[1]["level"] != "fatal" {
+		t.Errorf("events %v, want the queued error then the fatal event", got)
+	}
+}
+
+func TestSentrySinkInvalidDSN(t *testing.T) {
+	s := &SentrySink{DSN: "https://sentry.example.com/1"}
+	if _, err := s.WriteLevel(zerolog.PanicLevel, []byte(`{"message":"panic"}`+"\n")); err == nil || !strings.Contains(err.Error(), "invalid DSN") {
+		t.Errorf("got %v, want an invalid DSN error", err)
+	}
+	s.Close()
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package logger
+
+import "os"
+
+// notifyReload is a no-op on platforms without SIGHUP
+func notifyReload(ch chan<- os.Signal) {}
+
+// notifyLevel is a no-op on platforms without SIGUSR1 and SIGUSR2
+func notifyLevel(ch chan<- os.Signal) {}
+
+// levelStep is never called on platforms without SIGUSR1 and SIGUSR2
+func levelStep(sig os.Signal) int { return 0 }
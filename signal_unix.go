@@ -0,0 +1,28 @@
+//go:build unix
+
+package logger
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReload relays SIGHUP to ch
+func notifyReload(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGHUP)
+}
+
+// notifyLevel relays SIGUSR1 and SIGUSR2 to ch
+func notifyLevel(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+}
+
+// levelStep returns -1 for SIGUSR1, which makes logging more verbose, and
+// +1 for SIGUSR2
+func levelStep(sig os.Signal) int {
+	if sig == syscall.SIGUSR1 {
+		return -1
+	}
+	return 1
+}
@@ -0,0 +1,67 @@
+//go:build unix
+
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestWatchLevelSignals(t *testing.T) {
+	cfg, c := TestConfig()
+	cfg.Level = "info"
+	useGlobal(t, cfg)
+	stop := WatchLevelSignals()
+	defer stop()
+
+	for i, tt := range []struct {
+		sig  syscall.Signal
+		want string
+	}{
+		{syscall.SIGUSR1, "debug"},
+		{syscall.SIGUSR2, "info"},
+		{syscall.SIGUSR2, "warn"},
+	} {
+		if err := syscall.Kill(syscall.Getpid(), tt.sig); err != nil {
+			t.Fatal(err)
+		}
+		// Wait for the change to be logged, which comes after it is made
+		for deadline := time.Now().Add(5 * time.Second); len(c.Entries()) <= i; time.Sleep(time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("after %v: no change logged", tt.sig)
+			}
+		}
+		if got := GetLevel(); got != tt.want {
+			t.Errorf("after %v: level %s, want %s", tt.sig, got, tt.want)
+		}
+	}
+}
+
+func TestFileSinkReopenOnSignal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	s := &FileSink{Path: path, ReopenOnSignal: true}
+	defer s.Close()
+	s.Write([]byte("before\n")) // opening the file starts watching SIGHUP
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("file not reopened after SIGHUP")
+		}
+	}
+	s.Write([]byte("after\n"))
+	if data, _ := os.ReadFile(path); string(data) != "after\n" {
+		t.Errorf("app.log holds %q, want after", data)
+	}
+}
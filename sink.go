@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// SinkConfig is one destination of a logger with its own threshold and
+// format, such as warnings and above as pretty text on stderr next to
+// everything at debug and above as JSON in a file
+type SinkConfig struct {
+	Writer   io.Writer // Destination, required
+	MinLevel string    // Least severe level written, everything the logger emits if empty
+	Format   string    // One of the formats of Config.Format, json if empty
+
+	// Async, if set, writes this sink's entries from a background
+	// goroutine, so a slow sink does not hold up log calls
+	Async *AsyncConfig
+
+	// Batch, if set, gathers this sink's entries into larger writes
+	Batch *BatchConfig
+}
+
+// sinkWriter passes on entries at or above min and drops the rest
+type sinkWriter struct {
+	w   io.Writer
+	min zerolog.Level
+}
+
+// Write implements io.Writer for entries without a known level, which are
+// held to the threshold by the level field of the entry
+func (s sinkWriter) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s sinkWriter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if lvl = lineLevel(lvl, p); baseLevel(lvl) < s.min {
+		return len(p), nil
+	}
+	if lw, ok := s.w.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(lvl, p)
+	}
+	return s.w.Write(p)
+}
+
+// LevelRouter is a writer that sends entries at or above Threshold to High
+// and everything else to Low, for example errors to an alerting pipe and
+// the rest to stdout. zerolog passes it the level of each entry, so lines
+// are routed without being parsed again. Entries written without a level
+// go to Low.
+type LevelRouter struct {
+	High      io.Writer
+	Low       io.Writer
+	Threshold zerolog.Level
+}
+
+// NewLevelRouter returns a LevelRouter sending error, fatal and panic
+// entries to high and the rest to low
+func NewLevelRouter(high, low io.Writer) *LevelRouter {
+	return &LevelRouter{High: high, Low: low, Threshold: zerolog.ErrorLevel}
+}
+
+// NewStdStreams returns a LevelRouter sending warn entries and above to
+// stderr and the rest to stdout, the split container runtimes and CI
+// systems use to tell problems from regular output
+func NewStdStreams() *LevelRouter {
+	return &LevelRouter{High: os.Stderr, Low: os.Stdout, Threshold: zerolog.WarnLevel}
+}
+
+// Write implements io.Writer
+func (r *LevelRouter) Write(p []byte) (int, error) {
+	return r.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter, passing the level on to High
+// and Low when they are LevelWriters themselves
+func (r *LevelRouter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	w := r.Low
+	if base := baseLevel(lvl); base >= r.Threshold && base < zerolog.NoLevel {
+		w = r.High
+	}
+	if lw, ok := w.(zerolog.LevelWriter); ok {
+		return lw.WriteLevel(lvl, p)
+	}
+	return w.Write(p)
+}
+
+// parallelWriter writes each entry to all its sinks at the same time and
+// returns once every sink is done, so the slowest sink sets the latency
+// rather than the sum of all of them
+type parallelWriter []sinkWriter
+
+// Write implements io.Writer
+func (w parallelWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (w parallelWriter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	lvl = lineLevel(lvl, p)
+	base := baseLevel(lvl)
+	var targets []sinkWriter
+	for _, s := range w {
+		if base >= s.min {
+			targets = append(targets, s)
+		}
+	}
+	if len(targets) <= 1 {
+		for _, s := range targets {
+			if _, err := s.WriteLevel(lvl, p); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+
+	// zerolog reuses p once WriteLevel returns, so wait for every writer
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, s := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = s.WriteLevel(lvl, p)
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// sinksWriter fans entries out to the configured sinks, adding the
+// AsyncWriters and BatchWriters made for them to bufs
+func sinksWriter(cfg Config, bufs *[]bufferedWriter) io.Writer {
+	sinks := make([]sinkWriter, 0, len(cfg.Sinks))
+	for _, sink := range cfg.Sinks {
+		w := formatWriter(batchWriter(sink.Writer, sink.Batch, bufs), sink.Format, cfg)
+		if sink.Async != nil {
+			a := newAsyncWriter(w, *sink.Async)
+			w = a
+			*bufs = append(*bufs, a)
+		}
+		threshold := zerolog.TraceLevel
+		if sink.MinLevel != "" {
+			threshold, _ = ParseLevel(sink.MinLevel) // checked by Validate
+		}
+		sinks = append(sinks, sinkWriter{w: w, min: threshold})
+	}
+	if cfg.ParallelSinks {
+		return parallelWriter(sinks)
+	}
+	writers := make([]io.Writer, len(sinks))
+	for i, s := range sinks {
+		writers[i] = s
+	}
+	return zerolog.MultiLevelWriter(writers...)
+}
+
+// sinksLevel is the logger level that lets every sink see the entries it
+// asks for. A sink without a threshold gets what the default level allows.
+func sinksLevel(sinks []SinkConfig) string {
+	level := zerolog.Disabled
+	for _, sink := range sinks {
+		lvl, err := levelOf(sink.MinLevel)
+		if err != nil {
+			continue
+		}
+		level = min(level, lvl)
+	}
+	return LevelString(level)
+}
+
+// validateSinks checks every sink's writer, threshold and format
+func validateSinks(sinks []SinkConfig) error {
+	var errs []error
+	for i, sink := range sinks {
+		if sink.Writer == nil {
+			errs = append(errs, fmt.Errorf("logger: sink %d: writer is required", i))
+		} else if err := validateOutput(sink.Writer); err != nil {
+			errs = append(errs, fmt.Errorf("sink %d: %w", i, err))
+		}
+		if sink.MinLevel != "" {
+			if _, err := ParseLevel(sink.MinLevel); err != nil {
+				errs = append(errs, fmt.Errorf("sink %d: %w", i, err))
+			}
+		}
+		if sink.Format != "" {
+			if _, err := parseFormat(sink.Format); err != nil {
+				errs = append(errs, fmt.Errorf("logger: sink %d: %w", i, err))
+			}
+		}
+		if err := sink.Async.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("sink %d: %w", i, err))
+		}
+		if err := sink.Batch.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("sink %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
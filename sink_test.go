@@ -0,0 +1,288 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+// levelRecorder records the level each entry was written with
+type levelRecorder struct {
+	levels []zerolog.Level
+}
+
+func (r *levelRecorder) Write(p []byte) (int, error) {
+	return r.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (r *levelRecorder) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	r.levels = append(r.levels, lvl)
+	return len(p), nil
+}
+
+// messages returns the message of every entry in buf
+func messages(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		msg, _ := decodeEntry([]byte(line))
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+func TestSinkMinLevelWithErrorOutput(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		var sink, errs bytes.Buffer
+		l, err := New(Config{
+			Level:         "debug",
+			Sinks:         []SinkConfig{{Writer: &sink, MinLevel: "warn"}},
+			ErrorOutput:   &errs,
+			ParallelSinks: parallel,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Debug("debug")
+		l.Info("info")
+		l.Warn("warn")
+		l.Error(errors.New("boom"), "error")
+
+		if got := strings.Join(messages(t, &sink), ","); got != "warn" {
+			t.Errorf("parallel %v: sink got %q, want warn", parallel, got)
+		}
+		if got := strings.Join(messages(t, &errs), ","); got != "error" {
+			t.Errorf("parallel %v: error output got %q, want error", parallel, got)
+		}
+	}
+}
+
+func TestSinkThresholds(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		var all, warn, errs bytes.Buffer
+		l, err := New(Config{
+			Sinks: []SinkConfig{
+				{Writer: &all, MinLevel: "info"},
+				{Writer: &warn, MinLevel: "warn", Format: "logfmt"},
+				{Writer: &errs, MinLevel: "error"},
+			},
+			ParallelSinks: parallel,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := l.GetLevel(); got != "info" {
+			t.Errorf("parallel %v: logger level %s, want the most verbose sink's info", parallel, got)
+		}
+		l.Info("info")
+		l.Warn("warn")
+		l.Error(errors.New("boom"), "error")
+
+		if got := strings.Join(messages(t, &all), ","); got != "info,warn,error" {
+			t.Errorf("parallel %v: info sink got %q", parallel, got)
+		}
+		if got := warn.String(); strings.Contains(got, "message=info") || !strings.Contains(got, "level=warn message=warn") || !strings.Contains(got, "level=error message=error") {
+			t.Errorf("parallel %v: warn sink got %q", parallel, got)
+		}
+		if got := strings.Join(messages(t, &errs), ","); got != "error" {
+			t.Errorf("parallel %v: error sink got %q", parallel, got)
+		}
+	}
+}
+
+func TestSinksLevel(t *testing.T) {
+	for _, tt := range []struct {
+		sinks []SinkConfig
+		want  string
+	}{
+		{[]SinkConfig{{MinLevel: "warn"}, {MinLevel: "error"}}, "warn"},
+		{[]SinkConfig{{MinLevel: "error"}, {}}, "info"},
+		{[]SinkConfig{{MinLevel: "debug"}, {MinLevel: "loud"}}, "debug"},
+	} {
+		if got := sinksLevel(tt.sinks); got != tt.want {
+			t.Errorf("sinksLevel(%+v) = %s, want %s", tt.sinks, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSinks(t *testing.T) {
+	err := validateSinks([]SinkConfig{
+		{MinLevel: "warn"},
+		{Writer: &bytes.Buffer{}, MinLevel: "loud"},
+		{Writer: &bytes.Buffer{}, Format: "yaml"},
+	})
+	if err == nil {
+		t.Fatal("no error")
+	}
+	for _, want := range []string{"sink 0: writer is required", "sink 1:", "sink 2:"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: want %q reported", err, want)
+		}
+	}
+}
+
+func TestSinkWriterWriteAppliesThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	s := sinkWriter{w: &buf, min: zerolog.WarnLevel}
+	s.Write([]byte(`{"level":"info","message":"info"}` + "\n"))
+	s.Write([]byte(`{"level":"error","message":"error"}` + "\n"))
+	s.Write([]byte(`{"message":"no level"}` + "\n"))
+	if got := strings.Join(messages(t, &buf), ","); got != "error,no level" {
+		t.Errorf("got %q, want error,no level", got)
+	}
+}
+
+func TestLevelRouterKeepsLevel(t *testing.T) {
+	var high, low levelRecorder
+	r := NewLevelRouter(&high, &low)
+	r.WriteLevel(zerolog.InfoLevel, []byte("{}\n"))
+	r.WriteLevel(zerolog.ErrorLevel, []byte("{}\n"))
+	r.WriteLevel(zerolog.FatalLevel, []byte("{}\n"))
+	r.Write([]byte("{}\n"))
+
+	if want := []zerolog.Level{zerolog.ErrorLevel, zerolog.FatalLevel}; !equalLevels(high.levels, want) {
+		t.Errorf("High got %v, want %v", high.levels, want)
+	}
+	if want := []zerolog.Level{zerolog.InfoLevel, zerolog.NoLevel}; !equalLevels(low.levels, want) {
+		t.Errorf("Low got %v, want %v", low.levels, want)
+	}
+}
+
+func equalLevels(a, b []zerolog.Level) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLevelRouterOutput(t *testing.T) {
+	var high, low bytes.Buffer
+	l, err := New(Config{Output: &LevelRouter{High: &high, Low: &low, Threshold: zerolog.WarnLevel}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("info")
+	l.Warn("warn")
+	l.Error(nil, "error")
+	if got := strings.Join(messages(t, &high), ","); got != "warn,error" {
+		t.Errorf("High got %q, want warn,error", got)
+	}
+	if got := strings.Join(messages(t, &low), ","); got != "info" {
+		t.Errorf("Low got %q, want info", got)
+	}
+
+	// Pretty output formats each side on its own
+	high.Reset()
+	low.Reset()
+	l, err = New(Config{Output: NewLevelRouter(&high, &low), Pretty: true, NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Warn("warn")
+	l.Error(nil, "error")
+	if got := low.String(); !strings.Contains(got, "WRN") || !strings.Contains(got, "warn") || strings.HasPrefix(got, "{") {
+		t.Errorf("Low got %q, want a pretty warn line", got)
+	}
+	if got := high.String(); !strings.Contains(got, "ERR") || !strings.Contains(got, "error") {
+		t.Errorf("High got %q, want a pretty error line", got)
+	}
+}
+
+// gateWriter counts writes in progress and holds each until gate closes
+type gateWriter struct {
+	busy *atomic.Int32
+	gate chan struct{}
+	err  error
+}
+
+func (w gateWriter) Write(p []byte) (int, error) {
+	w.busy.Add(1)
+	<-w.gate
+	return len(p), w.err
+}
+
+func TestParallelSinks(t *testing.T) {
+	var busy atomic.Int32
+	gate := make(chan struct{})
+	boom := errors.New("disk full")
+	w := parallelWriter{
+		{w: gateWriter{busy: &busy, gate: gate}, min: zerolog.TraceLevel},
+		{w: gateWriter{busy: &busy, gate: gate, err: boom}, min: zerolog.TraceLevel},
+		{w: gateWriter{busy: &busy, gate: gate}, min: zerolog.ErrorLevel},
+	}
+	done := make(chan error)
+	go func() {
+		_, err := w.WriteLevel(zerolog.InfoLevel, []byte(`{"level":"info"}`+"\n"))
+		done <- err
+	}()
+	// Both sinks taking info entries are written at the same time
+	for busy.Load() != 2 {
+		runtime.Gosched()
+	}
+	close(gate)
+	if err := <-done; !errors.Is(err, boom) {
+		t.Errorf("got %v, want the failing sink's error", err)
+	}
+	if busy.Load() != 2 {
+		t.Errorf("%d sinks written, want 2", busy.Load())
+	}
+}
+
+func TestSinkFormats(t *testing.T) {
+	var js, pretty, logfmt bytes.Buffer
+	l, err := New(Config{
+		Sinks: []SinkConfig{
+			{Writer: &js},
+			{Writer: &pretty, Format: "pretty"},
+			{Writer: &logfmt, Format: "logfmt"},
+		},
+		NoTimestamp: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Info("served", "status", 200)
+	if got := js.String(); got != `{"level":"info","status":200,"message":"served"}`+"\n" {
+		t.Errorf("json sink got %q", got)
+	}
+	if got := pretty.String(); !strings.Contains(got, "INF") || !strings.Contains(got, "served") || strings.HasPrefix(got, "{") {
+		t.Errorf("pretty sink got %q", got)
+	}
+	if got := logfmt.String(); got != "level=info message=served status=200\n" {
+		t.Errorf("logfmt sink got %q", got)
+	}
+}
+
+func TestStdStreams(t *testing.T) {
+	r := NewStdStreams()
+	if r.High != os.Stderr || r.Low != os.Stdout || r.Threshold != zerolog.WarnLevel {
+		t.Errorf("NewStdStreams() = %+v, want warn and above on stderr, the rest on stdout", r)
+	}
+	for _, open := range []func() (io.Writer, error){
+		func() (io.Writer, error) { return openOutput("split") },
+		func() (io.Writer, error) { return openOutputConfig(OutputConfig{Path: "SPLIT"}) },
+	} {
+		w, err := open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := describeWriter(w); got != "split" {
+			t.Errorf("opened %s, want split", got)
+		}
+	}
+}
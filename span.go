@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// Keys of the trace context added to entries logged with a context
+const (
+	TraceIDField    = "trace_id"
+	SpanIDField     = "span_id"
+	TraceFlagsField = "trace_flags"
+)
+
+// SpanContext identifies the span active on a context
+type SpanContext struct {
+	TraceID string // 32 lowercase hex digits
+	SpanID  string // 16 lowercase hex digits
+	Flags   byte   // W3C trace flags, 01 when sampled
+}
+
+// SpanContextFunc returns the span active on ctx, if there is one
+type SpanContextFunc func(ctx context.Context) (SpanContext, bool)
+
+// spanContextFunc is the function set with SetSpanContextFunc
+var spanContextFunc atomic.Pointer[SpanContextFunc]
+
+// SetSpanContextFunc makes entries logged with a context carrying an
+// active span get trace_id, span_id and trace_flags fields, so logs line
+// up with traces in Grafana, Jaeger and the otel, gcp and ecs formats.
+// The package does not depend on OpenTelemetry; set its lookup once at
+// startup:
+//
+//	logger.SetSpanContextFunc(func(ctx context.Context) (logger.SpanContext, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return logger.SpanContext{
+//			TraceID: sc.TraceID().String(),
+//			SpanID:  sc.SpanID().String(),
+//			Flags:   byte(sc.TraceFlags()),
+//		}, sc.IsValid()
+//	})
+//
+// The fields are added by InfoCtx and the other Ctx functions and to
+// zerolog events of this package's loggers that carry a context through
+// Event.Ctx. A nil f turns this off again.
+func SetSpanContextFunc(f SpanContextFunc) {
+	if f == nil {
+		spanContextFunc.Store(nil)
+		return
+	}
+	spanContextFunc.Store(&f)
+}
+
+// addSpanContext adds the trace context of the span on ctx to e
+func addSpanContext(e *zerolog.Event, ctx context.Context) {
+	f := spanContextFunc.Load()
+	if f == nil {
+		return
+	}
+	sc, ok := (*f)(ctx)
+	if !ok {
+		return
+	}
+	const hex = "0123456789abcdef"
+	e.Str(TraceIDField, sc.TraceID).
+		Str(SpanIDField, sc.SpanID).
+		Str(TraceFlagsField, string([]byte{hex[sc.Flags>>4], hex[sc.Flags&0xf]}))
+}
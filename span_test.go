@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// spanKey is the context key of the test span
+type spanKey struct{}
+
+func TestSetSpanContextFunc(t *testing.T) {
+	SetSpanContextFunc(func(ctx context.Context) (SpanContext, bool) {
+		sc, ok := ctx.Value(spanKey{}).(SpanContext)
+		return sc, ok
+	})
+	t.Cleanup(func() { SetSpanContextFunc(nil) })
+
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, NoTimestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), spanKey{}, SpanContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+		Flags:   0x01,
+	})
+	l.InfoCtx(ctx, "traced")
+	l.InfoCtx(context.Background(), "untraced")
+	zl := l.Zerolog()
+	zl.Warn().Ctx(ctx).Msg("zerolog")
+	want := `{"level":"info","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01","message":"traced"}` + "\n" +
+		`{"level":"info","message":"untraced"}` + "\n" +
+		`{"level":"warn","trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7","trace_flags":"01","message":"zerolog"}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// A nil function turns the fields off
+	SetSpanContextFunc(nil)
+	buf.Reset()
+	l.InfoCtx(ctx, "off")
+	if got := buf.String(); got != `{"level":"info","message":"off"}`+"\n" {
+		t.Errorf("got %s", got)
+	}
+}
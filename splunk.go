@@ -0,0 +1,216 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SplunkHECSink posts entries to a Splunk HTTP Event Collector, in batches
+// from a background goroutine
+//
+//	sink := &logger.SplunkHECSink{
+//		URL:        "https://splunk:8088",
+//		Token:      os.Getenv("SPLUNK_HEC_TOKEN"),
+//		Index:      "app",
+//		SourceType: "_json",
+//		Ack:        true,
+//	}
+//	defer sink.Close()
+//
+// Each entry is sent as one event with its fields as the event body. With
+// Ack, which needs indexer acknowledgment enabled on the token, the sink
+// polls the collector until Splunk confirms the batch was indexed and
+// sends it again if that does not happen within AckTimeout, so entries
+// are delivered at least once.
+type SplunkHECSink struct {
+	URL           string        // Collector base URL, such as https://splunk:8088, required
+	Token         string        // HEC token, required
+	Index         string        // Target index, the token's default if empty
+	Source        string        // source of every event
+	SourceType    string        // sourcetype of every event
+	Host          string        // host of every event, the host's name if empty
+	Ack           bool          // Wait for indexer acknowledgment of each batch
+	AckTimeout    time.Duration // How long to wait for an acknowledgment, 60s if zero
+	Client        *http.Client  // HTTP client, http.DefaultClient if nil
+	BatchSize     int           // Events per request, 100 if zero
+	FlushInterval time.Duration // Longest wait before sending, 5s if zero
+	QueueWait     time.Duration // Longest a log call waits for room in a full queue, none if zero
+	OnError       func(error)   // Called when entries are lost, stderr if nil
+
+	setup   sync.Once
+	batch   entryBatcher
+	channel string
+}
+
+// splunkAckPoll is how often acknowledgments are polled
+const splunkAckPoll = time.Second
+
+// Write implements io.Writer for entries without a known level
+func (s *SplunkHECSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *SplunkHECSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if err := s.batcher().add(lvl, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush sends the queued entries and waits until they are delivered
+func (s *SplunkHECSink) Flush() error {
+	s.batcher().flush()
+	return nil
+}
+
+// Close sends the queued entries and stops the background goroutine
+func (s *SplunkHECSink) Close() error {
+	s.batcher().close()
+	return nil
+}
+
+// batcher returns the sink's batcher, configured from its fields on
+// first use
+func (s *SplunkHECSink) batcher() *entryBatcher {
+	s.setup.Do(func() {
+		s.batch = entryBatcher{send: s.send, size: s.BatchSize, interval: s.FlushInterval, wait: s.QueueWait, onError: s.OnError}
+		s.channel = newChannelID()
+	})
+	return &s.batch
+}
+
+// send posts one batch and, with Ack, waits until it is indexed
+func (s *SplunkHECSink) send(batch []batchEntry) error {
+	if s.URL == "" || s.Token == "" {
+		return permanentError{fmt.Errorf("logger: splunk: URL and Token are required")}
+	}
+	host := s.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, e := range batch {
+		_, event := decodeEntry(e.data)
+		t := e.time
+		if parsed, ok := sinkTime(event[zerolog.TimestampFieldName]); ok {
+			t = parsed
+		}
+		if _, ok := event[zerolog.LevelFieldName]; !ok && e.level != zerolog.NoLevel {
+			event[zerolog.LevelFieldName] = LevelString(e.level)
+		}
+		wrapped := map[string]interface{}{
+			"time":  float64(t.UnixMilli()) / 1e3,
+			"host":  host,
+			"event": event,
+		}
+		for k, v := range map[string]string{"index": s.Index, "source": s.Source, "sourcetype": s.SourceType} {
+			if v != "" {
+				wrapped[k] = v
+			}
+		}
+		if err := enc.Encode(wrapped); err != nil {
+			return permanentError{err}
+		}
+	}
+
+	var resp struct {
+		Text  string `json:"text"`
+		Code  int    `json:"code"`
+		AckID *int64 `json:"ackId"`
+	}
+	if err := s.post("/services/collector/event", &body, &resp); err != nil {
+		return fmt.Errorf("logger: splunk: %w", err)
+	}
+	if !s.Ack {
+		return nil
+	}
+	if resp.AckID == nil {
+		return permanentError{fmt.Errorf("logger: splunk: no ackId in response, is indexer acknowledgment enabled for the token?")}
+	}
+	if err := s.awaitAck(*resp.AckID); err != nil {
+		return fmt.Errorf("logger: splunk: %w", err)
+	}
+	return nil
+}
+
+// awaitAck polls the collector until ack is confirmed or the timeout
+// passes
+func (s *SplunkHECSink) awaitAck(ack int64) error {
+	timeout := s.AckTimeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	deadline := time.Now().Add(timeout)
+	query, _ := json.Marshal(map[string][]int64{"acks": {ack}})
+	for {
+		var resp struct {
+			Acks map[string]bool `json:"acks"`
+		}
+		if err := s.post("/services/collector/ack", bytes.NewReader(query), &resp); err != nil {
+			return fmt.Errorf("ack %d: %w", ack, err)
+		}
+		if resp.Acks[strconv.FormatInt(ack, 10)] {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("ack %d not confirmed within %s", ack, timeout)
+		}
+		time.Sleep(splunkAckPoll)
+	}
+}
+
+// post sends body to a collector endpoint and decodes the JSON response
+func (s *SplunkHECSink) post(path string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, strings.TrimRight(s.URL, "/")+path, body)
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Splunk "+s.Token)
+	req.Header.Set("X-Splunk-Request-Channel", s.channel)
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		var msg struct {
+			Text string `json:"text"`
+		}
+		json.NewDecoder(resp.Body).Decode(&msg)
+		err := fmt.Errorf("%s: %s", resp.Status, msg.Text)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			return err
+		}
+		return permanentError{err}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// newChannelID returns a random UUID for the X-Splunk-Request-Channel
+// header
+func newChannelID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestSplunkHECSinkAck(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	var polls int
+	channels := map[string]bool{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Splunk hec-token" {
+			t.Errorf("Authorization %q", r.Header.Get("Authorization"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		channels[r.Header.Get("X-Splunk-Request-Channel")] = true
+		switch r.URL.Path {
+		case "/services/collector/event":
+			events = append(events, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+			w.Write([]byte(`{"text":"Success","code":0,"ackId":7}`))
+		case "/services/collector/ack":
+			if string(body) != `{"acks":[7]}` {
+				t.Errorf("ack query %s", body)
+			}
+			// Indexed by the second poll
+			polls++
+			w.Write([]byte(`{"acks":{"7":` + strconv.FormatBool(polls > 1) + `}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var errs []error
+	s := &SplunkHECSink{
+		URL:        srv.URL,
+		Token:      "hec-token",
+		Index:      "app",
+		SourceType: "_json",
+		Host:       "web-1",
+		Ack:        true,
+		OnError:    func(err error) { errs = append(errs, err) },
+	}
+	s.WriteLevel(zerolog.ErrorLevel, []byte(`{"time":"2026-10-15T01:02:03.5Z","message":"charge failed"}`+"\n"))
+	s.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := `{"event":{"level":"error","message":"charge failed","time":"2026-10-15T01:02:03.5Z"},"host":"web-1","index":"app","sourcetype":"_json","time":1792026123.5}`
+	if len(events) != 1 || events[0] != want {
+		t.Errorf("events %q, want %s", events, want)
+	}
+	if polls != 2 || len(errs) != 0 {
+		t.Errorf("%d polls and errors %v, want the batch acknowledged on the second poll", polls, errs)
+	}
+	if len(channels) != 1 {
+		t.Errorf("channels %v, want one for every request", channels)
+	}
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for ch := range channels {
+		if !uuid.MatchString(ch) {
+			t.Errorf("channel %q is not a random UUID", ch)
+		}
+	}
+}
+
+func TestSplunkHECSinkAckDisabled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"text":"Success","code":0}`))
+	}))
+	defer srv.Close()
+
+	var errs []error
+	s := &SplunkHECSink{URL: srv.URL, Token: "hec-token", Ack: true, OnError: func(err error) { errs = append(errs, err) }}
+	s.Write([]byte(`{"message":"lost"}` + "\n"))
+	s.Close()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "no ackId") {
+		t.Errorf("reported %v, want acknowledgment reported as disabled", errs)
+	}
+}
@@ -0,0 +1,473 @@
+package logger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// DiskSpool is a persistent queue in front of a sink, so entries logged
+// while the sink's destination is unreachable are delivered once it is
+// back, even across restarts
+//
+//	redis := &logger.RedisSink{Addr: "logs:6379"}
+//	spool, err := logger.NewDiskSpool("/var/spool/app-logs", redis, logger.WithMaxSpoolSize(512))
+//	...
+//	defer spool.Close()
+//	logger.InitLogger(logger.Config{Output: spool})
+//
+// Write appends each entry with its level and a CRC-32C checksum to a
+// segment file in the spool directory and returns. A background goroutine
+// hands the entries to the target in order, retrying with backoff while
+// the target's Write fails, and removes segment files once they are
+// delivered. Its position is kept in the directory too, so after a restart
+// delivery resumes where it stopped; entries delivered just before a crash
+// may be delivered twice. Records that fail their checksum, such as a
+// record torn by a crash, are skipped with the rest of their segment.
+// When the spool outgrows its maximum size the oldest segments are
+// dropped.
+//
+// The spool only helps in front of sinks whose Write reports failures,
+// such as RedisSink, MQTTSink or SyslogSink; sinks that queue entries in
+// memory, like the HTTP ones, accept everything until their queue is
+// full. Files are not synced, so entries survive a process crash but not
+// necessarily a machine crash. Close does not close the target.
+type DiskSpool struct {
+	dir         string
+	target      io.Writer
+	maxSize     int64
+	segmentSize int64
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	onError     func(error)
+
+	mu      sync.Mutex
+	segs    []spoolSegment // on disk, oldest first
+	w       *os.File       // segment being appended to, the last of segs
+	nextSeq uint64         // of the next segment to start
+	closed  bool
+
+	rseq    uint64    // segment being delivered, owned by run like the fields below
+	roff    int64     // offset of the next record to deliver in it
+	saved   [2]int64  // rseq and roff last written to the cursor file
+	savedAt time.Time // when the cursor file was last written
+	failing bool      // the last delivery attempt failed
+
+	wake chan struct{}
+	done chan struct{}
+	wg   sync.WaitGroup
+	once sync.Once
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	corrupt   atomic.Uint64
+}
+
+// spoolSegment is one segment file of a DiskSpool
+type spoolSegment struct {
+	seq  uint64
+	size int64 // of the complete records written so far
+}
+
+// SpoolStats is a snapshot of a DiskSpool's counters
+type SpoolStats struct {
+	Segments  int    // Segment files on disk
+	Bytes     int64  // Size of the segment files
+	Delivered uint64 // Entries handed to the target
+	Dropped   uint64 // Segments removed to stay within the maximum size
+	Corrupt   uint64 // Records skipped because their checksum did not match
+}
+
+// SpoolOption configures a DiskSpool
+type SpoolOption func(*DiskSpool)
+
+// WithMaxSpoolSize sets how many megabytes of segment files the spool may
+// keep, 256 by default
+func WithMaxSpoolSize(mb int) SpoolOption {
+	return func(s *DiskSpool) {
+		if mb > 0 {
+			s.maxSize = int64(mb) * 1024 * 1024
+		}
+	}
+}
+
+// WithSpoolBackoff sets the first and the longest wait between delivery
+// attempts while the target fails, 100ms and 30s by default
+func WithSpoolBackoff(first, longest time.Duration) SpoolOption {
+	return func(s *DiskSpool) {
+		s.minBackoff, s.maxBackoff = first, longest
+	}
+}
+
+// WithSpoolErrorHandler sets the function told about failed deliveries,
+// dropped segments and corrupt records, stderr by default
+func WithSpoolErrorHandler(f func(error)) SpoolOption {
+	return func(s *DiskSpool) {
+		s.onError = f
+	}
+}
+
+// spoolRecordHeader is the size of a record's length, checksum and level
+const spoolRecordHeader = 9
+
+// spoolTable is the CRC-32C table for record checksums
+var spoolTable = crc32.MakeTable(crc32.Castagnoli)
+
+// NewDiskSpool opens or creates the spool in dir, delivering to target,
+// and starts delivering what an earlier run left in it
+func NewDiskSpool(dir string, target io.Writer, opts ...SpoolOption) (*DiskSpool, error) {
+	if target == nil {
+		return nil, fmt.Errorf("logger: spool: target is required")
+	}
+	s := &DiskSpool{
+		dir:        dir,
+		target:     target,
+		maxSize:    256 * 1024 * 1024,
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 30 * time.Second,
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.segmentSize = min(8*1024*1024, s.maxSize/4)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("logger: spool: %w", err)
+	}
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("logger: spool: %w", err)
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s, nil
+}
+
+// Write implements io.Writer for entries without a known level
+func (s *DiskSpool) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel appends the entry to the spool
+func (s *DiskSpool) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	record := make([]byte, spoolRecordHeader, spoolRecordHeader+len(p))
+	binary.BigEndian.PutUint32(record, uint32(len(p)))
+	binary.BigEndian.PutUint32(record[4:], crc32.Checksum(p, spoolTable))
+	record[8] = byte(lvl)
+	record = append(record, p...)
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return 0, errSinkClosed
+	}
+	if s.w == nil || s.segs[len(s.segs)-1].size+int64(len(record)) > s.segmentSize {
+		if err := s.startSegment(); err != nil {
+			s.mu.Unlock()
+			return 0, fmt.Errorf("logger: spool: %w", err)
+		}
+	}
+	if _, err := s.w.Write(record); err != nil {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("logger: spool: %w", err)
+	}
+	s.segs[len(s.segs)-1].size += int64(len(record))
+	dropped := s.trim()
+	s.mu.Unlock()
+
+	// Reported outside the lock, the handler may well log
+	for _, seg := range dropped {
+		s.report(fmt.Errorf("logger: spool: over %d MB, dropped segment %d with %d bytes", s.maxSize/(1024*1024), seg.seq, seg.size))
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return len(p), nil
+}
+
+// Stats returns the spool's current counters
+func (s *DiskSpool) Stats() SpoolStats {
+	s.mu.Lock()
+	st := SpoolStats{Segments: len(s.segs)}
+	for _, seg := range s.segs {
+		st.Bytes += seg.size
+	}
+	s.mu.Unlock()
+	st.Delivered = s.delivered.Load()
+	st.Dropped = s.dropped.Load()
+	st.Corrupt = s.corrupt.Load()
+	return st
+}
+
+// Close stops accepting entries, makes one more attempt to deliver the
+// spooled ones and closes the segment file. Undelivered entries stay in
+// the directory for the next NewDiskSpool.
+func (s *DiskSpool) Close() error {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		close(s.done)
+	})
+	s.wg.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.w == nil {
+		return nil
+	}
+	err := s.w.Close()
+	s.w = nil
+	return err
+}
+
+// load finds the segments and the delivery position left in the directory
+func (s *DiskSpool) load() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".seg") {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, ".seg"), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return err
+		}
+		s.segs = append(s.segs, spoolSegment{seq: seq, size: info.Size()})
+	}
+	sort.Slice(s.segs, func(i, j int) bool { return s.segs[i].seq < s.segs[j].seq })
+
+	if data, err := os.ReadFile(filepath.Join(s.dir, "cursor")); err == nil {
+		fmt.Sscanf(string(data), "%d %d", &s.rseq, &s.roff)
+	}
+	s.saved = [2]int64{int64(s.rseq), s.roff}
+	s.nextSeq = s.rseq + 1
+	if len(s.segs) > 0 {
+		s.nextSeq = max(s.nextSeq, s.segs[len(s.segs)-1].seq+1)
+	}
+	return nil
+}
+
+// startSegment closes the current segment and starts a new one. Callers
+// must hold s.mu.
+func (s *DiskSpool) startSegment() error {
+	if s.w != nil {
+		s.w.Close()
+		s.w = nil
+	}
+	seq := s.nextSeq
+	s.nextSeq++
+	f, err := os.OpenFile(s.segmentPath(seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	s.w = f
+	s.segs = append(s.segs, spoolSegment{seq: seq})
+	return nil
+}
+
+// trim drops the oldest segments while the spool is over its maximum
+// size, keeping the one being written, and returns them. Callers must
+// hold s.mu.
+func (s *DiskSpool) trim() []spoolSegment {
+	var dropped []spoolSegment
+	var total int64
+	for _, seg := range s.segs {
+		total += seg.size
+	}
+	for total > s.maxSize && len(s.segs) > 1 {
+		oldest := s.segs[0]
+		os.Remove(s.segmentPath(oldest.seq))
+		s.segs = s.segs[1:]
+		total -= oldest.size
+		s.dropped.Add(1)
+		dropped = append(dropped, oldest)
+	}
+	return dropped
+}
+
+// run delivers spooled entries whenever there are new ones, backing off
+// while the target fails
+func (s *DiskSpool) run() {
+	defer s.wg.Done()
+	backoff := s.minBackoff
+	for {
+		wake, retry := s.wake, (<-chan time.Time)(nil)
+		if err := s.deliver(); err != nil {
+			if !s.failing {
+				// Once per outage rather than on every attempt
+				s.report(fmt.Errorf("logger: spool: deliver: %w, retrying", err))
+				s.failing = true
+			}
+			// New entries do not cut the wait short
+			wake, retry = nil, time.After(backoff)
+			backoff = min(backoff*2, s.maxBackoff)
+		} else {
+			s.failing = false
+			backoff = s.minBackoff
+		}
+		select {
+		case <-wake:
+		case <-retry:
+		case <-s.done:
+			s.deliver()
+			s.saveCursor(true)
+			return
+		}
+	}
+}
+
+// deliver hands spooled entries to the target until it is caught up or
+// the target fails
+func (s *DiskSpool) deliver() error {
+	defer s.saveCursor(false)
+	for {
+		s.mu.Lock()
+		if len(s.segs) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		// Move on to the oldest segment if the current one is gone, because
+		// it was dropped by trim or never existed
+		size, found := int64(0), false
+		for _, seg := range s.segs {
+			if seg.seq == s.rseq {
+				size, found = seg.size, true
+			}
+		}
+		if !found {
+			s.rseq, s.roff = s.segs[0].seq, 0
+			size = s.segs[0].size
+		}
+		writing := s.w != nil && s.rseq == s.segs[len(s.segs)-1].seq
+		s.mu.Unlock()
+
+		if s.roff >= size {
+			if writing {
+				return nil
+			}
+			s.removeSegment(s.rseq)
+			s.rseq, s.roff = s.rseq+1, 0
+			continue
+		}
+		if err := s.deliverSegment(size); err != nil {
+			return err
+		}
+	}
+}
+
+// deliverSegment delivers the records of segment s.rseq from s.roff up to
+// size
+func (s *DiskSpool) deliverSegment(size int64) error {
+	f, err := os.Open(s.segmentPath(s.rseq))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.roff = size
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	data := make([]byte, size-s.roff)
+	if _, err := f.ReadAt(data, s.roff); err != nil && err != io.EOF {
+		return err
+	}
+
+	lw, leveled := s.target.(zerolog.LevelWriter)
+	for len(data) > 0 {
+		n := int64(-1)
+		if len(data) >= spoolRecordHeader {
+			n = int64(binary.BigEndian.Uint32(data))
+		}
+		if n < 0 || spoolRecordHeader+n > int64(len(data)) {
+			s.corrupt.Add(1)
+			s.report(fmt.Errorf("logger: spool: truncated record in segment %d at offset %d, skipping the rest of it", s.rseq, s.roff))
+			s.roff = size
+			return nil
+		}
+		entry := data[spoolRecordHeader : spoolRecordHeader+n]
+		if crc32.Checksum(entry, spoolTable) != binary.BigEndian.Uint32(data[4:]) {
+			s.corrupt.Add(1)
+			s.report(fmt.Errorf("logger: spool: checksum mismatch in segment %d at offset %d, skipping the rest of it", s.rseq, s.roff))
+			s.roff = size
+			return nil
+		}
+		if leveled {
+			_, err = lw.WriteLevel(zerolog.Level(int8(data[8])), entry)
+		} else {
+			_, err = s.target.Write(entry)
+		}
+		if err != nil {
+			return err
+		}
+		s.delivered.Add(1)
+		s.roff += spoolRecordHeader + n
+		data = data[spoolRecordHeader+n:]
+	}
+	return nil
+}
+
+// removeSegment deletes a delivered segment
+func (s *DiskSpool) removeSegment(seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, seg := range s.segs {
+		if seg.seq == seq {
+			s.segs = append(s.segs[:i], s.segs[i+1:]...)
+			break
+		}
+	}
+	os.Remove(s.segmentPath(seq))
+}
+
+// saveCursor records the delivery position so a restart resumes there.
+// Unless forced it writes at most once a second, at the price of
+// delivering up to a second of entries again after a crash.
+func (s *DiskSpool) saveCursor(force bool) {
+	pos := [2]int64{int64(s.rseq), s.roff}
+	if pos == s.saved || !force && time.Since(s.savedAt) < time.Second {
+		return
+	}
+	path := filepath.Join(s.dir, "cursor")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(fmt.Sprintf("%d %d\n", s.rseq, s.roff)), 0o644); err != nil {
+		s.report(fmt.Errorf("logger: spool: %w", err))
+		return
+	}
+	os.Rename(tmp, path)
+	s.saved, s.savedAt = pos, time.Now()
+}
+
+// segmentPath returns the file name of segment seq
+func (s *DiskSpool) segmentPath(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d.seg", seq))
+}
+
+// report passes a failure to the error handler or stderr
+func (s *DiskSpool) report(err error) {
+	if s.onError != nil {
+		s.onError(err)
+		return
+	}
+	fmt.Fprintln(os.Stderr, err)
+}
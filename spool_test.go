@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// spoolTarget records the entries delivered to it, with their levels,
+// and fails every write while down is set
+type spoolTarget struct {
+	mu      sync.Mutex
+	down    bool
+	entries []string
+}
+
+func (t *spoolTarget) Write(p []byte) (int, error) {
+	return t.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (t *spoolTarget) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.down {
+		return 0, errors.New("connection refused")
+	}
+	t.entries = append(t.entries, lvl.String()+" "+strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+func (t *spoolTarget) received() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.entries...)
+}
+
+// spoolErrors collects what a spool reports
+type spoolErrors struct {
+	mu   sync.Mutex
+	errs []string
+}
+
+func (e *spoolErrors) handle(err error) {
+	e.mu.Lock()
+	e.errs = append(e.errs, err.Error())
+	e.mu.Unlock()
+}
+
+func (e *spoolErrors) reported() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.errs...)
+}
+
+func TestDiskSpoolDeliversAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	down := &spoolTarget{down: true}
+	var errs spoolErrors
+	s, err := NewDiskSpool(dir, down, WithSpoolBackoff(time.Millisecond, 10*time.Millisecond), WithSpoolErrorHandler(errs.handle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.WriteLevel(zerolog.InfoLevel, []byte(`{"message":"one"}`+"\n"))
+	s.WriteLevel(zerolog.ErrorLevel, []byte(`{"message":"two"}`+"\n"))
+	s.Write([]byte(`{"message":"three"}` + "\n"))
+	for len(errs.reported()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Write([]byte("late\n")); !errors.Is(err, errSinkClosed) {
+		t.Errorf("write after Close: %v", err)
+	}
+	if got := errs.reported(); len(got) != 1 || !strings.Contains(got[0], "connection refused") {
+		t.Errorf("reported %q, want the outage once", got)
+	}
+
+	up := &spoolTarget{}
+	s, err = NewDiskSpool(dir, up)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	want := []string{`info {"message":"one"}`, `error {"message":"two"}`, ` {"message":"three"}`}
+	if got := up.received(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("delivered %q, want %q", got, want)
+	}
+	if st := s.Stats(); st.Delivered != 3 || st.Segments != 0 {
+		t.Errorf("stats %+v, want 3 delivered and no segments left", st)
+	}
+
+	// Delivered entries are not delivered again
+	again := &spoolTarget{}
+	s, _ = NewDiskSpool(dir, again)
+	s.Close()
+	if got := again.received(); len(got) != 0 {
+		t.Errorf("delivered %q again", got)
+	}
+}
+
+func TestDiskSpoolSkipsCorruptRecords(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewDiskSpool(dir, &spoolTarget{down: true}, WithSpoolErrorHandler(func(error) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"kept", "torn", "lost with the rest"} {
+		s.Write([]byte(msg + "\n"))
+	}
+	s.Close()
+
+	segs, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+	if len(segs) != 1 {
+		t.Fatalf("segments %v, want 1", segs)
+	}
+	data, _ := os.ReadFile(segs[0])
+	second := spoolRecordHeader + len("kept\n")
+	data[second+spoolRecordHeader] ^= 0xff
+	os.WriteFile(segs[0], data, 0o644)
+
+	up := &spoolTarget{}
+	var errs spoolErrors
+	s, err = NewDiskSpool(dir, up, WithSpoolErrorHandler(errs.handle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if got := up.received(); len(got) != 1 || got[0] != " kept" {
+		t.Errorf("delivered %q, want only the record before the corrupt one", got)
+	}
+	if st := s.Stats(); st.Corrupt != 1 {
+		t.Errorf("stats %+v, want one corrupt record", st)
+	}
+	if got := errs.reported(); len(got) != 1 || !strings.Contains(got[0], "checksum mismatch") {
+		t.Errorf("reported %q", got)
+	}
+}
+
+func TestDiskSpoolDropsOldestSegments(t *testing.T) {
+	var errs spoolErrors
+	s, err := NewDiskSpool(t.TempDir(), &spoolTarget{down: true}, WithMaxSpoolSize(1), WithSpoolBackoff(time.Hour, time.Hour), WithSpoolErrorHandler(errs.handle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	entry := []byte(fmt.Sprintf(`{"message":%q}`+"\n", strings.Repeat("x", 1000)))
+	for i := 0; i < 2000; i++ {
+		if _, err := s.Write(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	st := s.Stats()
+	if st.Dropped == 0 || st.Bytes > 1024*1024 {
+		t.Errorf("stats %+v, want old segments dropped to stay within 1 MB", st)
+	}
+	dropped := 0
+	for _, e := range errs.reported() {
+		if strings.Contains(e, "dropped segment") {
+			dropped++
+		}
+	}
+	if dropped != int(st.Dropped) {
+		t.Errorf("%d drops reported, want %d", dropped, st.Dropped)
+	}
+}
+
+func TestNewDiskSpoolRequiresTarget(t *testing.T) {
+	if _, err := NewDiskSpool(t.TempDir(), nil); err == nil {
+		t.Error("no error without a target")
+	}
+}
@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SQLiteSink stores entries in a table of a local SQLite database, so
+// desktop and command-line tools get a queryable history
+//
+//	db, err := sql.Open("sqlite", filepath.Join(dataDir, "history.db"))
+//	...
+//	sink := &logger.SQLiteSink{DB: db, MaxRows: 100000, MaxAge: 30 * 24 * time.Hour}
+//	defer sink.Close()
+//
+// The package does not pull in a SQLite driver; open DB with the one the
+// program already uses, such as modernc.org/sqlite or
+// github.com/mattn/go-sqlite3. On first use the sink switches the database
+// to WAL mode, so reading the history does not block logging, and creates
+// the table if it does not exist:
+//
+//	CREATE TABLE logs (id INTEGER PRIMARY KEY, timestamp TEXT, level TEXT,
+//		component TEXT, message TEXT, fields TEXT)
+//
+// timestamp is UTC with millisecond precision in a form SQLite's date
+// functions understand, and fields holds the remaining fields as a JSON
+// object for json_extract. Entries are inserted from a background goroutine,
+// one transaction per batch, and after each batch rows beyond MaxRows or
+// older than MaxAge are deleted. Close does not close DB.
+type SQLiteSink struct {
+	DB            *sql.DB       // Open database, required
+	Table         string        // Table name, "logs" if empty
+	MaxRows       int           // Rows to keep, no limit if zero
+	MaxAge        time.Duration // Age of rows to keep, no limit if zero
+	BatchSize     int           // Entries per transaction, 100 if zero
+	FlushInterval time.Duration // Longest wait before inserting, 5s if zero
+	QueueWait     time.Duration // Longest a log call waits for room in a full queue, none if zero
+	OnError       func(error)   // Called when entries are lost, stderr if nil
+
+	setup    sync.Once
+	batch    entryBatcher
+	migrated bool // owned by the batcher goroutine
+}
+
+// sqliteTimeFormat sorts lexically and is understood by SQLite's date and
+// time functions
+const sqliteTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// Write implements io.Writer for entries without a known level
+func (s *SQLiteSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *SQLiteSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if err := s.batcher().add(lvl, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush inserts the queued entries and waits until they are stored
+func (s *SQLiteSink) Flush() error {
+	s.batcher().flush()
+	return nil
+}
+
+// Close inserts the queued entries and stops the background goroutine
+func (s *SQLiteSink) Close() error {
+	s.batcher().close()
+	return nil
+}
+
+// batcher returns the sink's batcher, configured from its fields on
+// first use
+func (s *SQLiteSink) batcher() *entryBatcher {
+	s.setup.Do(func() {
+		s.batch = entryBatcher{send: s.send, size: s.BatchSize, interval: s.FlushInterval, wait: s.QueueWait, onError: s.OnError}
+	})
+	return &s.batch
+}
+
+// table returns the table name, unquoted
+func (s *SQLiteSink) table() string {
+	if s.Table == "" {
+		return "logs"
+	}
+	return s.Table
+}
+
+// migrate enables WAL mode and creates the table and its index
+func (s *SQLiteSink) migrate(ctx context.Context) error {
+	if _, err := s.DB.ExecContext(ctx, "PRAGMA journal_mode=WAL"); err != nil {
+		return err
+	}
+	table := quoteIdent(s.table())
+	for _, stmt := range []string{
+		"CREATE TABLE IF NOT EXISTS " + table + " (id INTEGER PRIMARY KEY, timestamp TEXT NOT NULL, level TEXT, component TEXT, message TEXT, fields TEXT)",
+		"CREATE INDEX IF NOT EXISTS " + quoteIdent(s.table()+"_timestamp") + " ON " + table + " (timestamp)",
+	} {
+		if _, err := s.DB.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send inserts one batch and prunes the table in a single transaction
+func (s *SQLiteSink) send(batch []batchEntry) error {
+	if s.DB == nil {
+		return permanentError{fmt.Errorf("logger: sqlite: DB is required")}
+	}
+	ctx := context.Background()
+	table := quoteIdent(s.table())
+	if !s.migrated {
+		if err := s.migrate(ctx); err != nil {
+			return fmt.Errorf("logger: sqlite: migrate: %w", err)
+		}
+		s.migrated = true
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("logger: sqlite: %w", err)
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO "+table+" (timestamp, level, component, message, fields) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("logger: sqlite: %w", err)
+	}
+	defer stmt.Close()
+	for _, e := range batch {
+		msg, fields := decodeEntry(e.data)
+		t := e.time
+		if parsed, ok := sinkTime(fields[zerolog.TimestampFieldName]); ok {
+			t = parsed
+		}
+		level := sqlColumn(fields, zerolog.LevelFieldName)
+		if level == nil && e.level != zerolog.NoLevel {
+			level = LevelString(e.level)
+		}
+		component := sqlColumn(fields, "component")
+		delete(fields, zerolog.MessageFieldName)
+		delete(fields, zerolog.TimestampFieldName)
+		rest, err := json.Marshal(fields)
+		if err != nil {
+			return permanentError{fmt.Errorf("logger: sqlite: %w", err)}
+		}
+		if _, err := stmt.ExecContext(ctx, t.UTC().Format(sqliteTimeFormat), level, component, msg, string(rest)); err != nil {
+			return fmt.Errorf("logger: sqlite: insert: %w", err)
+		}
+	}
+
+	if s.MaxRows > 0 {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE id <= (SELECT MAX(id) FROM "+table+") - ?", s.MaxRows); err != nil {
+			return fmt.Errorf("logger: sqlite: prune: %w", err)
+		}
+	}
+	if s.MaxAge > 0 {
+		cutoff := time.Now().Add(-s.MaxAge).UTC().Format(sqliteTimeFormat)
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE timestamp < ?", cutoff); err != nil {
+			return fmt.Errorf("logger: sqlite: prune: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("logger: sqlite: %w", err)
+	}
+	return nil
+}
+
+// sqlColumn removes key from fields and returns it as a string, or nil
+// for NULL when it is missing
+func sqlColumn(fields map[string]interface{}, key string) interface{} {
+	v, ok := fields[key]
+	if !ok {
+		return nil
+	}
+	delete(fields, key)
+	return fieldString(v)
+}
+
+// quoteIdent quotes a SQL identifier such as a table name
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingDriver is a database/sql driver and connector that runs
+// nothing and records every statement executed, with its arguments, and
+// every commit
+type recordingDriver struct {
+	mu    sync.Mutex
+	execs []string
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return recordingConn{d}, nil }
+
+func (d *recordingDriver) Connect(context.Context) (driver.Conn, error) { return recordingConn{d}, nil }
+
+func (d *recordingDriver) Driver() driver.Driver { return d }
+
+func (d *recordingDriver) record(s string) {
+	d.mu.Lock()
+	d.execs = append(d.execs, s)
+	d.mu.Unlock()
+}
+
+func (d *recordingDriver) recorded() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.execs...)
+}
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return recordingStmt{c.d, query}, nil
+}
+func (c recordingConn) Close() error              { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) { return recordingTx{c.d}, nil }
+
+type recordingStmt struct {
+	d     *recordingDriver
+	query string
+}
+
+func (s recordingStmt) Close() error  { return nil }
+func (s recordingStmt) NumInput() int { return -1 }
+func (s recordingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	stmt := s.query
+	for _, a := range args {
+		stmt += fmt.Sprintf(" [%v]", a)
+	}
+	s.d.record(stmt)
+	return driver.RowsAffected(1), nil
+}
+func (s recordingStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+type recordingTx struct{ d *recordingDriver }
+
+func (tx recordingTx) Commit() error   { tx.d.record("COMMIT"); return nil }
+func (tx recordingTx) Rollback() error { return nil }
+
+func TestSQLiteSink(t *testing.T) {
+	rec := &recordingDriver{}
+	db := sql.OpenDB(rec)
+	defer db.Close()
+
+	s := &SQLiteSink{DB: db, Table: "app logs", MaxRows: 1000}
+	s.Write([]byte(`{"level":"info","time":"2026-10-15T01:02:03.25Z","component":"api","status":200,"message":"served"}` + "\n"))
+	s.Flush()
+	s.Write([]byte(`{"level":"warn","time":"2026-10-15T01:02:04Z","message":"slow"}` + "\n"))
+	s.Close()
+
+	insert := `INSERT INTO "app logs" (timestamp, level, component, message, fields) VALUES (?, ?, ?, ?, ?)`
+	prune := `DELETE FROM "app logs" WHERE id <= (SELECT MAX(id) FROM "app logs") - ? [1000]`
+	want := []string{
+		"PRAGMA journal_mode=WAL",
+		`CREATE TABLE IF NOT EXISTS "app logs" (id INTEGER PRIMARY KEY, timestamp TEXT NOT NULL, level TEXT, component TEXT, message TEXT, fields TEXT)`,
+		`CREATE INDEX IF NOT EXISTS "app logs_timestamp" ON "app logs" (timestamp)`,
+		insert + ` [2026-10-15T01:02:03.250Z] [info] [api] [served] [{"status":200}]`,
+		prune,
+		"COMMIT",
+		insert + ` [2026-10-15T01:02:04.000Z] [warn] [<nil>] [slow] [{}]`,
+		prune,
+		"COMMIT",
+	}
+	if got := rec.recorded(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("executed\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	for name, want := range map[string]string{
+		"logs":       `"logs"`,
+		`my "logs"`:  `"my ""logs"""`,
+		"logs; DROP": `"logs; DROP"`,
+	} {
+		if got := quoteIdent(name); got != want {
+			t.Errorf("quoteIdent(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// SyslogFormat selects the syslog message format
+type SyslogFormat int
+
+// Supported syslog formats
+const (
+	RFC5424 SyslogFormat = iota // Structured syslog, fields become structured data
+	RFC3164                     // BSD syslog, the whole JSON entry is the message
+)
+
+// SyslogFacility is the syslog facility entries are logged under
+type SyslogFacility int
+
+// Common syslog facilities
+const (
+	SyslogUser   SyslogFacility = 1
+	SyslogDaemon SyslogFacility = 3
+	SyslogAuth   SyslogFacility = 4
+	SyslogLocal0 SyslogFacility = 16
+	SyslogLocal1 SyslogFacility = 17
+	SyslogLocal2 SyslogFacility = 18
+	SyslogLocal3 SyslogFacility = 19
+	SyslogLocal4 SyslogFacility = 20
+	SyslogLocal5 SyslogFacility = 21
+	SyslogLocal6 SyslogFacility = 22
+	SyslogLocal7 SyslogFacility = 23
+)
+
+// syslogSDEnterprise is the private enterprise number of the structured
+// data IDs, the one RFC 5612 reserves for documentation
+const syslogSDEnterprise = "@32473"
+
+// syslogSDID is the structured data ID fields are sent under
+const syslogSDID = "fields" + syslogSDEnterprise
+
+// SyslogSink sends entries to a syslog daemon, locally or over the network
+//
+//	sink := &logger.SyslogSink{Network: "udp", Addr: "logs.internal:514", Facility: logger.SyslogLocal0}
+//	logger.InitLogger(logger.Config{Output: sink})
+//
+// Levels map to syslog severities: trace and debug to debug, info to
+// informational, warn to warning, error to err, fatal to crit and panic to
+// alert. In RFC 5424 format the entry's fields are sent as structured data
+// and the message as MSG. The timestamp is the entry's time. Over stream
+// connections, TCP or a unix stream socket, messages are framed by octet
+// counting for RFC 5424 and by newlines for RFC 3164. A failed write
+// reconnects once before giving up.
+type SyslogSink struct {
+	Network  string         // udp, tcp, unix or unixgram; the local syslog socket if empty
+	Addr     string         // Address or socket path, ignored for the local socket
+	Format   SyslogFormat   // RFC5424 (the default) or RFC3164
+	Facility SyslogFacility // Facility of every entry, user if zero
+	AppName  string         // APP-NAME / TAG, the program name if empty
+	Hostname string         // HOSTNAME, the host's name if empty
+
+	mu      sync.Mutex
+	conn    net.Conn
+	network string // network conn was dialed on
+}
+
+// syslogSockets are the usual paths of the local syslog socket
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// Write implements io.Writer for entries without a known level
+func (s *SyslogSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *SyslogSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	msg := s.format(lvl, p)
+	if err := s.send(msg); err != nil {
+		// The daemon may have restarted; try once more on a new connection
+		s.closeConn()
+		if err := s.send(msg); err != nil {
+			return 0, fmt.Errorf("logger: syslog: %w", err)
+		}
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to the syslog daemon
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closeConn()
+}
+
+// send writes one message, connecting first if needed. Callers must hold s.mu.
+func (s *SyslogSink) send(msg []byte) error {
+	if s.conn == nil {
+		conn, network, err := s.dial()
+		if err != nil {
+			return err
+		}
+		s.conn, s.network = conn, network
+	}
+	if streamNetwork(s.network) {
+		if s.Format == RFC5424 {
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		} else {
+			msg = append(msg, '\n')
+		}
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// dial connects to the configured daemon or the local socket, and returns
+// the network it connected on
+func (s *SyslogSink) dial() (net.Conn, string, error) {
+	if s.Network != "" {
+		conn, err := net.DialTimeout(s.Network, s.Addr, 5*time.Second)
+		return conn, s.Network, err
+	}
+	var lastErr error
+	for _, path := range syslogSockets {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.Dial(network, path)
+			if err == nil {
+				return conn, network, nil
+			}
+			lastErr = err
+		}
+	}
+	return nil, "", fmt.Errorf("no local syslog socket: %w", lastErr)
+}
+
+// streamNetwork reports whether messages sent on network need framing,
+// as they do on every network but the datagram ones
+func streamNetwork(network string) bool {
+	switch network {
+	case "udp", "udp4", "udp6", "unixgram":
+		return false
+	}
+	return true
+}
+
+// closeConn drops the connection. Callers must hold s.mu.
+func (s *SyslogSink) closeConn() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// format renders an entry as a syslog message, stamped with the entry's
+// time or the current time if it has none
+func (s *SyslogSink) format(lvl zerolog.Level, p []byte) []byte {
+	facility := s.Facility
+	if facility == 0 {
+		facility = SyslogUser
+	}
+	line := bytes.TrimRight(p, "\n")
+	msg, fields := decodeEntry(line)
+	pri := int(facility)*8 + syslogSeverity(entryLevel(lvl, fields))
+	t, ok := sinkTime(fields[zerolog.TimestampFieldName])
+	if !ok {
+		t = time.Now()
+	}
+	host, app := s.hostname(), s.appName()
+
+	var b bytes.Buffer
+	if s.Format == RFC3164 {
+		fmt.Fprintf(&b, "<%d>%s %s %s[%d]: %s", pri, t.Format(time.Stamp), host, app, os.Getpid(), line)
+		return b.Bytes()
+	}
+
+	delete(fields, zerolog.MessageFieldName)
+	delete(fields, zerolog.LevelFieldName)
+	delete(fields, zerolog.TimestampFieldName)
+	writeRFC5424(&b, pri, t, host, app, msg, fields)
+	return b.Bytes()
+}
+
+// hostname returns the HOSTNAME to send
+func (s *SyslogSink) hostname() string {
+	return syslogHostname(s.Hostname)
+}
+
+// appName returns the APP-NAME to send
+func (s *SyslogSink) appName() string {
+	return syslogAppName(s.AppName)
+}
+
+// RFC5424Writer writes entries to Out as RFC 5424 syslog lines, for
+// feeding syslog tooling through a pipe, a file or any other writer
+// rather than a syslog connection
+//
+//	w := &logger.RFC5424Writer{Out: os.Stdout, Facility: logger.SyslogLocal0, AppName: "billing"}
+//	logger.InitLogger(logger.Config{Output: w})
+//
+// Each entry becomes one line with its level as the severity and its
+// time as the timestamp:
+//
+//	<132>1 2024-05-01T12:00:00.000000Z web-1 billing 4242 - [fields@32473 component="db"][http@32473 method="GET" status="503"] \xEF\xBB\xBFslow query
+//
+// Fields holding objects get an SD-ELEMENT of their own named after the
+// field, as http above, and the other fields share the fields element.
+// SyslogSink sends the same messages. The format name "rfc5424" uses an
+// RFC5424Writer with the defaults.
+type RFC5424Writer struct {
+	Out      io.Writer      // Destination, required
+	Facility SyslogFacility // Facility of every entry, user if zero
+	AppName  string         // APP-NAME, the program name if empty
+	Hostname string         // HOSTNAME, the host's name if empty
+
+	// TimeFormat is the layout of the entries' time field, Config's default
+	// if empty. The "rfc5424" format uses the logger's.
+	TimeFormat string
+}
+
+// rfc5424Writer is the "rfc5424" output format
+func rfc5424Writer(w io.Writer, cfg Config) io.Writer {
+	return &RFC5424Writer{Out: w, TimeFormat: cfg.TimeFormat}
+}
+
+// Write implements io.Writer for entries without a known level
+func (r *RFC5424Writer) Write(p []byte) (int, error) {
+	return r.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (r *RFC5424Writer) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	msg, fields := decodeEntry(bytes.TrimRight(p, "\n"))
+	lvl = entryLevel(lvl, fields)
+	timeFormat := r.TimeFormat
+	if timeFormat == "" {
+		timeFormat = defaultConfig.TimeFormat
+	}
+	t, ok := entryTime(fields[zerolog.TimestampFieldName], timeFormat)
+	if !ok {
+		t = time.Now()
+	}
+	delete(fields, zerolog.MessageFieldName)
+	delete(fields, zerolog.LevelFieldName)
+	delete(fields, zerolog.TimestampFieldName)
+
+	facility := r.Facility
+	if facility == 0 {
+		facility = SyslogUser
+	}
+	var b bytes.Buffer
+	writeRFC5424(&b, int(facility)*8+syslogSeverity(lvl), t, syslogHostname(r.Hostname), syslogAppName(r.AppName), msg, fields)
+	b.WriteByte('\n')
+
+	var err error
+	if lw, ok := r.Out.(zerolog.LevelWriter); ok {
+		_, err = lw.WriteLevel(lvl, b.Bytes())
+	} else {
+		_, err = r.Out.Write(b.Bytes())
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// writeRFC5424 writes an RFC 5424 message without framing
+func writeRFC5424(b *bytes.Buffer, pri int, t time.Time, host, app, msg string, fields map[string]interface{}) {
+	fmt.Fprintf(b, "<%d>1 %s %s %s %d - ", pri, t.Format("2006-01-02T15:04:05.000000Z07:00"), host, app, os.Getpid())
+	writeStructuredData(b, fields)
+	if msg != "" {
+		b.WriteString(" \xEF\xBB\xBF") // BOM marks MSG as UTF-8
+		b.WriteString(msg)
+	}
+}
+
+// syslogHostname returns the HOSTNAME to send, name unless it is empty
+func syslogHostname(name string) string {
+	if name != "" {
+		return name
+	}
+	if h, err := os.Hostname(); err == nil && h != "" {
+		return h
+	}
+	return "-"
+}
+
+// syslogAppName returns the APP-NAME to send, name unless it is empty
+func syslogAppName(name string) string {
+	if name != "" {
+		return name
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// syslogSeverity maps a zerolog level to a syslog severity
+func syslogSeverity(lvl zerolog.Level) int {
+	switch baseLevel(lvl) {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return 7
+	case zerolog.WarnLevel:
+		return 4
+	case zerolog.ErrorLevel:
+		return 3
+	case zerolog.FatalLevel:
+		return 2
+	case zerolog.PanicLevel:
+		return 1
+	}
+	return 6
+}
+
+// splitEntry decodes a JSON entry into its message and the remaining
+// fields, leaving out the level and time that syslog carries itself. A
+// line that is not a JSON object becomes the message.
+func splitEntry(line []byte) (string, map[string]interface{}) {
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return string(line), nil
+	}
+	msg, _ := fields[zerolog.MessageFieldName].(string)
+	delete(fields, zerolog.MessageFieldName)
+	delete(fields, zerolog.LevelFieldName)
+	delete(fields, zerolog.TimestampFieldName)
+	return msg, fields
+}
+
+// writeStructuredData writes fields as RFC 5424 SD-ELEMENTs, or the nil
+// value if there are none: fields holding objects as elements of their
+// own and the rest as the syslogSDID element
+func writeStructuredData(b *bytes.Buffer, fields map[string]interface{}) {
+	if len(fields) == 0 {
+		b.WriteByte('-')
+		return
+	}
+	flat := make(map[string]interface{}, len(fields))
+	var groups []string
+	for k, v := range fields {
+		if _, ok := v.(map[string]interface{}); ok && len(sdName(k)) > 0 {
+			groups = append(groups, k)
+		} else {
+			flat[k] = v
+		}
+	}
+	sort.Strings(groups)
+
+	if len(flat) > 0 {
+		writeSDElement(b, syslogSDID, flat)
+	}
+	for _, k := range groups {
+		// The enterprise number counts towards the SD-NAME's 32 characters
+		name := sdName(k)
+		name = name[:min(len(name), 32-len(syslogSDEnterprise))]
+		writeSDElement(b, name+syslogSDEnterprise, fields[k].(map[string]interface{}))
+	}
+}
+
+// writeSDElement writes one SD-ELEMENT with the params in key order
+func writeSDElement(b *bytes.Buffer, id string, params map[string]interface{}) {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b.WriteString("[" + id)
+	for _, k := range keys {
+		name := sdName(k)
+		if name == "" {
+			continue
+		}
+		b.WriteString(" " + name + `="`)
+		sdEscaper.WriteString(b, fieldString(params[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte(']')
+}
+
+// sdEscaper escapes the characters RFC 5424 reserves in PARAM-VALUE
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// sdName turns a field name into a valid SD-NAME: printable ASCII without
+// '=', ' ', ']' and '"', at most 32 characters
+func sdName(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		if b.Len() == 32 {
+			break
+		}
+		if r > 32 && r < 127 && r != '=' && r != ']' && r != '"' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// fieldString renders a decoded JSON value: strings as they are and
+// anything else as JSON
+func fieldString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
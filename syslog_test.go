@@ -0,0 +1,204 @@
+package logger
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const syslogEntry = `{"level":"warn","component":"db","time":"2026-10-15T01:02:03Z","message":"slow query"}` + "\n"
+
+func TestSyslogSinkFormat(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	for _, tt := range []struct {
+		name string
+		sink *SyslogSink
+		want string
+	}{
+		{
+			"rfc5424",
+			&SyslogSink{Facility: SyslogLocal0, Hostname: "web-1", AppName: "billing"},
+			`<132>1 2026-10-15T01:02:03.000000Z web-1 billing ` + pid + ` - [fields@32473 component="db"] ` + "\xEF\xBB\xBF" + `slow query`,
+		},
+		{
+			"rfc3164",
+			&SyslogSink{Format: RFC3164, Hostname: "web-1", AppName: "billing"},
+			`<12>Oct 15 01:02:03 web-1 billing[` + pid + `]: ` + strings.TrimSpace(syslogEntry),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.sink.format(zerolog.NoLevel, []byte(syslogEntry))); got != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyslogSinkFraming(t *testing.T) {
+	dir, err := os.MkdirTemp("", "syslog") // short enough for a socket path
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tt := range []struct {
+		network, addr string
+		framed        bool
+	}{
+		{"tcp", "127.0.0.1:0", true},
+		{"unix", filepath.Join(dir, "stream.sock"), true},
+		{"udp", "127.0.0.1:0", false},
+		{"unixgram", filepath.Join(dir, "dgram.sock"), false},
+	} {
+		t.Run(tt.network, func(t *testing.T) {
+			received, addr := syslogListener(t, tt.network, tt.addr)
+			s := &SyslogSink{Network: tt.network, Addr: addr, Hostname: "web-1", AppName: "billing"}
+			if _, err := s.Write([]byte(syslogEntry)); err != nil {
+				t.Fatal(err)
+			}
+			s.Close()
+
+			msg := string(s.format(zerolog.NoLevel, []byte(syslogEntry)))
+			want := msg
+			if tt.framed {
+				want = strconv.Itoa(len(msg)) + " " + msg
+			}
+			if got := <-received; got != want {
+				t.Errorf("got\n%q\nwant\n%q", got, want)
+			}
+		})
+	}
+}
+
+// syslogListener listens on network and returns what the first client
+// sends, and the address to send to
+func syslogListener(t *testing.T, network, addr string) (<-chan string, string) {
+	received := make(chan string, 1)
+	switch network {
+	case "udp", "unixgram":
+		conn, err := net.ListenPacket(network, addr)
+		if err != nil {
+			t.Skip(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		go func() {
+			buf := make([]byte, 4096)
+			n, _, _ := conn.ReadFrom(buf)
+			received <- string(buf[:n])
+		}()
+		return received, conn.LocalAddr().String()
+	}
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		t.Skip(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- err.Error()
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- string(data)
+	}()
+	return received, ln.Addr().String()
+}
+
+func TestSyslogSeverity(t *testing.T) {
+	for lvl, want := range map[zerolog.Level]int{
+		zerolog.TraceLevel: 7,
+		zerolog.DebugLevel: 7,
+		zerolog.InfoLevel:  6,
+		zerolog.WarnLevel:  4,
+		zerolog.ErrorLevel: 3,
+		zerolog.FatalLevel: 2,
+		zerolog.PanicLevel: 1,
+		zerolog.NoLevel:    6,
+	} {
+		if got := syslogSeverity(lvl); got != want {
+			t.Errorf("syslogSeverity(%v) = %d, want %d", lvl, got, want)
+		}
+	}
+
+	// The level passed by zerolog wins over the level field
+	s := &SyslogSink{Format: RFC3164, Hostname: "web-1", AppName: "billing"}
+	if got := string(s.format(zerolog.ErrorLevel, []byte(syslogEntry))); !strings.HasPrefix(got, "<11>") {
+		t.Errorf("got %q, want error severity", got)
+	}
+}
+
+func TestSyslogSinkReconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer ln.Close()
+	received := make(chan string, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 4096)
+			n, _ := conn.Read(buf)
+			received <- string(buf[:n])
+			conn.Close() // the daemon restarts
+		}
+	}()
+
+	s := &SyslogSink{Network: "tcp", Addr: ln.Addr().String(), Format: RFC3164, Hostname: "web-1", AppName: "billing"}
+	defer s.Close()
+	if _, err := s.Write([]byte(`{"level":"info","message":"first"}`)); err != nil {
+		t.Fatal(err)
+	}
+	<-received
+	// The first write to the dropped connection may appear to succeed,
+	// so keep writing until one reaches the new connection
+	for deadline := time.Now().Add(5 * time.Second); len(received) == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("no write reached the restarted daemon")
+		}
+		if _, err := s.Write([]byte(`{"level":"info","message":"second"}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := <-received; !strings.Contains(got, `"message":"second"`) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestRFC5424Writer(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	var buf strings.Builder
+	w := &RFC5424Writer{Out: &buf, Facility: SyslogLocal0, Hostname: "web-1", AppName: "billing"}
+	w.WriteLevel(zerolog.ErrorLevel, []byte(`{"time":"2026-10-15T01:02:03Z","query":"a=\"b\" [c]\\d","http":{"method":"GET","status":503},"a_very_long_group_name_for_a_field":{"x":1},"message":"slow query"}`+"\n"))
+	w.Write([]byte(`{"level":"debug","time":"2026-10-15T01:02:03Z"}` + "\n"))
+	want := `<131>1 2026-10-15T01:02:03.000000Z web-1 billing ` + pid + ` - [fields@32473 query="a=\"b\" [c\]\\d"][a_very_long_group_name_for@32473 x="1"][http@32473 method="GET" status="503"] ` + "\xEF\xBB\xBF" + "slow query\n" +
+		`<135>1 2026-10-15T01:02:03.000000Z web-1 billing ` + pid + " - -\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRFC5424Format(t *testing.T) {
+	var buf strings.Builder
+	l, err := New(Config{Output: &buf, Format: "rfc5424"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Warn("disk low", "free", "5%")
+	// user facility, warning severity
+	if got := buf.String(); !strings.HasPrefix(got, "<12>1 ") || !strings.HasSuffix(got, ` [fields@32473 free="5%"] `+"\xEF\xBB\xBF"+"disk low\n") {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// TemplateWriter writes entries to Out as text laid out by a template, for
+// mandated log layouts that neither JSON nor the pretty format match
+//
+//	w, err := logger.NewTemplateWriter(os.Stdout, `{{.Time}} [{{upper .Level | pad 5}}] {{.Component}} — {{.Message}} {{.Fields}}`)
+//	...
+//	logger.InitLogger(logger.Config{Output: w})
+//
+// The template is executed with a TemplateEntry for each entry and a
+// newline is added unless it ends in one. Besides text/template's own
+// functions it can use upper, lower, and pad, which pads a value with
+// spaces to a width.
+type TemplateWriter struct {
+	Out io.Writer // Destination, required
+
+	// TimeFormat is the layout of the entries' time field, used to fill in
+	// TemplateEntry.Timestamp; Config's default if empty
+	TimeFormat string
+
+	tmpl *template.Template
+	mu   sync.Mutex
+	buf  bytes.Buffer
+}
+
+// TemplateEntry is what a TemplateWriter's template is executed with
+type TemplateEntry struct {
+	Time      string         // Time field as logged, in the logger's time format
+	Timestamp time.Time      // Time field parsed, zero if missing
+	Level     string         // Level name
+	Message   string         // Message
+	Component string         // Component of loggers returned by GetLogger
+	Caller    string         // Caller, if the logger adds it
+	Error     string         // Error field
+	Fields    TemplateFields // The remaining fields
+}
+
+// TemplateFields are the fields of an entry not given their own place in
+// TemplateEntry. Fields are printed as key=value pairs in key order, and a
+// single one is reached with {{.Fields.Get "name"}}, which is empty when
+// the entry lacks it, unlike {{.Fields.name}}.
+type TemplateFields map[string]interface{}
+
+// Get returns the named field as text, or "" if there is no such field
+func (f TemplateFields) Get(name string) string {
+	v, ok := f[name]
+	if !ok {
+		return ""
+	}
+	return fieldString(v)
+}
+
+// String renders the fields as space-separated key=value pairs, quoting
+// values that contain spaces, quotes or equal signs
+func (f TemplateFields) String() string {
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		v := fieldString(f[k])
+		if v == "" || strings.ContainsAny(v, " \t\r\n\"=") {
+			v = fmt.Sprintf("%q", v)
+		}
+		b.WriteString(k + "=" + v)
+	}
+	return b.String()
+}
+
+// templateFuncs are the functions templates can use besides the built-in
+// ones
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"pad": func(width int, v interface{}) string {
+		return fmt.Sprintf("%-*v", width, v)
+	},
+}
+
+// NewTemplateWriter returns a TemplateWriter writing to out with the given
+// template text
+func NewTemplateWriter(out io.Writer, text string) (*TemplateWriter, error) {
+	tmpl, err := template.New("entry").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("logger: template: %w", err)
+	}
+	return &TemplateWriter{Out: out, tmpl: tmpl}, nil
+}
+
+// Write implements io.Writer for entries without a known level
+func (t *TemplateWriter) Write(p []byte) (int, error) {
+	return t.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (t *TemplateWriter) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	msg, fields := decodeEntry(bytes.TrimRight(p, "\n"))
+	entry := TemplateEntry{Message: msg}
+	delete(fields, zerolog.MessageFieldName)
+	if lvl = entryLevel(lvl, fields); lvl != zerolog.NoLevel {
+		entry.Level = LevelString(lvl)
+	}
+	delete(fields, zerolog.LevelFieldName)
+	if v, ok := fields[zerolog.TimestampFieldName]; ok {
+		timeFormat := t.TimeFormat
+		if timeFormat == "" {
+			timeFormat = defaultConfig.TimeFormat
+		}
+		entry.Time = fieldString(v)
+		entry.Timestamp, _ = entryTime(v, timeFormat)
+		delete(fields, zerolog.TimestampFieldName)
+	}
+	for key, dst := range map[string]*string{
+		"component":             &entry.Component,
+		zerolog.CallerFieldName: &entry.Caller,
+		zerolog.ErrorFieldName:  &entry.Error,
+	} {
+		if v, ok := fields[key]; ok {
+			*dst = fieldString(v)
+			delete(fields, key)
+		}
+	}
+	entry.Fields = fields
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tmpl == nil {
+		return 0, fmt.Errorf("logger: template: writer not created by NewTemplateWriter")
+	}
+	t.buf.Reset()
+	if err := t.tmpl.Execute(&t.buf, entry); err != nil {
+		return 0, fmt.Errorf("logger: template: %w", err)
+	}
+	if !bytes.HasSuffix(t.buf.Bytes(), []byte("\n")) {
+		t.buf.WriteByte('\n')
+	}
+
+	var err error
+	if lw, ok := t.Out.(zerolog.LevelWriter); ok {
+		_, err = lw.WriteLevel(lvl, t.buf.Bytes())
+	} else {
+		_, err = t.Out.Write(t.buf.Bytes())
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
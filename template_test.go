@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestTemplateWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewTemplateWriter(&buf, `{{.Time}} [{{upper .Level | pad 5}}] {{.Component}} — {{.Message}} {{.Fields}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteLevel(zerolog.InfoLevel, []byte(`{"level":"info","time":"2026-10-15T01:02:03Z","component":"api","caller":"main.go:7","path":"/a b","status":200,"empty":"","message":"served"}`+"\n"))
+	want := `2026-10-15T01:02:03Z [INFO ] api — served empty="" path="/a b" status=200` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+
+	// Without a level from the logger, the entry's level field is used
+	buf.Reset()
+	w.Write([]byte(`{"level":"warn","message":"slow"}`))
+	if got, want := buf.String(), "[WARN ]  — slow \n"; !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, want suffix %q", got, want)
+	}
+}
+
+func TestTemplateEntry(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewTemplateWriter(&buf, `{{.Timestamp.Unix}}|{{.Caller}}|{{.Error}}|{{.Fields.Get "order"}}|{{.Fields.Get "missing"}}|{{lower .Level}}`+"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.TimeFormat = time.RFC1123
+	w.WriteLevel(zerolog.ErrorLevel, []byte(`{"time":"Thu, 15 Oct 2026 01:02:03 UTC","caller":"charge.go:42","error":"declined","order":7,"message":"charge failed"}`))
+	// The template's own newline is kept and no other added
+	if got, want := buf.String(), "1792026123|charge.go:42|declined|7||error\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTemplateWriterErrors(t *testing.T) {
+	if _, err := NewTemplateWriter(&bytes.Buffer{}, "{{.Message"); err == nil || !strings.HasPrefix(err.Error(), "logger: template:") {
+		t.Errorf("NewTemplateWriter with a bad template: %v", err)
+	}
+
+	w, _ := NewTemplateWriter(&bytes.Buffer{}, "{{.Nope}}")
+	if _, err := w.Write([]byte(`{"message":"x"}`)); err == nil {
+		t.Error("Write with an unknown field: no error")
+	}
+
+	var zero TemplateWriter
+	if _, err := zero.Write([]byte(`{"message":"x"}`)); err == nil || !strings.Contains(err.Error(), "NewTemplateWriter") {
+		t.Errorf("Write on a zero TemplateWriter: %v", err)
+	}
+}
+
+func TestTemplateWriterLevelOutput(t *testing.T) {
+	rec := &levelRecorder{}
+	w, _ := NewTemplateWriter(rec, "{{.Message}}")
+	w.WriteLevel(zerolog.ErrorLevel, []byte(`{"message":"boom"}`))
+	if len(rec.levels) != 1 || rec.levels[0] != zerolog.ErrorLevel {
+		t.Errorf("levels %v, want [error]", rec.levels)
+	}
+}
@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Theme sets the colors of pretty output. Colors are ANSI SGR parameters,
+// such as "31" for red, "1;34" for bold blue or "30;43" for black on
+// yellow; an empty color leaves that part as the terminal draws text.
+//
+//	theme := logger.HighContrastTheme()
+//	theme.Levels["debug"] = "35"
+//	logger.InitLogger(logger.Config{Pretty: true, Theme: &theme})
+//
+// Colors are left out altogether when NoColor is set or the NO_COLOR
+// environment variable is not empty, following https://no-color.org.
+type Theme struct {
+	Levels     map[string]string // Color of each level's label, by level name
+	Timestamp  string            // Color of the time
+	Caller     string            // Color of the caller
+	Message    string            // Color of the message
+	FieldName  string            // Color of field names and their equal sign
+	FieldValue string            // Color of field values
+	ErrorName  string            // Color of the error field's name
+	ErrorValue string            // Color of the error field's value
+	NoColor    bool              // Write plain text
+}
+
+// DefaultTheme returns the colors pretty output uses without a theme, as
+// a starting point for changing some of them
+func DefaultTheme() Theme {
+	return Theme{
+		Levels: map[string]string{
+			"trace": "34",
+			"info":  "32",
+			"warn":  "33",
+			"error": "31",
+			"fatal": "31",
+			"panic": "31",
+		},
+		Timestamp:  "90",
+		Caller:     "1",
+		Message:    "1",
+		FieldName:  "36",
+		ErrorName:  "36",
+		ErrorValue: "1;31",
+	}
+}
+
+// HighContrastTheme returns a theme without dim colors, readable on light
+// and dark terminals alike: levels as bold labels, warnings and worse on a
+// colored background, and everything else in the terminal's text color or
+// bold primaries
+func HighContrastTheme() Theme {
+	return Theme{
+		Levels: map[string]string{
+			"trace": "1;35",
+			"debug": "1;34",
+			"info":  "1;32",
+			"warn":  "1;30;43",
+			"error": "1;37;41",
+			"fatal": "1;37;41",
+			"panic": "1;37;41",
+		},
+		Timestamp:  "1",
+		Caller:     "1",
+		Message:    "1",
+		FieldName:  "1;34",
+		ErrorName:  "1;31",
+		ErrorValue: "1;31",
+	}
+}
+
+// parseTheme resolves the theme names of configuration files
+func parseTheme(name string) (*Theme, error) {
+	var theme Theme
+	switch strings.ToLower(name) {
+	case "default":
+		theme = DefaultTheme()
+	case "high-contrast":
+		theme = HighContrastTheme()
+	case "none":
+		theme = DefaultTheme()
+		theme.NoColor = true
+	default:
+		return nil, fmt.Errorf("unknown theme %q: want default, high-contrast or none", name)
+	}
+	return &theme, nil
+}
+
+// apply sets the formatters of cw to draw the theme. timeFormat is the
+// layout of the entries' time field.
+func (t *Theme) apply(cw *zerolog.ConsoleWriter, timeFormat string) {
+	noColor := t.NoColor || os.Getenv("NO_COLOR") != ""
+	cw.NoColor = noColor
+	paint := func(s, color string) string {
+		if noColor || color == "" {
+			return s
+		}
+		return "\x1b[" + color + "m" + s + "\x1b[0m"
+	}
+
+	cw.FormatTimestamp = func(i interface{}) string {
+		s := fieldString(i)
+		if n, ok := i.(json.Number); ok {
+			// Unix times are hard to read; show them the default way
+			if ts, ok := entryTime(n, timeFormat); ok {
+				s = ts.Format(time.RFC3339)
+			}
+		}
+		return paint(s, t.Timestamp)
+	}
+	cw.FormatLevel = func(i interface{}) string {
+		name, _ := i.(string)
+		label, color := strings.ToUpper(name), t.Levels[name]
+		if lvl, err := ParseLevel(name); err == nil {
+			if l, ok := zerolog.FormattedLevels[lvl]; ok {
+				label = l
+			}
+		} else if _, ok := t.Levels[name]; !ok {
+			// Custom levels look like the level they are based on
+			for _, c := range customLevelMap() {
+				if c.name == name {
+					color = t.Levels[LevelString(c.base)]
+				}
+			}
+		}
+		if len(label) > 3 {
+			label = label[:3]
+		} else if label == "" {
+			label = "???"
+		}
+		return paint(label, color)
+	}
+	cw.FormatCaller = func(i interface{}) string {
+		c, _ := i.(string)
+		if c == "" {
+			return ""
+		}
+		if cwd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(cwd, c); err == nil {
+				c = rel
+			}
+		}
+		return paint(c+" >", t.Caller)
+	}
+	cw.FormatMessage = func(i interface{}) string {
+		if i == nil || i == "" {
+			return ""
+		}
+		return paint(fmt.Sprint(i), t.Message)
+	}
+	cw.FormatFieldName = func(i interface{}) string {
+		return paint(fmt.Sprint(i)+"=", t.FieldName)
+	}
+	cw.FormatFieldValue = func(i interface{}) string {
+		return paint(fmt.Sprint(i), t.FieldValue)
+	}
+	cw.FormatErrFieldName = func(i interface{}) string {
+		return paint(fmt.Sprint(i)+"=", t.ErrorName)
+	}
+	cw.FormatErrFieldValue = func(i interface{}) string {
+		return paint(fmt.Sprint(i), t.ErrorValue)
+	}
+}
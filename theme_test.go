@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTheme(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	theme := HighContrastTheme()
+	theme.FieldValue = "35"
+	var buf bytes.Buffer
+	l, err := New(Config{Output: &buf, Pretty: true, NoTimestamp: true, Theme: &theme})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Error(errors.New("declined"), "charge failed", "order", 7)
+	want := "\x1b[1;37;41mERR\x1b[0m \x1b[1mcharge failed\x1b[0m " +
+		"\x1b[1;31merror=\x1b[0m\x1b[1;31mdeclined\x1b[0m \x1b[1;34morder=\x1b[0m\x1b[35m7\x1b[0m\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got\n%q\nwant\n%q", got, want)
+	}
+
+	// Custom levels take the colors of the level they are based on
+	buf.Reset()
+	l.Log(testSecurity, "intrusion")
+	if got := buf.String(); !strings.HasPrefix(got, "\x1b[1;37;41mTES\x1b[0m ") {
+		t.Errorf("custom level drawn as %q", got)
+	}
+}
+
+func TestThemeNoColor(t *testing.T) {
+	theme := DefaultTheme()
+	for name, noColor := range map[string]bool{"NoColor": true, "NO_COLOR": false} {
+		t.Run(name, func(t *testing.T) {
+			if !noColor {
+				t.Setenv("NO_COLOR", "1")
+			}
+			theme.NoColor = noColor
+			var buf bytes.Buffer
+			l, _ := New(Config{Output: &buf, Pretty: true, NoTimestamp: true, Theme: &theme})
+			l.Warn("slow", "ms", 900)
+			if got, want := buf.String(), "WRN slow ms=900\n"; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestParseTheme(t *testing.T) {
+	for name, want := range map[string]string{
+		"default":       "31",
+		"High-Contrast": "1;37;41",
+		"none":          "31",
+	} {
+		theme, err := parseTheme(name)
+		if err != nil || theme.Levels["error"] != want || theme.NoColor != (name == "none") {
+			t.Errorf("parseTheme(%q) = %+v, %v", name, theme, err)
+		}
+	}
+	if _, err := parseTheme("neon"); err == nil || !strings.Contains(err.Error(), `unknown theme "neon"`) {
+		t.Errorf("parseTheme(neon) = %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// withTimestamp adds the entry time to the entries of zl, and records
+// the logger's time format for sinks parsing it back. zerolog's own
+// timestamp is used when the logger writes the host's zone in zerolog's
+// TimeFieldFormat, otherwise a timestampHook.
+func withTimestamp(zl zerolog.Logger, cfg Config) zerolog.Logger {
+	addTimeFormat(cfg.TimeFormat)
+	if (cfg.Location == nil || cfg.Location == time.Local) && cfg.TimeFormat == zerolog.TimeFieldFormat {
+		return zl.With().Timestamp().Logger()
+	}
+	return zl.Hook(timestampHook{loc: cfg.Location, format: cfg.TimeFormat})
+}
+
+// timestampHook adds the entry time in a logger's own zone and format, as
+// zerolog's Timestamp uses the process-wide TimeFieldFormat and the host's
+// zone, which would make every logger share them.
+type timestampHook struct {
+	loc    *time.Location
+	format string
+}
+
+// Run implements zerolog.Hook
+func (h timestampHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	t := zerolog.TimestampFunc()
+	if h.loc != nil {
+		t = t.In(h.loc)
+	}
+
+	key := zerolog.TimestampFieldName
+	switch h.format {
+	case zerolog.TimeFormatUnix:
+		e.Int64(key, t.Unix())
+	case zerolog.TimeFormatUnixMs:
+		e.Int64(key, t.UnixMilli())
+	case zerolog.TimeFormatUnixMicro:
+		e.Int64(key, t.UnixMicro())
+	case zerolog.TimeFormatUnixNano:
+		e.Int64(key, t.UnixNano())
+	default:
+		// Formatted on the stack, as a string would cost an allocation
+		var buf [64]byte
+		e.Bytes(key, t.AppendFormat(buf[:0], h.format))
+	}
+}
+
+// timeFormats holds the layouts loggers were configured with, for sinks
+// parsing the time field of entries back, replaced as a whole under
+// timeFormatsMu so sinks read it without locking
+var (
+	timeFormatsMu sync.Mutex
+	timeFormats   atomic.Pointer[[]string]
+)
+
+// addTimeFormat records the layout of a logger's timestamps. RFC3339 and
+// zerolog's Unix formats are understood without it.
+func addTimeFormat(format string) {
+	switch format {
+	case time.RFC3339, time.RFC3339Nano, zerolog.TimeFormatUnix, zerolog.TimeFormatUnixMs,
+		zerolog.TimeFormatUnixMicro, zerolog.TimeFormatUnixNano:
+		return
+	}
+	timeFormatsMu.Lock()
+	defer timeFormatsMu.Unlock()
+	current := configuredTimeFormats()
+	if slices.Contains(current, format) {
+		return
+	}
+	next := append(slices.Clip(current), format)
+	timeFormats.Store(&next)
+}
+
+// configuredTimeFormats returns the layouts recorded by addTimeFormat
+func configuredTimeFormats() []string {
+	if p := timeFormats.Load(); p != nil {
+		return *p
+	}
+	return nil
+}
+
+// parseLocation resolves a zone name such as "UTC", "Local" or an IANA
+// name like "Europe/Berlin"
+func parseLocation(name string) (*time.Location, error) {
+	return time.LoadLocation(name)
+}
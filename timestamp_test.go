@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+func TestTimestampAllocationFree(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cfg  Config
+	}{
+		{"host zone", Config{}},
+		{"own zone", Config{Location: time.UTC}},
+		{"own format", Config{TimeFormat: time.RFC3339Nano}},
+		{"unix", Config{TimeFormat: zerolog.TimeFormatUnixMs}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Output = io.Discard
+			zl, _ := newLogger(withDefaults(tt.cfg), nil)
+			allocs := testing.AllocsPerRun(100, func() {
+				zl.Info().Str("component", "api").Msg("served")
+			})
+			if allocs != 0 {
+				t.Errorf("%v allocations per entry, want 0", allocs)
+			}
+		})
+	}
+}
+
+func TestTimestampZoneAndFormat(t *testing.T) {
+	defer func(f func() time.Time) { zerolog.TimestampFunc = f }(zerolog.TimestampFunc)
+	zerolog.TimestampFunc = func() time.Time {
+		return time.Date(2026, 10, 15, 1, 2, 3, 0, time.UTC)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, tt := range []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"zone", Config{Location: berlin}, `"time":"2026-10-15T03:02:03+02:00"`},
+		{"format", Config{Location: time.UTC, TimeFormat: "2006-01-02 15:04"}, `"time":"2026-10-15 01:02"`},
+		{"unix ms", Config{TimeFormat: zerolog.TimeFormatUnixMs}, `"time":1792026123000`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.cfg.Output = &buf
+			zl, _ := newLogger(withDefaults(tt.cfg), nil)
+			zl.Info().Msg("m")
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("got %s, want %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTimestampBeforeContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	zl, _ := newLogger(withDefaults(Config{Output: &buf, Location: time.UTC}), nil)
+	zl.Info().Ctx(ContextWithRequestID(context.Background(), "r1")).Msg("m")
+	if got := buf.String(); strings.Index(got, `"time"`) > strings.Index(got, `"request_id"`) {
+		t.Errorf("time after the context fields: %s", got)
+	}
+}
+
+func TestNoTimestamp(t *testing.T) {
+	for _, format := range []string{"json", "pretty", "logfmt"} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			l, err := New(Config{Output: &buf, Format: format, NoTimestamp: true, TimeFormat: "2006-01-02"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			l.Info("m")
+			if got := buf.String(); strings.Contains(got, "time") || strings.Contains(got, time.Now().Format("2006-01-02")) {
+				t.Errorf("got %q, want no timestamp", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// InitLoggerE is like InitLogger but validates cfg first and returns a
+// descriptive error instead of falling back to defaults for bad values
+// A valid config is applied only if the logger has not been initialized yet.
+func InitLoggerE(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	InitLogger(cfg)
+	return nil
+}
+
+// Validate checks the level name, time format and output writer
+// Zero values are valid and mean the defaults will be used.
+func (cfg Config) Validate() error {
+	var errs []error
+	if v := cfg.withEnvLevel().Level; v != cfg.Level {
+		if _, err := ParseLevel(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", EnvLevelOverride, err))
+		}
+	} else if cfg.Level != "" {
+		if _, err := ParseLevel(cfg.Level); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if cfg.Format != "" {
+		if _, err := parseFormat(cfg.Format); err != nil {
+			errs = append(errs, fmt.Errorf("logger: %w", err))
+		}
+	}
+	if err := validateTimeFormat(cfg.TimeFormat); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateOutput(cfg.Output); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateOutput(cfg.ErrorOutput); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateSinks(cfg.Sinks); err != nil {
+		errs = append(errs, err)
+	}
+	if err := cfg.Async.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := cfg.Batch.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateSampling(cfg.Sampling); err != nil {
+		errs = append(errs, err)
+	}
+	if err := cfg.RateLimit.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := cfg.FieldNames.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if cfg.Scoped && cfg.FieldNames != (FieldNames{}) {
+		errs = append(errs, errors.New("logger: field names are process-wide and cannot be set on a scoped configuration"))
+	}
+	for name, sub := range cfg.Loggers {
+		if err := sub.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("logger %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateTimeFormat rejects layouts that contain no time elements, which
+// would stamp every entry with the same literal string
+func validateTimeFormat(format string) error {
+	switch format {
+	case "", zerolog.TimeFormatUnixMs, zerolog.TimeFormatUnixMicro, zerolog.TimeFormatUnixNano:
+		return nil
+	}
+	// Any time other than the reference time itself will do
+	probe := time.Date(2009, time.November, 10, 23, 59, 58, 0, time.UTC)
+	if probe.Format(format) == format {
+		return fmt.Errorf("logger: time format %q contains no time elements", format)
+	}
+	return nil
+}
+
+// validateOutput rejects nil pointer writers and closed files
+func validateOutput(w io.Writer) error {
+	if w == nil {
+		return nil
+	}
+	if v := reflect.ValueOf(w); v.Kind() == reflect.Pointer && v.IsNil() {
+		return fmt.Errorf("logger: output writer is a nil %T", w)
+	}
+	switch w := w.(type) {
+	case *os.File:
+		if _, err := w.Stat(); err != nil {
+			return fmt.Errorf("logger: output file %s is not usable: %w", w.Name(), err)
+		}
+	case *FileSink:
+		return w.validate()
+	}
+	return nil
+}
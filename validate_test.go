@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	closed, err := os.Create(filepath.Join(t.TempDir(), "closed.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	var nilBuf *bytes.Buffer
+
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("zero Config: %v", err)
+	}
+	if err := (Config{Level: "warn", Format: "logfmt", TimeFormat: "2006-01-02 15:04:05", Output: &bytes.Buffer{}}).Validate(); err != nil {
+		t.Errorf("valid Config: %v", err)
+	}
+	for _, tt := range []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"level", Config{Level: "loud"}, "loud"},
+		{"format", Config{Format: "yaml"}, "yaml"},
+		{"time format", Config{TimeFormat: "today"}, "no time elements"},
+		{"nil output", Config{Output: nilBuf}, "nil *bytes.Buffer"},
+		{"closed output", Config{ErrorOutput: closed}, "not usable"},
+		{"named logger", Config{Loggers: map[string]Config{"db": {Level: "loud"}}}, `logger "db"`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+
+	// Every problem is reported, not just the first
+	err = Config{Level: "loud", Format: "yaml"}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "loud") || !strings.Contains(err.Error(), "yaml") {
+		t.Errorf("got %v, want both the level and the format reported", err)
+	}
+}
+
+func TestInitLoggerERejectsInvalidConfig(t *testing.T) {
+	if err := InitLoggerE(Config{Level: "loud"}); err == nil {
+		t.Fatal("no error for an invalid level")
+	}
+}
@@ -0,0 +1,184 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Payload templates for chat webhooks, for use as WebhookSink.Template
+const (
+	// SlackTemplate posts the batch as the text of a Slack message
+	SlackTemplate = `{"text":{{json (lines .Entries)}}}`
+
+	// TeamsTemplate posts the batch as a Microsoft Teams message card
+	TeamsTemplate = `{"@type":"MessageCard","@context":"http://schema.org/extensions","summary":{{json (printf "%d log entries" (len .Entries))}},"text":{{json (lines .Entries)}}}`
+)
+
+// WebhookSink POSTs batches of entries to a URL from a background
+// goroutine, as a JSON array or in a payload rendered from Template
+//
+//	alerts := &logger.WebhookSink{URL: slackURL, Template: logger.SlackTemplate, MinInterval: 10 * time.Second}
+//	defer alerts.Close()
+//	logger.InitLogger(logger.Config{Sinks: []logger.SinkConfig{
+//		{Writer: os.Stdout},
+//		{Writer: alerts, MinLevel: "error"},
+//	}})
+//
+// Template is a text/template executed with .Entries, the decoded entries
+// of the batch. Besides the standard functions it can use json, which
+// renders a value as JSON, and lines, which renders entries one per line
+// as "level: message key=value ...". Requests are at least MinInterval
+// apart; entries logged in between wait for the next request. Failed
+// requests are retried with backoff.
+type WebhookSink struct {
+	URL           string            // Endpoint, required
+	Template      string            // Payload template, a JSON array of the entries if empty
+	ContentType   string            // Content-Type header, application/json if empty
+	Headers       map[string]string // Extra request headers, such as Authorization
+	MinInterval   time.Duration     // Least time between requests, no limit if zero
+	Client        *http.Client      // HTTP client, http.DefaultClient if nil
+	BatchSize     int               // Entries per request, 100 if zero
+	FlushInterval time.Duration     // Longest wait before sending, 5s if zero
+	QueueWait     time.Duration     // Longest a log call waits for room in a full queue, none if zero
+	OnError       func(error)       // Called when entries are lost, stderr if nil
+
+	setup sync.Once
+	batch entryBatcher
+	tmpl  *template.Template
+	err   error     // from parsing Template
+	last  time.Time // of the last request, owned by the batcher goroutine
+}
+
+// webhookFuncs are the functions payload templates can use
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"lines": webhookLines,
+}
+
+// Write implements io.Writer for entries without a known level
+func (s *WebhookSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter
+func (s *WebhookSink) WriteLevel(lvl zerolog.Level, p []byte) (int, error) {
+	if err := s.batcher().add(lvl, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush sends the queued entries and waits until they are delivered
+func (s *WebhookSink) Flush() error {
+	s.batcher().flush()
+	return nil
+}
+
+// Close sends the queued entries and stops the background goroutine
+func (s *WebhookSink) Close() error {
+	s.batcher().close()
+	return nil
+}
+
+// batcher returns the sink's batcher, configured from its fields on
+// first use
+func (s *WebhookSink) batcher() *entryBatcher {
+	s.setup.Do(func() {
+		s.batch = entryBatcher{send: s.send, size: s.BatchSize, interval: s.FlushInterval, wait: s.QueueWait, onError: s.OnError}
+		if s.Template != "" {
+			s.tmpl, s.err = template.New("webhook").Funcs(webhookFuncs).Parse(s.Template)
+		}
+	})
+	return &s.batch
+}
+
+// send renders and posts one batch, waiting out MinInterval first
+func (s *WebhookSink) send(batch []batchEntry) error {
+	if s.URL == "" {
+		return permanentError{fmt.Errorf("logger: webhook: URL is required")}
+	}
+	if s.err != nil {
+		return permanentError{fmt.Errorf("logger: webhook: template: %w", s.err)}
+	}
+	entries := make([]map[string]interface{}, len(batch))
+	for i, e := range batch {
+		_, entries[i] = decodeEntry(e.data)
+		if _, ok := entries[i][zerolog.LevelFieldName]; !ok && e.level != zerolog.NoLevel {
+			entries[i][zerolog.LevelFieldName] = LevelString(e.level)
+		}
+	}
+	var body bytes.Buffer
+	if s.tmpl != nil {
+		if err := s.tmpl.Execute(&body, map[string]interface{}{"Entries": entries}); err != nil {
+			return permanentError{fmt.Errorf("logger: webhook: template: %w", err)}
+		}
+	} else if err := json.NewEncoder(&body).Encode(entries); err != nil {
+		return permanentError{err}
+	}
+
+	if wait := time.Until(s.last.Add(s.MinInterval)); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-s.batch.done:
+		}
+	}
+	s.last = time.Now()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, s.URL, &body)
+	if err != nil {
+		return permanentError{err}
+	}
+	contentType := s.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range s.Headers {
+		req.Header.Set(k, v)
+	}
+	if err := doHTTP(s.Client, req); err != nil {
+		return fmt.Errorf("logger: webhook: %w", err)
+	}
+	return nil
+}
+
+// webhookLines renders decoded entries one per line as level, message and
+// the remaining fields sorted by name
+func webhookLines(entries []map[string]interface{}) string {
+	var b strings.Builder
+	for i, fields := range entries {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		if lvl, ok := fields[zerolog.LevelFieldName]; ok {
+			b.WriteString(fieldString(lvl) + ": ")
+		}
+		if msg, ok := fields[zerolog.MessageFieldName]; ok {
+			b.WriteString(fieldString(msg))
+		}
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			if k != zerolog.LevelFieldName && k != zerolog.MessageFieldName && k != zerolog.TimestampFieldName {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b.WriteString(" " + k + "=" + fieldString(fields[k]))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// webhookServer records the requests posted to it
+type webhookServer struct {
+	mu     sync.Mutex
+	bodies []string
+	header http.Header
+}
+
+func (s *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = append(s.bodies, string(body))
+	s.header = r.Header
+}
+
+func TestWebhookSinkTemplates(t *testing.T) {
+	for _, tt := range []struct {
+		name, template, want string
+	}{
+		{"json", "", `[{"level":"error","message":"charge failed","order":7,"time":"2026-10-15T01:02:03Z"},{"level":"warn","message":"slow"}]` + "\n"},
+		{"slack", SlackTemplate, `{"text":"error: charge failed order=7\nwarn: slow"}`},
+		{"teams", TeamsTemplate, `{"@type":"MessageCard","@context":"http://schema.org/extensions","summary":"2 log entries","text":"error: charge failed order=7\nwarn: slow"}`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var srv webhookServer
+			ts := httptest.NewServer(&srv)
+			defer ts.Close()
+
+			s := &WebhookSink{URL: ts.URL, Template: tt.template, Headers: map[string]string{"Authorization": "Bearer hook"}}
+			s.WriteLevel(zerolog.ErrorLevel, []byte(`{"time":"2026-10-15T01:02:03Z","order":7,"message":"charge failed"}`+"\n"))
+			s.Write([]byte(`{"level":"warn","message":"slow"}` + "\n"))
+			s.Close()
+
+			srv.mu.Lock()
+			defer srv.mu.Unlock()
+			if len(srv.bodies) != 1 || srv.bodies[0] != tt.want {
+				t.Errorf("posted %q, want %q", srv.bodies, tt.want)
+			}
+			if srv.header.Get("Authorization") != "Bearer hook" || srv.header.Get("Content-Type") != "application/json" {
+				t.Errorf("headers %v", srv.header)
+			}
+		})
+	}
+}
+
+func TestWebhookSinkMinInterval(t *testing.T) {
+	var srv webhookServer
+	ts := httptest.NewServer(&srv)
+	defer ts.Close()
+
+	s := &WebhookSink{URL: ts.URL, Template: SlackTemplate, MinInterval: 200 * time.Millisecond}
+	start := time.Now()
+	s.Write([]byte(`{"message":"first"}` + "\n"))
+	s.Flush()
+	s.Write([]byte(`{"message":"second"}` + "\n"))
+	s.Flush()
+	elapsed := time.Since(start)
+	s.Close()
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.bodies) != 2 {
+		t.Fatalf("%d requests, want 2", len(srv.bodies))
+	}
+	if elapsed < 200*time.Millisecond {
+		t.Errorf("both requests sent within %v, want them MinInterval apart", elapsed)
+	}
+}
+
+func TestWebhookSinkInvalidTemplate(t *testing.T) {
+	var errs []error
+	s := &WebhookSink{URL: "http://127.0.0.1:1", Template: "{{.Entries", OnError: func(err error) { errs = append(errs, err) }}
+	s.Write([]byte(`{"message":"lost"}` + "\n"))
+	s.Close()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "template") {
+		t.Errorf("reported %v, want the template error", errs)
+	}
+}